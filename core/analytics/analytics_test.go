@@ -0,0 +1,54 @@
+package analytics_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Jason5Lee/simple-blog/core/analytics"
+	"github.com/Jason5Lee/simple-blog/core/data"
+	infra_repository "github.com/Jason5Lee/simple-blog/infra/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+const browser = "Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/107.0 Safari/537.36"
+
+func Test_IsBot(t *testing.T) {
+	assert.False(t, analytics.IsBot(browser))
+	assert.True(t, analytics.IsBot("Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)"))
+	assert.True(t, analytics.IsBot("curl/7.86.0"))
+	assert.True(t, analytics.IsBot(""))
+}
+
+func Test_ViewCounter(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := context.Background()
+	repo := infra_repository.NewViewRepositoryInMemory()
+	counter := analytics.NewViewCounter(repo, time.Hour)
+
+	assert.True(counter.Record("1", "10.0.0.1", browser), "first view should be counted")
+	assert.False(counter.Record("1", "10.0.0.1", browser), "repeated view in the dedup window should not be counted")
+	assert.True(counter.Record("1", "10.0.0.2", browser), "view of another visitor should be counted")
+	assert.True(counter.Record("2", "10.0.0.1", browser), "view of another article should be counted")
+	assert.False(counter.Record("2", "10.0.0.3", "Googlebot/2.1"), "view of a bot should not be counted")
+
+	today := data.Day(time.Now())
+	daily, err := repo.GetDaily(ctx, "1", today, today)
+	assert.Nil(err)
+	assert.Empty(daily, "views should be buffered until flushed")
+
+	assert.Nil(counter.Flush(ctx))
+	daily, err = repo.GetDaily(ctx, "1", today, today)
+	assert.Nil(err)
+	assert.Equal([]data.DailyViews{{Day: today, Views: 2}}, daily)
+
+	top, err := repo.GetTop(ctx, today, today, 10)
+	assert.Nil(err)
+	assert.Equal([]data.ArticleViews{{ArticleID: "1", Views: 2}, {ArticleID: "2", Views: 1}}, top)
+
+	assert.Nil(counter.Flush(ctx), "flushing nothing should not fail")
+	daily, err = repo.GetDaily(ctx, "1", today, today)
+	assert.Nil(err)
+	assert.Equal([]data.DailyViews{{Day: today, Views: 2}}, daily, "views should not be flushed twice")
+}
@@ -0,0 +1,24 @@
+package analytics
+
+import "strings"
+
+// botUserAgentTokens are the lower-cased user agent parts of crawlers, link previewers and HTTP tools.
+var botUserAgentTokens = []string{
+	"bot", "crawl", "spider", "slurp", "facebookexternalhit", "preview", "headless",
+	"curl", "wget", "python-requests", "go-http-client", "java/", "okhttp", "httpclient",
+}
+
+// IsBot returns whether the user agent looks like a bot rather than a reader.
+// An empty user agent is considered a bot, as browsers always send one.
+func IsBot(userAgent string) bool {
+	if userAgent == "" {
+		return true
+	}
+	userAgent = strings.ToLower(userAgent)
+	for _, token := range botUserAgentTokens {
+		if strings.Contains(userAgent, token) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,114 @@
+package analytics
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/Jason5Lee/simple-blog/core/data"
+	"github.com/Jason5Lee/simple-blog/core/repository"
+)
+
+type visitKey struct {
+	articleID data.ArticleID
+	visitor   string
+}
+
+type dayKey struct {
+	articleID data.ArticleID
+	day       time.Time
+}
+
+// ViewCounter counts article views, ignoring bots and repeated views of the same visitor within the dedup window.
+// The views are buffered in memory and written to the repository in batches by Flush,
+// so reading an article does not cost a write.
+type ViewCounter struct {
+	repo        repository.ViewRepository
+	dedupWindow time.Duration
+
+	mu sync.Mutex
+	// lastCounted is when a visitor was last counted viewing an article.
+	lastCounted map[visitKey]time.Time
+	pending     map[dayKey]int64
+}
+
+func NewViewCounter(repo repository.ViewRepository, dedupWindow time.Duration) *ViewCounter {
+	return &ViewCounter{
+		repo:        repo,
+		dedupWindow: dedupWindow,
+		lastCounted: make(map[visitKey]time.Time),
+		pending:     make(map[dayKey]int64),
+	}
+}
+
+// Record records a view of the article by the visitor, returning whether it is counted.
+// The visitor is an opaque identifier such as the client IP.
+func (c *ViewCounter) Record(articleID data.ArticleID, visitor string, userAgent string) bool {
+	if IsBot(userAgent) {
+		return false
+	}
+	now := time.Now()
+	key := visitKey{articleID: articleID, visitor: visitor + "\x00" + userAgent}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if last, ok := c.lastCounted[key]; ok && now.Sub(last) < c.dedupWindow {
+		return false
+	}
+	c.lastCounted[key] = now
+	c.pending[dayKey{articleID: articleID, day: data.Day(now)}]++
+	return true
+}
+
+// Flush writes the buffered views to the repository.
+// If it fails, the views are kept in the buffer for the next flush.
+func (c *ViewCounter) Flush(ctx context.Context) error {
+	c.mu.Lock()
+	pending := c.pending
+	c.pending = make(map[dayKey]int64)
+	// Forget the visits out of the dedup window so the memory does not grow forever.
+	now := time.Now()
+	for key, last := range c.lastCounted {
+		if now.Sub(last) >= c.dedupWindow {
+			delete(c.lastCounted, key)
+		}
+	}
+	c.mu.Unlock()
+
+	if len(pending) == 0 {
+		return nil
+	}
+	increments := make([]data.ViewIncrement, 0, len(pending))
+	for key, views := range pending {
+		increments = append(increments, data.ViewIncrement{ArticleID: key.articleID, Day: key.day, Views: views})
+	}
+	if err := c.repo.AddViews(ctx, increments); err != nil {
+		c.mu.Lock()
+		for key, views := range pending {
+			c.pending[key] += views
+		}
+		c.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// Run flushes the views every interval until the context is done, then flushes the remaining views.
+func (c *ViewCounter) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := c.Flush(ctx); err != nil {
+				log.Printf("failed to flush views: %v", err)
+			}
+		case <-ctx.Done():
+			if err := c.Flush(context.Background()); err != nil {
+				log.Printf("failed to flush views: %v", err)
+			}
+			return
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package data
+
+import "context"
+
+// Actor is who performs an action.
+type Actor struct {
+	// AuthorID is the author acting, empty if the actor is not an author.
+	AuthorID AuthorID
+	Admin    bool
+}
+
+// Anonymous is the actor of requests without credentials.
+var Anonymous = &Actor{}
+
+// IsAnonymous returns whether the actor has no credentials.
+func (actor *Actor) IsAnonymous() bool {
+	return actor.AuthorID == "" && !actor.Admin
+}
+
+// CanEdit returns whether the actor can edit or delete the article,
+// which is allowed to the admin and any of the co-authors.
+func (actor *Actor) CanEdit(article *ArticleInfo) bool {
+	return actor.Admin || (actor.AuthorID != "" && article.HasAuthor(actor.AuthorID))
+}
+
+type actorContextKey struct{}
+
+// WithActor returns a copy of the context carrying the actor,
+// for the repositories recording who made a change.
+func WithActor(ctx context.Context, actor *Actor) context.Context {
+	return context.WithValue(ctx, actorContextKey{}, actor)
+}
+
+// ActorFromContext gets the actor carried by the context, or Anonymous if there is none.
+func ActorFromContext(ctx context.Context) *Actor {
+	if actor, ok := ctx.Value(actorContextKey{}).(*Actor); ok {
+		return actor
+	}
+	return Anonymous
+}
@@ -0,0 +1,85 @@
+package data
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/Jason5Lee/simple-blog/core/errors"
+)
+
+type AuthorID string
+
+// Use type definition to represent the validated value.
+type AuthorName string
+type AuthorBio string
+type AuthorURL string
+
+type AuthorInfo struct {
+	DisplayName AuthorName
+	Bio         AuthorBio
+	// Avatar is the URL of the avatar image, empty if the author has none.
+	Avatar AuthorURL
+	Links  []AuthorURL
+}
+
+type Author struct {
+	ID AuthorID
+	AuthorInfo
+}
+
+const MAX_AUTHOR_NAME_LENGTH = 1024
+const MAX_AUTHOR_BIO_LENGTH = 4 * 1024 // 4KB
+const MAX_AUTHOR_URL_LENGTH = 2048
+const MAX_AUTHOR_LINKS = 16
+
+// NewAuthorName returns a new AuthorName if the name is valid.
+func NewAuthorName(name string) (AuthorName, error) {
+	if name == "" {
+		return "", errors.ErrAuthorEmpty
+	}
+	if len(name) > MAX_AUTHOR_NAME_LENGTH {
+		return "", errors.ErrAuthorTooLong
+	}
+	return AuthorName(name), nil
+}
+
+// NewAuthorBio returns a new AuthorBio if the bio is valid. The bio can be empty.
+func NewAuthorBio(bio string) (AuthorBio, error) {
+	if len(bio) > MAX_AUTHOR_BIO_LENGTH {
+		return "", errors.ErrAuthorBioTooLong
+	}
+	return AuthorBio(bio), nil
+}
+
+// NewAuthorURL returns a new AuthorURL if it is an absolute HTTP(S) URL.
+func NewAuthorURL(rawURL string) (AuthorURL, error) {
+	if len(rawURL) > MAX_AUTHOR_URL_LENGTH {
+		return "", errors.ErrInvalidAuthorURL
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", errors.ErrInvalidAuthorURL
+	}
+	return AuthorURL(rawURL), nil
+}
+
+// NewAuthorLinks returns the links if they are all valid.
+func NewAuthorLinks(links []string) ([]AuthorURL, error) {
+	if len(links) > MAX_AUTHOR_LINKS {
+		return nil, errors.ErrTooManyAuthorLinks
+	}
+	result := make([]AuthorURL, len(links))
+	for i, link := range links {
+		var err error
+		if result[i], err = NewAuthorURL(link); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// AuthorNameKey normalizes an author name so that names only differing in case or surrounding spaces,
+// like "John" and " john", are considered the same author.
+func AuthorNameKey(name AuthorName) string {
+	return strings.ToLower(strings.TrimSpace(string(name)))
+}
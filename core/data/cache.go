@@ -0,0 +1,7 @@
+package data
+
+// CacheStats is the number of reads served from a cache and from the storage behind it.
+type CacheStats struct {
+	Hits   int64
+	Misses int64
+}
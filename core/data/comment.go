@@ -0,0 +1,72 @@
+package data
+
+import (
+	"time"
+
+	"github.com/Jason5Lee/simple-blog/core/errors"
+)
+
+type CommentID string
+
+// Use type definition to represent the validated value.
+type CommentAuthor string
+type CommentContent string
+
+// CommentStatus is the moderation state of a comment.
+type CommentStatus string
+
+const (
+	CommentPending  CommentStatus = "pending"
+	CommentApproved CommentStatus = "approved"
+	CommentRejected CommentStatus = "rejected"
+	CommentSpam     CommentStatus = "spam"
+)
+
+type CommentInfo struct {
+	ArticleID ArticleID
+	Author    CommentAuthor
+	Content   CommentContent
+}
+
+type Comment struct {
+	ID CommentID
+	CommentInfo
+	Status CommentStatus
+	// SpamScore is the spam probability given by the classifier when the comment was created.
+	SpamScore float64
+	CreatedAt time.Time
+}
+
+const MAX_COMMENT_AUTHOR_LENGTH = 256
+const MAX_COMMENT_CONTENT_LENGTH = 16 * 1024 // 16KB
+
+// NewCommentAuthor returns a new CommentAuthor if the author is valid.
+func NewCommentAuthor(author string) (CommentAuthor, error) {
+	if author == "" {
+		return "", errors.ErrCommentAuthorEmpty
+	}
+	if len(author) > MAX_COMMENT_AUTHOR_LENGTH {
+		return "", errors.ErrCommentAuthorTooLong
+	}
+	return CommentAuthor(author), nil
+}
+
+// NewCommentContent returns a new CommentContent if the content is valid.
+func NewCommentContent(content string) (CommentContent, error) {
+	if content == "" {
+		return "", errors.ErrCommentContentEmpty
+	}
+	if len(content) > MAX_COMMENT_CONTENT_LENGTH {
+		return "", errors.ErrCommentContentTooLong
+	}
+	return CommentContent(content), nil
+}
+
+// NewCommentStatus returns a new CommentStatus if the status is one of the known states.
+func NewCommentStatus(status string) (CommentStatus, error) {
+	switch s := CommentStatus(status); s {
+	case CommentPending, CommentApproved, CommentRejected, CommentSpam:
+		return s, nil
+	}
+	return "", errors.ErrInvalidCommentStatus
+}
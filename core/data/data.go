@@ -1,28 +1,46 @@
 package data
 
-import "github.com/Jason5Lee/simple-blog/core/errors"
+import (
+	"time"
+
+	"github.com/Jason5Lee/simple-blog/core/errors"
+)
 
 // Use type definition to represent the validated value.
 type ArticleTitle string
 type ArticleContent string
-type ArticleAuthor string
 
 type ArticleInfo struct {
 	Title   ArticleTitle
 	Content ArticleContent
-	Author  ArticleAuthor
+	// AuthorIDs references the profiles of the authors, in the order they are credited.
+	// It contains at least one author.
+	AuthorIDs []AuthorID
 }
 
 type ArticleID string
 
+// ArticleVersion is the number of times an article has been written, 1 once created.
+// An article is only changed as of the version it was read, so a concurrent change is not lost.
+type ArticleVersion int64
+
+// FirstArticleVersion is the version of a created article.
+const FirstArticleVersion ArticleVersion = 1
+
+// AnyArticleVersion is given to change an article whatever its current version, which is never stored.
+const AnyArticleVersion ArticleVersion = 0
+
 type Article struct {
 	ID ArticleID
 	ArticleInfo
+	Version   ArticleVersion
+	CreatedAt time.Time
+	UpdatedAt time.Time
 }
 
 const MAX_ARTICLE_TITLE_LENGTH = 1024
 const MAX_ARTICLE_CONTENT_LENGTH = 4 * 1024 * 1024 // 4MB
-const MAX_ARTICLE_AUTHOR_LENGTH = 1024
+const MAX_ARTICLE_AUTHORS = 16
 
 // NewArticleTitle returns a new ArticleTitle if the title is valid.
 func NewArticleTitle(title string) (ArticleTitle, error) {
@@ -46,13 +64,40 @@ func NewArticleContent(content string) (ArticleContent, error) {
 	return ArticleContent(content), nil
 }
 
-// NewArticleAuthor returns a new ArticleAuthor if the author is valid.
-func NewArticleAuthor(author string) (ArticleAuthor, error) {
-	if author == "" {
-		return "", errors.ErrAuthorEmpty
+// NewArticleVersion returns a new ArticleVersion if the version is valid.
+func NewArticleVersion(version int64) (ArticleVersion, error) {
+	if version < int64(FirstArticleVersion) {
+		return 0, errors.ErrInvalidVersion
 	}
-	if len(author) > MAX_ARTICLE_AUTHOR_LENGTH {
-		return "", errors.ErrAuthorTooLong
+	return ArticleVersion(version), nil
+}
+
+// NewArticleAuthors returns the author IDs if there is at least one and none is repeated.
+func NewArticleAuthors(ids []string) ([]AuthorID, error) {
+	if len(ids) == 0 {
+		return nil, errors.ErrNoAuthor
+	}
+	if len(ids) > MAX_ARTICLE_AUTHORS {
+		return nil, errors.ErrTooManyAuthors
+	}
+	result := make([]AuthorID, len(ids))
+	seen := make(map[string]bool, len(ids))
+	for i, id := range ids {
+		if seen[id] {
+			return nil, errors.ErrDuplicateAuthor
+		}
+		seen[id] = true
+		result[i] = AuthorID(id)
+	}
+	return result, nil
+}
+
+// HasAuthor returns whether the author is one of the authors of the article.
+func (article *ArticleInfo) HasAuthor(id AuthorID) bool {
+	for _, authorID := range article.AuthorIDs {
+		if authorID == id {
+			return true
+		}
 	}
-	return ArticleAuthor(author), nil
+	return false
 }
@@ -0,0 +1,23 @@
+package data
+
+// ArticleEventKind is what happened to an article.
+type ArticleEventKind string
+
+const (
+	ArticleCreated ArticleEventKind = "created"
+	ArticleUpdated ArticleEventKind = "updated"
+	ArticleDeleted ArticleEventKind = "deleted"
+	// ArticlesReset is that the changes since the last event may have been missed, e.g. when the history of the
+	// storage is lost, so any article may have changed.
+	ArticlesReset ArticleEventKind = "reset"
+)
+
+// ArticleEvent is a change of an article in the storage, made by any replica.
+type ArticleEvent struct {
+	Kind ArticleEventKind
+	// ArticleID is empty for ArticlesReset.
+	ArticleID ArticleID
+	// Article is the article after the change, nil for ArticleDeleted and ArticlesReset, or if it could not be read,
+	// e.g. because it has been deleted since.
+	Article *Article
+}
@@ -0,0 +1,126 @@
+package data
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Jason5Lee/simple-blog/core/errors"
+)
+
+// MediaID is the hex-encoded SHA-256 hash of the content, so the same content is stored once.
+// Image variants are the exception, see NewImageVariantID.
+type MediaID string
+
+// Use type definition to represent the validated value.
+type MediaType string
+
+// ImageFormat is the output format of an image variant.
+type ImageFormat string
+
+const (
+	ImageJPEG ImageFormat = "jpeg"
+	ImagePNG  ImageFormat = "png"
+	ImageWebP ImageFormat = "webp"
+)
+
+// ImageFormats are all the supported image formats.
+var ImageFormats = []ImageFormat{ImageJPEG, ImagePNG, ImageWebP}
+
+// Media is the metadata of an uploaded file.
+type Media struct {
+	ID        MediaID
+	Type      MediaType
+	Size      int64
+	CreatedAt time.Time
+}
+
+// MEDIA_SNIFF_LENGTH is the number of bytes from the start of the content used to detect its type.
+const MEDIA_SNIFF_LENGTH = 512
+
+// allowedMediaTypes are the media types that can be uploaded.
+// Types rendered by the browser as documents, like HTML and SVG, are excluded as they can run scripts.
+var allowedMediaTypes = map[MediaType]bool{
+	"image/png":       true,
+	"image/jpeg":      true,
+	"image/gif":       true,
+	"image/webp":      true,
+	"image/bmp":       true,
+	"video/mp4":       true,
+	"video/webm":      true,
+	"audio/mpeg":      true,
+	"audio/wave":      true,
+	"application/ogg": true,
+	"application/pdf": true,
+}
+
+// NewMediaID returns the MediaID of the content hash.
+func NewMediaID(sha256 []byte) MediaID {
+	return MediaID(hex.EncodeToString(sha256))
+}
+
+// IsValidMediaID checks if the ID is a hex-encoded SHA-256 hash.
+// Stores should treat invalid IDs as not found, as they may come from a path.
+func IsValidMediaID(id string) bool {
+	if len(id) != 64 {
+		return false
+	}
+	for _, c := range id {
+		if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// SniffMediaType detects the type from the start of the content, ignoring what the client claims,
+// and returns it if it can be uploaded.
+func SniffMediaType(head []byte) (MediaType, error) {
+	detected := http.DetectContentType(head)
+	if i := strings.IndexByte(detected, ';'); i >= 0 {
+		detected = detected[:i]
+	}
+	mediaType := MediaType(detected)
+	if !allowedMediaTypes[mediaType] {
+		return "", errors.ErrUnsupportedMediaType
+	}
+	return mediaType, nil
+}
+
+// NewImageFormat returns the ImageFormat if it is supported.
+func NewImageFormat(format string) (ImageFormat, error) {
+	switch ImageFormat(format) {
+	case ImageJPEG, ImagePNG, ImageWebP:
+		return ImageFormat(format), nil
+	}
+	return "", errors.ErrInvalidImageVariant
+}
+
+// IsImage checks if the media type is an image that can have variants.
+func (mediaType MediaType) IsImage() bool {
+	return strings.HasPrefix(string(mediaType), "image/")
+}
+
+// DefaultImageFormat is the format of the variants of the image if none is requested,
+// which is the format of the image if it is supported, otherwise PNG.
+func (mediaType MediaType) DefaultImageFormat() ImageFormat {
+	if format, err := NewImageFormat(strings.TrimPrefix(string(mediaType), "image/")); err == nil {
+		return format
+	}
+	return ImagePNG
+}
+
+// MediaType is the media type of the image format.
+func (format ImageFormat) MediaType() MediaType {
+	return MediaType("image/" + string(format))
+}
+
+// NewImageVariantID returns the ID of a variant of the image, which is derived from the image ID,
+// the width and the format instead of the content, so the variant can be found before being generated.
+func NewImageVariantID(id MediaID, width int, format ImageFormat) MediaID {
+	hash := sha256.Sum256([]byte(fmt.Sprintf("%s/w%d.%s", id, width, format)))
+	return NewMediaID(hash[:])
+}
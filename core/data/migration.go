@@ -0,0 +1,35 @@
+package data
+
+// RecordKind is a kind of record copied by a migration, whose IDs are mapped separately.
+type RecordKind string
+
+const (
+	RecordAuthor  RecordKind = "author"
+	RecordArticle RecordKind = "article"
+	RecordComment RecordKind = "comment"
+	RecordSeries  RecordKind = "series"
+	RecordViews   RecordKind = "views"
+	// The reactions and the author tokens have no IDs, and are identified by what they belong to.
+	RecordReaction    RecordKind = "reaction"
+	RecordAuthorToken RecordKind = "token"
+)
+
+// MigratedRecords is what a migration did with a kind of records, and its verification.
+type MigratedRecords struct {
+	Kind RecordKind
+	// Copied is the number of records copied by this run.
+	Copied int
+	// Skipped is the number of records copied by a previous run.
+	Skipped int
+	// SourceCount and TargetCount are the number of records in the source and the target after the migration.
+	SourceCount int
+	TargetCount int
+	// SourceChecksum and TargetChecksum are the digests of the records, with the IDs of the source mapped to the target.
+	SourceChecksum string
+	TargetChecksum string
+}
+
+// Verified returns whether the target has the same records as the source.
+func (records *MigratedRecords) Verified() bool {
+	return records.SourceCount == records.TargetCount && records.SourceChecksum == records.TargetChecksum
+}
@@ -0,0 +1,16 @@
+package data
+
+import "github.com/Jason5Lee/simple-blog/core/errors"
+
+// PageLimit is the maximum number of items in a page.
+type PageLimit int
+
+const MAX_PAGE_LIMIT = 100
+
+// NewPageLimit returns a new PageLimit if the limit is between 1 and MAX_PAGE_LIMIT.
+func NewPageLimit(limit int) (PageLimit, error) {
+	if limit < 1 || limit > MAX_PAGE_LIMIT {
+		return 0, errors.ErrInvalidPageLimit
+	}
+	return PageLimit(limit), nil
+}
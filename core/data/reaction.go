@@ -0,0 +1,60 @@
+package data
+
+import (
+	"strings"
+
+	"github.com/Jason5Lee/simple-blog/core/errors"
+)
+
+// Use type definition to represent the validated value.
+type ReactionKind string
+type ReactionUser string
+
+// ReactionLike is always an allowed reaction kind.
+const ReactionLike ReactionKind = "like"
+
+// Reaction is a reaction of a user to an article.
+type Reaction struct {
+	User ReactionUser
+	Kind ReactionKind
+}
+
+// ReactionCounts is the number of reactions of each kind.
+type ReactionCounts map[ReactionKind]int64
+
+const MAX_REACTION_USER_LENGTH = 256
+
+// ReactionKinds is the set of allowed reaction kinds.
+type ReactionKinds map[ReactionKind]struct{}
+
+// NewReactionKinds returns the set of the like reaction plus the emojis.
+// Emojis containing "." or "$" are rejected, as they cannot be used as keys in storage.
+func NewReactionKinds(emojis []string) (ReactionKinds, error) {
+	kinds := ReactionKinds{ReactionLike: {}}
+	for _, emoji := range emojis {
+		if emoji == "" || strings.ContainsAny(emoji, ".$") {
+			return nil, errors.ErrInvalidReactionKind
+		}
+		kinds[ReactionKind(emoji)] = struct{}{}
+	}
+	return kinds, nil
+}
+
+// NewReactionKind returns a new ReactionKind if the kind is allowed.
+func (kinds ReactionKinds) NewReactionKind(kind string) (ReactionKind, error) {
+	if _, ok := kinds[ReactionKind(kind)]; !ok {
+		return "", errors.ErrInvalidReactionKind
+	}
+	return ReactionKind(kind), nil
+}
+
+// NewReactionUser returns a new ReactionUser if the user is valid.
+func NewReactionUser(user string) (ReactionUser, error) {
+	if user == "" {
+		return "", errors.ErrReactionUserEmpty
+	}
+	if len(user) > MAX_REACTION_USER_LENGTH {
+		return "", errors.ErrReactionUserTooLong
+	}
+	return ReactionUser(user), nil
+}
@@ -0,0 +1,17 @@
+package data
+
+import "time"
+
+// RevisionID identifies a revision of an article in the history of the storage.
+type RevisionID string
+
+// ArticleRevision is a change of an article recorded in the history, newest first when listed.
+type ArticleRevision struct {
+	ID RevisionID
+	// Author is the name of who made the change.
+	Author    string
+	Message   string
+	CreatedAt time.Time
+	// Deleted is whether the change deleted the article, in which case the revision has no content.
+	Deleted bool
+}
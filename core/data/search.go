@@ -0,0 +1,29 @@
+package data
+
+import (
+	"strings"
+
+	"github.com/Jason5Lee/simple-blog/core/errors"
+)
+
+// SearchQuery is a full-text search query of the articles, matching the articles containing all of its terms.
+type SearchQuery string
+
+const MAX_SEARCH_QUERY_LENGTH = 256
+
+// NewSearchQuery returns a new SearchQuery if the query has at least one term and is not too long.
+func NewSearchQuery(query string) (SearchQuery, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return "", errors.ErrSearchQueryEmpty
+	}
+	if len(query) > MAX_SEARCH_QUERY_LENGTH {
+		return "", errors.ErrSearchQueryTooLong
+	}
+	return SearchQuery(query), nil
+}
+
+// Terms returns the whitespace-separated terms of the query.
+func (query SearchQuery) Terms() []string {
+	return strings.Fields(string(query))
+}
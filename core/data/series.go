@@ -0,0 +1,78 @@
+package data
+
+import "github.com/Jason5Lee/simple-blog/core/errors"
+
+type SeriesID string
+
+// Use type definition to represent the validated value.
+type SeriesTitle string
+type SeriesDescription string
+
+type SeriesInfo struct {
+	Title       SeriesTitle
+	Description SeriesDescription
+}
+
+// Series groups articles in an explicit order, like the parts of a tutorial.
+type Series struct {
+	ID SeriesID
+	SeriesInfo
+	// ArticleIDs are the parts of the series in order.
+	ArticleIDs []ArticleID
+}
+
+// SeriesNavigation is the position of an article in a series, with its neighbors.
+type SeriesNavigation struct {
+	Series *Series
+	// Position is the 0-based index of the article in the series, counting the parts that no longer exist.
+	Position int
+	// Previous and Next are nil if the article is the first or last part.
+	Previous *Article
+	Next     *Article
+}
+
+const MAX_SERIES_TITLE_LENGTH = 1024
+const MAX_SERIES_DESCRIPTION_LENGTH = 4 * 1024 // 4KB
+
+// NewSeriesTitle returns a new SeriesTitle if the title is valid.
+func NewSeriesTitle(title string) (SeriesTitle, error) {
+	if title == "" {
+		return "", errors.ErrSeriesTitleEmpty
+	}
+	if len(title) > MAX_SERIES_TITLE_LENGTH {
+		return "", errors.ErrSeriesTitleTooLong
+	}
+	return SeriesTitle(title), nil
+}
+
+// NewSeriesDescription returns a new SeriesDescription if the description is valid. It can be empty.
+func NewSeriesDescription(description string) (SeriesDescription, error) {
+	if len(description) > MAX_SERIES_DESCRIPTION_LENGTH {
+		return "", errors.ErrSeriesDescriptionTooLong
+	}
+	return SeriesDescription(description), nil
+}
+
+// NewSeriesOrder returns the article IDs as a series order if none is repeated.
+func NewSeriesOrder(ids []string) ([]ArticleID, error) {
+	result := make([]ArticleID, len(ids))
+	seen := make(map[string]bool, len(ids))
+	for i, id := range ids {
+		if seen[id] {
+			return nil, errors.ErrInvalidSeriesOrder
+		}
+		seen[id] = true
+		result[i] = ArticleID(id)
+	}
+	return result, nil
+}
+
+// IndexOf returns the position of the article in the series, or -1 if it is not a part.
+func (series *Series) IndexOf(id ArticleID) int {
+	for i, articleID := range series.ArticleIDs {
+		if articleID == id {
+			return i
+		}
+	}
+	return -1
+}
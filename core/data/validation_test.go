@@ -31,12 +31,55 @@ func Test_LongContent(t *testing.T) {
 }
 
 func Test_EmptyAuthor(t *testing.T) {
-	_, err := data.NewArticleAuthor("")
+	_, err := data.NewAuthorName("")
 	assert.Equal(t, errors.ErrAuthorEmpty, err)
 }
 
 func Test_LongAuthor(t *testing.T) {
-	longAuthor := make([]byte, data.MAX_ARTICLE_AUTHOR_LENGTH+1)
-	_, err := data.NewArticleAuthor(string(longAuthor))
+	longAuthor := make([]byte, data.MAX_AUTHOR_NAME_LENGTH+1)
+	_, err := data.NewAuthorName(string(longAuthor))
 	assert.Equal(t, errors.ErrAuthorTooLong, err)
 }
+
+func Test_LongAuthorBio(t *testing.T) {
+	longBio := make([]byte, data.MAX_AUTHOR_BIO_LENGTH+1)
+	_, err := data.NewAuthorBio(string(longBio))
+	assert.Equal(t, errors.ErrAuthorBioTooLong, err)
+}
+
+func Test_AuthorURL(t *testing.T) {
+	_, err := data.NewAuthorURL("https://example.com/avatar.png")
+	assert.Nil(t, err)
+	_, err = data.NewAuthorURL("javascript:alert(1)")
+	assert.Equal(t, errors.ErrInvalidAuthorURL, err)
+	_, err = data.NewAuthorURL("/relative")
+	assert.Equal(t, errors.ErrInvalidAuthorURL, err)
+}
+
+func Test_AuthorNameKey(t *testing.T) {
+	assert.Equal(t, data.AuthorNameKey("John"), data.AuthorNameKey(" john "))
+}
+
+func Test_ReactionKinds(t *testing.T) {
+	kinds, err := data.NewReactionKinds([]string{"🎉"})
+	assert.Nil(t, err)
+	_, err = kinds.NewReactionKind("like")
+	assert.Nil(t, err)
+	_, err = kinds.NewReactionKind("🎉")
+	assert.Nil(t, err)
+	_, err = kinds.NewReactionKind("👎")
+	assert.Equal(t, errors.ErrInvalidReactionKind, err)
+
+	_, err = data.NewReactionKinds([]string{"a.b"})
+	assert.Equal(t, errors.ErrInvalidReactionKind, err)
+}
+
+func Test_ArticleAuthors(t *testing.T) {
+	_, err := data.NewArticleAuthors(nil)
+	assert.Equal(t, errors.ErrNoAuthor, err)
+	_, err = data.NewArticleAuthors([]string{"1", "2", "1"})
+	assert.Equal(t, errors.ErrDuplicateAuthor, err)
+	ids, err := data.NewArticleAuthors([]string{"2", "1"})
+	assert.Nil(t, err)
+	assert.Equal(t, []data.AuthorID{"2", "1"}, ids)
+}
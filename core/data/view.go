@@ -0,0 +1,28 @@
+package data
+
+import "time"
+
+// ViewIncrement is a number of views to add to an article on a day.
+type ViewIncrement struct {
+	ArticleID ArticleID
+	// Day is the midnight in UTC of the day.
+	Day   time.Time
+	Views int64
+}
+
+// DailyViews is the number of views on a day.
+type DailyViews struct {
+	Day   time.Time
+	Views int64
+}
+
+// ArticleViews is the number of views of an article in a period.
+type ArticleViews struct {
+	ArticleID ArticleID
+	Views     int64
+}
+
+// Day returns the midnight in UTC of the day of the time.
+func Day(t time.Time) time.Time {
+	return t.UTC().Truncate(24 * time.Hour)
+}
@@ -1,6 +1,9 @@
 package errors
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 var ErrNotFound = errors.New("article not found")
 var ErrTitleEmpty = errors.New("title is empty")
@@ -9,3 +12,63 @@ var ErrAuthorEmpty = errors.New("author is empty")
 var ErrTitleTooLong = errors.New("title is too long")
 var ErrContentTooLong = errors.New("content is too long")
 var ErrAuthorTooLong = errors.New("author is too long")
+var ErrCommentNotFound = errors.New("comment not found")
+var ErrCommentAuthorEmpty = errors.New("comment author is empty")
+var ErrCommentContentEmpty = errors.New("comment content is empty")
+var ErrCommentAuthorTooLong = errors.New("comment author is too long")
+var ErrCommentContentTooLong = errors.New("comment content is too long")
+var ErrInvalidCommentStatus = errors.New("invalid comment status")
+var ErrInvalidReactionKind = errors.New("invalid reaction kind")
+var ErrReactionUserEmpty = errors.New("reaction user is empty")
+var ErrReactionUserTooLong = errors.New("reaction user is too long")
+var ErrAuthorNotFound = errors.New("author not found")
+var ErrAuthorBioTooLong = errors.New("author bio is too long")
+var ErrInvalidAuthorURL = errors.New("invalid author URL")
+var ErrTooManyAuthorLinks = errors.New("too many author links")
+var ErrNoAuthor = errors.New("at least one author is required")
+var ErrTooManyAuthors = errors.New("too many authors")
+var ErrDuplicateAuthor = errors.New("duplicate author")
+var ErrUnauthorized = errors.New("unauthorized")
+var ErrForbidden = errors.New("forbidden")
+var ErrSeriesNotFound = errors.New("series not found")
+var ErrSeriesTitleEmpty = errors.New("series title is empty")
+var ErrSeriesTitleTooLong = errors.New("series title is too long")
+var ErrSeriesDescriptionTooLong = errors.New("series description is too long")
+var ErrInvalidSeriesOrder = errors.New("series order must contain each article of the series exactly once")
+var ErrArticleAlreadyInSeries = errors.New("article is already in the series")
+var ErrArticleNotInSeries = errors.New("article is not in the series")
+var ErrMediaNotFound = errors.New("media not found")
+var ErrMediaEmpty = errors.New("media is empty")
+var ErrMediaTooLarge = errors.New("media is too large")
+var ErrUnsupportedMediaType = errors.New("unsupported media type")
+var ErrInvalidImage = errors.New("invalid image")
+var ErrInvalidImageVariant = errors.New("invalid image variant")
+var ErrSearchQueryEmpty = errors.New("search query is empty")
+var ErrSearchQueryTooLong = errors.New("search query is too long")
+var ErrSearchNotSupported = errors.New("search is not supported by the storage")
+var ErrInvalidPageLimit = errors.New("page limit is invalid")
+var ErrInvalidPageCursor = errors.New("page cursor is invalid")
+var ErrPaginationNotSupported = errors.New("pagination is not supported by the storage")
+var ErrBackupNotSupported = errors.New("backup is not supported by the storage")
+var ErrRevisionNotFound = errors.New("revision not found")
+var ErrRevisionsNotSupported = errors.New("revisions are not supported by the storage")
+var ErrMigrationMismatch = errors.New("migrated data does not match the source")
+var ErrChangeStreamNotSupported = errors.New("change streams are not supported by the storage")
+var ErrConflict = errors.New("article has been changed since the version")
+var ErrVersionRequired = errors.New("version of the article is required")
+var ErrInvalidVersion = errors.New("version of the article is invalid")
+
+// CorruptDocumentError is returned when a stored document is not valid data, e.g. written by another tool,
+// with the ID of the document and why it is invalid.
+type CorruptDocumentError struct {
+	ID  string
+	Err error
+}
+
+func (err *CorruptDocumentError) Error() string {
+	return fmt.Sprintf("corrupt document %s: %v", err.ID, err.Err)
+}
+
+func (err *CorruptDocumentError) Unwrap() error {
+	return err.Err
+}
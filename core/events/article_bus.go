@@ -0,0 +1,80 @@
+package events
+
+import (
+	"sync"
+
+	"github.com/Jason5Lee/simple-blog/core/data"
+)
+
+// ArticleBus fans the article events out to the subscribers in the process, such as caches and live clients.
+// Publishing never waits for a subscriber. A subscriber too slow to keep up is unsubscribed instead, closing its
+// channel, so it knows it missed events.
+type ArticleBus struct {
+	mu          sync.Mutex
+	subscribers map[*ArticleSubscription]struct{}
+	closed      bool
+}
+
+// ArticleSubscription receives the events published after it subscribed, in order.
+type ArticleSubscription struct {
+	bus *ArticleBus
+	// C receives the events, and is closed once unsubscribed.
+	C      <-chan *data.ArticleEvent
+	events chan *data.ArticleEvent
+}
+
+func NewArticleBus() *ArticleBus {
+	return &ArticleBus{subscribers: make(map[*ArticleSubscription]struct{})}
+}
+
+// Subscribe subscribes to the events, buffering up to `buffer` of them. If the bus is closed,
+// the channel of the subscription is closed already.
+func (bus *ArticleBus) Subscribe(buffer int) *ArticleSubscription {
+	events := make(chan *data.ArticleEvent, buffer)
+	subscription := &ArticleSubscription{bus: bus, C: events, events: events}
+	bus.mu.Lock()
+	defer bus.mu.Unlock()
+	if bus.closed {
+		close(events)
+	} else {
+		bus.subscribers[subscription] = struct{}{}
+	}
+	return subscription
+}
+
+// Publish sends the event to all the subscribers, unsubscribing those whose buffer is full.
+func (bus *ArticleBus) Publish(event *data.ArticleEvent) {
+	bus.mu.Lock()
+	defer bus.mu.Unlock()
+	for subscription := range bus.subscribers {
+		select {
+		case subscription.events <- event:
+		default:
+			bus.unsubscribe(subscription)
+		}
+	}
+}
+
+// Close unsubscribes all the subscribers, and those subscribing later.
+func (bus *ArticleBus) Close() {
+	bus.mu.Lock()
+	defer bus.mu.Unlock()
+	bus.closed = true
+	for subscription := range bus.subscribers {
+		bus.unsubscribe(subscription)
+	}
+}
+
+// Unsubscribe stops receiving the events, closing the channel. It can be called more than once.
+func (subscription *ArticleSubscription) Unsubscribe() {
+	subscription.bus.mu.Lock()
+	defer subscription.bus.mu.Unlock()
+	subscription.bus.unsubscribe(subscription)
+}
+
+func (bus *ArticleBus) unsubscribe(subscription *ArticleSubscription) {
+	if _, ok := bus.subscribers[subscription]; ok {
+		delete(bus.subscribers, subscription)
+		close(subscription.events)
+	}
+}
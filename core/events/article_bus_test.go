@@ -0,0 +1,41 @@
+package events_test
+
+import (
+	"testing"
+
+	"github.com/Jason5Lee/simple-blog/core/data"
+	"github.com/Jason5Lee/simple-blog/core/events"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ArticleBus(t *testing.T) {
+	assert := assert.New(t)
+	bus := events.NewArticleBus()
+	fast := bus.Subscribe(2)
+	slow := bus.Subscribe(1)
+
+	created := &data.ArticleEvent{Kind: data.ArticleCreated, ArticleID: "1"}
+	deleted := &data.ArticleEvent{Kind: data.ArticleDeleted, ArticleID: "1"}
+	bus.Publish(created)
+	bus.Publish(deleted)
+	assert.Equal(created, <-fast.C)
+	assert.Equal(deleted, <-fast.C)
+
+	assert.Equal(created, <-slow.C, "the buffered event should be received")
+	_, ok := <-slow.C
+	assert.False(ok, "a subscriber missing an event should be unsubscribed")
+
+	late := bus.Subscribe(1)
+	fast.Unsubscribe()
+	fast.Unsubscribe()
+	_, ok = <-fast.C
+	assert.False(ok)
+	bus.Publish(deleted)
+	assert.Equal(deleted, <-late.C, "a subscriber should receive the events published after it subscribed")
+
+	bus.Close()
+	_, ok = <-late.C
+	assert.False(ok, "closing should unsubscribe all")
+	_, ok = <-bus.Subscribe(1).C
+	assert.False(ok, "subscribing to a closed bus should return a closed subscription")
+}
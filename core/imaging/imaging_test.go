@@ -0,0 +1,121 @@
+package imaging_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/HugoSmits86/nativewebp"
+	"github.com/Jason5Lee/simple-blog/core/data"
+	"github.com/Jason5Lee/simple-blog/core/errors"
+	"github.com/Jason5Lee/simple-blog/core/imaging"
+	"github.com/stretchr/testify/assert"
+)
+
+const testEXIF = "Exif\x00\x00GPS 51.5N 0.1W"
+
+func testImage(width, height int) image.Image {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for x := 0; x < width; x++ {
+		for y := 0; y < height; y++ {
+			img.Set(x, y, color.NRGBA{R: uint8(x), G: uint8(y), B: 128, A: 255})
+		}
+	}
+	return img
+}
+
+func Test_StripMetadata_JPEG(t *testing.T) {
+	assert := assert.New(t)
+
+	var encoded bytes.Buffer
+	assert.Nil(jpeg.Encode(&encoded, testImage(8, 8), nil))
+	// Insert an APP1 segment after SOI, like cameras do.
+	app1 := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(app1[2:], uint16(2+len(testEXIF)))
+	withEXIF := append(append(append([]byte{}, encoded.Bytes()[:2]...), append(app1, testEXIF...)...), encoded.Bytes()[2:]...)
+
+	stripped, err := imaging.StripMetadata("image/jpeg", withEXIF)
+	assert.Nil(err)
+	assert.NotContains(string(stripped), "GPS", "EXIF should be removed")
+	assert.Equal(encoded.Bytes(), stripped, "the rest of the image should be kept")
+	_, err = jpeg.Decode(bytes.NewReader(stripped))
+	assert.Nil(err, "stripped image should be decodable")
+
+	_, err = imaging.StripMetadata("image/jpeg", []byte("\xFF\xD8garbage"))
+	assert.Equal(errors.ErrInvalidImage, err)
+}
+
+func Test_StripMetadata_PNG(t *testing.T) {
+	assert := assert.New(t)
+
+	var encoded bytes.Buffer
+	assert.Nil(png.Encode(&encoded, testImage(8, 8)))
+	// Insert an eXIf chunk after IHDR, which is 8 + 25 bytes from the start. The CRC is not checked by the stripper.
+	chunk := make([]byte, 8, 12+len(testEXIF))
+	binary.BigEndian.PutUint32(chunk, uint32(len(testEXIF)))
+	copy(chunk[4:], "eXIf")
+	chunk = append(append(chunk, testEXIF...), 0, 0, 0, 0)
+	withEXIF := append(append(append([]byte{}, encoded.Bytes()[:33]...), chunk...), encoded.Bytes()[33:]...)
+
+	stripped, err := imaging.StripMetadata("image/png", withEXIF)
+	assert.Nil(err)
+	assert.Equal(encoded.Bytes(), stripped, "only the eXIf chunk should be removed")
+}
+
+func Test_StripMetadata_WebP(t *testing.T) {
+	assert := assert.New(t)
+
+	var encoded bytes.Buffer
+	assert.Nil(nativewebp.Encode(&encoded, testImage(8, 8), nil))
+	// Wrap the image with a VP8X chunk flagging an EXIF chunk at the end.
+	vp8x := append([]byte("VP8X\x0a\x00\x00\x00"), webpFlags(0x08)...)
+	exif := append([]byte("EXIF\x00\x00\x00\x00"), testEXIF...)
+	binary.LittleEndian.PutUint32(exif[4:], uint32(len(testEXIF)))
+	if len(testEXIF)%2 == 1 {
+		exif = append(exif, 0)
+	}
+	body := append(append(append([]byte("WEBP"), vp8x...), encoded.Bytes()[12:]...), exif...)
+	withEXIF := append([]byte("RIFF\x00\x00\x00\x00"), body...)
+	binary.LittleEndian.PutUint32(withEXIF[4:], uint32(len(body)))
+
+	stripped, err := imaging.StripMetadata("image/webp", withEXIF)
+	assert.Nil(err)
+	assert.NotContains(string(stripped), "GPS", "EXIF should be removed")
+	assert.Equal(byte(0), stripped[20], "EXIF flag should be cleared")
+	assert.Equal(uint32(len(stripped)-8), binary.LittleEndian.Uint32(stripped[4:]), "RIFF size should be updated")
+}
+
+// webpFlags returns the VP8X payload with the flags, for a 1x1 canvas.
+func webpFlags(flags byte) []byte {
+	return []byte{flags, 0, 0, 0, 0, 0, 0, 0, 0, 0}
+}
+
+func Test_Resize(t *testing.T) {
+	assert := assert.New(t)
+
+	var encoded bytes.Buffer
+	assert.Nil(png.Encode(&encoded, testImage(100, 50)))
+
+	for _, format := range []data.ImageFormat{data.ImageJPEG, data.ImagePNG, data.ImageWebP} {
+		resized, err := imaging.Resize(bytes.NewReader(encoded.Bytes()), 40, format)
+		assert.Nil(err, "resize to %s should not return error", format)
+		config, decodedFormat, err := image.DecodeConfig(bytes.NewReader(resized))
+		assert.Nil(err)
+		assert.Equal(string(format), decodedFormat)
+		assert.Equal(40, config.Width)
+		assert.Equal(20, config.Height, "aspect ratio should be kept")
+	}
+
+	resized, err := imaging.Resize(bytes.NewReader(encoded.Bytes()), 1000, data.ImagePNG)
+	assert.Nil(err)
+	config, _, err := image.DecodeConfig(bytes.NewReader(resized))
+	assert.Nil(err)
+	assert.Equal(100, config.Width, "image should not be scaled up")
+
+	_, err = imaging.Resize(bytes.NewReader([]byte("not an image")), 40, data.ImagePNG)
+	assert.Equal(errors.ErrInvalidImage, err)
+}
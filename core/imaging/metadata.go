@@ -0,0 +1,143 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+
+	"github.com/Jason5Lee/simple-blog/core/data"
+	"github.com/Jason5Lee/simple-blog/core/errors"
+)
+
+// StripMetadata removes the metadata that may reveal private information, like the EXIF location,
+// from a JPEG, PNG or WebP image without re-encoding it. Other types are returned as is.
+func StripMetadata(mediaType data.MediaType, content []byte) ([]byte, error) {
+	switch mediaType {
+	case "image/jpeg":
+		return stripJPEG(content)
+	case "image/png":
+		return stripPNG(content)
+	case "image/webp":
+		return stripWebP(content)
+	}
+	return content, nil
+}
+
+// JPEG markers of the segments removed: APP1 (EXIF and XMP), APP13 (IPTC) and comments.
+var strippedJPEGMarkers = map[byte]bool{0xE1: true, 0xED: true, 0xFE: true}
+
+func stripJPEG(content []byte) ([]byte, error) {
+	if len(content) < 2 || content[0] != 0xFF || content[1] != 0xD8 {
+		return nil, errors.ErrInvalidImage
+	}
+	result := bytes.NewBuffer(make([]byte, 0, len(content)))
+	result.Write(content[:2])
+	rest := content[2:]
+	for {
+		if len(rest) < 2 || rest[0] != 0xFF {
+			return nil, errors.ErrInvalidImage
+		}
+		marker := rest[1]
+		if marker == 0xFF {
+			// Fill byte.
+			rest = rest[1:]
+			continue
+		}
+		if marker == 0xD9 || marker == 0xDA {
+			// The metadata segments are before the image data, which starts at the start of scan.
+			result.Write(rest)
+			return result.Bytes(), nil
+		}
+		if 0xD0 <= marker && marker <= 0xD7 || marker == 0x01 {
+			// Markers without a segment.
+			result.Write(rest[:2])
+			rest = rest[2:]
+			continue
+		}
+		if len(rest) < 4 {
+			return nil, errors.ErrInvalidImage
+		}
+		length := 2 + int(binary.BigEndian.Uint16(rest[2:4]))
+		if length < 4 || length > len(rest) {
+			return nil, errors.ErrInvalidImage
+		}
+		if !strippedJPEGMarkers[marker] {
+			result.Write(rest[:length])
+		}
+		rest = rest[length:]
+	}
+}
+
+// PNG chunks removed, which are the EXIF, the text and the modification time.
+var strippedPNGChunks = map[string]bool{"eXIf": true, "tEXt": true, "zTXt": true, "iTXt": true, "tIME": true}
+
+const pngSignature = "\x89PNG\r\n\x1a\n"
+
+func stripPNG(content []byte) ([]byte, error) {
+	if !bytes.HasPrefix(content, []byte(pngSignature)) {
+		return nil, errors.ErrInvalidImage
+	}
+	result := bytes.NewBuffer(make([]byte, 0, len(content)))
+	result.WriteString(pngSignature)
+	rest := content[len(pngSignature):]
+	for len(rest) > 0 {
+		if len(rest) < 12 {
+			return nil, errors.ErrInvalidImage
+		}
+		// Length, type, data and CRC.
+		length := 12 + int(binary.BigEndian.Uint32(rest[:4]))
+		if length < 12 || length > len(rest) {
+			return nil, errors.ErrInvalidImage
+		}
+		chunkType := string(rest[4:8])
+		if !strippedPNGChunks[chunkType] {
+			result.Write(rest[:length])
+		}
+		rest = rest[length:]
+		if chunkType == "IEND" {
+			break
+		}
+	}
+	return result.Bytes(), nil
+}
+
+// VP8X flags of the EXIF and XMP chunks.
+const (
+	webpFlagEXIF = 0x08
+	webpFlagXMP  = 0x04
+)
+
+func stripWebP(content []byte) ([]byte, error) {
+	if len(content) < 12 || string(content[:4]) != "RIFF" || string(content[8:12]) != "WEBP" {
+		return nil, errors.ErrInvalidImage
+	}
+	result := make([]byte, 12, len(content))
+	copy(result, content[:12])
+	rest := content[12:]
+	for len(rest) > 0 {
+		if len(rest) < 8 {
+			return nil, errors.ErrInvalidImage
+		}
+		// FourCC, size and data padded to an even size.
+		size := int(binary.LittleEndian.Uint32(rest[4:8]))
+		length := 8 + size + size%2
+		if size < 0 || length > len(rest) {
+			return nil, errors.ErrInvalidImage
+		}
+		chunk := rest[:length]
+		switch string(chunk[:4]) {
+		case "EXIF", "XMP ":
+		case "VP8X":
+			if size < 1 {
+				return nil, errors.ErrInvalidImage
+			}
+			start := len(result)
+			result = append(result, chunk...)
+			result[start+8] &^= webpFlagEXIF | webpFlagXMP
+		default:
+			result = append(result, chunk...)
+		}
+		rest = rest[length:]
+	}
+	binary.LittleEndian.PutUint32(result[4:8], uint32(len(result)-8))
+	return result, nil
+}
@@ -0,0 +1,78 @@
+package imaging
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io"
+
+	// Register the decoders of the other uploadable image types.
+	_ "image/gif"
+
+	_ "golang.org/x/image/bmp"
+	_ "golang.org/x/image/webp"
+
+	"github.com/HugoSmits86/nativewebp"
+	"github.com/Jason5Lee/simple-blog/core/data"
+	"github.com/Jason5Lee/simple-blog/core/errors"
+	"golang.org/x/image/draw"
+)
+
+// MAX_IMAGE_PIXELS limits the size of a decoded image, so a small file cannot take a lot of memory.
+const MAX_IMAGE_PIXELS = 50 * 1000 * 1000
+
+// JPEG_QUALITY is the quality of the JPEG variants.
+const JPEG_QUALITY = 85
+
+// Resize scales the image down to the width keeping the aspect ratio, and encodes it in the format.
+// Images narrower than the width are only re-encoded. Animated GIFs keep only the first frame.
+// The metadata is never copied to the result.
+func Resize(content io.ReadSeeker, width int, format data.ImageFormat) ([]byte, error) {
+	config, _, err := image.DecodeConfig(content)
+	if err != nil {
+		return nil, errors.ErrInvalidImage
+	}
+	if config.Width*config.Height > MAX_IMAGE_PIXELS {
+		return nil, errors.ErrMediaTooLarge
+	}
+	if _, err = content.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	src, _, err := image.Decode(content)
+	if err != nil {
+		return nil, errors.ErrInvalidImage
+	}
+
+	bounds := src.Bounds()
+	if width > bounds.Dx() {
+		width = bounds.Dx()
+	}
+	height := (bounds.Dy()*width + bounds.Dx()/2) / bounds.Dx()
+	if height < 1 {
+		height = 1
+	}
+	dst := image.NewNRGBA(image.Rect(0, 0, width, height))
+	if format == data.ImageJPEG {
+		// JPEG has no transparency, so transparent pixels are shown on white instead of black.
+		draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	}
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, bounds, draw.Over, nil)
+
+	var result bytes.Buffer
+	switch format {
+	case data.ImageJPEG:
+		err = jpeg.Encode(&result, dst, &jpeg.Options{Quality: JPEG_QUALITY})
+	case data.ImagePNG:
+		err = png.Encode(&result, dst)
+	case data.ImageWebP:
+		err = nativewebp.Encode(&result, dst, nil)
+	default:
+		return nil, errors.ErrInvalidImageVariant
+	}
+	if err != nil {
+		return nil, err
+	}
+	return result.Bytes(), nil
+}
@@ -0,0 +1,12 @@
+package moderation
+
+// SpamClassifier estimates how likely a text is spam, and learns from moderator decisions.
+// Implementations must be safe for concurrent use.
+type SpamClassifier interface {
+	// SpamProbability returns the probability in [0, 1] that the text is spam.
+	SpamProbability(text string) float64
+	// Train teaches the classifier that the text is spam or not.
+	Train(text string, spam bool)
+	// Untrain makes the classifier forget the text it was taught was spam or not, such as when the decision changes.
+	Untrain(text string, spam bool)
+}
@@ -0,0 +1,47 @@
+package moderation
+
+import "strings"
+
+var linkPrefixes = []string{"http://", "https://", "www."}
+
+// CountLinks counts the links in the text.
+func CountLinks(text string) int {
+	text = strings.ToLower(text)
+	count := 0
+	for _, field := range strings.Fields(text) {
+		for _, prefix := range linkPrefixes {
+			if strings.Contains(field, prefix) {
+				count++
+				break
+			}
+		}
+	}
+	return count
+}
+
+// Blocklist matches texts containing any of its terms, case-insensitively.
+type Blocklist struct {
+	terms []string
+}
+
+func NewBlocklist(terms []string) *Blocklist {
+	blocklist := &Blocklist{}
+	for _, term := range terms {
+		term = strings.ToLower(strings.TrimSpace(term))
+		if term != "" {
+			blocklist.terms = append(blocklist.terms, term)
+		}
+	}
+	return blocklist
+}
+
+// Match returns whether the text contains a blocked term.
+func (b *Blocklist) Match(text string) bool {
+	text = strings.ToLower(text)
+	for _, term := range b.terms {
+		if strings.Contains(text, term) {
+			return true
+		}
+	}
+	return false
+}
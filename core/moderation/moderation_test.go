@@ -0,0 +1,100 @@
+package moderation_test
+
+import (
+	"testing"
+
+	"github.com/Jason5Lee/simple-blog/core/data"
+	"github.com/Jason5Lee/simple-blog/core/moderation"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_NaiveBayes(t *testing.T) {
+	assert := assert.New(t)
+
+	nb := moderation.NewNaiveBayes()
+	assert.Equal(0.5, nb.SpamProbability("buy cheap pills now"), "untrained classifier should be undecided")
+
+	nb.Train("buy cheap pills now, limited offer", true)
+	nb.Train("cheap watches, buy now", true)
+	nb.Train("great article, thanks for the explanation", false)
+	nb.Train("I have a question about the second example", false)
+
+	assert.Greater(nb.SpamProbability("buy cheap pills"), 0.9, "spammy text should have high spam probability")
+	assert.Less(nb.SpamProbability("thanks for the great explanation"), 0.1, "normal text should have low spam probability")
+}
+
+func Test_NaiveBayesUntrain(t *testing.T) {
+	assert := assert.New(t)
+
+	nb := moderation.NewNaiveBayes()
+	nb.Train("buy cheap pills now, limited offer", true)
+	nb.Train("great article, thanks for the explanation", false)
+	before := nb.SpamProbability("thanks for the cheap offer")
+
+	nb.Train("thanks, cheap offer", false)
+	assert.NotEqual(before, nb.SpamProbability("thanks for the cheap offer"))
+	nb.Untrain("thanks, cheap offer", false)
+	assert.Equal(before, nb.SpamProbability("thanks for the cheap offer"), "untraining should forget the text")
+
+	nb.Untrain("never trained", true)
+	nb.Untrain("buy cheap pills now, limited offer", true)
+	nb.Untrain("buy cheap pills now, limited offer", true)
+	assert.Equal(0.5, nb.SpamProbability("buy cheap pills"), "the counts should not go below zero")
+}
+
+func Test_CountLinks(t *testing.T) {
+	assert.Equal(t, 0, moderation.CountLinks("no links here"))
+	assert.Equal(t, 3, moderation.CountLinks("see https://a.example and http://b.example or www.c.example"))
+}
+
+func newTestModerator() *moderation.Moderator {
+	return moderation.NewModerator(moderation.Policy{
+		DefaultStatus:  data.CommentPending,
+		TrustedAuthors: []data.AuthorID{"1"},
+		SpamThreshold:  0.9,
+		MaxLinks:       1,
+		Blocklist:      []string{"casino"},
+	}, moderation.NewNaiveBayes())
+}
+
+func Test_Moderate(t *testing.T) {
+	assert := assert.New(t)
+	moderator := newTestModerator()
+
+	trusted := &data.Actor{AuthorID: "1"}
+	status, _ := moderator.Moderate(&data.CommentInfo{Author: "bob", Content: "nice post"}, data.Anonymous)
+	assert.Equal(data.CommentPending, status, "normal comment should get the default status")
+
+	status, _ = moderator.Moderate(&data.CommentInfo{Author: "alice", Content: "nice post http://a.example http://b.example"}, trusted)
+	assert.Equal(data.CommentApproved, status, "trusted author should be auto-approved regardless of links")
+
+	status, _ = moderator.Moderate(&data.CommentInfo{Author: "alice", Content: "nice post http://a.example http://b.example"}, data.Anonymous)
+	assert.Equal(data.CommentPending, status, "the name of a comment should not be trusted without credentials")
+
+	status, _ = moderator.Moderate(&data.CommentInfo{Author: "bob", Content: "nice post http://a.example http://b.example"}, &data.Actor{AuthorID: "2"})
+	assert.Equal(data.CommentPending, status, "an untrusted author should be moderated")
+
+	status, _ = moderator.Moderate(&data.CommentInfo{Author: "admin", Content: "nice post http://a.example http://b.example"}, &data.Actor{Admin: true})
+	assert.Equal(data.CommentApproved, status, "the admin should be auto-approved")
+
+	status, _ = moderator.Moderate(&data.CommentInfo{Author: "alice", Content: "visit my CASINO"}, trusted)
+	assert.Equal(data.CommentSpam, status, "blocklist should apply to trusted authors")
+
+	status, _ = moderator.Moderate(&data.CommentInfo{Author: "bob", Content: "http://a.example http://b.example"}, data.Anonymous)
+	assert.Equal(data.CommentPending, status, "too many links should be held for moderation")
+}
+
+func Test_ModerateLearned(t *testing.T) {
+	assert := assert.New(t)
+	moderator := newTestModerator()
+
+	for i := 0; i < 5; i++ {
+		moderator.Learn(&data.CommentInfo{Author: "spammer", Content: "cheap replica watches discount"}, data.CommentSpam)
+		moderator.Learn(&data.CommentInfo{Author: "reader", Content: "thanks, this helped me understand the topic"}, data.CommentApproved)
+	}
+	moderator.Learn(&data.CommentInfo{Author: "reader", Content: "cheap replica watches"}, data.CommentRejected)
+
+	status, score := moderator.Moderate(&data.CommentInfo{Author: "spammer", Content: "discount replica watches"}, data.Anonymous)
+	assert.Equal(data.CommentSpam, status, "comment similar to learned spam should be marked as spam")
+	assert.Greater(score, 0.9)
+}
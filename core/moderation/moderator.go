@@ -0,0 +1,86 @@
+package moderation
+
+import (
+	"github.com/Jason5Lee/simple-blog/core/data"
+)
+
+// Policy configures how new comments are moderated.
+type Policy struct {
+	// DefaultStatus is the status of comments not caught by any rule, either pending or approved.
+	DefaultStatus data.CommentStatus
+	// TrustedAuthors are the authors whose comments, sent with their credentials, are auto-approved unless
+	// they hit the blocklist. The comment author is a name anyone can type, so it is never trusted.
+	TrustedAuthors []data.AuthorID
+	// SpamThreshold is the spam probability from which a comment is marked as spam.
+	SpamThreshold float64
+	// MaxLinks is the maximum number of links a comment can contain before it is held for moderation.
+	MaxLinks int
+	// Blocklist contains the terms that mark a comment as spam.
+	Blocklist []string
+}
+
+// Moderator decides the initial status of new comments and learns from moderator decisions.
+type Moderator struct {
+	policy     Policy
+	trusted    map[data.AuthorID]bool
+	blocklist  *Blocklist
+	classifier SpamClassifier
+}
+
+func NewModerator(policy Policy, classifier SpamClassifier) *Moderator {
+	trusted := make(map[data.AuthorID]bool, len(policy.TrustedAuthors))
+	for _, author := range policy.TrustedAuthors {
+		trusted[author] = true
+	}
+	return &Moderator{
+		policy:     policy,
+		trusted:    trusted,
+		blocklist:  NewBlocklist(policy.Blocklist),
+		classifier: classifier,
+	}
+}
+
+// Moderate returns the initial status of a new comment sent by the actor and its spam probability.
+// The comments of the admin and of the trusted authors are auto-approved.
+func (m *Moderator) Moderate(comment *data.CommentInfo, actor *data.Actor) (data.CommentStatus, float64) {
+	text := commentText(comment)
+	if m.blocklist.Match(text) {
+		return data.CommentSpam, 1
+	}
+	score := m.classifier.SpamProbability(text)
+	if actor.Admin || (actor.AuthorID != "" && m.trusted[actor.AuthorID]) {
+		return data.CommentApproved, score
+	}
+	if score >= m.policy.SpamThreshold {
+		return data.CommentSpam, score
+	}
+	if CountLinks(string(comment.Content)) > m.policy.MaxLinks {
+		return data.CommentPending, score
+	}
+	return m.policy.DefaultStatus, score
+}
+
+// Learn trains the classifier with a moderator decision.
+// Only approved and spam comments are used, as a rejected comment is not necessarily spam.
+func (m *Moderator) Learn(comment *data.CommentInfo, status data.CommentStatus) {
+	switch status {
+	case data.CommentApproved:
+		m.classifier.Train(commentText(comment), false)
+	case data.CommentSpam:
+		m.classifier.Train(commentText(comment), true)
+	}
+}
+
+// Unlearn makes the classifier forget a decision it learned, before the comment is given another status.
+func (m *Moderator) Unlearn(comment *data.CommentInfo, status data.CommentStatus) {
+	switch status {
+	case data.CommentApproved:
+		m.classifier.Untrain(commentText(comment), false)
+	case data.CommentSpam:
+		m.classifier.Untrain(commentText(comment), true)
+	}
+}
+
+func commentText(comment *data.CommentInfo) string {
+	return string(comment.Author) + "\n" + string(comment.Content)
+}
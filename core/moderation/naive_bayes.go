@@ -0,0 +1,111 @@
+package moderation
+
+import (
+	"math"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// NaiveBayes is a multinomial naive Bayes SpamClassifier.
+// It knows nothing until it is trained, in which case it returns 0.5 for every text.
+type NaiveBayes struct {
+	mu         sync.RWMutex
+	docs       [2]int
+	tokens     [2]map[string]int
+	tokenTotal [2]int
+	vocabulary map[string]struct{}
+}
+
+const (
+	ham  = 0
+	spam = 1
+)
+
+func NewNaiveBayes() *NaiveBayes {
+	return &NaiveBayes{
+		tokens:     [2]map[string]int{make(map[string]int), make(map[string]int)},
+		vocabulary: make(map[string]struct{}),
+	}
+}
+
+func (nb *NaiveBayes) Train(text string, isSpam bool) {
+	class := ham
+	if isSpam {
+		class = spam
+	}
+
+	nb.mu.Lock()
+	defer nb.mu.Unlock()
+	nb.docs[class]++
+	for _, token := range tokenize(text) {
+		nb.tokens[class][token]++
+		nb.tokenTotal[class]++
+		nb.vocabulary[token] = struct{}{}
+	}
+}
+
+// Untrain removes the counts of the text, which are never below zero if it was not trained.
+func (nb *NaiveBayes) Untrain(text string, isSpam bool) {
+	class := ham
+	if isSpam {
+		class = spam
+	}
+
+	nb.mu.Lock()
+	defer nb.mu.Unlock()
+	if nb.docs[class] > 0 {
+		nb.docs[class]--
+	}
+	for _, token := range tokenize(text) {
+		if nb.tokens[class][token] == 0 {
+			continue
+		}
+		nb.tokens[class][token]--
+		nb.tokenTotal[class]--
+		if nb.tokens[class][token] == 0 {
+			delete(nb.tokens[class], token)
+			if nb.tokens[1-class][token] == 0 {
+				delete(nb.vocabulary, token)
+			}
+		}
+	}
+}
+
+func (nb *NaiveBayes) SpamProbability(text string) float64 {
+	nb.mu.RLock()
+	defer nb.mu.RUnlock()
+	if nb.docs[ham] == 0 || nb.docs[spam] == 0 {
+		return 0.5
+	}
+
+	totalDocs := float64(nb.docs[ham] + nb.docs[spam])
+	vocabularySize := float64(len(nb.vocabulary))
+	var logProb [2]float64
+	for class := range logProb {
+		logProb[class] = math.Log(float64(nb.docs[class]) / totalDocs)
+		denominator := float64(nb.tokenTotal[class]) + vocabularySize
+		for _, token := range tokenize(text) {
+			// Laplace smoothing so unseen tokens do not zero out the probability.
+			logProb[class] += math.Log(float64(nb.tokens[class][token]+1) / denominator)
+		}
+	}
+	// P(spam | text) = 1 / (1 + P(ham, text) / P(spam, text)), computed in log space.
+	return 1 / (1 + math.Exp(logProb[ham]-logProb[spam]))
+}
+
+// tokenize splits the text into lower-cased words, ignoring single characters.
+func tokenize(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	tokens := words[:0]
+	for _, w := range words {
+		if len(w) > 1 {
+			tokens = append(tokens, w)
+		}
+	}
+	return tokens
+}
+
+var _ SpamClassifier = (*NaiveBayes)(nil)
@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Jason5Lee/simple-blog/core/data"
+)
+
+// ArticleCache stores the articles read by ID, and the IDs without article, in front of an article repository.
+type ArticleCache interface {
+	// Get gets the cached article, nil if no article is cached as having the ID, or false if the ID is not cached.
+	Get(ctx context.Context, id data.ArticleID) (*data.Article, bool, error)
+	// Set caches the article with the ID, or that no article has the ID if it is nil.
+	Set(ctx context.Context, id data.ArticleID, article *data.Article) error
+	// Delete removes the ID from the cache.
+	Delete(ctx context.Context, id data.ArticleID) error
+	// Clear removes all the IDs from the cache.
+	Clear(ctx context.Context) error
+}
+
+// SharedArticleCache is implemented by the article caches shared by processes, which may remove an ID while
+// another process is reading the article, so the article read before must not be cached after.
+type SharedArticleCache interface {
+	ArticleCache
+	// Generation gets the generation of the ID, which changes whenever the ID is removed, including by Clear.
+	Generation(ctx context.Context, id data.ArticleID) (string, error)
+	// SetIfGeneration caches the article like Set, unless the ID has been removed since the generation was got.
+	SetIfGeneration(ctx context.Context, id data.ArticleID, article *data.Article, generation string) error
+}
+
+// ArticleRepositoryWrapper is implemented by the article repositories adding a behavior to another one, such as
+// caching, which have the optional capabilities of the wrapped repository.
+type ArticleRepositoryWrapper interface {
+	Unwrap() ArticleRepository
+}
+
+// ArticleCapability gets the optional capability, such as ArticleSearcher, of the repository or of the one it wraps.
+func ArticleCapability[T any](repo ArticleRepository) (T, bool) {
+	for {
+		if capability, ok := repo.(T); ok {
+			return capability, true
+		}
+		wrapper, ok := repo.(ArticleRepositoryWrapper)
+		if !ok {
+			var zero T
+			return zero, false
+		}
+		repo = wrapper.Unwrap()
+	}
+}
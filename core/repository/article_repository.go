@@ -6,11 +6,68 @@ import (
 	"github.com/Jason5Lee/simple-blog/core/data"
 )
 
+// ArticleRepository stores the articles. The behavior every implementation must have is tested by
+// the `repositorytest` package, such as returning the error of a done context without making any change.
 type ArticleRepository interface {
 	// Create creates a new article.
 	Create(ctx context.Context, article *data.ArticleInfo) (data.ArticleID, error)
+	// Update replaces the information of an article of the version, incrementing it.
+	// It returns ErrNotFound if no article has the ID, including a malformed ID,
+	// and ErrConflict if the article has another version.
+	Update(ctx context.Context, id data.ArticleID, version data.ArticleVersion, article *data.ArticleInfo) error
+	// Delete deletes an article of the version.
+	// It returns ErrNotFound if no article has the ID, including a malformed ID,
+	// and ErrConflict if the article has another version.
+	Delete(ctx context.Context, id data.ArticleID, version data.ArticleVersion) error
 	// GetByID gets an article by ID.
+	// It returns ErrNotFound if no article has the ID, including a malformed ID.
 	GetByID(ctx context.Context, id data.ArticleID) (*data.Article, error)
-	// GetAll gets all articles.
+	// GetAll gets all articles, oldest first.
 	GetAll(ctx context.Context) ([]*data.Article, error)
+	// GetByAuthor gets all articles having the author among their authors, oldest first.
+	GetByAuthor(ctx context.Context, authorID data.AuthorID) ([]*data.Article, error)
+}
+
+// ArticleSearcher is implemented by the article repositories supporting full-text search.
+type ArticleSearcher interface {
+	// Search gets the articles matching the query, most relevant first.
+	Search(ctx context.Context, query data.SearchQuery) ([]*data.Article, error)
+}
+
+// ArticlePager is implemented by the article repositories supporting keyset pagination.
+type ArticlePager interface {
+	// GetPage gets at most `limit` articles in creation order, following the article with the ID `after`,
+	// or from the first article if `after` is empty. The article `after` does not need to exist anymore.
+	// It returns ErrInvalidPageCursor if `after` is not a valid ID.
+	GetPage(ctx context.Context, after data.ArticleID, limit data.PageLimit) ([]*data.Article, error)
+}
+
+// ArticleHistory is implemented by the article repositories keeping the history of the changes.
+type ArticleHistory interface {
+	// GetRevisions gets the revisions of the article, newest first, including those of a deleted article.
+	// It returns ErrNotFound if the article has no revision.
+	GetRevisions(ctx context.Context, id data.ArticleID) ([]*data.ArticleRevision, error)
+	// GetRevision gets the article as of the revision.
+	// It returns ErrRevisionNotFound if the revision does not exist or the article did not exist as of it.
+	GetRevision(ctx context.Context, id data.ArticleID, revision data.RevisionID) (*data.Article, error)
+	// EachRevisionText calls the function with the stored text of every article as of every revision, once per
+	// distinct text, including the deleted articles and the invalid ones.
+	EachRevisionText(ctx context.Context, f func(text string) error) error
+}
+
+// ArticleStrictLister is implemented by the article repositories skipping the invalid stored articles in the lists,
+// so the callers which must not miss any article can fail instead.
+type ArticleStrictLister interface {
+	// GetAllStrict gets all the articles like GetAll, but returns a CorruptDocumentError if a stored article is invalid
+	// instead of skipping it.
+	GetAllStrict(ctx context.Context) ([]*data.Article, error)
+}
+
+// ArticleImporter is implemented by the article repositories able to create an article keeping its timestamps,
+// such as when it is copied from another storage.
+type ArticleImporter interface {
+	// Import creates a new article with the information, the version and the timestamps of the article,
+	// ignoring its ID.
+	// Importing the articles oldest first keeps their creation order.
+	Import(ctx context.Context, article *data.Article) (data.ArticleID, error)
 }
@@ -0,0 +1,24 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Jason5Lee/simple-blog/core/data"
+)
+
+type AuthorRepository interface {
+	// Create creates a new author profile.
+	Create(ctx context.Context, author *data.AuthorInfo) (data.AuthorID, error)
+	// GetByID gets an author by ID.
+	GetByID(ctx context.Context, id data.AuthorID) (*data.Author, error)
+	// GetByIDs gets the authors by IDs. IDs that do not match any author are absent from the result.
+	GetByIDs(ctx context.Context, ids []data.AuthorID) (map[data.AuthorID]*data.Author, error)
+	// GetAll gets all authors.
+	GetAll(ctx context.Context) ([]*data.Author, error)
+	// SetTokenHash sets the hash of the token authenticating the author, replacing the previous one.
+	SetTokenHash(ctx context.Context, id data.AuthorID, tokenHash string) error
+	// GetByTokenHash gets the author authenticated by the token with the hash.
+	GetByTokenHash(ctx context.Context, tokenHash string) (*data.Author, error)
+	// GetTokenHash gets the hash of the token authenticating the author, empty if no token has been issued.
+	GetTokenHash(ctx context.Context, id data.AuthorID) (string, error)
+}
@@ -0,0 +1,13 @@
+package repository
+
+import (
+	"context"
+	"io"
+)
+
+// BackupWriter is implemented by the storages able to write an online backup of all their data.
+type BackupWriter interface {
+	// Backup writes a consistent copy of the stored data while the storage keeps serving,
+	// returning the number of bytes written.
+	Backup(ctx context.Context, w io.Writer) (int64, error)
+}
@@ -0,0 +1,23 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Jason5Lee/simple-blog/core/data"
+)
+
+type CommentRepository interface {
+	// Create creates a new comment with the given moderation status.
+	Create(ctx context.Context, comment *data.CommentInfo, status data.CommentStatus, spamScore float64) (data.CommentID, error)
+	// GetByID gets a comment by ID.
+	GetByID(ctx context.Context, id data.CommentID) (*data.Comment, error)
+	// GetByArticle gets the comments of an article with the given status, oldest first.
+	GetByArticle(ctx context.Context, articleID data.ArticleID, status data.CommentStatus) ([]*data.Comment, error)
+	// GetByStatus gets all comments with the given status, oldest first.
+	GetByStatus(ctx context.Context, status data.CommentStatus) ([]*data.Comment, error)
+	// SetStatus sets the status of the comments, returning the number of comments updated.
+	// IDs that do not match any comment are ignored.
+	SetStatus(ctx context.Context, ids []data.CommentID, status data.CommentStatus) (int64, error)
+	// DeleteByStatus deletes all comments with the given status, returning the number of comments deleted.
+	DeleteByStatus(ctx context.Context, status data.CommentStatus) (int64, error)
+}
@@ -0,0 +1,23 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Jason5Lee/simple-blog/core/data"
+)
+
+// IDMapping stores the IDs given by the target storage of a migration to the records copied from the source,
+// so the migration can resume and the old IDs can be redirected.
+type IDMapping interface {
+	// Get gets the new ID of the record of the kind with the old ID, or false if it has not been copied.
+	Get(ctx context.Context, kind data.RecordKind, oldID string) (string, bool, error)
+	// Put stores the new ID of a copied record.
+	Put(ctx context.Context, kind data.RecordKind, oldID string, newID string) error
+	// Pend marks the record as being copied until its new ID is put, so a migration interrupted in between
+	// knows the target may have the record.
+	Pend(ctx context.Context, kind data.RecordKind, oldID string) error
+	// IsPending returns whether the record has been marked as being copied without its new ID being put.
+	IsPending(ctx context.Context, kind data.RecordKind, oldID string) (bool, error)
+	// IsNewID returns whether the ID has been put as the new ID of a record of the kind.
+	IsNewID(ctx context.Context, kind data.RecordKind, newID string) (bool, error)
+}
@@ -0,0 +1,20 @@
+package repository
+
+import (
+	"context"
+	"io"
+
+	"github.com/Jason5Lee/simple-blog/core/data"
+)
+
+type MediaStore interface {
+	// Put stores the content of the media, returning false without reading the content if the media is already stored.
+	Put(ctx context.Context, media *data.Media, content io.Reader) (bool, error)
+	// Get gets the metadata and the content of the media. The caller must close the content.
+	Get(ctx context.Context, id data.MediaID) (*data.Media, io.ReadSeekCloser, error)
+	// Delete deletes the media.
+	Delete(ctx context.Context, id data.MediaID) error
+	// List gets the metadata of all the media, including the image variants.
+	// The type may be empty if the store cannot list it cheaply.
+	List(ctx context.Context) ([]*data.Media, error)
+}
@@ -0,0 +1,21 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Jason5Lee/simple-blog/core/data"
+)
+
+type ReactionRepository interface {
+	// Add adds a reaction of the user to the article.
+	// It returns false if the user has already reacted with this kind.
+	Add(ctx context.Context, articleID data.ArticleID, user data.ReactionUser, kind data.ReactionKind) (bool, error)
+	// Remove removes a reaction of the user from the article.
+	// It returns false if the user has not reacted with this kind.
+	Remove(ctx context.Context, articleID data.ArticleID, user data.ReactionUser, kind data.ReactionKind) (bool, error)
+	// Counts gets the reaction counts of the articles.
+	// Articles without any reaction may be absent from the result.
+	Counts(ctx context.Context, articleIDs []data.ArticleID) (map[data.ArticleID]data.ReactionCounts, error)
+	// GetByArticle gets the reactions to the article, to copy them to another storage.
+	GetByArticle(ctx context.Context, articleID data.ArticleID) ([]data.Reaction, error)
+}
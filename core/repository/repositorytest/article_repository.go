@@ -0,0 +1,407 @@
+// Package repositorytest provides the behavioral contract tests of the repositories,
+// which each implementation runs against itself, so the backends cannot drift apart.
+package repositorytest
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Jason5Lee/simple-blog/core/data"
+	"github.com/Jason5Lee/simple-blog/core/errors"
+	"github.com/Jason5Lee/simple-blog/core/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// NewArticleRepository creates an empty repository for a contract test.
+// The repository is cleaned up by the function, such as with `t.Cleanup`.
+type NewArticleRepository func(t *testing.T) repository.ArticleRepository
+
+// articleContractTest is a contract test run against a new empty repository.
+type articleContractTest struct {
+	name string
+	test func(t *testing.T, repo repository.ArticleRepository)
+}
+
+var articleContractTests = []articleContractTest{
+	{"Create_And_GetByID", testCreateAndGetByID},
+	{"Create_Copies_Input", testCreateCopiesInput},
+	{"Get_Returns_Copies", testGetReturnsCopies},
+	{"Update", testUpdate},
+	{"Delete", testDelete},
+	{"Stale_Version", testStaleVersion},
+	{"Missing_And_Malformed_IDs", testMissingAndMalformedIDs},
+	{"Empty_Repository", testEmptyRepository},
+	{"Creation_Order", testCreationOrder},
+	{"Concurrent_Creates", testConcurrentCreates},
+	{"Concurrent_Updates", testConcurrentUpdates},
+	{"Canceled_Context", testCanceledContext},
+	{"Pager", testPager},
+	{"Searcher", testSearcher},
+	{"Importer", testImporter},
+}
+
+// TestArticleRepository runs the contract tests of ArticleRepository, each against a new empty repository.
+// The tests of the optional capabilities, such as ArticlePager and ArticleSearcher, are skipped
+// unless the repository implements them.
+func TestArticleRepository(t *testing.T, newRepo NewArticleRepository) {
+	for _, contract := range articleContractTests {
+		contract := contract
+		t.Run(contract.name, func(t *testing.T) {
+			contract.test(t, newRepo(t))
+		})
+	}
+}
+
+// newArticle returns the information of a test article.
+func newArticle(title string, authorIDs ...data.AuthorID) *data.ArticleInfo {
+	return &data.ArticleInfo{
+		Title:     data.ArticleTitle(title),
+		Content:   data.ArticleContent("The content of " + title + "."),
+		AuthorIDs: authorIDs,
+	}
+}
+
+// createArticles creates articles with the titles by the author, returning their IDs in creation order.
+func createArticles(t *testing.T, repo repository.ArticleRepository, titles ...string) []data.ArticleID {
+	ids := make([]data.ArticleID, len(titles))
+	for i, title := range titles {
+		id, err := repo.Create(context.Background(), newArticle(title, "1"))
+		require.NoError(t, err, "create should not return error")
+		ids[i] = id
+	}
+	return ids
+}
+
+func articleIDs(articles []*data.Article) []data.ArticleID {
+	ids := make([]data.ArticleID, len(articles))
+	for i, article := range articles {
+		ids[i] = article.ID
+	}
+	return ids
+}
+
+func testCreateAndGetByID(t *testing.T, repo repository.ArticleRepository) {
+	ctx := context.Background()
+	info := newArticle("Hello", "2", "1")
+	id, err := repo.Create(ctx, info)
+	require.NoError(t, err, "create should not return error")
+	assert.NotEmpty(t, id, "create should return an ID")
+
+	article, err := repo.GetByID(ctx, id)
+	require.NoError(t, err, "get by id should not return error")
+	assert.Equal(t, id, article.ID, "the article should have the created ID")
+	assert.Equal(t, *info, article.ArticleInfo, "the information should be stored, with the authors in credit order")
+	assert.False(t, article.CreatedAt.IsZero(), "the creation time should be set")
+	assert.True(t, article.CreatedAt.Equal(article.UpdatedAt), "a new article should not be updated")
+	assert.Equal(t, data.FirstArticleVersion, article.Version, "a new article should have the first version")
+}
+
+func testCreateCopiesInput(t *testing.T, repo repository.ArticleRepository) {
+	ctx := context.Background()
+	info := newArticle("Hello", "1", "2")
+	id, err := repo.Create(ctx, info)
+	require.NoError(t, err, "create should not return error")
+	info.AuthorIDs[0] = "3"
+
+	article, err := repo.GetByID(ctx, id)
+	require.NoError(t, err, "get by id should not return error")
+	assert.Equal(t, []data.AuthorID{"1", "2"}, article.AuthorIDs, "changing the input after create should not change the article")
+}
+
+func testGetReturnsCopies(t *testing.T, repo repository.ArticleRepository) {
+	ctx := context.Background()
+	id := createArticles(t, repo, "Hello")[0]
+	article, err := repo.GetByID(ctx, id)
+	require.NoError(t, err, "get by id should not return error")
+	article.AuthorIDs[0] = "3"
+	all, err := repo.GetAll(ctx)
+	require.NoError(t, err, "get all should not return error")
+	all[0].AuthorIDs[0] = "3"
+
+	article, err = repo.GetByID(ctx, id)
+	require.NoError(t, err, "get by id should not return error")
+	assert.Equal(t, []data.AuthorID{"1"}, article.AuthorIDs, "changing a returned article should not change the stored one")
+}
+
+func testUpdate(t *testing.T, repo repository.ArticleRepository) {
+	ctx := context.Background()
+	id := createArticles(t, repo, "Hello")[0]
+	created, err := repo.GetByID(ctx, id)
+	require.NoError(t, err, "get by id should not return error")
+
+	info := newArticle("Updated", "3", "2")
+	require.NoError(t, repo.Update(ctx, id, created.Version, info), "update should not return error")
+	article, err := repo.GetByID(ctx, id)
+	require.NoError(t, err, "get by id should not return error")
+	assert.Equal(t, *info, article.ArticleInfo, "the information should be replaced")
+	assert.Equal(t, created.Version+1, article.Version, "the version should be incremented")
+	assert.True(t, created.CreatedAt.Equal(article.CreatedAt), "the creation time should be kept")
+	assert.False(t, article.UpdatedAt.Before(article.CreatedAt), "the update time should not be before the creation time")
+
+	byAuthor, err := repo.GetByAuthor(ctx, "1")
+	require.NoError(t, err, "get by author should not return error")
+	assert.Empty(t, byAuthor, "a removed author should no longer match")
+	byAuthor, err = repo.GetByAuthor(ctx, "3")
+	require.NoError(t, err, "get by author should not return error")
+	assert.Equal(t, []data.ArticleID{id}, articleIDs(byAuthor), "an added author should match")
+}
+
+func testDelete(t *testing.T, repo repository.ArticleRepository) {
+	ctx := context.Background()
+	ids := createArticles(t, repo, "First", "Second")
+	require.NoError(t, repo.Delete(ctx, ids[0], data.FirstArticleVersion), "delete should not return error")
+
+	_, err := repo.GetByID(ctx, ids[0])
+	assert.Equal(t, errors.ErrNotFound, err, "a deleted article should not be found")
+	assert.Equal(t, errors.ErrNotFound, repo.Delete(ctx, ids[0], data.FirstArticleVersion), "deleting twice should return ErrNotFound")
+	assert.Equal(t, errors.ErrNotFound, repo.Update(ctx, ids[0], data.FirstArticleVersion, newArticle("Again", "1")), "updating a deleted article should return ErrNotFound")
+	all, err := repo.GetAll(ctx)
+	require.NoError(t, err, "get all should not return error")
+	assert.Equal(t, ids[1:], articleIDs(all), "a deleted article should not be listed")
+	byAuthor, err := repo.GetByAuthor(ctx, "1")
+	require.NoError(t, err, "get by author should not return error")
+	assert.Equal(t, ids[1:], articleIDs(byAuthor), "a deleted article should not match its authors")
+}
+
+func testStaleVersion(t *testing.T, repo repository.ArticleRepository) {
+	ctx := context.Background()
+	id := createArticles(t, repo, "Hello")[0]
+	require.NoError(t, repo.Update(ctx, id, data.FirstArticleVersion, newArticle("Updated", "1")), "update should not return error")
+
+	assert.Equal(t, errors.ErrConflict, repo.Update(ctx, id, data.FirstArticleVersion, newArticle("Stale", "1")), "updating a stale version should return ErrConflict")
+	assert.Equal(t, errors.ErrConflict, repo.Update(ctx, id, data.FirstArticleVersion+2, newArticle("Future", "1")), "updating a future version should return ErrConflict")
+	assert.Equal(t, errors.ErrConflict, repo.Delete(ctx, id, data.FirstArticleVersion), "deleting a stale version should return ErrConflict")
+	article, err := repo.GetByID(ctx, id)
+	require.NoError(t, err, "a conflict should not delete the article")
+	assert.Equal(t, data.ArticleTitle("Updated"), article.Title, "a conflict should not change the article")
+	assert.Equal(t, data.FirstArticleVersion+1, article.Version, "a conflict should not change the version")
+}
+
+func testMissingAndMalformedIDs(t *testing.T, repo repository.ArticleRepository) {
+	ctx := context.Background()
+	id := createArticles(t, repo, "Hello")[0]
+	invalidIDs := []data.ArticleID{
+		"", " ", "missing", "0", "-1", "01", "../" + id, id + " ", id + "/", " " + id,
+		data.ArticleID(strings.Repeat("f", 24)), data.ArticleID(strings.Repeat("9", 40)),
+	}
+	// An ID is only valid in the form returned by Create.
+	if upper := data.ArticleID(strings.ToUpper(string(id))); upper != id {
+		invalidIDs = append(invalidIDs, upper)
+	}
+	for _, invalid := range invalidIDs {
+		_, err := repo.GetByID(ctx, invalid)
+		assert.Equal(t, errors.ErrNotFound, err, "get by id %q should return ErrNotFound", invalid)
+		assert.Equal(t, errors.ErrNotFound, repo.Update(ctx, invalid, data.FirstArticleVersion, newArticle("Updated", "1")), "update %q should return ErrNotFound", invalid)
+		assert.Equal(t, errors.ErrNotFound, repo.Delete(ctx, invalid, data.FirstArticleVersion), "delete %q should return ErrNotFound", invalid)
+	}
+
+	article, err := repo.GetByID(ctx, id)
+	require.NoError(t, err, "the invalid IDs should not change the article")
+	assert.Equal(t, data.ArticleTitle("Hello"), article.Title, "the invalid IDs should not change the article")
+}
+
+func testEmptyRepository(t *testing.T, repo repository.ArticleRepository) {
+	ctx := context.Background()
+	all, err := repo.GetAll(ctx)
+	require.NoError(t, err, "get all should not return error")
+	assert.NotNil(t, all, "get all should return an empty slice, which is encoded as an empty array")
+	assert.Empty(t, all, "an empty repository should have no article")
+	byAuthor, err := repo.GetByAuthor(ctx, "1")
+	require.NoError(t, err, "get by author should not return error")
+	assert.NotNil(t, byAuthor, "get by author should return an empty slice, which is encoded as an empty array")
+	assert.Empty(t, byAuthor, "an empty repository should have no article")
+}
+
+func testCreationOrder(t *testing.T, repo repository.ArticleRepository) {
+	ctx := context.Background()
+	var ids []data.ArticleID
+	for i, authorIDs := range [][]data.AuthorID{{"1"}, {"2"}, {"2", "1"}, {"1"}} {
+		id, err := repo.Create(ctx, newArticle(fmt.Sprintf("Article %d", i), authorIDs...))
+		require.NoError(t, err, "create should not return error")
+		ids = append(ids, id)
+	}
+
+	all, err := repo.GetAll(ctx)
+	require.NoError(t, err, "get all should not return error")
+	assert.Equal(t, ids, articleIDs(all), "get all should return the articles oldest first")
+	byAuthor, err := repo.GetByAuthor(ctx, "1")
+	require.NoError(t, err, "get by author should not return error")
+	assert.Equal(t, []data.ArticleID{ids[0], ids[2], ids[3]}, articleIDs(byAuthor), "get by author should return the articles oldest first")
+	byAuthor, err = repo.GetByAuthor(ctx, "3")
+	require.NoError(t, err, "get by author should not return error")
+	assert.Empty(t, byAuthor, "an unknown author should match no article")
+}
+
+const concurrency = 8
+
+func testConcurrentCreates(t *testing.T, repo repository.ArticleRepository) {
+	ctx := context.Background()
+	ids := make([]data.ArticleID, concurrency)
+	errs := make([]error, concurrency)
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			// The same title tests the repositories deriving the ID from it.
+			ids[i], errs[i] = repo.Create(ctx, newArticle("Concurrent", "1"))
+		}(i)
+	}
+	wg.Wait()
+
+	unique := make(map[data.ArticleID]bool)
+	for i := range ids {
+		require.NoError(t, errs[i], "concurrent creates should not return error")
+		unique[ids[i]] = true
+	}
+	assert.Len(t, unique, concurrency, "concurrent creates should return distinct IDs")
+	all, err := repo.GetAll(ctx)
+	require.NoError(t, err, "get all should not return error")
+	assert.Len(t, all, concurrency, "every concurrent create should be stored")
+}
+
+func testConcurrentUpdates(t *testing.T, repo repository.ArticleRepository) {
+	ctx := context.Background()
+	id := createArticles(t, repo, "Hello")[0]
+	titles := make(map[data.ArticleTitle]bool)
+	errs := make([]error, concurrency)
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		title := fmt.Sprintf("Title %d", i)
+		titles[data.ArticleTitle(title)] = true
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = repo.Update(ctx, id, data.FirstArticleVersion, newArticle(title, "1"))
+			if errs[i] == nil {
+				_, errs[i] = repo.GetByID(ctx, id)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	updated := 0
+	for _, err := range errs {
+		if err != errors.ErrConflict {
+			require.NoError(t, err, "concurrent updates and gets should not return error")
+			updated++
+		}
+	}
+	assert.Equal(t, 1, updated, "only one of the concurrent updates of the same version should succeed")
+	article, err := repo.GetByID(ctx, id)
+	require.NoError(t, err, "get by id should not return error")
+	assert.True(t, titles[article.Title], "the successful update should win as a whole")
+	assert.Equal(t, data.ArticleContent("The content of "+string(article.Title)+"."), article.Content, "an update should not be mixed with another")
+	assert.Equal(t, data.FirstArticleVersion+1, article.Version, "the version should be incremented once")
+}
+
+func testCanceledContext(t *testing.T, repo repository.ArticleRepository) {
+	id := createArticles(t, repo, "Hello")[0]
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := repo.Create(ctx, newArticle("Canceled", "1"))
+	assert.ErrorIs(t, err, context.Canceled, "create should return the error of the context")
+	assert.ErrorIs(t, repo.Update(ctx, id, data.FirstArticleVersion, newArticle("Canceled", "1")), context.Canceled, "update should return the error of the context")
+	assert.ErrorIs(t, repo.Delete(ctx, id, data.FirstArticleVersion), context.Canceled, "delete should return the error of the context")
+	_, err = repo.GetByID(ctx, id)
+	assert.ErrorIs(t, err, context.Canceled, "get by id should return the error of the context")
+	_, err = repo.GetAll(ctx)
+	assert.ErrorIs(t, err, context.Canceled, "get all should return the error of the context")
+	_, err = repo.GetByAuthor(ctx, "1")
+	assert.ErrorIs(t, err, context.Canceled, "get by author should return the error of the context")
+
+	all, err := repo.GetAll(context.Background())
+	require.NoError(t, err, "get all should not return error")
+	require.Len(t, all, 1, "a canceled create or delete should change nothing")
+	assert.Equal(t, data.ArticleTitle("Hello"), all[0].Title, "a canceled update should change nothing")
+}
+
+func testPager(t *testing.T, repo repository.ArticleRepository) {
+	pager, ok := repository.ArticleCapability[repository.ArticlePager](repo)
+	if !ok {
+		t.Skip("the repository does not implement ArticlePager")
+	}
+	ctx := context.Background()
+	ids := createArticles(t, repo, "First", "Second", "Third", "Fourth", "Fifth")
+
+	var paged []data.ArticleID
+	after := data.ArticleID("")
+	for {
+		page, err := pager.GetPage(ctx, after, 2)
+		require.NoError(t, err, "get page should not return error")
+		require.LessOrEqual(t, len(page), 2, "a page should not exceed the limit")
+		paged = append(paged, articleIDs(page)...)
+		if len(page) < 2 {
+			break
+		}
+		after = page[len(page)-1].ID
+	}
+	assert.Equal(t, ids, paged, "the pages should return every article once in creation order")
+
+	require.NoError(t, repo.Delete(ctx, ids[2], data.FirstArticleVersion), "delete should not return error")
+	page, err := pager.GetPage(ctx, ids[2], 2)
+	require.NoError(t, err, "the cursor of a deleted article should still be valid")
+	assert.Equal(t, ids[3:], articleIDs(page), "the page should follow the deleted article")
+
+	_, err = pager.GetPage(ctx, "not an id", 2)
+	assert.Equal(t, errors.ErrInvalidPageCursor, err, "a malformed cursor should return ErrInvalidPageCursor")
+}
+
+func testSearcher(t *testing.T, repo repository.ArticleRepository) {
+	searcher, ok := repository.ArticleCapability[repository.ArticleSearcher](repo)
+	if !ok {
+		t.Skip("the repository does not implement ArticleSearcher")
+	}
+	ctx := context.Background()
+	goID, err := repo.Create(ctx, &data.ArticleInfo{Title: "Learning Go", Content: "Goroutines and channels.", AuthorIDs: []data.AuthorID{"1"}})
+	require.NoError(t, err, "create should not return error")
+	rustID, err := repo.Create(ctx, &data.ArticleInfo{Title: "Learning Rust", Content: "Ownership and channels.", AuthorIDs: []data.AuthorID{"1"}})
+	require.NoError(t, err, "create should not return error")
+
+	articles, err := searcher.Search(ctx, "channels")
+	require.NoError(t, err, "search should not return error")
+	assert.ElementsMatch(t, []data.ArticleID{goID, rustID}, articleIDs(articles), "the articles containing the term should match")
+	articles, err = searcher.Search(ctx, "LEARNING goroutines")
+	require.NoError(t, err, "search should not return error")
+	assert.Equal(t, []data.ArticleID{goID}, articleIDs(articles), "only the articles containing all the terms should match, ignoring case")
+	articles, err = searcher.Search(ctx, `"ownership OR*`)
+	require.NoError(t, err, "the syntax of the query language of the backend should be ignored")
+	assert.Empty(t, articles, "no article contains the literal terms")
+
+	require.NoError(t, repo.Update(ctx, rustID, data.FirstArticleVersion, &data.ArticleInfo{Title: "Learning Zig", Content: "Comptime.", AuthorIDs: []data.AuthorID{"1"}}), "update should not return error")
+	require.NoError(t, repo.Delete(ctx, goID, data.FirstArticleVersion), "delete should not return error")
+	articles, err = searcher.Search(ctx, "channels")
+	require.NoError(t, err, "search should not return error")
+	assert.Empty(t, articles, "the search should follow the updates and deletions")
+}
+
+func testImporter(t *testing.T, repo repository.ArticleRepository) {
+	importer, ok := repository.ArticleCapability[repository.ArticleImporter](repo)
+	if !ok {
+		t.Skip("the repository does not implement ArticleImporter")
+	}
+	ctx := context.Background()
+	createdAt := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	updatedAt := createdAt.Add(48 * time.Hour)
+	info := newArticle("Imported", "2", "1")
+	id, err := importer.Import(ctx, &data.Article{ID: "ignored", ArticleInfo: *info, Version: 3, CreatedAt: createdAt, UpdatedAt: updatedAt})
+	require.NoError(t, err, "import should not return error")
+	assert.NotEqual(t, data.ArticleID("ignored"), id, "import should give a new ID")
+	newID := createArticles(t, repo, "Created")[0]
+
+	article, err := repo.GetByID(ctx, id)
+	require.NoError(t, err, "get by id should not return error")
+	assert.Equal(t, *info, article.ArticleInfo, "the information should be imported")
+	assert.True(t, createdAt.Equal(article.CreatedAt), "the creation time should be kept")
+	assert.True(t, updatedAt.Equal(article.UpdatedAt), "the update time should be kept")
+	assert.Equal(t, data.ArticleVersion(3), article.Version, "the version should be kept")
+	all, err := repo.GetAll(ctx)
+	require.NoError(t, err, "get all should not return error")
+	assert.Equal(t, []data.ArticleID{id, newID}, articleIDs(all), "an article imported first should be listed first")
+}
@@ -0,0 +1,28 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Jason5Lee/simple-blog/core/data"
+)
+
+type SeriesRepository interface {
+	// Create creates a new empty series.
+	Create(ctx context.Context, series *data.SeriesInfo) (data.SeriesID, error)
+	// GetByID gets a series by ID.
+	GetByID(ctx context.Context, id data.SeriesID) (*data.Series, error)
+	// GetAll gets all series.
+	GetAll(ctx context.Context) ([]*data.Series, error)
+	// GetByArticle gets the series containing the article.
+	GetByArticle(ctx context.Context, articleID data.ArticleID) ([]*data.Series, error)
+	// AddArticle appends an article to the series.
+	// It returns ErrArticleAlreadyInSeries if the article is already a part.
+	AddArticle(ctx context.Context, id data.SeriesID, articleID data.ArticleID) error
+	// RemoveArticle removes an article from the series.
+	// It returns ErrArticleNotInSeries if the article is not a part.
+	RemoveArticle(ctx context.Context, id data.SeriesID, articleID data.ArticleID) error
+	// Reorder atomically replaces the order of the parts of the series.
+	// It returns ErrInvalidSeriesOrder unless the order contains exactly the current parts,
+	// so a concurrent addition or removal is never lost.
+	Reorder(ctx context.Context, id data.SeriesID, articleIDs []data.ArticleID) error
+}
@@ -0,0 +1,18 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Jason5Lee/simple-blog/core/data"
+)
+
+type ViewRepository interface {
+	// AddViews adds the view increments in a batch.
+	AddViews(ctx context.Context, increments []data.ViewIncrement) error
+	// GetDaily gets the daily views of an article from the day `from` to the day `to`, both inclusive, ordered by day.
+	// Days without any view may be absent from the result.
+	GetDaily(ctx context.Context, articleID data.ArticleID, from time.Time, to time.Time) ([]data.DailyViews, error)
+	// GetTop gets the n most viewed articles from the day `from` to the day `to`, both inclusive, most viewed first.
+	GetTop(ctx context.Context, from time.Time, to time.Time, n int) ([]data.ArticleViews, error)
+}
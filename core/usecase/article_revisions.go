@@ -0,0 +1,44 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/Jason5Lee/simple-blog/core/data"
+	"github.com/Jason5Lee/simple-blog/core/errors"
+	"github.com/Jason5Lee/simple-blog/core/repository"
+)
+
+// getArticleHistory gets the history of the repository if the actor can read that of the article,
+// which is allowed to the admin, even after the article is deleted, and to the co-authors of the current article.
+func getArticleHistory(ctx context.Context, repo repository.ArticleRepository, actor *data.Actor, id data.ArticleID) (repository.ArticleHistory, error) {
+	history, ok := repository.ArticleCapability[repository.ArticleHistory](repo)
+	if !ok {
+		return nil, errors.ErrRevisionsNotSupported
+	}
+	if !actor.Admin {
+		if _, err := getEditableArticle(ctx, repo, actor, id); err != nil {
+			return nil, err
+		}
+	}
+	return history, nil
+}
+
+// GetArticleRevisions gets the revisions of an article, newest first.
+// It returns ErrRevisionsNotSupported if the repository does not keep the history.
+func GetArticleRevisions(ctx context.Context, repo repository.ArticleRepository, actor *data.Actor, id data.ArticleID) ([]*data.ArticleRevision, error) {
+	history, err := getArticleHistory(ctx, repo, actor, id)
+	if err != nil {
+		return nil, err
+	}
+	return history.GetRevisions(ctx, id)
+}
+
+// GetArticleRevision gets an article as of a revision.
+// It returns ErrRevisionsNotSupported if the repository does not keep the history.
+func GetArticleRevision(ctx context.Context, repo repository.ArticleRepository, actor *data.Actor, id data.ArticleID, revision data.RevisionID) (*data.Article, error) {
+	history, err := getArticleHistory(ctx, repo, actor, id)
+	if err != nil {
+		return nil, err
+	}
+	return history.GetRevision(ctx, id, revision)
+}
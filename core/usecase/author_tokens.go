@@ -0,0 +1,45 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+
+	"github.com/Jason5Lee/simple-blog/core/data"
+	"github.com/Jason5Lee/simple-blog/core/errors"
+	"github.com/Jason5Lee/simple-blog/core/repository"
+)
+
+// hashToken hashes a token so the repository never stores it in plain text.
+func hashToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
+
+// IssueAuthorToken issues a new token authenticating an existing author, revoking the previous one.
+// The token is only returned once, as the repository only keeps its hash.
+func IssueAuthorToken(ctx context.Context, authorRepo repository.AuthorRepository, id data.AuthorID) (string, error) {
+	if _, err := authorRepo.GetByID(ctx, id); err != nil {
+		return "", err
+	}
+	random := make([]byte, 32)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(random)
+	if err := authorRepo.SetTokenHash(ctx, id, hashToken(token)); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// AuthenticateAuthor gets the author authenticated by the token, or returns ErrUnauthorized.
+func AuthenticateAuthor(ctx context.Context, authorRepo repository.AuthorRepository, token string) (*data.Author, error) {
+	author, err := authorRepo.GetByTokenHash(ctx, hashToken(token))
+	if err == errors.ErrAuthorNotFound {
+		return nil, errors.ErrUnauthorized
+	}
+	return author, err
+}
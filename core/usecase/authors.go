@@ -0,0 +1,46 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/Jason5Lee/simple-blog/core/data"
+	"github.com/Jason5Lee/simple-blog/core/repository"
+)
+
+// CreateAuthor creates a new author profile.
+func CreateAuthor(ctx context.Context, authorRepo repository.AuthorRepository, author *data.AuthorInfo) (data.AuthorID, error) {
+	return authorRepo.Create(ctx, author)
+}
+
+// GetAuthorByID gets an author profile by ID.
+func GetAuthorByID(ctx context.Context, authorRepo repository.AuthorRepository, id data.AuthorID) (*data.Author, error) {
+	return authorRepo.GetByID(ctx, id)
+}
+
+// GetAllAuthors gets all author profiles.
+func GetAllAuthors(ctx context.Context, authorRepo repository.AuthorRepository) ([]*data.Author, error) {
+	return authorRepo.GetAll(ctx)
+}
+
+// GetAuthorsOfArticles gets the author profiles of the articles, keyed by author ID.
+func GetAuthorsOfArticles(ctx context.Context, authorRepo repository.AuthorRepository, articles []*data.Article) (map[data.AuthorID]*data.Author, error) {
+	ids := make([]data.AuthorID, 0, len(articles))
+	seen := make(map[data.AuthorID]bool, len(articles))
+	for _, article := range articles {
+		for _, id := range article.AuthorIDs {
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+	}
+	return authorRepo.GetByIDs(ctx, ids)
+}
+
+// GetAuthorArticles gets the articles an existing author wrote or co-wrote.
+func GetAuthorArticles(ctx context.Context, articleRepo repository.ArticleRepository, authorRepo repository.AuthorRepository, id data.AuthorID) ([]*data.Article, error) {
+	if _, err := authorRepo.GetByID(ctx, id); err != nil {
+		return nil, err
+	}
+	return articleRepo.GetByAuthor(ctx, id)
+}
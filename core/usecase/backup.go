@@ -0,0 +1,19 @@
+package usecase
+
+import (
+	"context"
+	"io"
+
+	"github.com/Jason5Lee/simple-blog/core/errors"
+	"github.com/Jason5Lee/simple-blog/core/repository"
+)
+
+// Backup writes an online backup of the storage, returning the number of bytes written.
+// It returns ErrBackupNotSupported if the repository cannot write a backup.
+func Backup(ctx context.Context, repo repository.ArticleRepository, w io.Writer) (int64, error) {
+	writer, ok := repository.ArticleCapability[repository.BackupWriter](repo)
+	if !ok {
+		return 0, errors.ErrBackupNotSupported
+	}
+	return writer.Backup(ctx, w)
+}
@@ -0,0 +1,120 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Jason5Lee/simple-blog/core/data"
+	"github.com/Jason5Lee/simple-blog/core/errors"
+	"github.com/Jason5Lee/simple-blog/core/moderation"
+	"github.com/Jason5Lee/simple-blog/core/usecase"
+	infra_repository "github.com/Jason5Lee/simple-blog/infra/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_CommentModeration(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := context.Background()
+	articleRepo := infra_repository.NewArticleRepositoryInMemory()
+	commentRepo := infra_repository.NewCommentRepositoryInMemory()
+	moderator := moderation.NewModerator(moderation.Policy{
+		DefaultStatus: data.CommentPending,
+		SpamThreshold: 0.9,
+		MaxLinks:      2,
+	}, moderation.NewNaiveBayes())
+
+	_, _, err := usecase.CreateComment(ctx, articleRepo, commentRepo, moderator, data.Anonymous, &data.CommentInfo{
+		ArticleID: "42",
+		Author:    "reader",
+		Content:   "nice post",
+	})
+	assert.Equal(errors.ErrNotFound, err, "comment on a missing article should return ErrNotFound")
+
+	authorRepo := infra_repository.NewAuthorRepositoryInMemory()
+	articleID, err := usecase.CreateArticle(ctx, articleRepo, authorRepo, testAdmin, &data.ArticleInfo{
+		Title:     testTitle,
+		Content:   testContent,
+		AuthorIDs: []data.AuthorID{createTestAuthor(t, ctx, authorRepo, testAuthor)},
+	})
+	assert.Nil(err, "create article should not return error")
+
+	commentID, status, err := usecase.CreateComment(ctx, articleRepo, commentRepo, moderator, data.Anonymous, &data.CommentInfo{
+		ArticleID: articleID,
+		Author:    "reader",
+		Content:   "nice post",
+	})
+	assert.Nil(err, "create comment should not return error")
+	assert.Equal(data.CommentPending, status, "new comment should be pending")
+
+	comments, err := usecase.GetArticleComments(ctx, commentRepo, articleID)
+	assert.Nil(err, "get article comments should not return error")
+	assert.Empty(comments, "pending comment should not be visible")
+
+	queue, err := usecase.GetCommentsByStatus(ctx, commentRepo, data.CommentPending)
+	assert.Nil(err, "get moderation queue should not return error")
+	assert.Len(queue, 1, "pending comment should be in the moderation queue")
+
+	updated, err := usecase.ModerateComments(ctx, commentRepo, moderator, []data.CommentID{commentID, "missing"}, data.CommentApproved)
+	assert.Nil(err, "moderate comments should not return error")
+	assert.Equal(int64(1), updated, "only the existing comment should be updated")
+
+	comments, err = usecase.GetArticleComments(ctx, commentRepo, articleID)
+	assert.Nil(err, "get article comments should not return error")
+	assert.Len(comments, 1, "approved comment should be visible")
+	assert.Equal("nice post", string(comments[0].Content))
+
+	_, _, err = usecase.CreateComment(ctx, articleRepo, commentRepo, moderator, data.Anonymous, &data.CommentInfo{
+		ArticleID: articleID,
+		Author:    "spammer",
+		Content:   "buy now",
+	})
+	assert.Nil(err, "create comment should not return error")
+	spam, err := usecase.GetCommentsByStatus(ctx, commentRepo, data.CommentPending)
+	assert.Nil(err)
+	_, err = usecase.ModerateComments(ctx, commentRepo, moderator, []data.CommentID{spam[0].ID}, data.CommentSpam)
+	assert.Nil(err)
+
+	deleted, err := usecase.PurgeComments(ctx, commentRepo, data.CommentSpam)
+	assert.Nil(err, "purge comments should not return error")
+	assert.Equal(int64(1), deleted, "the spam comment should be purged")
+	spam, err = usecase.GetCommentsByStatus(ctx, commentRepo, data.CommentSpam)
+	assert.Nil(err)
+	assert.Empty(spam, "no spam should remain after purging")
+}
+
+func Test_ModerateComments_Reclassify(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := context.Background()
+	articleRepo := infra_repository.NewArticleRepositoryInMemory()
+	commentRepo := infra_repository.NewCommentRepositoryInMemory()
+	classifier := moderation.NewNaiveBayes()
+	moderator := moderation.NewModerator(moderation.Policy{DefaultStatus: data.CommentPending, SpamThreshold: 0.9, MaxLinks: 2}, classifier)
+	articleID, err := articleRepo.Create(ctx, &data.ArticleInfo{Title: testTitle, Content: testContent, AuthorIDs: []data.AuthorID{"1"}})
+	assert.Nil(err)
+
+	create := func(author string, content string) data.CommentID {
+		id, _, err := usecase.CreateComment(ctx, articleRepo, commentRepo, moderator, data.Anonymous, &data.CommentInfo{
+			ArticleID: articleID,
+			Author:    data.CommentAuthor(author),
+			Content:   data.CommentContent(content),
+		})
+		assert.Nil(err)
+		return id
+	}
+	ham := create("reader", "great article, thanks for the explanation")
+	spam := create("seller", "cheap watches, buy now")
+	_, err = usecase.ModerateComments(ctx, commentRepo, moderator, []data.CommentID{ham, spam}, data.CommentApproved)
+	assert.Nil(err)
+	_, err = usecase.ModerateComments(ctx, commentRepo, moderator, []data.CommentID{spam}, data.CommentSpam)
+	assert.Nil(err)
+
+	// The classifier should only know the final decisions.
+	expected := moderation.NewNaiveBayes()
+	expected.Train("reader\ngreat article, thanks for the explanation", false)
+	expected.Train("seller\ncheap watches, buy now", true)
+	for _, text := range []string{"cheap watches", "thanks for the article"} {
+		assert.Equal(expected.SpamProbability(text), classifier.SpamProbability(text), "the reclassified comment should be unlearned")
+	}
+}
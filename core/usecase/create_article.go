@@ -4,12 +4,37 @@ import (
 	"context"
 
 	"github.com/Jason5Lee/simple-blog/core/data"
+	"github.com/Jason5Lee/simple-blog/core/errors"
 	"github.com/Jason5Lee/simple-blog/core/repository"
 )
 
-// CreateArticle creates a new article.
+// CreateArticle creates a new article by existing authors, which is only allowed to one of the authors and the admin.
 // Note that each field in the type `ArticleInfo` uses the type definition,
 // which means they are validated.
-func CreateArticle(ctx context.Context, repo repository.ArticleRepository, article *data.ArticleInfo) (data.ArticleID, error) {
-	return repo.Create(ctx, article)
+func CreateArticle(ctx context.Context, repo repository.ArticleRepository, authorRepo repository.AuthorRepository, actor *data.Actor, article *data.ArticleInfo) (data.ArticleID, error) {
+	if actor.IsAnonymous() {
+		return "", errors.ErrUnauthorized
+	}
+	if !actor.CanEdit(article) {
+		return "", errors.ErrForbidden
+	}
+	if err := checkAuthorsExist(ctx, authorRepo, article.AuthorIDs); err != nil {
+		return "", err
+	}
+	// The actor is passed to the repositories recording who made the change.
+	return repo.Create(data.WithActor(ctx, actor), article)
+}
+
+// checkAuthorsExist returns ErrAuthorNotFound if any of the authors does not exist.
+func checkAuthorsExist(ctx context.Context, authorRepo repository.AuthorRepository, ids []data.AuthorID) error {
+	authors, err := authorRepo.GetByIDs(ctx, ids)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if _, ok := authors[id]; !ok {
+			return errors.ErrAuthorNotFound
+		}
+	}
+	return nil
 }
@@ -0,0 +1,24 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/Jason5Lee/simple-blog/core/data"
+	"github.com/Jason5Lee/simple-blog/core/moderation"
+	"github.com/Jason5Lee/simple-blog/core/repository"
+)
+
+// CreateComment creates a new comment of the actor on an existing article, with the status decided by the moderator,
+// which learns the decision like TrainModerator does with the stored comments, so it can be unlearned if it changes.
+func CreateComment(ctx context.Context, articleRepo repository.ArticleRepository, commentRepo repository.CommentRepository, moderator *moderation.Moderator, actor *data.Actor, comment *data.CommentInfo) (data.CommentID, data.CommentStatus, error) {
+	if _, err := articleRepo.GetByID(ctx, comment.ArticleID); err != nil {
+		return "", "", err
+	}
+	status, score := moderator.Moderate(comment, actor)
+	id, err := commentRepo.Create(ctx, comment, status, score)
+	if err != nil {
+		return "", "", err
+	}
+	moderator.Learn(comment, status)
+	return id, status, nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/Jason5Lee/simple-blog/core/data"
+	"github.com/Jason5Lee/simple-blog/core/errors"
 	"github.com/Jason5Lee/simple-blog/core/repository"
 )
 
@@ -11,3 +12,13 @@ import (
 func GetAllArticles(ctx context.Context, repo repository.ArticleRepository) ([]*data.Article, error) {
 	return repo.GetAll(ctx)
 }
+
+// GetArticlePage gets a page of the articles in creation order, following the article with the ID `after`.
+// It returns ErrPaginationNotSupported if the repository does not support keyset pagination.
+func GetArticlePage(ctx context.Context, repo repository.ArticleRepository, after data.ArticleID, limit data.PageLimit) ([]*data.Article, error) {
+	pager, ok := repository.ArticleCapability[repository.ArticlePager](repo)
+	if !ok {
+		return nil, errors.ErrPaginationNotSupported
+	}
+	return pager.GetPage(ctx, after, limit)
+}
@@ -0,0 +1,18 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/Jason5Lee/simple-blog/core/data"
+	"github.com/Jason5Lee/simple-blog/core/repository"
+)
+
+// GetArticleComments gets the approved comments of an article.
+func GetArticleComments(ctx context.Context, commentRepo repository.CommentRepository, articleID data.ArticleID) ([]*data.Comment, error) {
+	return commentRepo.GetByArticle(ctx, articleID, data.CommentApproved)
+}
+
+// GetCommentsByStatus gets the comments with the given status, e.g. the pending ones for the moderation queue.
+func GetCommentsByStatus(ctx context.Context, commentRepo repository.CommentRepository, status data.CommentStatus) ([]*data.Comment, error) {
+	return commentRepo.GetByStatus(ctx, status)
+}
@@ -0,0 +1,223 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"io"
+	"regexp"
+	"time"
+
+	"github.com/Jason5Lee/simple-blog/core/data"
+	"github.com/Jason5Lee/simple-blog/core/errors"
+	"github.com/Jason5Lee/simple-blog/core/imaging"
+	"github.com/Jason5Lee/simple-blog/core/repository"
+	"golang.org/x/sync/singleflight"
+)
+
+// UploadMedia stores the content of the given size if it is within the limit and of an allowed type.
+// The metadata of images is stripped for privacy. Content already stored is deduplicated, in which case false is returned with the existing media.
+func UploadMedia(ctx context.Context, store repository.MediaStore, content io.ReadSeeker, size int64, maxSize int64) (*data.Media, bool, error) {
+	if size == 0 {
+		return nil, false, errors.ErrMediaEmpty
+	}
+	if size > maxSize {
+		return nil, false, errors.ErrMediaTooLarge
+	}
+
+	head := make([]byte, data.MEDIA_SNIFF_LENGTH)
+	n, err := io.ReadFull(content, head)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return nil, false, err
+	}
+	mediaType, err := data.SniffMediaType(head[:n])
+	if err != nil {
+		return nil, false, err
+	}
+
+	if _, err = content.Seek(0, io.SeekStart); err != nil {
+		return nil, false, err
+	}
+	if mediaType.IsImage() {
+		// The metadata is stripped before hashing, so the same image with different metadata is stored once.
+		raw, err := io.ReadAll(content)
+		if err != nil {
+			return nil, false, err
+		}
+		stripped, err := imaging.StripMetadata(mediaType, raw)
+		if err != nil {
+			return nil, false, err
+		}
+		content = bytes.NewReader(stripped)
+		size = int64(len(stripped))
+	}
+	hash := sha256.New()
+	if _, err = io.Copy(hash, content); err != nil {
+		return nil, false, err
+	}
+	if _, err = content.Seek(0, io.SeekStart); err != nil {
+		return nil, false, err
+	}
+
+	media := &data.Media{
+		ID:        data.NewMediaID(hash.Sum(nil)),
+		Type:      mediaType,
+		Size:      size,
+		CreatedAt: time.Now().UTC().Truncate(time.Second),
+	}
+	created, err := store.Put(ctx, media, content)
+	if err != nil {
+		return nil, false, err
+	}
+	if !created {
+		existing, existingContent, err := store.Get(ctx, media.ID)
+		if err != nil {
+			return nil, false, err
+		}
+		existingContent.Close()
+		return existing, false, nil
+	}
+	return media, true, nil
+}
+
+// GetMedia gets the metadata and the content of the media. The caller must close the content.
+func GetMedia(ctx context.Context, store repository.MediaStore, id data.MediaID) (*data.Media, io.ReadSeekCloser, error) {
+	return store.Get(ctx, id)
+}
+
+// imageVariantGroup makes concurrent requests of a missing variant generate it once.
+var imageVariantGroup singleflight.Group
+
+// imageVariantTimeout limits the generation of a variant, which is not canceled with the request starting it
+// as the other requests wait for it.
+const imageVariantTimeout = time.Minute
+
+// GetImageVariant gets the variant of the image resized to the width and encoded in the format, generating
+// and storing it on the first request. The format defaults to the one of the image if it is empty.
+// Only the given widths are allowed, so requests cannot fill the store with arbitrary variants.
+// The caller must close the content.
+func GetImageVariant(ctx context.Context, store repository.MediaStore, id data.MediaID, width int, format data.ImageFormat, widths []int) (*data.Media, io.ReadSeekCloser, error) {
+	allowed := false
+	for _, w := range widths {
+		allowed = allowed || w == width
+	}
+	if !allowed {
+		return nil, nil, errors.ErrInvalidImageVariant
+	}
+	if format == "" {
+		original, content, err := store.Get(ctx, id)
+		if err != nil {
+			return nil, nil, err
+		}
+		content.Close()
+		format = original.Type.DefaultImageFormat()
+	}
+
+	variantID := data.NewImageVariantID(id, width, format)
+	media, content, err := store.Get(ctx, variantID)
+	if err != errors.ErrMediaNotFound {
+		return media, content, err
+	}
+	_, err, _ = imageVariantGroup.Do(string(variantID), func() (interface{}, error) {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), imageVariantTimeout)
+		defer cancel()
+		return nil, generateImageVariant(ctx, store, id, variantID, width, format)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return store.Get(ctx, variantID)
+}
+
+func generateImageVariant(ctx context.Context, store repository.MediaStore, id data.MediaID, variantID data.MediaID, width int, format data.ImageFormat) error {
+	original, content, err := store.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	defer content.Close()
+	if !original.Type.IsImage() {
+		return errors.ErrInvalidImageVariant
+	}
+	resized, err := imaging.Resize(content, width, format)
+	if err != nil {
+		return err
+	}
+	_, err = store.Put(ctx, &data.Media{
+		ID:        variantID,
+		Type:      format.MediaType(),
+		Size:      int64(len(resized)),
+		CreatedAt: original.CreatedAt,
+	}, bytes.NewReader(resized))
+	return err
+}
+
+// mediaReference matches the paths of the media served by the blog, like in the markdown images.
+var mediaReference = regexp.MustCompile(`/media/([0-9a-f]{64})`)
+
+// CollectMediaGarbage deletes the media not referenced by any article content or author avatar, with their image variants.
+// The media referenced by a revision of an article is also kept if the repository keeps the history, and the collection
+// fails if the repository has invalid articles, which could reference some media.
+// The media created within the grace period is kept, as it may be uploaded for an article still being written.
+// It returns the IDs of the deleted media, or of the media that would be deleted if it is a dry run.
+func CollectMediaGarbage(ctx context.Context, store repository.MediaStore, articleRepo repository.ArticleRepository, authorRepo repository.AuthorRepository, imageWidths []int, gracePeriod time.Duration, dryRun bool) ([]data.MediaID, error) {
+	// The media is listed first, so the media uploaded and referenced during the collection is not deleted.
+	allMedia, err := store.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	referenced := make(map[data.MediaID]bool)
+	reference := func(text string) {
+		for _, match := range mediaReference.FindAllStringSubmatch(text, -1) {
+			id := data.MediaID(match[1])
+			referenced[id] = true
+			for _, width := range imageWidths {
+				for _, format := range data.ImageFormats {
+					referenced[data.NewImageVariantID(id, width, format)] = true
+				}
+			}
+		}
+	}
+	getAllArticles := articleRepo.GetAll
+	if lister, ok := repository.ArticleCapability[repository.ArticleStrictLister](articleRepo); ok {
+		getAllArticles = lister.GetAllStrict
+	}
+	articles, err := getAllArticles(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, article := range articles {
+		reference(string(article.Content))
+	}
+	if history, ok := repository.ArticleCapability[repository.ArticleHistory](articleRepo); ok {
+		err := history.EachRevisionText(ctx, func(text string) error {
+			reference(text)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	authors, err := authorRepo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, author := range authors {
+		reference(string(author.Avatar))
+	}
+
+	deadline := time.Now().Add(-gracePeriod)
+	garbage := make([]data.MediaID, 0)
+	for _, media := range allMedia {
+		if referenced[media.ID] || media.CreatedAt.After(deadline) {
+			continue
+		}
+		if !dryRun {
+			if err = store.Delete(ctx, media.ID); err != nil && err != errors.ErrMediaNotFound {
+				return garbage, err
+			}
+		}
+		garbage = append(garbage, media.ID)
+	}
+	return garbage, nil
+}
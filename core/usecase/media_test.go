@@ -0,0 +1,200 @@
+package usecase_test
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Jason5Lee/simple-blog/core/data"
+	"github.com/Jason5Lee/simple-blog/core/errors"
+	"github.com/Jason5Lee/simple-blog/core/usecase"
+	infra_repository "github.com/Jason5Lee/simple-blog/infra/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+// A 1x1 transparent PNG.
+var testPNG = func() []byte {
+	var encoded bytes.Buffer
+	if err := png.Encode(&encoded, image.NewNRGBA(image.Rect(0, 0, 1, 1))); err != nil {
+		panic(err)
+	}
+	return encoded.Bytes()
+}()
+
+func Test_UploadMedia(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := context.Background()
+	store, err := infra_repository.NewMediaStoreLocal(t.TempDir())
+	assert.Nil(err)
+
+	_, _, err = usecase.UploadMedia(ctx, store, bytes.NewReader(testPNG), int64(len(testPNG)), 16)
+	assert.Equal(errors.ErrMediaTooLarge, err, "media over the limit should return ErrMediaTooLarge")
+	html := []byte("<html><script>alert(1)</script></html>")
+	_, _, err = usecase.UploadMedia(ctx, store, bytes.NewReader(html), int64(len(html)), 1024)
+	assert.Equal(errors.ErrUnsupportedMediaType, err, "HTML should return ErrUnsupportedMediaType")
+	_, _, err = usecase.UploadMedia(ctx, store, bytes.NewReader(nil), 0, 1024)
+	assert.Equal(errors.ErrMediaEmpty, err, "empty media should return ErrMediaEmpty")
+
+	media, created, err := usecase.UploadMedia(ctx, store, bytes.NewReader(testPNG), int64(len(testPNG)), 1024)
+	assert.Nil(err, "upload PNG should not return error")
+	assert.True(created)
+	assert.Equal("image/png", string(media.Type))
+	assert.Equal(int64(len(testPNG)), media.Size)
+
+	again, created, err := usecase.UploadMedia(ctx, store, bytes.NewReader(testPNG), int64(len(testPNG)), 1024)
+	assert.Nil(err)
+	assert.False(created, "same content should be deduplicated")
+	assert.Equal(media.ID, again.ID)
+	assert.True(media.CreatedAt.Equal(again.CreatedAt), "deduplicated media should keep the original creation time")
+
+	got, content, err := usecase.GetMedia(ctx, store, media.ID)
+	assert.Nil(err)
+	defer content.Close()
+	gotContent, err := io.ReadAll(content)
+	assert.Nil(err)
+	assert.Equal(testPNG, gotContent)
+	assert.Equal(media.Type, got.Type)
+
+	_, _, err = usecase.GetMedia(ctx, store, "../../etc/passwd")
+	assert.Equal(errors.ErrMediaNotFound, err, "invalid ID should return ErrMediaNotFound")
+}
+
+func Test_GetImageVariant(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := context.Background()
+	store, err := infra_repository.NewMediaStoreLocal(t.TempDir())
+	assert.Nil(err)
+	var encoded bytes.Buffer
+	assert.Nil(png.Encode(&encoded, image.NewNRGBA(image.Rect(0, 0, 64, 32))))
+	media, _, err := usecase.UploadMedia(ctx, store, bytes.NewReader(encoded.Bytes()), int64(encoded.Len()), 1<<20)
+	assert.Nil(err)
+	widths := []int{16, 32}
+
+	_, _, err = usecase.GetImageVariant(ctx, store, media.ID, 20, "", widths)
+	assert.Equal(errors.ErrInvalidImageVariant, err, "width not configured should return ErrInvalidImageVariant")
+
+	variant, content, err := usecase.GetImageVariant(ctx, store, media.ID, 16, data.ImageWebP, widths)
+	assert.Nil(err, "get variant should not return error")
+	assert.Equal(data.MediaType("image/webp"), variant.Type)
+	assert.Equal(data.NewImageVariantID(media.ID, 16, data.ImageWebP), variant.ID)
+	config, _, err := image.DecodeConfig(content)
+	content.Close()
+	assert.Nil(err)
+	assert.Equal(16, config.Width)
+	assert.Equal(8, config.Height)
+
+	variant, content, err = usecase.GetImageVariant(ctx, store, media.ID, 32, "", widths)
+	assert.Nil(err)
+	content.Close()
+	assert.Equal(data.MediaType("image/png"), variant.Type, "variant should default to the format of the image")
+
+	pdf := []byte("%PDF-1.4")
+	document, _, err := usecase.UploadMedia(ctx, store, bytes.NewReader(pdf), int64(len(pdf)), 1024)
+	assert.Nil(err)
+	_, _, err = usecase.GetImageVariant(ctx, store, document.ID, 16, data.ImagePNG, widths)
+	assert.Equal(errors.ErrInvalidImageVariant, err, "non-image should not have variants")
+}
+
+func Test_CollectMediaGarbage(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := context.Background()
+	store, err := infra_repository.NewMediaStoreLocal(t.TempDir())
+	assert.Nil(err)
+	articleRepo := infra_repository.NewArticleRepositoryInMemory()
+	authorRepo := infra_repository.NewAuthorRepositoryInMemory()
+	widths := []int{16}
+
+	upload := func(width int) *data.Media {
+		var encoded bytes.Buffer
+		assert.Nil(png.Encode(&encoded, image.NewNRGBA(image.Rect(0, 0, width, 1))))
+		media, _, err := usecase.UploadMedia(ctx, store, bytes.NewReader(encoded.Bytes()), int64(encoded.Len()), 1<<20)
+		assert.Nil(err)
+		return media
+	}
+	inArticle, avatar, unreferenced := upload(20), upload(21), upload(22)
+	_, content, err := usecase.GetImageVariant(ctx, store, inArticle.ID, 16, data.ImageWebP, widths)
+	assert.Nil(err)
+	content.Close()
+	_, content, err = usecase.GetImageVariant(ctx, store, unreferenced.ID, 16, data.ImageWebP, widths)
+	assert.Nil(err)
+	content.Close()
+
+	authorID, err := usecase.CreateAuthor(ctx, authorRepo, &data.AuthorInfo{
+		DisplayName: testAuthor,
+		Avatar:      data.AuthorURL("https://blog.example.com/media/" + string(avatar.ID)),
+	})
+	assert.Nil(err)
+	_, err = usecase.CreateArticle(ctx, articleRepo, authorRepo, testAdmin, &data.ArticleInfo{
+		Title:     testTitle,
+		Content:   data.ArticleContent("![image](/media/" + string(inArticle.ID) + ")"),
+		AuthorIDs: []data.AuthorID{authorID},
+	})
+	assert.Nil(err)
+
+	garbage, err := usecase.CollectMediaGarbage(ctx, store, articleRepo, authorRepo, widths, time.Hour, false)
+	assert.Nil(err)
+	assert.Empty(garbage, "media within the grace period should be kept")
+
+	unreferencedVariant := data.NewImageVariantID(unreferenced.ID, 16, data.ImageWebP)
+	garbage, err = usecase.CollectMediaGarbage(ctx, store, articleRepo, authorRepo, widths, 0, true)
+	assert.Nil(err)
+	assert.ElementsMatch([]data.MediaID{unreferenced.ID, unreferencedVariant}, garbage, "unreferenced media and its variant should be garbage")
+	_, content, err = usecase.GetMedia(ctx, store, unreferenced.ID)
+	assert.Nil(err, "dry run should not delete")
+	content.Close()
+
+	garbage, err = usecase.CollectMediaGarbage(ctx, store, articleRepo, authorRepo, widths, 0, false)
+	assert.Nil(err)
+	assert.Len(garbage, 2)
+	_, _, err = usecase.GetMedia(ctx, store, unreferenced.ID)
+	assert.Equal(errors.ErrMediaNotFound, err, "unreferenced media should be deleted")
+	for _, id := range []data.MediaID{inArticle.ID, avatar.ID, data.NewImageVariantID(inArticle.ID, 16, data.ImageWebP)} {
+		_, content, err = usecase.GetMedia(ctx, store, id)
+		assert.Nil(err, "referenced media should be kept")
+		content.Close()
+	}
+}
+
+func Test_CollectMediaGarbage_Articles(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := context.Background()
+	store, err := infra_repository.NewMediaStoreLocal(t.TempDir())
+	assert.Nil(err)
+	authorRepo := infra_repository.NewAuthorRepositoryInMemory()
+	media, _, err := usecase.UploadMedia(ctx, store, bytes.NewReader(testPNG), int64(len(testPNG)), 1<<20)
+	assert.Nil(err)
+	markdown := "![image](/media/" + string(media.ID) + ")"
+
+	// The media is only referenced by an older revision.
+	gitRepo, err := infra_repository.NewArticleRepositoryGit(t.TempDir())
+	assert.Nil(err)
+	id, err := gitRepo.Create(ctx, &data.ArticleInfo{Title: testTitle, Content: data.ArticleContent(markdown), AuthorIDs: []data.AuthorID{"1"}})
+	assert.Nil(err)
+	assert.Nil(gitRepo.Update(ctx, id, data.FirstArticleVersion, &data.ArticleInfo{Title: testTitle, Content: testContent, AuthorIDs: []data.AuthorID{"1"}}))
+	garbage, err := usecase.CollectMediaGarbage(ctx, store, gitRepo, authorRepo, nil, 0, false)
+	assert.Nil(err)
+	assert.Empty(garbage, "the media referenced by a revision should be kept")
+
+	// The media is only referenced by an invalid file.
+	dir := t.TempDir()
+	assert.Nil(os.WriteFile(filepath.Join(dir, "invalid.md"), []byte("---\ntitle: [\n---\n"+markdown), 0644))
+	filesystemRepo, err := infra_repository.NewArticleRepositoryFilesystem(dir)
+	assert.Nil(err)
+	defer filesystemRepo.Close()
+	garbage, err = usecase.CollectMediaGarbage(ctx, store, filesystemRepo, authorRepo, nil, 0, false)
+	assert.IsType(&errors.CorruptDocumentError{}, err, "an invalid article should fail the collection")
+	assert.Nil(garbage)
+	_, content, err := usecase.GetMedia(ctx, store, media.ID)
+	assert.Nil(err, "the media should not be deleted")
+	content.Close()
+}
@@ -0,0 +1,705 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/Jason5Lee/simple-blog/core/data"
+	"github.com/Jason5Lee/simple-blog/core/errors"
+	"github.com/Jason5Lee/simple-blog/core/repository"
+)
+
+// MigrationStorage is the repositories of a storage a migration copies from or to.
+type MigrationStorage struct {
+	ArticleRepo  repository.ArticleRepository
+	AuthorRepo   repository.AuthorRepository
+	CommentRepo  repository.CommentRepository
+	ViewRepo     repository.ViewRepository
+	SeriesRepo   repository.SeriesRepository
+	ReactionRepo repository.ReactionRepository
+}
+
+// MigrateStorage copies the authors, articles, series, comments, views, reactions and author tokens from the source
+// storage to the target, then verifies the target has the same records, returning what was done with each kind of
+// records. It returns ErrMigrationMismatch along with the result if the verification fails.
+//
+// The target gives new IDs to the records, which are stored in the ID mapping as soon as each record is copied,
+// so running the migration again resumes it, skipping what was already copied, including a record created by a run
+// interrupted before storing its ID.
+// The reactions and the author tokens have no IDs, so they are skipped if the target already has them.
+// The articles keep their timestamps if the target is an ArticleImporter.
+func MigrateStorage(ctx context.Context, source *MigrationStorage, target *MigrationStorage, ids repository.IDMapping) ([]data.MigratedRecords, error) {
+	m := &migration{source: source, target: target, ids: ids}
+	m.importer, _ = repository.ArticleCapability[repository.ArticleImporter](target.ArticleRepo)
+
+	steps := []struct {
+		kind   data.RecordKind
+		copy   func(ctx context.Context, records *data.MigratedRecords) error
+		digest func(ctx context.Context, storage *MigrationStorage, mapped bool) (recordDigests, error)
+	}{
+		{data.RecordAuthor, m.copyAuthors, m.digestAuthors},
+		{data.RecordArticle, m.copyArticles, m.digestArticles},
+		{data.RecordSeries, m.copySeries, m.digestSeries},
+		{data.RecordComment, m.copyComments, m.digestComments},
+		{data.RecordViews, m.copyViews, m.digestViews},
+		{data.RecordReaction, m.copyReactions, m.digestReactions},
+		{data.RecordAuthorToken, m.copyAuthorTokens, m.digestAuthorTokens},
+	}
+	result := make([]data.MigratedRecords, len(steps))
+	for i, step := range steps {
+		result[i].Kind = step.kind
+		if err := step.copy(ctx, &result[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	verified := true
+	for i, step := range steps {
+		sourceDigests, err := step.digest(ctx, source, true)
+		if err != nil {
+			return nil, err
+		}
+		targetDigests, err := step.digest(ctx, target, false)
+		if err != nil {
+			return nil, err
+		}
+		result[i].SourceCount, result[i].SourceChecksum = len(sourceDigests), sourceDigests.checksum()
+		result[i].TargetCount, result[i].TargetChecksum = len(targetDigests), targetDigests.checksum()
+		verified = verified && result[i].Verified()
+	}
+	if !verified {
+		return result, errors.ErrMigrationMismatch
+	}
+	return result, nil
+}
+
+type migration struct {
+	source   *MigrationStorage
+	target   *MigrationStorage
+	ids      repository.IDMapping
+	importer repository.ArticleImporter
+}
+
+// copyRecord creates the record in the target unless it has already been copied, then stores its new ID.
+// The record is marked as pending before it is created, so if a run is interrupted before storing its ID, resuming
+// looks for it among the records `find` gets from the target with the same content, and creates it again only if
+// none is left once those copied from other records are skipped.
+func (m *migration) copyRecord(ctx context.Context, records *data.MigratedRecords, oldID string, create func() (string, error), find func() ([]string, error)) (string, error) {
+	newID, ok, err := m.ids.Get(ctx, records.Kind, oldID)
+	if err != nil {
+		return "", err
+	}
+	if ok {
+		records.Skipped++
+		return newID, nil
+	}
+	pending, err := m.ids.IsPending(ctx, records.Kind, oldID)
+	if err != nil {
+		return "", err
+	}
+	if pending {
+		if newID, ok, err = m.findCopy(ctx, records.Kind, find); err != nil {
+			return "", err
+		}
+	} else if err := m.ids.Pend(ctx, records.Kind, oldID); err != nil {
+		return "", err
+	}
+	if !ok {
+		if newID, err = create(); err != nil {
+			return "", err
+		}
+	}
+	if err := m.ids.Put(ctx, records.Kind, oldID, newID); err != nil {
+		return "", err
+	}
+	records.Copied++
+	return newID, nil
+}
+
+// findCopy gets the first of the records found in the target that has not been copied from another record.
+func (m *migration) findCopy(ctx context.Context, kind data.RecordKind, find func() ([]string, error)) (string, bool, error) {
+	candidates, err := find()
+	if err != nil {
+		return "", false, err
+	}
+	for _, id := range candidates {
+		copied, err := m.ids.IsNewID(ctx, kind, id)
+		if err != nil {
+			return "", false, err
+		}
+		if !copied {
+			return id, true, nil
+		}
+	}
+	return "", false, nil
+}
+
+// sameRecord returns whether the records have the same content, compared like their digests.
+func sameRecord(a interface{}, b interface{}) bool {
+	// Marshalling a struct never fails.
+	contentA, _ := json.Marshal(a)
+	contentB, _ := json.Marshal(b)
+	return bytes.Equal(contentA, contentB)
+}
+
+// mapID gets the ID in the target of the record in the source.
+// A record that has not been copied, like one referenced but missing from the source, keeps its ID.
+func (m *migration) mapID(ctx context.Context, kind data.RecordKind, oldID string) (string, error) {
+	newID, ok, err := m.ids.Get(ctx, kind, oldID)
+	if err != nil || !ok {
+		return oldID, err
+	}
+	return newID, nil
+}
+
+func (m *migration) mapArticleInfo(ctx context.Context, article *data.ArticleInfo) (*data.ArticleInfo, error) {
+	result := *article
+	result.AuthorIDs = make([]data.AuthorID, len(article.AuthorIDs))
+	for i, id := range article.AuthorIDs {
+		newID, err := m.mapID(ctx, data.RecordAuthor, string(id))
+		if err != nil {
+			return nil, err
+		}
+		result.AuthorIDs[i] = data.AuthorID(newID)
+	}
+	return &result, nil
+}
+
+func (m *migration) copyAuthors(ctx context.Context, records *data.MigratedRecords) error {
+	authors, err := m.source.AuthorRepo.GetAll(ctx)
+	if err != nil {
+		return err
+	}
+	for _, author := range authors {
+		_, err := m.copyRecord(ctx, records, string(author.ID), func() (string, error) {
+			id, err := m.target.AuthorRepo.Create(ctx, &author.AuthorInfo)
+			return string(id), err
+		}, func() ([]string, error) {
+			candidates, err := m.target.AuthorRepo.GetAll(ctx)
+			if err != nil {
+				return nil, err
+			}
+			var ids []string
+			for _, candidate := range candidates {
+				if sameRecord(candidate.AuthorInfo, author.AuthorInfo) {
+					ids = append(ids, string(candidate.ID))
+				}
+			}
+			return ids, nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *migration) copyArticles(ctx context.Context, records *data.MigratedRecords) error {
+	return eachArticle(ctx, m.source.ArticleRepo, func(article *data.Article) error {
+		_, err := m.copyRecord(ctx, records, string(article.ID), func() (string, error) {
+			info, err := m.mapArticleInfo(ctx, &article.ArticleInfo)
+			if err != nil {
+				return "", err
+			}
+			var id data.ArticleID
+			if m.importer != nil {
+				id, err = m.importer.Import(ctx, &data.Article{ArticleInfo: *info, Version: article.Version, CreatedAt: article.CreatedAt, UpdatedAt: article.UpdatedAt})
+			} else {
+				id, err = m.target.ArticleRepo.Create(ctx, info)
+			}
+			return string(id), err
+		}, func() ([]string, error) {
+			info, err := m.mapArticleInfo(ctx, &article.ArticleInfo)
+			if err != nil {
+				return nil, err
+			}
+			record := m.articleRecord(article, info)
+			var ids []string
+			err = eachArticle(ctx, m.target.ArticleRepo, func(candidate *data.Article) error {
+				if sameRecord(m.articleRecord(candidate, &candidate.ArticleInfo), record) {
+					ids = append(ids, string(candidate.ID))
+				}
+				return nil
+			})
+			return ids, err
+		})
+		return err
+	})
+}
+
+func (m *migration) copySeries(ctx context.Context, records *data.MigratedRecords) error {
+	allSeries, err := m.source.SeriesRepo.GetAll(ctx)
+	if err != nil {
+		return err
+	}
+	for _, series := range allSeries {
+		newID, err := m.copyRecord(ctx, records, string(series.ID), func() (string, error) {
+			id, err := m.target.SeriesRepo.Create(ctx, &series.SeriesInfo)
+			return string(id), err
+		}, func() ([]string, error) {
+			candidates, err := m.target.SeriesRepo.GetAll(ctx)
+			if err != nil {
+				return nil, err
+			}
+			var ids []string
+			for _, candidate := range candidates {
+				// The parts are added after the series is created.
+				if sameRecord(candidate.SeriesInfo, series.SeriesInfo) {
+					ids = append(ids, string(candidate.ID))
+				}
+			}
+			return ids, nil
+		})
+		if err != nil {
+			return err
+		}
+		// The parts are appended in order, so those missing are the ones not added before an interruption.
+		copied, err := m.target.SeriesRepo.GetByID(ctx, data.SeriesID(newID))
+		if err != nil {
+			return err
+		}
+		for _, articleID := range series.ArticleIDs {
+			newArticleID, err := m.mapID(ctx, data.RecordArticle, string(articleID))
+			if err != nil {
+				return err
+			}
+			if copied.IndexOf(data.ArticleID(newArticleID)) >= 0 {
+				continue
+			}
+			if err := m.target.SeriesRepo.AddArticle(ctx, data.SeriesID(newID), data.ArticleID(newArticleID)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// copyComments copies the comments of the copied articles.
+// The comments left by a deleted article are dropped, as the target may not accept them.
+func (m *migration) copyComments(ctx context.Context, records *data.MigratedRecords) error {
+	comments, err := getAllComments(ctx, m.source.CommentRepo)
+	if err != nil {
+		return err
+	}
+	for _, comment := range comments {
+		articleID, ok, err := m.ids.Get(ctx, data.RecordArticle, string(comment.ArticleID))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+		info := comment.CommentInfo
+		info.ArticleID = data.ArticleID(articleID)
+		_, err = m.copyRecord(ctx, records, string(comment.ID), func() (string, error) {
+			id, err := m.target.CommentRepo.Create(ctx, &info, comment.Status, comment.SpamScore)
+			return string(id), err
+		}, func() ([]string, error) {
+			candidates, err := getAllComments(ctx, m.target.CommentRepo)
+			if err != nil {
+				return nil, err
+			}
+			record := commentRecord(comment, info)
+			var ids []string
+			for _, candidate := range candidates {
+				if sameRecord(commentRecord(candidate, candidate.CommentInfo), record) {
+					ids = append(ids, string(candidate.ID))
+				}
+			}
+			return ids, nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// copyViews copies the daily views of the articles, counting each day as a record.
+// The views of an article are skipped if the target already has some, as they can only come from a previous run.
+func (m *migration) copyViews(ctx context.Context, records *data.MigratedRecords) error {
+	from, to := allViewDays()
+	return eachArticle(ctx, m.source.ArticleRepo, func(article *data.Article) error {
+		daily, err := m.source.ViewRepo.GetDaily(ctx, article.ID, from, to)
+		if err != nil || len(daily) == 0 {
+			return err
+		}
+		newID, err := m.mapID(ctx, data.RecordArticle, string(article.ID))
+		if err != nil {
+			return err
+		}
+		existing, err := m.target.ViewRepo.GetDaily(ctx, data.ArticleID(newID), from, to)
+		if err != nil {
+			return err
+		}
+		if len(existing) > 0 {
+			records.Skipped += len(daily)
+			return nil
+		}
+		increments := make([]data.ViewIncrement, len(daily))
+		for i, views := range daily {
+			increments[i] = data.ViewIncrement{ArticleID: data.ArticleID(newID), Day: views.Day, Views: views.Views}
+		}
+		if err := m.target.ViewRepo.AddViews(ctx, increments); err != nil {
+			return err
+		}
+		records.Copied += len(daily)
+		return nil
+	})
+}
+
+// copyReactions copies the reactions to the copied articles.
+func (m *migration) copyReactions(ctx context.Context, records *data.MigratedRecords) error {
+	return eachArticle(ctx, m.source.ArticleRepo, func(article *data.Article) error {
+		reactions, err := m.source.ReactionRepo.GetByArticle(ctx, article.ID)
+		if err != nil || len(reactions) == 0 {
+			return err
+		}
+		newID, err := m.mapID(ctx, data.RecordArticle, string(article.ID))
+		if err != nil {
+			return err
+		}
+		for _, reaction := range reactions {
+			added, err := m.target.ReactionRepo.Add(ctx, data.ArticleID(newID), reaction.User, reaction.Kind)
+			if err != nil {
+				return err
+			}
+			if added {
+				records.Copied++
+			} else {
+				records.Skipped++
+			}
+		}
+		return nil
+	})
+}
+
+// copyAuthorTokens copies the hashes of the tokens of the copied authors, so the tokens keep working.
+func (m *migration) copyAuthorTokens(ctx context.Context, records *data.MigratedRecords) error {
+	authors, err := m.source.AuthorRepo.GetAll(ctx)
+	if err != nil {
+		return err
+	}
+	for _, author := range authors {
+		tokenHash, err := m.source.AuthorRepo.GetTokenHash(ctx, author.ID)
+		if err != nil {
+			return err
+		}
+		if tokenHash == "" {
+			continue
+		}
+		newID, err := m.mapID(ctx, data.RecordAuthor, string(author.ID))
+		if err != nil {
+			return err
+		}
+		copied, err := m.target.AuthorRepo.GetTokenHash(ctx, data.AuthorID(newID))
+		if err != nil {
+			return err
+		}
+		if copied == tokenHash {
+			records.Skipped++
+			continue
+		}
+		if err := m.target.AuthorRepo.SetTokenHash(ctx, data.AuthorID(newID), tokenHash); err != nil {
+			return err
+		}
+		records.Copied++
+	}
+	return nil
+}
+
+// digestAuthors gets the digests of the authors of the storage, with the IDs mapped to the target if `mapped` is true,
+// so those of the source and the target are equal once the migration is done. The other digest methods do the same
+// with the other kinds of records.
+func (m *migration) digestAuthors(ctx context.Context, storage *MigrationStorage, mapped bool) (recordDigests, error) {
+	authors, err := storage.AuthorRepo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var digests recordDigests
+	for _, author := range authors {
+		id := string(author.ID)
+		if mapped {
+			if id, err = m.mapID(ctx, data.RecordAuthor, id); err != nil {
+				return nil, err
+			}
+		}
+		digests.add(id, author.AuthorInfo)
+	}
+	return digests, nil
+}
+
+func (m *migration) digestArticles(ctx context.Context, storage *MigrationStorage, mapped bool) (recordDigests, error) {
+	var digests recordDigests
+	err := eachArticle(ctx, storage.ArticleRepo, func(article *data.Article) error {
+		id, info := string(article.ID), &article.ArticleInfo
+		if mapped {
+			var err error
+			if id, err = m.mapID(ctx, data.RecordArticle, id); err != nil {
+				return err
+			}
+			if info, err = m.mapArticleInfo(ctx, info); err != nil {
+				return err
+			}
+		}
+		digests.add(id, m.articleRecord(article, info))
+		return nil
+	})
+	return digests, err
+}
+
+// articleRecord is what is compared of the article with the information, and its timestamps if they are kept.
+func (m *migration) articleRecord(article *data.Article, info *data.ArticleInfo) interface{} {
+	record := struct {
+		*data.ArticleInfo
+		CreatedAt int64
+		UpdatedAt int64
+	}{ArticleInfo: info}
+	// The timestamps are compared in seconds, the precision all the storages have.
+	if m.importer != nil {
+		record.CreatedAt, record.UpdatedAt = article.CreatedAt.Unix(), article.UpdatedAt.Unix()
+	}
+	return record
+}
+
+func (m *migration) digestSeries(ctx context.Context, storage *MigrationStorage, mapped bool) (recordDigests, error) {
+	allSeries, err := storage.SeriesRepo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var digests recordDigests
+	for _, series := range allSeries {
+		id, articleIDs := string(series.ID), make([]string, len(series.ArticleIDs))
+		for i, articleID := range series.ArticleIDs {
+			articleIDs[i] = string(articleID)
+			if mapped {
+				if articleIDs[i], err = m.mapID(ctx, data.RecordArticle, articleIDs[i]); err != nil {
+					return nil, err
+				}
+			}
+		}
+		if mapped {
+			if id, err = m.mapID(ctx, data.RecordSeries, id); err != nil {
+				return nil, err
+			}
+		}
+		digests.add(id, struct {
+			data.SeriesInfo
+			ArticleIDs []string
+		}{series.SeriesInfo, articleIDs})
+	}
+	return digests, nil
+}
+
+func (m *migration) digestComments(ctx context.Context, storage *MigrationStorage, mapped bool) (recordDigests, error) {
+	comments, err := getAllComments(ctx, storage.CommentRepo)
+	if err != nil {
+		return nil, err
+	}
+	var digests recordDigests
+	for _, comment := range comments {
+		id, info := string(comment.ID), comment.CommentInfo
+		if mapped {
+			articleID, ok, err := m.ids.Get(ctx, data.RecordArticle, string(info.ArticleID))
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				// The comment of a deleted article is not copied.
+				continue
+			}
+			info.ArticleID = data.ArticleID(articleID)
+			if id, err = m.mapID(ctx, data.RecordComment, id); err != nil {
+				return nil, err
+			}
+		}
+		digests.add(id, commentRecord(comment, info))
+	}
+	return digests, nil
+}
+
+// commentRecord is what is compared of the comment with the information.
+func commentRecord(comment *data.Comment, info data.CommentInfo) interface{} {
+	return struct {
+		data.CommentInfo
+		Status    data.CommentStatus
+		SpamScore float64
+	}{info, comment.Status, comment.SpamScore}
+}
+
+func (m *migration) digestViews(ctx context.Context, storage *MigrationStorage, mapped bool) (recordDigests, error) {
+	from, to := allViewDays()
+	var digests recordDigests
+	err := eachArticle(ctx, storage.ArticleRepo, func(article *data.Article) error {
+		daily, err := storage.ViewRepo.GetDaily(ctx, article.ID, from, to)
+		if err != nil {
+			return err
+		}
+		id := string(article.ID)
+		if mapped {
+			if id, err = m.mapID(ctx, data.RecordArticle, id); err != nil {
+				return err
+			}
+		}
+		for _, views := range daily {
+			digests.add(id, struct {
+				Day   int64
+				Views int64
+			}{views.Day.Unix(), views.Views})
+		}
+		return nil
+	})
+	return digests, err
+}
+
+func (m *migration) digestReactions(ctx context.Context, storage *MigrationStorage, mapped bool) (recordDigests, error) {
+	var digests recordDigests
+	err := eachArticle(ctx, storage.ArticleRepo, func(article *data.Article) error {
+		reactions, err := storage.ReactionRepo.GetByArticle(ctx, article.ID)
+		if err != nil {
+			return err
+		}
+		id := string(article.ID)
+		if mapped {
+			if id, err = m.mapID(ctx, data.RecordArticle, id); err != nil {
+				return err
+			}
+		}
+		for _, reaction := range reactions {
+			digests.add(id, reaction)
+		}
+		return nil
+	})
+	return digests, err
+}
+
+func (m *migration) digestAuthorTokens(ctx context.Context, storage *MigrationStorage, mapped bool) (recordDigests, error) {
+	authors, err := storage.AuthorRepo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var digests recordDigests
+	for _, author := range authors {
+		tokenHash, err := storage.AuthorRepo.GetTokenHash(ctx, author.ID)
+		if err != nil {
+			return nil, err
+		}
+		if tokenHash == "" {
+			continue
+		}
+		id := string(author.ID)
+		if mapped {
+			if id, err = m.mapID(ctx, data.RecordAuthor, id); err != nil {
+				return nil, err
+			}
+		}
+		digests.add(id, tokenHash)
+	}
+	return digests, nil
+}
+
+// eachArticle calls the function with every article, oldest first,
+// getting them a page at a time if the repository is an ArticlePager.
+func eachArticle(ctx context.Context, repo repository.ArticleRepository, f func(article *data.Article) error) error {
+	pager, ok := repository.ArticleCapability[repository.ArticlePager](repo)
+	if !ok {
+		articles, err := repo.GetAll(ctx)
+		if err != nil {
+			return err
+		}
+		for _, article := range articles {
+			if err := f(article); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	var after data.ArticleID
+	for {
+		articles, err := pager.GetPage(ctx, after, data.MAX_PAGE_LIMIT)
+		if err != nil {
+			return err
+		}
+		for _, article := range articles {
+			if err := f(article); err != nil {
+				return err
+			}
+		}
+		if len(articles) < data.MAX_PAGE_LIMIT {
+			return nil
+		}
+		after = articles[len(articles)-1].ID
+	}
+}
+
+// getAllComments gets the comments of every status, oldest first.
+func getAllComments(ctx context.Context, repo repository.CommentRepository) ([]*data.Comment, error) {
+	var result []*data.Comment
+	for _, status := range []data.CommentStatus{data.CommentPending, data.CommentApproved, data.CommentRejected, data.CommentSpam} {
+		comments, err := repo.GetByStatus(ctx, status)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, comments...)
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].CreatedAt.Before(result[j].CreatedAt)
+	})
+	return result, nil
+}
+
+// allViewDays is the period including all the views.
+func allViewDays() (time.Time, time.Time) {
+	return time.Unix(0, 0).UTC(), data.Day(time.Now())
+}
+
+// recordDigests are the hashes of the records of a kind, whose checksum does not depend on their order.
+type recordDigests []string
+
+func (digests *recordDigests) add(id string, record interface{}) {
+	// Marshalling a struct never fails.
+	content, _ := json.Marshal(record)
+	hash := sha256.Sum256(append([]byte(id+"\x00"), content...))
+	*digests = append(*digests, hex.EncodeToString(hash[:]))
+}
+
+func (digests recordDigests) checksum() string {
+	sort.Strings(digests)
+	hash := sha256.New()
+	for _, digest := range digests {
+		hash.Write([]byte(digest))
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// GetMigratedID gets the new ID of the record having the old ID before the migration to the storage,
+// or false if the ID is not in the mapping or is also the ID of a record of the storage.
+func GetMigratedID(ctx context.Context, ids repository.IDMapping, storage *MigrationStorage, kind data.RecordKind, oldID string) (string, bool, error) {
+	newID, ok, err := ids.Get(ctx, kind, oldID)
+	if err != nil || !ok {
+		return "", false, err
+	}
+	// Storages like SQLite give the same IDs again, so the old ID may be the one of another record now.
+	switch kind {
+	case data.RecordArticle:
+		_, err = storage.ArticleRepo.GetByID(ctx, data.ArticleID(oldID))
+	case data.RecordAuthor:
+		_, err = storage.AuthorRepo.GetByID(ctx, data.AuthorID(oldID))
+	case data.RecordSeries:
+		_, err = storage.SeriesRepo.GetByID(ctx, data.SeriesID(oldID))
+	case data.RecordComment:
+		_, err = storage.CommentRepo.GetByID(ctx, data.CommentID(oldID))
+	}
+	switch err {
+	case errors.ErrNotFound, errors.ErrAuthorNotFound, errors.ErrSeriesNotFound, errors.ErrCommentNotFound:
+		return newID, true, nil
+	case nil:
+		return "", false, nil
+	}
+	return "", false, err
+}
@@ -0,0 +1,194 @@
+package usecase_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Jason5Lee/simple-blog/core/data"
+	"github.com/Jason5Lee/simple-blog/core/errors"
+	"github.com/Jason5Lee/simple-blog/core/repository"
+	"github.com/Jason5Lee/simple-blog/core/usecase"
+	infra_repository "github.com/Jason5Lee/simple-blog/infra/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestMigrationStorage() *usecase.MigrationStorage {
+	return &usecase.MigrationStorage{
+		ArticleRepo:  infra_repository.NewArticleRepositoryInMemory(),
+		AuthorRepo:   infra_repository.NewAuthorRepositoryInMemory(),
+		CommentRepo:  infra_repository.NewCommentRepositoryInMemory(),
+		ViewRepo:     infra_repository.NewViewRepositoryInMemory(),
+		SeriesRepo:   infra_repository.NewSeriesRepositoryInMemory(),
+		ReactionRepo: infra_repository.NewReactionRepositoryInMemory(),
+	}
+}
+
+func Test_MigrateStorage(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	ctx := context.Background()
+	source := newTestMigrationStorage()
+	ghostID := createTestAuthor(t, ctx, source.AuthorRepo, "ghost")
+	authorID := createTestAuthor(t, ctx, source.AuthorRepo, testAuthor)
+	coAuthorID := createTestAuthor(t, ctx, source.AuthorRepo, "Jane")
+
+	// The deleted article shifts the IDs given by the target, and leaves a comment behind.
+	deletedID, err := source.ArticleRepo.Create(ctx, &data.ArticleInfo{Title: "deleted", Content: testContent, AuthorIDs: []data.AuthorID{ghostID}})
+	require.Nil(err)
+	_, err = source.CommentRepo.Create(ctx, &data.CommentInfo{ArticleID: deletedID, Author: "bob", Content: "orphan"}, data.CommentApproved, 0)
+	require.Nil(err)
+	require.Nil(source.ArticleRepo.Delete(ctx, deletedID, data.FirstArticleVersion))
+
+	firstID, err := source.ArticleRepo.Create(ctx, &data.ArticleInfo{Title: "first", Content: testContent, AuthorIDs: []data.AuthorID{authorID}})
+	require.Nil(err)
+	secondID, err := source.ArticleRepo.Create(ctx, &data.ArticleInfo{Title: "second", Content: testContent, AuthorIDs: []data.AuthorID{coAuthorID, authorID}})
+	require.Nil(err)
+	seriesID, err := source.SeriesRepo.Create(ctx, &data.SeriesInfo{Title: "tutorial"})
+	require.Nil(err)
+	require.Nil(source.SeriesRepo.AddArticle(ctx, seriesID, secondID))
+	require.Nil(source.SeriesRepo.AddArticle(ctx, seriesID, firstID))
+	_, err = source.CommentRepo.Create(ctx, &data.CommentInfo{ArticleID: secondID, Author: "alice", Content: "nice"}, data.CommentPending, 0.5)
+	require.Nil(err)
+	day := data.Day(time.Now())
+	require.Nil(source.ViewRepo.AddViews(ctx, []data.ViewIncrement{
+		{ArticleID: firstID, Day: day.AddDate(0, 0, -1), Views: 3},
+		{ArticleID: firstID, Day: day, Views: 5},
+	}))
+	_, err = source.ReactionRepo.Add(ctx, firstID, "alice", data.ReactionLike)
+	require.Nil(err)
+	_, err = source.ReactionRepo.Add(ctx, secondID, "alice", data.ReactionLike)
+	require.Nil(err)
+	token, err := usecase.IssueAuthorToken(ctx, source.AuthorRepo, authorID)
+	require.Nil(err)
+
+	target := newTestMigrationStorage()
+	ids := infra_repository.NewIDMappingInMemory()
+	result, err := usecase.MigrateStorage(ctx, source, target, ids)
+	require.Nil(err, "migration should be verified")
+	copied := map[data.RecordKind]int{}
+	for _, records := range result {
+		assert.True(records.Verified(), "%s should be verified", records.Kind)
+		assert.Zero(records.Skipped)
+		copied[records.Kind] = records.Copied
+	}
+	assert.Equal(map[data.RecordKind]int{
+		data.RecordAuthor:      3,
+		data.RecordArticle:     2,
+		data.RecordSeries:      1,
+		data.RecordComment:     1,
+		data.RecordViews:       2,
+		data.RecordReaction:    2,
+		data.RecordAuthorToken: 1,
+	}, copied, "the comment of the deleted article should be dropped")
+
+	newFirstID, ok, err := ids.Get(ctx, data.RecordArticle, string(firstID))
+	require.Nil(err)
+	require.True(ok)
+	newSecondID, _, _ := ids.Get(ctx, data.RecordArticle, string(secondID))
+	assert.NotEqual(string(firstID), newFirstID, "the target should give new IDs")
+	firstArticle, err := source.ArticleRepo.GetByID(ctx, firstID)
+	require.Nil(err)
+	newFirstArticle, err := target.ArticleRepo.GetByID(ctx, data.ArticleID(newFirstID))
+	require.Nil(err)
+	assert.Equal(firstArticle.CreatedAt, newFirstArticle.CreatedAt, "an importer should keep the timestamps")
+
+	newAuthorID, _, _ := ids.Get(ctx, data.RecordAuthor, string(authorID))
+	newCoAuthorID, _, _ := ids.Get(ctx, data.RecordAuthor, string(coAuthorID))
+	newSecondArticle, err := target.ArticleRepo.GetByID(ctx, data.ArticleID(newSecondID))
+	require.Nil(err)
+	assert.Equal([]data.AuthorID{data.AuthorID(newCoAuthorID), data.AuthorID(newAuthorID)}, newSecondArticle.AuthorIDs,
+		"the authors should be mapped in credit order")
+	counts, err := target.ReactionRepo.Counts(ctx, []data.ArticleID{data.ArticleID(newFirstID)})
+	require.Nil(err)
+	assert.Equal(data.ReactionCounts{data.ReactionLike: 1}, counts[data.ArticleID(newFirstID)])
+	author, err := usecase.AuthenticateAuthor(ctx, target.AuthorRepo, token)
+	require.Nil(err)
+	assert.Equal(data.AuthorID(newAuthorID), author.ID, "the token should authenticate the copied author")
+	allSeries, err := target.SeriesRepo.GetAll(ctx)
+	require.Nil(err)
+	require.Len(allSeries, 1)
+	assert.Equal([]data.ArticleID{data.ArticleID(newSecondID), data.ArticleID(newFirstID)}, allSeries[0].ArticleIDs)
+
+	// Running again skips everything, as if resuming a migration which was done.
+	result, err = usecase.MigrateStorage(ctx, source, target, ids)
+	require.Nil(err)
+	for _, records := range result {
+		assert.Zero(records.Copied, "%s should not be copied again", records.Kind)
+	}
+	authors, err := target.AuthorRepo.GetAll(ctx)
+	require.Nil(err)
+	assert.Len(authors, 3, "resuming should not duplicate the records")
+
+	// The old ID is redirected unless another record has it in the target.
+	newID, ok, err := usecase.GetMigratedID(ctx, ids, target, data.RecordArticle, string(secondID))
+	require.Nil(err)
+	assert.True(ok)
+	assert.Equal(newSecondID, newID)
+	_, ok, err = usecase.GetMigratedID(ctx, ids, target, data.RecordArticle, string(firstID))
+	require.Nil(err)
+	assert.False(ok, "an old ID given to another record should not be redirected")
+
+	_, err = target.ArticleRepo.Create(ctx, &data.ArticleInfo{Title: "extra", Content: testContent, AuthorIDs: []data.AuthorID{data.AuthorID(newAuthorID)}})
+	require.Nil(err)
+	result, err = usecase.MigrateStorage(ctx, source, target, ids)
+	assert.Equal(errors.ErrMigrationMismatch, err, "an extra article in the target should fail the verification")
+	assert.False(result[1].Verified())
+	assert.Equal(2, result[1].SourceCount)
+	assert.Equal(3, result[1].TargetCount)
+}
+
+// interruptedIDMapping fails to put the new IDs of the records of the kind,
+// as if the migration was interrupted after creating the first one.
+type interruptedIDMapping struct {
+	repository.IDMapping
+	kind data.RecordKind
+}
+
+func (m *interruptedIDMapping) Put(ctx context.Context, kind data.RecordKind, oldID string, newID string) error {
+	if kind == m.kind {
+		return context.Canceled
+	}
+	return m.IDMapping.Put(ctx, kind, oldID, newID)
+}
+
+func Test_MigrateStorage_Interrupted(t *testing.T) {
+	ctx := context.Background()
+	source := newTestMigrationStorage()
+	// The twins are the same, so each must be copied to its own author.
+	twinID := createTestAuthor(t, ctx, source.AuthorRepo, "twin")
+	createTestAuthor(t, ctx, source.AuthorRepo, "twin")
+	articleID, err := source.ArticleRepo.Create(ctx, &data.ArticleInfo{Title: "first", Content: testContent, AuthorIDs: []data.AuthorID{twinID}})
+	require.Nil(t, err)
+	seriesID, err := source.SeriesRepo.Create(ctx, &data.SeriesInfo{Title: "tutorial"})
+	require.Nil(t, err)
+	require.Nil(t, source.SeriesRepo.AddArticle(ctx, seriesID, articleID))
+	_, err = source.CommentRepo.Create(ctx, &data.CommentInfo{ArticleID: articleID, Author: "alice", Content: "nice"}, data.CommentApproved, 0)
+	require.Nil(t, err)
+
+	for _, kind := range []data.RecordKind{data.RecordAuthor, data.RecordArticle, data.RecordSeries, data.RecordComment} {
+		assert := assert.New(t)
+		path := filepath.Join(t.TempDir(), "ids.jsonl")
+		ids, err := infra_repository.NewIDMappingFile(path)
+		require.Nil(t, err)
+		target := newTestMigrationStorage()
+		_, err = usecase.MigrateStorage(ctx, source, target, &interruptedIDMapping{IDMapping: ids, kind: kind})
+		assert.Equal(context.Canceled, err, "the migration should be interrupted at the %s", kind)
+		require.Nil(t, ids.Close())
+
+		ids, err = infra_repository.NewIDMappingFile(path)
+		require.Nil(t, err)
+		result, err := usecase.MigrateStorage(ctx, source, target, ids)
+		assert.Nil(err, "resuming at the %s should be verified", kind)
+		for _, records := range result {
+			if records.Kind == kind {
+				assert.Equal(records.SourceCount, records.TargetCount, "resuming should not duplicate the %s", kind)
+				assert.Equal(records.SourceCount, records.Copied+records.Skipped, "the %s created before the interruption should be counted", kind)
+			}
+		}
+		require.Nil(t, ids.Close())
+	}
+}
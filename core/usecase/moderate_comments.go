@@ -0,0 +1,57 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/Jason5Lee/simple-blog/core/data"
+	"github.com/Jason5Lee/simple-blog/core/errors"
+	"github.com/Jason5Lee/simple-blog/core/moderation"
+	"github.com/Jason5Lee/simple-blog/core/repository"
+)
+
+// ModerateComments sets the status of the comments and trains the moderator with the decision once it is stored,
+// replacing the decision it learned of the previous status. IDs that do not match any comment are ignored.
+func ModerateComments(ctx context.Context, commentRepo repository.CommentRepository, moderator *moderation.Moderator, ids []data.CommentID, status data.CommentStatus) (int64, error) {
+	// Only learn from decisions that change something, so the classifier knows each comment once, by its status.
+	changed := make([]*data.Comment, 0, len(ids))
+	for _, id := range ids {
+		comment, err := commentRepo.GetByID(ctx, id)
+		if err == errors.ErrCommentNotFound {
+			continue
+		}
+		if err != nil {
+			return 0, err
+		}
+		if comment.Status != status {
+			changed = append(changed, comment)
+		}
+	}
+	updated, err := commentRepo.SetStatus(ctx, ids, status)
+	if err != nil {
+		return 0, err
+	}
+	for _, comment := range changed {
+		moderator.Unlearn(&comment.CommentInfo, comment.Status)
+		moderator.Learn(&comment.CommentInfo, status)
+	}
+	return updated, nil
+}
+
+// PurgeComments deletes all comments with the given status.
+func PurgeComments(ctx context.Context, commentRepo repository.CommentRepository, status data.CommentStatus) (int64, error) {
+	return commentRepo.DeleteByStatus(ctx, status)
+}
+
+// TrainModerator trains the moderator with the previous decisions, i.e. the approved and spam comments.
+func TrainModerator(ctx context.Context, commentRepo repository.CommentRepository, moderator *moderation.Moderator) error {
+	for _, status := range []data.CommentStatus{data.CommentApproved, data.CommentSpam} {
+		comments, err := commentRepo.GetByStatus(ctx, status)
+		if err != nil {
+			return err
+		}
+		for _, comment := range comments {
+			moderator.Learn(&comment.CommentInfo, status)
+		}
+	}
+	return nil
+}
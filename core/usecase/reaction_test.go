@@ -0,0 +1,58 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Jason5Lee/simple-blog/core/data"
+	"github.com/Jason5Lee/simple-blog/core/errors"
+	"github.com/Jason5Lee/simple-blog/core/usecase"
+	infra_repository "github.com/Jason5Lee/simple-blog/infra/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Reactions(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := context.Background()
+	articleRepo := infra_repository.NewArticleRepositoryInMemory()
+	reactionRepo := infra_repository.NewReactionRepositoryInMemory()
+
+	_, err := usecase.AddReaction(ctx, articleRepo, reactionRepo, "42", "alice", data.ReactionLike)
+	assert.Equal(errors.ErrNotFound, err, "react to a missing article should return ErrNotFound")
+
+	authorRepo := infra_repository.NewAuthorRepositoryInMemory()
+	articleID, err := usecase.CreateArticle(ctx, articleRepo, authorRepo, testAdmin, &data.ArticleInfo{
+		Title:     testTitle,
+		Content:   testContent,
+		AuthorIDs: []data.AuthorID{createTestAuthor(t, ctx, authorRepo, testAuthor)},
+	})
+	assert.Nil(err, "create article should not return error")
+
+	added, err := usecase.AddReaction(ctx, articleRepo, reactionRepo, articleID, "alice", data.ReactionLike)
+	assert.Nil(err)
+	assert.True(added, "first like should be added")
+	added, err = usecase.AddReaction(ctx, articleRepo, reactionRepo, articleID, "alice", data.ReactionLike)
+	assert.Nil(err)
+	assert.False(added, "second like of the same user should not be added")
+	_, err = usecase.AddReaction(ctx, articleRepo, reactionRepo, articleID, "alice", "🎉")
+	assert.Nil(err)
+	_, err = usecase.AddReaction(ctx, articleRepo, reactionRepo, articleID, "bob", data.ReactionLike)
+	assert.Nil(err)
+
+	counts, err := usecase.GetReactionCounts(ctx, reactionRepo, []data.ArticleID{articleID, "42"})
+	assert.Nil(err)
+	assert.Equal(data.ReactionCounts{data.ReactionLike: 2, "🎉": 1}, counts[articleID])
+	assert.Equal(data.ReactionCounts{}, counts["42"], "article without reactions should have empty counts")
+
+	removed, err := usecase.RemoveReaction(ctx, reactionRepo, articleID, "alice", "🎉")
+	assert.Nil(err)
+	assert.True(removed, "existing reaction should be removed")
+	removed, err = usecase.RemoveReaction(ctx, reactionRepo, articleID, "alice", "🎉")
+	assert.Nil(err)
+	assert.False(removed, "missing reaction should not be removed")
+
+	counts, err = usecase.GetReactionCounts(ctx, reactionRepo, []data.ArticleID{articleID})
+	assert.Nil(err)
+	assert.Equal(data.ReactionCounts{data.ReactionLike: 2}, counts[articleID])
+}
@@ -0,0 +1,37 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/Jason5Lee/simple-blog/core/data"
+	"github.com/Jason5Lee/simple-blog/core/repository"
+)
+
+// AddReaction adds a reaction of the user to an existing article.
+// Each user can react at most once with each kind, so it returns false if the reaction already exists.
+func AddReaction(ctx context.Context, articleRepo repository.ArticleRepository, reactionRepo repository.ReactionRepository, articleID data.ArticleID, user data.ReactionUser, kind data.ReactionKind) (bool, error) {
+	if _, err := articleRepo.GetByID(ctx, articleID); err != nil {
+		return false, err
+	}
+	return reactionRepo.Add(ctx, articleID, user, kind)
+}
+
+// RemoveReaction removes a reaction of the user from an article.
+func RemoveReaction(ctx context.Context, reactionRepo repository.ReactionRepository, articleID data.ArticleID, user data.ReactionUser, kind data.ReactionKind) (bool, error) {
+	return reactionRepo.Remove(ctx, articleID, user, kind)
+}
+
+// GetReactionCounts gets the reaction counts of the articles.
+// Every article is present in the result, with empty counts if nobody reacted to it.
+func GetReactionCounts(ctx context.Context, reactionRepo repository.ReactionRepository, articleIDs []data.ArticleID) (map[data.ArticleID]data.ReactionCounts, error) {
+	counts, err := reactionRepo.Counts(ctx, articleIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range articleIDs {
+		if counts[id] == nil {
+			counts[id] = data.ReactionCounts{}
+		}
+	}
+	return counts, nil
+}
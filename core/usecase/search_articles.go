@@ -0,0 +1,19 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/Jason5Lee/simple-blog/core/data"
+	"github.com/Jason5Lee/simple-blog/core/errors"
+	"github.com/Jason5Lee/simple-blog/core/repository"
+)
+
+// SearchArticles gets the articles matching the query, most relevant first.
+// It returns ErrSearchNotSupported if the repository does not support full-text search.
+func SearchArticles(ctx context.Context, repo repository.ArticleRepository, query data.SearchQuery) ([]*data.Article, error) {
+	searcher, ok := repository.ArticleCapability[repository.ArticleSearcher](repo)
+	if !ok {
+		return nil, errors.ErrSearchNotSupported
+	}
+	return searcher.Search(ctx, query)
+}
@@ -0,0 +1,119 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/Jason5Lee/simple-blog/core/data"
+	"github.com/Jason5Lee/simple-blog/core/errors"
+	"github.com/Jason5Lee/simple-blog/core/repository"
+)
+
+// CreateSeries creates a new empty series.
+func CreateSeries(ctx context.Context, seriesRepo repository.SeriesRepository, series *data.SeriesInfo) (data.SeriesID, error) {
+	return seriesRepo.Create(ctx, series)
+}
+
+// SeriesWithParts is a series with its existing parts in order.
+type SeriesWithParts struct {
+	*data.Series
+	Parts []*data.Article
+}
+
+// getSeriesParts gets the parts of the series, skipping the articles that no longer exist.
+func getSeriesParts(ctx context.Context, articleRepo repository.ArticleRepository, series *data.Series) (*SeriesWithParts, error) {
+	result := &SeriesWithParts{Series: series, Parts: make([]*data.Article, 0, len(series.ArticleIDs))}
+	for _, id := range series.ArticleIDs {
+		article, err := articleRepo.GetByID(ctx, id)
+		if err == errors.ErrNotFound {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		result.Parts = append(result.Parts, article)
+	}
+	return result, nil
+}
+
+// GetSeriesByID gets a series with its parts.
+func GetSeriesByID(ctx context.Context, seriesRepo repository.SeriesRepository, articleRepo repository.ArticleRepository, id data.SeriesID) (*SeriesWithParts, error) {
+	series, err := seriesRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return getSeriesParts(ctx, articleRepo, series)
+}
+
+// GetAllSeries gets all series with their parts.
+func GetAllSeries(ctx context.Context, seriesRepo repository.SeriesRepository, articleRepo repository.ArticleRepository) ([]*SeriesWithParts, error) {
+	allSeries, err := seriesRepo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*SeriesWithParts, len(allSeries))
+	for i, series := range allSeries {
+		if result[i], err = getSeriesParts(ctx, articleRepo, series); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// AddArticleToSeries appends an existing article to a series.
+func AddArticleToSeries(ctx context.Context, seriesRepo repository.SeriesRepository, articleRepo repository.ArticleRepository, id data.SeriesID, articleID data.ArticleID) error {
+	if _, err := articleRepo.GetByID(ctx, articleID); err != nil {
+		return err
+	}
+	return seriesRepo.AddArticle(ctx, id, articleID)
+}
+
+// RemoveArticleFromSeries removes an article from a series.
+func RemoveArticleFromSeries(ctx context.Context, seriesRepo repository.SeriesRepository, id data.SeriesID, articleID data.ArticleID) error {
+	return seriesRepo.RemoveArticle(ctx, id, articleID)
+}
+
+// ReorderSeries replaces the order of the parts of a series.
+func ReorderSeries(ctx context.Context, seriesRepo repository.SeriesRepository, id data.SeriesID, articleIDs []data.ArticleID) error {
+	return seriesRepo.Reorder(ctx, id, articleIDs)
+}
+
+// GetSeriesNavigation gets the position and the neighbors of an article in each series containing it.
+// Neighbors that no longer exist are skipped, so only the nearest existing parts are read.
+func GetSeriesNavigation(ctx context.Context, seriesRepo repository.SeriesRepository, articleRepo repository.ArticleRepository, articleID data.ArticleID) ([]*data.SeriesNavigation, error) {
+	allSeries, err := seriesRepo.GetByArticle(ctx, articleID)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*data.SeriesNavigation, 0, len(allSeries))
+	for _, series := range allSeries {
+		position := series.IndexOf(articleID)
+		if position < 0 {
+			continue
+		}
+		navigation := &data.SeriesNavigation{Series: series, Position: position}
+		if navigation.Previous, err = getSeriesNeighbor(ctx, articleRepo, series.ArticleIDs[:position], -1); err != nil {
+			return nil, err
+		}
+		if navigation.Next, err = getSeriesNeighbor(ctx, articleRepo, series.ArticleIDs[position+1:], 1); err != nil {
+			return nil, err
+		}
+		result = append(result, navigation)
+	}
+	return result, nil
+}
+
+// getSeriesNeighbor gets the first existing article of the parts, from the last one if `step` is -1,
+// or nil if none exists.
+func getSeriesNeighbor(ctx context.Context, articleRepo repository.ArticleRepository, parts []data.ArticleID, step int) (*data.Article, error) {
+	i := 0
+	if step < 0 {
+		i = len(parts) - 1
+	}
+	for ; i >= 0 && i < len(parts); i += step {
+		article, err := articleRepo.GetByID(ctx, parts[i])
+		if err != errors.ErrNotFound {
+			return article, err
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,90 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Jason5Lee/simple-blog/core/data"
+	"github.com/Jason5Lee/simple-blog/core/errors"
+	"github.com/Jason5Lee/simple-blog/core/usecase"
+	infra_repository "github.com/Jason5Lee/simple-blog/infra/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Series(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := context.Background()
+	articleRepo := infra_repository.NewArticleRepositoryInMemory()
+	authorRepo := infra_repository.NewAuthorRepositoryInMemory()
+	seriesRepo := infra_repository.NewSeriesRepositoryInMemory()
+	authorID := createTestAuthor(t, ctx, authorRepo, testAuthor)
+
+	articleIDs := make([]data.ArticleID, 3)
+	for i := range articleIDs {
+		id, err := usecase.CreateArticle(ctx, articleRepo, authorRepo, testAdmin, &data.ArticleInfo{
+			Title:     testTitle,
+			Content:   testContent,
+			AuthorIDs: []data.AuthorID{authorID},
+		})
+		assert.Nil(err, "create article should not return error")
+		articleIDs[i] = id
+	}
+
+	seriesID, err := usecase.CreateSeries(ctx, seriesRepo, &data.SeriesInfo{Title: "tutorial"})
+	assert.Nil(err, "create series should not return error")
+	for _, id := range articleIDs {
+		assert.Nil(usecase.AddArticleToSeries(ctx, seriesRepo, articleRepo, seriesID, id))
+	}
+	assert.Equal(errors.ErrArticleAlreadyInSeries, usecase.AddArticleToSeries(ctx, seriesRepo, articleRepo, seriesID, articleIDs[0]),
+		"adding an article twice should return ErrArticleAlreadyInSeries")
+	assert.Equal(errors.ErrNotFound, usecase.AddArticleToSeries(ctx, seriesRepo, articleRepo, seriesID, "42"),
+		"adding a missing article should return ErrNotFound")
+	assert.Equal(errors.ErrSeriesNotFound, usecase.AddArticleToSeries(ctx, seriesRepo, articleRepo, "42", articleIDs[0]),
+		"adding to a missing series should return ErrSeriesNotFound")
+
+	navigations, err := usecase.GetSeriesNavigation(ctx, seriesRepo, articleRepo, articleIDs[1])
+	assert.Nil(err)
+	assert.Len(navigations, 1)
+	assert.Equal(1, navigations[0].Position)
+	assert.Equal(articleIDs[0], navigations[0].Previous.ID)
+	assert.Equal(articleIDs[2], navigations[0].Next.ID)
+
+	navigations, err = usecase.GetSeriesNavigation(ctx, seriesRepo, articleRepo, articleIDs[0])
+	assert.Nil(err)
+	assert.Nil(navigations[0].Previous, "the first part should not have a previous part")
+
+	assert.Equal(errors.ErrInvalidSeriesOrder, usecase.ReorderSeries(ctx, seriesRepo, seriesID, articleIDs[:2]),
+		"order missing a part should return ErrInvalidSeriesOrder")
+	assert.Equal(errors.ErrInvalidSeriesOrder, usecase.ReorderSeries(ctx, seriesRepo, seriesID, []data.ArticleID{articleIDs[0], articleIDs[1], "42"}),
+		"order with an unknown article should return ErrInvalidSeriesOrder")
+	_, err = data.NewSeriesOrder([]string{"1", "1", "2"})
+	assert.Equal(errors.ErrInvalidSeriesOrder, err, "order with a repeated article should return ErrInvalidSeriesOrder")
+
+	reversed := []data.ArticleID{articleIDs[2], articleIDs[1], articleIDs[0]}
+	assert.Nil(usecase.ReorderSeries(ctx, seriesRepo, seriesID, reversed))
+	series, err := usecase.GetSeriesByID(ctx, seriesRepo, articleRepo, seriesID)
+	assert.Nil(err)
+	assert.Equal(reversed, series.ArticleIDs)
+
+	// A deleted part is skipped in the navigation.
+	assert.Nil(articleRepo.Delete(ctx, articleIDs[1], data.FirstArticleVersion))
+	navigations, err = usecase.GetSeriesNavigation(ctx, seriesRepo, articleRepo, articleIDs[2])
+	assert.Nil(err)
+	assert.Equal(articleIDs[0], navigations[0].Next.ID)
+	navigations, err = usecase.GetSeriesNavigation(ctx, seriesRepo, articleRepo, articleIDs[0])
+	assert.Nil(err)
+	assert.Equal(articleIDs[2], navigations[0].Previous.ID)
+	assert.Equal(2, navigations[0].Position, "the position should count the deleted part")
+
+	assert.Nil(usecase.RemoveArticleFromSeries(ctx, seriesRepo, seriesID, articleIDs[0]))
+	assert.Equal(errors.ErrArticleNotInSeries, usecase.RemoveArticleFromSeries(ctx, seriesRepo, seriesID, articleIDs[0]))
+	navigations, err = usecase.GetSeriesNavigation(ctx, seriesRepo, articleRepo, articleIDs[0])
+	assert.Nil(err)
+	assert.Empty(navigations, "removed article should not be in any series")
+
+	allSeries, err := usecase.GetAllSeries(ctx, seriesRepo, articleRepo)
+	assert.Nil(err)
+	assert.Len(allSeries, 1)
+	assert.Len(allSeries[0].Parts, 1)
+}
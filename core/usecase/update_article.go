@@ -0,0 +1,76 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/Jason5Lee/simple-blog/core/data"
+	"github.com/Jason5Lee/simple-blog/core/errors"
+	"github.com/Jason5Lee/simple-blog/core/repository"
+)
+
+// getEditableArticle gets an article that the actor can edit.
+func getEditableArticle(ctx context.Context, repo repository.ArticleRepository, actor *data.Actor, id data.ArticleID) (*data.Article, error) {
+	if actor.IsAnonymous() {
+		return nil, errors.ErrUnauthorized
+	}
+	article, err := repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !actor.CanEdit(&article.ArticleInfo) {
+		return nil, errors.ErrForbidden
+	}
+	return article, nil
+}
+
+// UpdateArticle replaces the information of an article, which is only allowed to its co-authors and the admin,
+// and returns its new version.
+// A co-author can change the author list, including removing themselves, as long as one author remains.
+// It fails with ErrConflict if the article is no longer of the version, unless it is AnyArticleVersion.
+func UpdateArticle(ctx context.Context, repo repository.ArticleRepository, authorRepo repository.AuthorRepository, actor *data.Actor, id data.ArticleID, version data.ArticleVersion, article *data.ArticleInfo) (data.ArticleVersion, error) {
+	var updated data.ArticleVersion
+	err := changeArticle(ctx, repo, actor, id, version, func(version data.ArticleVersion) error {
+		if err := checkAuthorsExist(ctx, authorRepo, article.AuthorIDs); err != nil {
+			return err
+		}
+		updated = version + 1
+		// The actor is passed to the repositories recording who made the change.
+		return repo.Update(data.WithActor(ctx, actor), id, version, article)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return updated, nil
+}
+
+// DeleteArticle deletes an article, which is only allowed to its co-authors and the admin.
+// It fails with ErrConflict if the article is no longer of the version, unless it is AnyArticleVersion.
+func DeleteArticle(ctx context.Context, repo repository.ArticleRepository, actor *data.Actor, id data.ArticleID, version data.ArticleVersion) error {
+	return changeArticle(ctx, repo, actor, id, version, func(version data.ArticleVersion) error {
+		return repo.Delete(data.WithActor(ctx, actor), id, version)
+	})
+}
+
+// maxAnyVersionAttempts is how many times an article is changed as of its current version
+// before giving up on the concurrent changes.
+const maxAnyVersionAttempts = 3
+
+// changeArticle checks that the actor can edit the article, and changes it as of the version.
+// The version is not compared with the article read, which can be stale, e.g. when cached, so only the repository
+// decides the conflicts. With AnyArticleVersion, the article is changed as of the version read, which is read again
+// if the change conflicts, as a stale article is invalidated by the conflict.
+func changeArticle(ctx context.Context, repo repository.ArticleRepository, actor *data.Actor, id data.ArticleID, version data.ArticleVersion, change func(data.ArticleVersion) error) error {
+	for attempt := 1; ; attempt++ {
+		current, err := getEditableArticle(ctx, repo, actor, id)
+		if err != nil {
+			return err
+		}
+		if version != data.AnyArticleVersion {
+			return change(version)
+		}
+		err = change(current.Version)
+		if err != errors.ErrConflict || attempt == maxAnyVersionAttempts {
+			return err
+		}
+	}
+}
@@ -7,13 +7,14 @@ import (
 
 	"github.com/Jason5Lee/simple-blog/core/data"
 	"github.com/Jason5Lee/simple-blog/core/errors"
+	"github.com/Jason5Lee/simple-blog/core/repository"
 	"github.com/Jason5Lee/simple-blog/core/usecase"
 	infra_repository "github.com/Jason5Lee/simple-blog/infra/repository"
 	"github.com/stretchr/testify/assert"
 )
 
-// Because the GetAllArticles does not garantee the order,
-// we need to sort the articles before comparing.
+// The articles are sorted by author before comparing,
+// since the tests create them in another order.
 type articleSorterByAuthor struct {
 	articles []*data.Article
 }
@@ -25,7 +26,14 @@ func (s *articleSorterByAuthor) Swap(i, j int) {
 	s.articles[i], s.articles[j] = s.articles[j], s.articles[i]
 }
 func (s *articleSorterByAuthor) Less(i, j int) bool {
-	return s.articles[i].Author < s.articles[j].Author
+	return s.articles[i].AuthorIDs[0] < s.articles[j].AuthorIDs[0]
+}
+
+// createTestAuthor creates an author profile with the display name.
+func createTestAuthor(t *testing.T, ctx context.Context, authorRepo repository.AuthorRepository, name string) data.AuthorID {
+	id, err := usecase.CreateAuthor(ctx, authorRepo, &data.AuthorInfo{DisplayName: data.AuthorName(name)})
+	assert.Nil(t, err, "create author should not return error")
+	return id
 }
 
 func Test_NoArticle(t *testing.T) {
@@ -46,15 +54,19 @@ const testTitle = "Hello World"
 const testContent = "Lorem ipsum dolor sit amet, consectetur adipiscing elit, sed do eiusmod tempor incididunt ut labore et dolore magna aliqua. Ut enim ad minim veniam, quis nostrud exercitation ullamco laboris nisi ut aliquip ex ea commodo consequat. Duis aute irure dolor in reprehenderit in voluptate velit esse cillum dolore eu fugiat nulla pariatur. Excepteur sint occaecat cupidatat non proident, sunt in culpa qui officia deserunt mollit anim id est laborum."
 const testAuthor = "John"
 
+var testAdmin = &data.Actor{Admin: true}
+
 func Test_SingleArticle(t *testing.T) {
 	assert := assert.New(t)
 
 	ctx := context.Background()
 	repo := infra_repository.NewArticleRepositoryInMemory()
-	articleId, err := usecase.CreateArticle(ctx, repo, &data.ArticleInfo{
-		Title:   testTitle,
-		Content: testContent,
-		Author:  testAuthor,
+	authorRepo := infra_repository.NewAuthorRepositoryInMemory()
+	authorID := createTestAuthor(t, ctx, authorRepo, testAuthor)
+	articleId, err := usecase.CreateArticle(ctx, repo, authorRepo, testAdmin, &data.ArticleInfo{
+		Title:     testTitle,
+		Content:   testContent,
+		AuthorIDs: []data.AuthorID{authorID},
 	})
 	assert.Nil(err, "create article should not return error")
 
@@ -62,7 +74,7 @@ func Test_SingleArticle(t *testing.T) {
 	assert.Nil(err, "get article from the created ID should not return error")
 	assert.Equal(testTitle, string(article.Title), "get article from the created ID should return correct title")
 	assert.Equal(testContent, string(article.Content), "get article from the created ID should return correct content")
-	assert.Equal(testAuthor, string(article.Author), "get article from the created ID should return correct author")
+	assert.Equal([]data.AuthorID{authorID}, article.AuthorIDs, "get article from the created ID should return correct author")
 
 	_, err = usecase.GetArticleByID(ctx, repo, data.ArticleID(string(articleId)+"e"))
 	assert.Equal(errors.ErrNotFound, err, "get article from the wrong ID should return ErrNotFound")
@@ -72,7 +84,7 @@ func Test_SingleArticle(t *testing.T) {
 	assert.Len(articles, 1, "get all articles should return 1 article")
 	assert.Equal(testTitle, string(articles[0].Title), "get all articles should return correct title")
 	assert.Equal(testContent, string(articles[0].Content), "get all articles should return correct content")
-	assert.Equal(testAuthor, string(articles[0].Author), "get all articles should return correct author")
+	assert.Equal([]data.AuthorID{authorID}, articles[0].AuthorIDs, "get all articles should return correct author")
 }
 
 func Test_TwoArticles(t *testing.T) {
@@ -80,17 +92,20 @@ func Test_TwoArticles(t *testing.T) {
 
 	ctx := context.Background()
 	repo := infra_repository.NewArticleRepositoryInMemory()
-	articleId1, err := usecase.CreateArticle(ctx, repo, &data.ArticleInfo{
-		Title:   "title 1",
-		Content: "content 1",
-		Author:  "author 1",
+	authorRepo := infra_repository.NewAuthorRepositoryInMemory()
+	authorID1 := createTestAuthor(t, ctx, authorRepo, "author 1")
+	authorID2 := createTestAuthor(t, ctx, authorRepo, "author 2")
+	articleId1, err := usecase.CreateArticle(ctx, repo, authorRepo, testAdmin, &data.ArticleInfo{
+		Title:     "title 1",
+		Content:   "content 1",
+		AuthorIDs: []data.AuthorID{authorID1},
 	})
 	assert.Nil(err, "create article 1 should not return error")
 
-	articleId2, err := usecase.CreateArticle(ctx, repo, &data.ArticleInfo{
-		Title:   "title 2",
-		Content: "content 2",
-		Author:  "author 2",
+	articleId2, err := usecase.CreateArticle(ctx, repo, authorRepo, testAdmin, &data.ArticleInfo{
+		Title:     "title 2",
+		Content:   "content 2",
+		AuthorIDs: []data.AuthorID{authorID2},
 	})
 	assert.Nil(err, "create article 2 should not return error")
 
@@ -98,13 +113,13 @@ func Test_TwoArticles(t *testing.T) {
 	assert.Nil(err, "get article 1 from the created ID should not return error")
 	assert.Equal("title 1", string(article.Title), "get article 1 from the created ID should return correct title")
 	assert.Equal("content 1", string(article.Content), "get article 1 from the created ID should return correct content")
-	assert.Equal("author 1", string(article.Author), "get article 1 from the created ID should return correct author")
+	assert.Equal([]data.AuthorID{authorID1}, article.AuthorIDs, "get article 1 from the created ID should return correct author")
 
 	article, err = usecase.GetArticleByID(ctx, repo, articleId2)
 	assert.Nil(err, "get article 2 from the created ID should not return error")
 	assert.Equal("title 2", string(article.Title), "get article 2 from the created ID should return correct title")
 	assert.Equal("content 2", string(article.Content), "get article 2 from the created ID should return correct content")
-	assert.Equal("author 2", string(article.Author), "get article 2 from the created ID should return correct author")
+	assert.Equal([]data.AuthorID{authorID2}, article.AuthorIDs, "get article 2 from the created ID should return correct author")
 
 	articles, err := usecase.GetAllArticles(ctx, repo)
 	assert.Nil(err, "get all articles should not return error")
@@ -114,8 +129,240 @@ func Test_TwoArticles(t *testing.T) {
 	sort.Sort(sorter)
 	assert.Equal("title 1", string(sorter.articles[0].Title), "get all articles should return correct title")
 	assert.Equal("content 1", string(sorter.articles[0].Content), "get all articles should return correct content")
-	assert.Equal("author 1", string(sorter.articles[0].Author), "get all articles should return correct author")
+	assert.Equal([]data.AuthorID{authorID1}, sorter.articles[0].AuthorIDs, "get all articles should return correct author")
 	assert.Equal("title 2", string(sorter.articles[1].Title), "get all articles should return correct title")
 	assert.Equal("content 2", string(sorter.articles[1].Content), "get all articles should return correct content")
-	assert.Equal("author 2", string(sorter.articles[1].Author), "get all articles should return correct author")
+	assert.Equal([]data.AuthorID{authorID2}, sorter.articles[1].AuthorIDs, "get all articles should return correct author")
+}
+
+func Test_AuthorArticles(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := context.Background()
+	repo := infra_repository.NewArticleRepositoryInMemory()
+	authorRepo := infra_repository.NewAuthorRepositoryInMemory()
+
+	_, err := usecase.CreateArticle(ctx, repo, authorRepo, testAdmin, &data.ArticleInfo{
+		Title:     testTitle,
+		Content:   testContent,
+		AuthorIDs: []data.AuthorID{"42"},
+	})
+	assert.Equal(errors.ErrAuthorNotFound, err, "create article of a missing author should return ErrAuthorNotFound")
+
+	_, err = usecase.GetAuthorArticles(ctx, repo, authorRepo, "42")
+	assert.Equal(errors.ErrAuthorNotFound, err, "get articles of a missing author should return ErrAuthorNotFound")
+
+	authorID1 := createTestAuthor(t, ctx, authorRepo, "author 1")
+	authorID2 := createTestAuthor(t, ctx, authorRepo, "author 2")
+	for _, authorID := range []data.AuthorID{authorID1, authorID1, authorID2} {
+		_, err = usecase.CreateArticle(ctx, repo, authorRepo, testAdmin, &data.ArticleInfo{
+			Title:     testTitle,
+			Content:   testContent,
+			AuthorIDs: []data.AuthorID{authorID},
+		})
+		assert.Nil(err, "create article should not return error")
+	}
+
+	articles, err := usecase.GetAuthorArticles(ctx, repo, authorRepo, authorID1)
+	assert.Nil(err, "get author articles should not return error")
+	assert.Len(articles, 2, "author 1 should have 2 articles")
+
+	authors, err := usecase.GetAuthorsOfArticles(ctx, authorRepo, articles)
+	assert.Nil(err, "get authors of articles should not return error")
+	assert.Len(authors, 1, "the articles should have a single author")
+	assert.Equal(data.AuthorName("author 1"), authors[authorID1].DisplayName)
+}
+
+func Test_CoAuthorsEdit(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := context.Background()
+	repo := infra_repository.NewArticleRepositoryInMemory()
+	authorRepo := infra_repository.NewAuthorRepositoryInMemory()
+	alice := createTestAuthor(t, ctx, authorRepo, "alice")
+	bob := createTestAuthor(t, ctx, authorRepo, "bob")
+	carol := createTestAuthor(t, ctx, authorRepo, "carol")
+
+	coAuthored := &data.ArticleInfo{
+		Title:     testTitle,
+		Content:   testContent,
+		AuthorIDs: []data.AuthorID{alice, bob},
+	}
+	_, err := usecase.CreateArticle(ctx, repo, authorRepo, data.Anonymous, coAuthored)
+	assert.Equal(errors.ErrUnauthorized, err, "anonymous should not create")
+	_, err = usecase.CreateArticle(ctx, repo, authorRepo, &data.Actor{AuthorID: carol}, coAuthored)
+	assert.Equal(errors.ErrForbidden, err, "an author should not create an article credited only to others")
+	articleID, err := usecase.CreateArticle(ctx, repo, authorRepo, &data.Actor{AuthorID: bob}, coAuthored)
+	assert.Nil(err, "create co-authored article should not return error")
+
+	for _, authorID := range []data.AuthorID{alice, bob} {
+		articles, err := usecase.GetAuthorArticles(ctx, repo, authorRepo, authorID)
+		assert.Nil(err)
+		assert.Len(articles, 1, "the article should be listed for every co-author")
+	}
+
+	updated := &data.ArticleInfo{
+		Title:     "updated",
+		Content:   testContent,
+		AuthorIDs: []data.AuthorID{bob, alice},
+	}
+	_, err = usecase.UpdateArticle(ctx, repo, authorRepo, data.Anonymous, articleID, data.FirstArticleVersion, updated)
+	assert.Equal(errors.ErrUnauthorized, err, "anonymous should not edit")
+	_, err = usecase.UpdateArticle(ctx, repo, authorRepo, &data.Actor{AuthorID: carol}, articleID, data.FirstArticleVersion, updated)
+	assert.Equal(errors.ErrForbidden, err, "non co-author should not edit")
+	_, err = usecase.UpdateArticle(ctx, repo, authorRepo, &data.Actor{AuthorID: bob}, "42", data.FirstArticleVersion, updated)
+	assert.Equal(errors.ErrNotFound, err, "edit missing article should return ErrNotFound")
+	_, err = usecase.UpdateArticle(ctx, repo, authorRepo, &data.Actor{AuthorID: bob}, articleID, data.FirstArticleVersion, &data.ArticleInfo{
+		Title:     "updated",
+		Content:   testContent,
+		AuthorIDs: []data.AuthorID{bob, "42"},
+	})
+	assert.Equal(errors.ErrAuthorNotFound, err, "edit with a missing author should return ErrAuthorNotFound")
+
+	_, err = usecase.UpdateArticle(ctx, repo, authorRepo, &data.Actor{AuthorID: bob}, articleID, data.FirstArticleVersion, updated)
+	assert.Nil(err, "second co-author should edit")
+	_, err = usecase.UpdateArticle(ctx, repo, authorRepo, &data.Actor{AuthorID: alice}, articleID, data.FirstArticleVersion, updated)
+	assert.Equal(errors.ErrConflict, err, "edit of a previous version should return ErrConflict")
+	version, err := usecase.UpdateArticle(ctx, repo, authorRepo, &data.Actor{AuthorID: alice}, articleID, data.AnyArticleVersion, updated)
+	assert.Nil(err, "edit of any version should change the current one")
+	assert.Equal(data.FirstArticleVersion+2, version)
+	article, err := usecase.GetArticleByID(ctx, repo, articleID)
+	assert.Nil(err)
+	assert.Equal(data.ArticleTitle("updated"), article.Title)
+	assert.Equal([]data.AuthorID{bob, alice}, article.AuthorIDs, "the author order should be kept")
+	assert.False(article.UpdatedAt.Before(article.CreatedAt))
+	assert.Equal(version, article.Version, "the edits should increment the version")
+
+	err = usecase.DeleteArticle(ctx, repo, &data.Actor{AuthorID: carol}, articleID, article.Version)
+	assert.Equal(errors.ErrForbidden, err, "non co-author should not delete")
+	err = usecase.DeleteArticle(ctx, repo, &data.Actor{Admin: true}, articleID, data.FirstArticleVersion)
+	assert.Equal(errors.ErrConflict, err, "delete of a previous version should return ErrConflict")
+	err = usecase.DeleteArticle(ctx, repo, &data.Actor{Admin: true}, articleID, article.Version)
+	assert.Nil(err, "admin should delete")
+	_, err = usecase.GetArticleByID(ctx, repo, articleID)
+	assert.Equal(errors.ErrNotFound, err, "deleted article should not be found")
+}
+
+func Test_AuthorToken(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := context.Background()
+	authorRepo := infra_repository.NewAuthorRepositoryInMemory()
+	authorID := createTestAuthor(t, ctx, authorRepo, testAuthor)
+
+	_, err := usecase.IssueAuthorToken(ctx, authorRepo, "42")
+	assert.Equal(errors.ErrAuthorNotFound, err, "issue token to a missing author should return ErrAuthorNotFound")
+
+	oldToken, err := usecase.IssueAuthorToken(ctx, authorRepo, authorID)
+	assert.Nil(err)
+	token, err := usecase.IssueAuthorToken(ctx, authorRepo, authorID)
+	assert.Nil(err)
+
+	author, err := usecase.AuthenticateAuthor(ctx, authorRepo, token)
+	assert.Nil(err)
+	assert.Equal(authorID, author.ID)
+	_, err = usecase.AuthenticateAuthor(ctx, authorRepo, oldToken)
+	assert.Equal(errors.ErrUnauthorized, err, "previous token should be revoked")
+}
+
+// articleRepositoryWithoutSearch hides the optional capabilities of the wrapped repository, such as search.
+type articleRepositoryWithoutSearch struct {
+	repository.ArticleRepository
+}
+
+func Test_SearchArticles(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := context.Background()
+	repo := infra_repository.NewArticleRepositoryInMemory()
+	authorIDs := []data.AuthorID{"1"}
+	goID, _ := repo.Create(ctx, &data.ArticleInfo{Title: "Learning Go", Content: "Goroutines and channels.", AuthorIDs: authorIDs})
+	_, _ = repo.Create(ctx, &data.ArticleInfo{Title: "Learning Rust", Content: "Ownership and channels.", AuthorIDs: authorIDs})
+
+	_, err := data.NewSearchQuery("   ")
+	assert.Equal(errors.ErrSearchQueryEmpty, err, "a blank query should be rejected")
+
+	query, err := data.NewSearchQuery(" CHANNELS  go ")
+	assert.Nil(err)
+	articles, err := usecase.SearchArticles(ctx, repo, query)
+	assert.Nil(err)
+	assert.Len(articles, 1, "only the articles containing all the terms should match")
+	assert.Equal(goID, articles[0].ID)
+
+	_, err = usecase.SearchArticles(ctx, articleRepositoryWithoutSearch{repo}, query)
+	assert.Equal(errors.ErrSearchNotSupported, err, "a repository without search should return ErrSearchNotSupported")
+}
+
+func Test_GetArticlePage(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := context.Background()
+	repo := infra_repository.NewArticleRepositoryInMemory()
+	var ids []data.ArticleID
+	// More than 9 articles, so the numeric order differs from the lexicographic one.
+	for i := 0; i < 12; i++ {
+		id, err := repo.Create(ctx, &data.ArticleInfo{Title: testTitle, Content: testContent, AuthorIDs: []data.AuthorID{"1"}})
+		assert.Nil(err)
+		ids = append(ids, id)
+	}
+	assert.Nil(repo.Delete(ctx, ids[5], data.FirstArticleVersion))
+
+	var paged []data.ArticleID
+	after := data.ArticleID("")
+	for {
+		page, err := usecase.GetArticlePage(ctx, repo, after, 5)
+		assert.Nil(err)
+		for _, article := range page {
+			paged = append(paged, article.ID)
+		}
+		if len(page) < 5 {
+			break
+		}
+		after = page[len(page)-1].ID
+	}
+	assert.Equal(append(append([]data.ArticleID{}, ids[:5]...), ids[6:]...), paged, "pages should cover the articles in creation order")
+
+	_, err := usecase.GetArticlePage(ctx, repo, "abc", 5)
+	assert.Equal(errors.ErrInvalidPageCursor, err, "a malformed cursor should return ErrInvalidPageCursor")
+	_, err = data.NewPageLimit(data.MAX_PAGE_LIMIT + 1)
+	assert.Equal(errors.ErrInvalidPageLimit, err, "a limit over the maximum should be rejected")
+	_, err = usecase.GetArticlePage(ctx, articleRepositoryWithoutSearch{repo}, "", 5)
+	assert.Equal(errors.ErrPaginationNotSupported, err, "a repository without pagination should return ErrPaginationNotSupported")
+}
+
+func Test_GetArticleRevisions(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := context.Background()
+	repo := infra_repository.NewArticleRepositoryInMemory()
+	admin := &data.Actor{Admin: true}
+	_, err := usecase.GetArticleRevisions(ctx, repo, admin, "1")
+	assert.Equal(errors.ErrRevisionsNotSupported, err, "a repository without history should return ErrRevisionsNotSupported")
+	_, err = usecase.GetArticleRevision(ctx, repo, admin, "1", "abc")
+	assert.Equal(errors.ErrRevisionsNotSupported, err, "a repository without history should return ErrRevisionsNotSupported")
+
+	gitRepo, err := infra_repository.NewArticleRepositoryGit(t.TempDir())
+	assert.Nil(err, "initializing a git repository should not return error")
+	author := &data.Actor{AuthorID: "1"}
+	id, err := gitRepo.Create(data.WithActor(ctx, author), &data.ArticleInfo{Title: "Hello", Content: "Draft", AuthorIDs: []data.AuthorID{"1"}})
+	assert.Nil(err, "create should not return error")
+
+	_, err = usecase.GetArticleRevisions(ctx, gitRepo, data.Anonymous, id)
+	assert.Equal(errors.ErrUnauthorized, err, "anonymous should not read the revisions")
+	_, err = usecase.GetArticleRevisions(ctx, gitRepo, &data.Actor{AuthorID: "2"}, id)
+	assert.Equal(errors.ErrForbidden, err, "another author should not read the revisions")
+	revisions, err := usecase.GetArticleRevisions(ctx, gitRepo, author, id)
+	assert.Nil(err, "the author should read the revisions")
+	assert.Len(revisions, 1)
+	_, err = usecase.GetArticleRevision(ctx, gitRepo, &data.Actor{AuthorID: "2"}, id, revisions[0].ID)
+	assert.Equal(errors.ErrForbidden, err, "another author should not read a revision")
+
+	assert.Nil(usecase.DeleteArticle(ctx, gitRepo, author, id, data.AnyArticleVersion), "delete should not return error")
+	_, err = usecase.GetArticleRevisions(ctx, gitRepo, author, id)
+	assert.Equal(errors.ErrNotFound, err, "the revisions of a deleted article should not be found by its author")
+	_, err = usecase.GetArticleRevision(ctx, gitRepo, author, id, revisions[0].ID)
+	assert.Equal(errors.ErrNotFound, err, "a revision of a deleted article should not be found by its author")
+	revisions, err = usecase.GetArticleRevisions(ctx, gitRepo, admin, id)
+	assert.Nil(err, "the admin should read the revisions of a deleted article")
+	assert.Len(revisions, 2)
 }
@@ -0,0 +1,65 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/Jason5Lee/simple-blog/core/data"
+	"github.com/Jason5Lee/simple-blog/core/errors"
+	"github.com/Jason5Lee/simple-blog/core/repository"
+)
+
+// GetDailyViews gets the views of an existing article on each of the last `days` days, including today.
+// Every day is present in the result, oldest first.
+func GetDailyViews(ctx context.Context, articleRepo repository.ArticleRepository, viewRepo repository.ViewRepository, articleID data.ArticleID, days int) ([]data.DailyViews, error) {
+	if _, err := articleRepo.GetByID(ctx, articleID); err != nil {
+		return nil, err
+	}
+	to := data.Day(time.Now())
+	from := to.AddDate(0, 0, 1-days)
+	daily, err := viewRepo.GetDaily(ctx, articleID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]data.DailyViews, 0, days)
+	i := 0
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		views := int64(0)
+		if i < len(daily) && daily[i].Day.Equal(day) {
+			views = daily[i].Views
+			i++
+		}
+		result = append(result, data.DailyViews{Day: day, Views: views})
+	}
+	return result, nil
+}
+
+// TopArticle is an article with its views in a period.
+type TopArticle struct {
+	*data.Article
+	Views int64
+}
+
+// GetTopArticles gets the n most viewed articles of the last `days` days, including today.
+// Articles that no longer exist are skipped.
+func GetTopArticles(ctx context.Context, articleRepo repository.ArticleRepository, viewRepo repository.ViewRepository, days int, n int) ([]TopArticle, error) {
+	to := data.Day(time.Now())
+	from := to.AddDate(0, 0, 1-days)
+	top, err := viewRepo.GetTop(ctx, from, to, n)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]TopArticle, 0, len(top))
+	for _, views := range top {
+		article, err := articleRepo.GetByID(ctx, views.ArticleID)
+		if err == errors.ErrNotFound {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, TopArticle{Article: article, Views: views.Views})
+	}
+	return result, nil
+}
@@ -0,0 +1,69 @@
+package infra
+
+import (
+	"context"
+	"log"
+
+	"github.com/Jason5Lee/simple-blog/core/data"
+	"github.com/Jason5Lee/simple-blog/core/events"
+	"github.com/Jason5Lee/simple-blog/core/repository"
+	infra_repository "github.com/Jason5Lee/simple-blog/infra/repository"
+)
+
+// articleCacheEventBuffer is the number of article events buffered for invalidating the cache.
+// If more are missed, the whole cache is cleared.
+const articleCacheEventBuffer = 256
+
+// NewCachedArticleRepository wraps the article repository with the cache selected by the configuration, or returns
+// nil if it is disabled. If the bus is not nil, its events invalidate the articles changed by other processes until
+// the context is done. The Redis cache, if any, is closed once the context is done.
+func NewCachedArticleRepository(ctx context.Context, config *ArticleCacheConfig, repo repository.ArticleRepository, articleEvents *events.ArticleBus) (*infra_repository.ArticleRepositoryCached, error) {
+	var cache repository.ArticleCache
+	if config.Size > 0 {
+		cache = infra_repository.NewArticleCacheLRU(config.Size, config.TTL)
+	}
+	var redisCache *infra_repository.ArticleCacheRedis
+	if config.RedisURL != "" {
+		// The cache in memory becomes the near cache in front of Redis.
+		var err error
+		if redisCache, err = infra_repository.NewArticleCacheRedis(ctx, config.RedisURL, config.TTL, cache); err != nil {
+			return nil, err
+		}
+		cache = redisCache
+	}
+	if cache == nil {
+		return nil, nil
+	}
+	cached := infra_repository.NewArticleRepositoryCached(repo, cache)
+	if redisCache != nil {
+		go func() {
+			if err := redisCache.Listen(ctx, cached.Invalidated); err != nil {
+				log.Printf("stopped receiving the article invalidations: %v", err)
+			}
+			<-ctx.Done()
+			_ = redisCache.Close()
+		}()
+	}
+	if articleEvents != nil {
+		go invalidateOnEvents(ctx, cached, articleEvents)
+	}
+	return cached, nil
+}
+
+func invalidateOnEvents(ctx context.Context, repo *infra_repository.ArticleRepositoryCached, articleEvents *events.ArticleBus) {
+	for {
+		subscription := articleEvents.Subscribe(articleCacheEventBuffer)
+		for event := range subscription.C {
+			if event.Kind == data.ArticlesReset {
+				repo.InvalidateAll(ctx)
+			} else {
+				repo.Invalidate(ctx, event.ArticleID)
+			}
+		}
+		// The subscription is closed after missing events, or once the bus is closed.
+		if ctx.Err() != nil {
+			return
+		}
+		repo.InvalidateAll(ctx)
+	}
+}
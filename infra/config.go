@@ -2,26 +2,419 @@ package infra
 
 import (
 	"errors"
+	"fmt"
 	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Jason5Lee/simple-blog/core/data"
 )
 
 type Config struct {
+	// Storage is the backend storing the blog, "mongo", "sqlite", "postgres" or "bolt".
+	Storage    string
 	MongoDBUri string
-	Listen     string
+	// MongoDBSchemaDryRun is whether the changes making the MongoDB schema match the declared one are only logged.
+	MongoDBSchemaDryRun bool
+	MongoDB             MongoDBConfig
+	PostgresUri         string
+	// SQLitePath is the path of the SQLite database file.
+	SQLitePath string
+	// BoltPath is the path of the bbolt database file.
+	BoltPath string
+	// ArticlesDir is the directory of the Markdown files storing the articles instead of the backend, if not empty.
+	ArticlesDir string
+	// ArticlesStore is how the articles directory is managed, "files" or "git".
+	ArticlesStore string
+	// BackupDir is the directory of the backup files written by the admin endpoint.
+	BackupDir string
+	// IDMapFile is the ID mapping file written by the migrate command, whose old IDs are redirected, if not empty.
+	IDMapFile string
+	Listen    string
+	// CacheControl is the Cache-Control policy of the GET routes, keyed by their path like "/articles/:article_id".
+	CacheControl map[string]string
+	// AdminToken protects the admin endpoints, which are disabled if it is empty.
+	AdminToken string
+	Moderation ModerationConfig
+	// ReactionKinds are the allowed reactions, i.e. like plus the configured emojis.
+	ReactionKinds data.ReactionKinds
+	// ViewDedupWindow is the period in which repeated views of the same visitor are counted once.
+	ViewDedupWindow time.Duration
+	// ViewFlushInterval is how often the buffered views are written to the database.
+	ViewFlushInterval time.Duration
+	Media             MediaConfig
+	ArticleCache      ArticleCacheConfig
+}
+
+type MongoDBConfig struct {
+	// Database is the name of the database.
+	Database string
+	// ArticleCollection is the name of the article collection.
+	ArticleCollection string
+	MinPoolSize       uint64
+	MaxPoolSize       uint64
+	// ReadPreference is "primary", "primaryPreferred", "secondary", "secondaryPreferred" or "nearest".
+	ReadPreference string
+	// WriteConcern is "majority" or the number of members acknowledging a write, or empty for the server default.
+	WriteConcern string
+	// ServerSelectionTimeout is how long an operation waits for a suitable server, so the startup fails
+	// after it if the database is unreachable.
+	ServerSelectionTimeout time.Duration
+	ConnectTimeout         time.Duration
+	// OperationTimeout bounds each operation, or none if zero.
+	OperationTimeout time.Duration
+	// ChangeStreamConsumer identifies the process watching the changes of the articles, so it resumes
+	// after the last change it published.
+	ChangeStreamConsumer string
+}
+
+type ArticleCacheConfig struct {
+	// Size is the number of articles cached in memory, or 0 to disable the cache in memory.
+	Size int
+	// TTL is how long an article is cached, or forever if zero.
+	TTL time.Duration
+	// RedisURL is the URL of the Redis caching the articles for all the replicas, or empty to only cache in memory.
+	RedisURL string
+}
+
+type ModerationConfig struct {
+	DefaultStatus data.CommentStatus
+	// TrustedAuthors are the IDs of the authors whose comments are auto-approved.
+	TrustedAuthors []data.AuthorID
+	SpamThreshold  float64
+	MaxLinks       int
+	Blocklist      []string
+}
+
+type MediaConfig struct {
+	// Store is where the media is stored, "local", "s3" or "gridfs".
+	Store string
+	// Dir is the directory of the local store.
+	Dir string
+	// MaxSize is the maximum size of an uploaded media in bytes.
+	MaxSize int64
+	// ImageWidths are the widths of the resized variants of images.
+	ImageWidths []int
+	// GCGracePeriod is how long an uploaded media is kept before it is referenced.
+	GCGracePeriod time.Duration
+	S3            S3Config
+}
+
+type S3Config struct {
+	Endpoint        string
+	Region          string
+	Bucket          string
+	AccessKeyID     string
+	SecretAccessKey string
 }
 
 func LoadConfig() (*Config, error) {
 	result := &Config{}
+	result.Storage = os.Getenv("STORAGE")
+	if result.Storage == "" {
+		result.Storage = "mongo"
+	}
 	result.MongoDBUri = os.Getenv("MONGODB_URI")
-
-	if result.MongoDBUri == "" {
-		return nil, errors.New("MONGODB_URI is not set")
+	if dryRun := os.Getenv("MONGODB_SCHEMA_DRY_RUN"); dryRun != "" {
+		var err error
+		if result.MongoDBSchemaDryRun, err = strconv.ParseBool(dryRun); err != nil {
+			return nil, fmt.Errorf("invalid MONGODB_SCHEMA_DRY_RUN: %w", err)
+		}
+	}
+	if err := loadMongoDBConfig(&result.MongoDB); err != nil {
+		return nil, err
+	}
+	result.PostgresUri = os.Getenv("POSTGRES_URI")
+	result.SQLitePath = os.Getenv("SQLITE_PATH")
+	if result.SQLitePath == "" {
+		result.SQLitePath = "simple-blog.db"
+	}
+	result.BoltPath = os.Getenv("BOLT_PATH")
+	if result.BoltPath == "" {
+		result.BoltPath = "simple-blog.bolt"
+	}
+	switch result.Storage {
+	case "mongo":
+		if result.MongoDBUri == "" {
+			return nil, errors.New("MONGODB_URI is not set")
+		}
+	case "postgres":
+		if result.PostgresUri == "" {
+			return nil, errors.New("POSTGRES_URI is not set")
+		}
+	case "sqlite", "bolt":
+	default:
+		return nil, fmt.Errorf("STORAGE must be mongo, sqlite, postgres or bolt, got %q", result.Storage)
 	}
+	result.ArticlesDir = os.Getenv("ARTICLES_DIR")
+	result.ArticlesStore = os.Getenv("ARTICLES_STORE")
+	if result.ArticlesStore == "" {
+		result.ArticlesStore = "files"
+	}
+	if result.ArticlesStore != "files" && result.ArticlesStore != "git" {
+		return nil, fmt.Errorf("ARTICLES_STORE must be files or git, got %q", result.ArticlesStore)
+	}
+	result.BackupDir = os.Getenv("BACKUP_DIR")
+	if result.BackupDir == "" {
+		result.BackupDir = "backups"
+	}
+	result.IDMapFile = os.Getenv("ID_MAP_FILE")
 
 	result.Listen = os.Getenv("LISTEN")
 	if result.Listen == "" {
 		result.Listen = ":8080"
 	}
 
+	result.AdminToken = os.Getenv("ADMIN_TOKEN")
+
+	var err error
+	if result.CacheControl, err = parseCacheControl(os.Getenv("CACHE_CONTROL")); err != nil {
+		return nil, err
+	}
+	result.Moderation.DefaultStatus = data.CommentPending
+	if status := os.Getenv("COMMENT_DEFAULT_STATUS"); status != "" {
+		result.Moderation.DefaultStatus = data.CommentStatus(status)
+		if result.Moderation.DefaultStatus != data.CommentPending && result.Moderation.DefaultStatus != data.CommentApproved {
+			return nil, errors.New("COMMENT_DEFAULT_STATUS must be pending or approved")
+		}
+	}
+	for _, id := range splitList(os.Getenv("TRUSTED_COMMENTERS")) {
+		result.Moderation.TrustedAuthors = append(result.Moderation.TrustedAuthors, data.AuthorID(id))
+	}
+	result.Moderation.Blocklist = splitList(os.Getenv("COMMENT_BLOCKLIST"))
+	result.Moderation.SpamThreshold = 0.9
+	if threshold := os.Getenv("SPAM_THRESHOLD"); threshold != "" {
+		if result.Moderation.SpamThreshold, err = strconv.ParseFloat(threshold, 64); err != nil {
+			return nil, fmt.Errorf("invalid SPAM_THRESHOLD: %w", err)
+		}
+	}
+	result.Moderation.MaxLinks = 2
+	if maxLinks := os.Getenv("COMMENT_MAX_LINKS"); maxLinks != "" {
+		if result.Moderation.MaxLinks, err = strconv.Atoi(maxLinks); err != nil {
+			return nil, fmt.Errorf("invalid COMMENT_MAX_LINKS: %w", err)
+		}
+	}
+
+	if result.ReactionKinds, err = data.NewReactionKinds(splitList(os.Getenv("REACTION_EMOJIS"))); err != nil {
+		return nil, fmt.Errorf("invalid REACTION_EMOJIS: %w", err)
+	}
+
+	if result.ViewDedupWindow, err = durationEnv("VIEW_DEDUP_WINDOW", 30*time.Minute); err != nil {
+		return nil, err
+	}
+	if result.ViewFlushInterval, err = durationEnv("VIEW_FLUSH_INTERVAL", 10*time.Second); err != nil {
+		return nil, err
+	}
+	if result.ViewFlushInterval <= 0 {
+		return nil, errors.New("VIEW_FLUSH_INTERVAL must be positive")
+	}
+
+	if result.ArticleCache.Size, err = intEnv("ARTICLE_CACHE_SIZE", 0); err != nil {
+		return nil, err
+	}
+	if result.ArticleCache.TTL, err = durationEnv("ARTICLE_CACHE_TTL", 5*time.Minute); err != nil {
+		return nil, err
+	}
+	result.ArticleCache.RedisURL = os.Getenv("ARTICLE_CACHE_REDIS_URL")
+	if result.ArticleCache.Size < 0 || result.ArticleCache.TTL < 0 {
+		return nil, errors.New("ARTICLE_CACHE_SIZE and ARTICLE_CACHE_TTL must not be negative")
+	}
+
+	if err = loadMediaConfig(&result.Media); err != nil {
+		return nil, err
+	}
+	if result.Media.Store == "gridfs" && result.Storage != "mongo" {
+		return nil, errors.New("MEDIA_STORE gridfs requires STORAGE mongo")
+	}
+
 	return result, nil
 }
+
+func loadMongoDBConfig(config *MongoDBConfig) error {
+	var err error
+	config.Database = os.Getenv("MONGODB_DATABASE")
+	if config.Database == "" {
+		config.Database = "simple-blog"
+	}
+	config.ArticleCollection = os.Getenv("MONGODB_ARTICLE_COLLECTION")
+	if config.ArticleCollection == "" {
+		config.ArticleCollection = "articles"
+	}
+	if minPoolSize := os.Getenv("MONGODB_MIN_POOL_SIZE"); minPoolSize != "" {
+		if config.MinPoolSize, err = strconv.ParseUint(minPoolSize, 10, 64); err != nil {
+			return fmt.Errorf("invalid MONGODB_MIN_POOL_SIZE: %w", err)
+		}
+	}
+	config.MaxPoolSize = 100
+	if maxPoolSize := os.Getenv("MONGODB_MAX_POOL_SIZE"); maxPoolSize != "" {
+		if config.MaxPoolSize, err = strconv.ParseUint(maxPoolSize, 10, 64); err != nil {
+			return fmt.Errorf("invalid MONGODB_MAX_POOL_SIZE: %w", err)
+		}
+	}
+	if config.MaxPoolSize == 0 || config.MinPoolSize > config.MaxPoolSize {
+		return errors.New("MONGODB_MAX_POOL_SIZE must be positive and at least MONGODB_MIN_POOL_SIZE")
+	}
+
+	config.ReadPreference = os.Getenv("MONGODB_READ_PREFERENCE")
+	switch config.ReadPreference {
+	case "":
+		config.ReadPreference = "primary"
+	case "primary", "primaryPreferred", "secondary", "secondaryPreferred", "nearest":
+	default:
+		return fmt.Errorf("MONGODB_READ_PREFERENCE must be primary, primaryPreferred, secondary, secondaryPreferred or nearest, got %q", config.ReadPreference)
+	}
+	config.WriteConcern = os.Getenv("MONGODB_WRITE_CONCERN")
+	if config.WriteConcern != "" && config.WriteConcern != "majority" {
+		if w, err := strconv.Atoi(config.WriteConcern); err != nil || w < 0 {
+			return fmt.Errorf("MONGODB_WRITE_CONCERN must be majority or a non-negative integer, got %q", config.WriteConcern)
+		}
+	}
+
+	if config.ServerSelectionTimeout, err = durationEnv("MONGODB_SERVER_SELECTION_TIMEOUT", 10*time.Second); err != nil {
+		return err
+	}
+	if config.ConnectTimeout, err = durationEnv("MONGODB_CONNECT_TIMEOUT", 10*time.Second); err != nil {
+		return err
+	}
+	if config.OperationTimeout, err = durationEnv("MONGODB_OPERATION_TIMEOUT", 0); err != nil {
+		return err
+	}
+	if config.ServerSelectionTimeout <= 0 || config.ConnectTimeout <= 0 || config.OperationTimeout < 0 {
+		return errors.New("MONGODB_SERVER_SELECTION_TIMEOUT and MONGODB_CONNECT_TIMEOUT must be positive, and MONGODB_OPERATION_TIMEOUT not negative")
+	}
+
+	config.ChangeStreamConsumer = os.Getenv("MONGODB_CHANGE_STREAM_CONSUMER")
+	if config.ChangeStreamConsumer == "" {
+		if config.ChangeStreamConsumer, err = os.Hostname(); err != nil {
+			return fmt.Errorf("MONGODB_CHANGE_STREAM_CONSUMER is not set and the hostname is unknown: %w", err)
+		}
+	}
+	return nil
+}
+
+func loadMediaConfig(config *MediaConfig) error {
+	var err error
+	config.Store = os.Getenv("MEDIA_STORE")
+	if config.Store == "" {
+		config.Store = "local"
+	}
+	config.Dir = os.Getenv("MEDIA_DIR")
+	if config.Dir == "" {
+		config.Dir = "media"
+	}
+	config.MaxSize = 10 * 1024 * 1024
+	if maxSize := os.Getenv("MEDIA_MAX_SIZE"); maxSize != "" {
+		if config.MaxSize, err = strconv.ParseInt(maxSize, 10, 64); err != nil {
+			return fmt.Errorf("invalid MEDIA_MAX_SIZE: %w", err)
+		}
+	}
+	if config.MaxSize <= 0 {
+		return errors.New("MEDIA_MAX_SIZE must be positive")
+	}
+
+	config.ImageWidths = []int{320, 640, 1280}
+	if widths := splitList(os.Getenv("MEDIA_IMAGE_WIDTHS")); widths != nil {
+		config.ImageWidths = make([]int, len(widths))
+		for i, width := range widths {
+			if config.ImageWidths[i], err = strconv.Atoi(width); err != nil || config.ImageWidths[i] <= 0 {
+				return fmt.Errorf("invalid MEDIA_IMAGE_WIDTHS: %q is not a positive integer", width)
+			}
+		}
+	}
+
+	if config.GCGracePeriod, err = durationEnv("MEDIA_GC_GRACE_PERIOD", 24*time.Hour); err != nil {
+		return err
+	}
+
+	config.S3 = S3Config{
+		Endpoint:        os.Getenv("S3_ENDPOINT"),
+		Region:          os.Getenv("S3_REGION"),
+		Bucket:          os.Getenv("S3_BUCKET"),
+		AccessKeyID:     os.Getenv("S3_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("S3_SECRET_ACCESS_KEY"),
+	}
+	if config.S3.Region == "" {
+		config.S3.Region = "us-east-1"
+	}
+	switch config.Store {
+	case "local", "gridfs":
+	case "s3":
+		if config.S3.Endpoint == "" || config.S3.Bucket == "" {
+			return errors.New("S3_ENDPOINT and S3_BUCKET are required when MEDIA_STORE is s3")
+		}
+	default:
+		return fmt.Errorf("MEDIA_STORE must be local, s3 or gridfs, got %q", config.Store)
+	}
+	return nil
+}
+
+// durationEnv gets a duration from the environment variable, or the default value if it is not set.
+func durationEnv(name string, defaultValue time.Duration) (time.Duration, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return defaultValue, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", name, err)
+	}
+	return d, nil
+}
+
+// intEnv gets an integer from the environment variable, or the default value if it is not set.
+func intEnv(name string, defaultValue int) (int, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return defaultValue, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", name, err)
+	}
+	return n, nil
+}
+
+// defaultCacheControl makes the clients revalidate the articles before using them, which costs no download
+// if they have not changed.
+var defaultCacheControl = map[string]string{
+	"/articles":             "no-cache",
+	"/articles/:article_id": "no-cache",
+}
+
+// parseCacheControl parses the Cache-Control policies, separated by semicolons like
+// "/articles/:article_id=public, max-age=60; /media/:media_id=no-store", overriding the default ones.
+// A route with an empty policy has no Cache-Control header.
+func parseCacheControl(s string) (map[string]string, error) {
+	policies := make(map[string]string, len(defaultCacheControl))
+	for route, policy := range defaultCacheControl {
+		policies[route] = policy
+	}
+	for _, item := range strings.Split(s, ";") {
+		if item = strings.TrimSpace(item); item == "" {
+			continue
+		}
+		route, policy, ok := strings.Cut(item, "=")
+		if !ok || !strings.HasPrefix(route, "/") {
+			return nil, fmt.Errorf("invalid CACHE_CONTROL policy %q, expected <route>=<policy>", item)
+		}
+		route, policy = strings.TrimSpace(route), strings.TrimSpace(policy)
+		if policy == "" {
+			delete(policies, route)
+		} else {
+			policies[route] = policy
+		}
+	}
+	return policies, nil
+}
+
+// splitList splits a comma-separated list, ignoring empty items.
+func splitList(s string) []string {
+	var result []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
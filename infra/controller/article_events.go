@@ -0,0 +1,33 @@
+package controller
+
+import (
+	"io"
+
+	"github.com/Jason5Lee/simple-blog/core/events"
+	"github.com/gin-gonic/gin"
+)
+
+// articleEventBuffer is the number of events buffered for a client, which is disconnected if it falls further behind.
+const articleEventBuffer = 64
+
+// NewArticleEventsController creates a controller streaming the article events as server-sent events named after
+// their kind, whose data is the article ID. The article is not sent, so a client only fetches those it shows.
+// Once the stream ends, e.g. because the client fell behind, the articles may have changed in between.
+func NewArticleEventsController(bus *events.ArticleBus) func(*gin.Context) {
+	return func(c *gin.Context) {
+		subscription := bus.Subscribe(articleEventBuffer)
+		defer subscription.Unsubscribe()
+		c.Stream(func(w io.Writer) bool {
+			select {
+			case event, ok := <-subscription.C:
+				if !ok {
+					return false
+				}
+				c.SSEvent(string(event.Kind), gin.H{"article_id": event.ArticleID})
+				return true
+			case <-c.Request.Context().Done():
+				return false
+			}
+		})
+	}
+}
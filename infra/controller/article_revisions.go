@@ -0,0 +1,50 @@
+package controller
+
+import (
+	"github.com/Jason5Lee/simple-blog/core/data"
+	"github.com/Jason5Lee/simple-blog/core/repository"
+	"github.com/Jason5Lee/simple-blog/core/usecase"
+	"github.com/gin-gonic/gin"
+)
+
+// NewGetArticleRevisionsController creates a controller listing the revisions of an article, newest first.
+// Only the co-authors and the admin can read the revisions, and only the admin those of a deleted article.
+func NewGetArticleRevisionsController(articleRepo repository.ArticleRepository) func(*gin.Context) {
+	return func(c *gin.Context) {
+		revisions, err := usecase.GetArticleRevisions(c, articleRepo, getActor(c), data.ArticleID(c.Param("article_id")))
+		if err != nil {
+			respondErr(c, err)
+			return
+		}
+		response := make([]gin.H, len(revisions))
+		for i, revision := range revisions {
+			response[i] = gin.H{
+				"id":         revision.ID,
+				"author":     revision.Author,
+				"message":    revision.Message,
+				"created_at": revision.CreatedAt,
+				"deleted":    revision.Deleted,
+			}
+		}
+		respond(c, 200, "Success", response)
+	}
+}
+
+// NewGetArticleRevisionController creates a controller for getting an article as of a revision,
+// with the same access as NewGetArticleRevisionsController.
+func NewGetArticleRevisionController(articleRepo repository.ArticleRepository, authorRepo repository.AuthorRepository) func(*gin.Context) {
+	return func(c *gin.Context) {
+		article, err := usecase.GetArticleRevision(c, articleRepo, getActor(c), data.ArticleID(c.Param("article_id")), data.RevisionID(c.Param("revision_id")))
+		if err != nil {
+			respondErr(c, err)
+			return
+		}
+		authors, err := usecase.GetAuthorsOfArticles(c, authorRepo, []*data.Article{article})
+		if err != nil {
+			respondErr(c, err)
+			return
+		}
+		// The reactions are not part of the history.
+		respond(c, 200, "Success", articleResponse(article, authors, nil))
+	}
+}
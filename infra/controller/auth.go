@@ -0,0 +1,67 @@
+package controller
+
+import (
+	"crypto/subtle"
+	"strings"
+
+	"github.com/Jason5Lee/simple-blog/core/data"
+	"github.com/Jason5Lee/simple-blog/core/repository"
+	"github.com/Jason5Lee/simple-blog/core/usecase"
+	"github.com/gin-gonic/gin"
+)
+
+const actorKey = "actor"
+
+// NewActorMiddleware creates a middleware identifying the actor from the `Authorization: Bearer <token>` header,
+// where the token is either the admin token or an author token.
+// Requests without the header are anonymous, and requests with an unknown token are rejected.
+func NewActorMiddleware(authorRepo repository.AuthorRepository, adminToken string) func(*gin.Context) {
+	return func(c *gin.Context) {
+		header := c.GetHeader("Authorization")
+		if header == "" {
+			c.Set(actorKey, data.Anonymous)
+			c.Next()
+			return
+		}
+		token := strings.TrimPrefix(header, "Bearer ")
+		if adminToken != "" && subtle.ConstantTimeCompare([]byte(token), []byte(adminToken)) == 1 {
+			c.Set(actorKey, &data.Actor{Admin: true})
+			c.Next()
+			return
+		}
+		author, err := usecase.AuthenticateAuthor(c, authorRepo, token)
+		if err != nil {
+			respondErr(c, err)
+			c.Abort()
+			return
+		}
+		c.Set(actorKey, &data.Actor{AuthorID: author.ID})
+		c.Next()
+	}
+}
+
+// getActor gets the actor identified by the actor middleware.
+func getActor(c *gin.Context) *data.Actor {
+	if actor, ok := c.Get(actorKey); ok {
+		return actor.(*data.Actor)
+	}
+	return data.Anonymous
+}
+
+// NewAdminAuthMiddleware creates a middleware only letting through the admin, identified by the actor middleware.
+// All requests are rejected if the admin token is empty, which disables the admin endpoints.
+func NewAdminAuthMiddleware(adminToken string) func(*gin.Context) {
+	return func(c *gin.Context) {
+		if adminToken == "" {
+			respond(c, 403, "admin endpoints are disabled", nil)
+			c.Abort()
+			return
+		}
+		if !getActor(c).Admin {
+			respond(c, 401, "unauthorized", nil)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,136 @@
+package controller
+
+import (
+	"github.com/Jason5Lee/simple-blog/core/data"
+	"github.com/Jason5Lee/simple-blog/core/repository"
+	"github.com/Jason5Lee/simple-blog/core/usecase"
+	"github.com/gin-gonic/gin"
+)
+
+// CreateAuthorRequest is the request body for creating an author profile.
+type CreateAuthorRequest struct {
+	DisplayName *string  `json:"display_name"`
+	Bio         string   `json:"bio"`
+	Avatar      string   `json:"avatar"`
+	Links       []string `json:"links"`
+}
+
+// authorResponse converts an author into the response data.
+func authorResponse(author *data.Author) gin.H {
+	links := make([]string, len(author.Links))
+	for i, link := range author.Links {
+		links[i] = string(link)
+	}
+	return gin.H{
+		"id":           author.ID,
+		"display_name": string(author.DisplayName),
+		"bio":          string(author.Bio),
+		"avatar":       string(author.Avatar),
+		"links":        links,
+	}
+}
+
+// NewCreateAuthorController creates a controller for creating an author profile.
+// The response contains the token authenticating the author, which is not retrievable later.
+func NewCreateAuthorController(authorRepo repository.AuthorRepository) func(*gin.Context) {
+	return func(c *gin.Context) {
+		var err error
+		var req CreateAuthorRequest
+		if err = c.ShouldBindJSON(&req); err != nil {
+			respondErr(c, err)
+			return
+		}
+		if req.DisplayName == nil {
+			respond(c, 400, "display_name is required", nil)
+			return
+		}
+
+		author := &data.AuthorInfo{}
+		author.DisplayName, err = data.NewAuthorName(*req.DisplayName)
+		if err != nil {
+			respondErr(c, err)
+			return
+		}
+		author.Bio, err = data.NewAuthorBio(req.Bio)
+		if err != nil {
+			respondErr(c, err)
+			return
+		}
+		if req.Avatar != "" {
+			author.Avatar, err = data.NewAuthorURL(req.Avatar)
+			if err != nil {
+				respondErr(c, err)
+				return
+			}
+		}
+		author.Links, err = data.NewAuthorLinks(req.Links)
+		if err != nil {
+			respondErr(c, err)
+			return
+		}
+
+		id, err := usecase.CreateAuthor(c, authorRepo, author)
+		if err != nil {
+			respondErr(c, err)
+			return
+		}
+		token, err := usecase.IssueAuthorToken(c, authorRepo, id)
+		if err != nil {
+			respondErr(c, err)
+			return
+		}
+		respond(c, 201, "Success", gin.H{"id": id, "token": token})
+	}
+}
+
+// NewIssueAuthorTokenController creates a controller for issuing a new token to an author, revoking the previous one.
+func NewIssueAuthorTokenController(authorRepo repository.AuthorRepository) func(*gin.Context) {
+	return func(c *gin.Context) {
+		token, err := usecase.IssueAuthorToken(c, authorRepo, data.AuthorID(c.Param("author_id")))
+		if err != nil {
+			respondErr(c, err)
+			return
+		}
+		respond(c, 200, "Success", gin.H{"token": token})
+	}
+}
+
+// NewGetAllAuthorsController creates a controller for getting all author profiles.
+func NewGetAllAuthorsController(authorRepo repository.AuthorRepository) func(*gin.Context) {
+	return func(c *gin.Context) {
+		authors, err := usecase.GetAllAuthors(c, authorRepo)
+		if err != nil {
+			respondErr(c, err)
+			return
+		}
+		response := make([]gin.H, len(authors))
+		for i, author := range authors {
+			response[i] = authorResponse(author)
+		}
+		respond(c, 200, "Success", response)
+	}
+}
+
+// NewGetAuthorByIDController creates a controller for getting an author profile by ID.
+func NewGetAuthorByIDController(authorRepo repository.AuthorRepository) func(*gin.Context) {
+	return func(c *gin.Context) {
+		author, err := usecase.GetAuthorByID(c, authorRepo, data.AuthorID(c.Param("author_id")))
+		if err != nil {
+			respondErr(c, err)
+			return
+		}
+		respond(c, 200, "Success", authorResponse(author))
+	}
+}
+
+// NewGetAuthorArticlesController creates a controller for getting the articles of an author.
+func NewGetAuthorArticlesController(articleRepo repository.ArticleRepository, authorRepo repository.AuthorRepository, reactionRepo repository.ReactionRepository) func(*gin.Context) {
+	return func(c *gin.Context) {
+		articles, err := usecase.GetAuthorArticles(c, articleRepo, authorRepo, data.AuthorID(c.Param("author_id")))
+		if err != nil {
+			respondErr(c, err)
+			return
+		}
+		respondArticles(c, authorRepo, reactionRepo, articles)
+	}
+}
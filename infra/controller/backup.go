@@ -0,0 +1,48 @@
+package controller
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Jason5Lee/simple-blog/core/repository"
+	"github.com/Jason5Lee/simple-blog/core/usecase"
+	"github.com/gin-gonic/gin"
+)
+
+// NewBackupController creates a controller writing an online backup of the storage to a new file in the directory.
+// The backup is written to a temporary file renamed once complete, so a file in the directory is never partial.
+func NewBackupController(articleRepo repository.ArticleRepository, dir string) func(*gin.Context) {
+	return func(c *gin.Context) {
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			respondErr(c, err)
+			return
+		}
+		file, err := os.CreateTemp(dir, ".backup-*")
+		if err != nil {
+			respondErr(c, err)
+			return
+		}
+		tempPath := file.Name()
+		// Removing the renamed file fails harmlessly.
+		defer os.Remove(tempPath)
+
+		size, err := usecase.Backup(c, articleRepo, file)
+		if err == nil {
+			err = file.Sync()
+		}
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			respondErr(c, err)
+			return
+		}
+		path := filepath.Join(dir, "backup-"+time.Now().UTC().Format("20060102T150405.000000000Z"))
+		if err := os.Rename(tempPath, path); err != nil {
+			respondErr(c, err)
+			return
+		}
+		respond(c, 201, "Success", gin.H{"file": path, "size": size})
+	}
+}
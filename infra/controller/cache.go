@@ -0,0 +1,16 @@
+package controller
+
+import (
+	"github.com/Jason5Lee/simple-blog/core/data"
+	"github.com/gin-gonic/gin"
+)
+
+// NewGetCacheStatsController creates a controller for getting the hits and misses of the article cache.
+func NewGetCacheStatsController(stats func() data.CacheStats) func(*gin.Context) {
+	return func(c *gin.Context) {
+		articles := stats()
+		respond(c, 200, "Success", gin.H{
+			"articles": gin.H{"hits": articles.Hits, "misses": articles.Misses},
+		})
+	}
+}
@@ -1,7 +1,12 @@
 package controller
 
 import (
+	"time"
+
+	"github.com/Jason5Lee/simple-blog/core/data"
 	"github.com/Jason5Lee/simple-blog/core/errors"
+	"github.com/Jason5Lee/simple-blog/core/repository"
+	"github.com/Jason5Lee/simple-blog/core/usecase"
 	"github.com/gin-gonic/gin"
 )
 
@@ -17,15 +22,101 @@ func respond(c *gin.Context, status int, message string, data interface{}) {
 // getStatusCode gets the status code from error.
 func getStatusCode(err error) int {
 	switch err {
-	case errors.ErrNotFound:
+	case errors.ErrNotFound, errors.ErrCommentNotFound, errors.ErrAuthorNotFound, errors.ErrSeriesNotFound, errors.ErrMediaNotFound, errors.ErrRevisionNotFound:
 		return 404
+	case errors.ErrUnauthorized:
+		return 401
+	case errors.ErrForbidden:
+		return 403
 	case errors.ErrAuthorEmpty, errors.ErrAuthorTooLong, errors.ErrContentEmpty, errors.ErrContentTooLong, errors.ErrTitleEmpty, errors.ErrTitleTooLong:
 		return 400
+	case errors.ErrCommentAuthorEmpty, errors.ErrCommentAuthorTooLong, errors.ErrCommentContentEmpty, errors.ErrCommentContentTooLong, errors.ErrInvalidCommentStatus,
+		errors.ErrInvalidReactionKind, errors.ErrReactionUserEmpty, errors.ErrReactionUserTooLong,
+		errors.ErrAuthorBioTooLong, errors.ErrInvalidAuthorURL, errors.ErrTooManyAuthorLinks,
+		errors.ErrNoAuthor, errors.ErrTooManyAuthors, errors.ErrDuplicateAuthor,
+		errors.ErrSeriesTitleEmpty, errors.ErrSeriesTitleTooLong, errors.ErrSeriesDescriptionTooLong,
+		errors.ErrMediaEmpty, errors.ErrInvalidImage, errors.ErrInvalidImageVariant,
+		errors.ErrSearchQueryEmpty, errors.ErrSearchQueryTooLong, errors.ErrInvalidPageLimit, errors.ErrInvalidPageCursor,
+		errors.ErrInvalidVersion:
+		return 400
+	case errors.ErrMediaTooLarge:
+		return 413
+	case errors.ErrUnsupportedMediaType:
+		return 415
+	case errors.ErrInvalidSeriesOrder, errors.ErrArticleAlreadyInSeries, errors.ErrArticleNotInSeries, errors.ErrConflict:
+		return 409
+	case errors.ErrVersionRequired:
+		return 428
+	case errors.ErrSearchNotSupported, errors.ErrPaginationNotSupported, errors.ErrBackupNotSupported, errors.ErrRevisionsNotSupported:
+		return 501
 	}
 	return 500
 }
 
 // respondErr is a helper function to respond error.
+// The error is not cached, whatever the Cache-Control policy of the route.
 func respondErr(c *gin.Context, err error) {
+	c.Writer.Header().Del("Cache-Control")
 	respond(c, getStatusCode(err), err.Error(), nil)
 }
+
+// articleResponse converts an article into the response data, with the authors in credit order.
+// The display name of an author is empty if the author profile is missing.
+func articleResponse(article *data.Article, authors map[data.AuthorID]*data.Author, reactions data.ReactionCounts) gin.H {
+	articleAuthors := make([]gin.H, len(article.AuthorIDs))
+	for i, id := range article.AuthorIDs {
+		displayName := ""
+		if author, ok := authors[id]; ok {
+			displayName = string(author.DisplayName)
+		}
+		articleAuthors[i] = gin.H{"id": id, "display_name": displayName}
+	}
+	return gin.H{
+		"id":         article.ID,
+		"title":      string(article.Title),
+		"content":    string(article.Content),
+		"authors":    articleAuthors,
+		"reactions":  reactions,
+		"version":    article.Version,
+		"created_at": article.CreatedAt,
+		"updated_at": article.UpdatedAt,
+	}
+}
+
+// articlesResponse converts the articles into the response data with their authors and reaction counts.
+func articlesResponse(c *gin.Context, authorRepo repository.AuthorRepository, reactionRepo repository.ReactionRepository, articles []*data.Article) ([]gin.H, error) {
+	authors, err := usecase.GetAuthorsOfArticles(c, authorRepo, articles)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]data.ArticleID, len(articles))
+	for i, a := range articles {
+		ids[i] = a.ID
+	}
+	reactions, err := usecase.GetReactionCounts(c, reactionRepo, ids)
+	if err != nil {
+		return nil, err
+	}
+	response := make([]gin.H, len(articles))
+	for i, a := range articles {
+		response[i] = articleResponse(a, authors, reactions[a.ID])
+	}
+	return response, nil
+}
+
+// respondArticles responds the articles with their authors and reaction counts,
+// or 304 if the client has them already.
+func respondArticles(c *gin.Context, authorRepo repository.AuthorRepository, reactionRepo repository.ReactionRepository, articles []*data.Article) {
+	response, err := articlesResponse(c, authorRepo, reactionRepo, articles)
+	if err != nil {
+		respondErr(c, err)
+		return
+	}
+	var lastModified time.Time
+	for _, article := range articles {
+		if article.UpdatedAt.After(lastModified) {
+			lastModified = article.UpdatedAt
+		}
+	}
+	respondConditional(c, response, lastModified)
+}
@@ -0,0 +1,80 @@
+package controller
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// NewCacheControlMiddleware creates a middleware setting the Cache-Control header of the successful GET responses
+// of the routes with a policy, keyed by their path like "/articles/:article_id".
+func NewCacheControlMiddleware(policies map[string]string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead {
+			if policy, ok := policies[c.FullPath()]; ok {
+				c.Header("Cache-Control", policy)
+			}
+		}
+		c.Next()
+	}
+}
+
+// respondConditional responds the data successfully, or with 304 if the ETag matches the `If-None-Match` header.
+// The ETag is the hash of the response, so it changes with anything in it, like the content, the reactions or the
+// authors of an article, or the articles of a list. Changing an article takes its version as `If-Match` instead,
+// since the hash cannot be checked without building the response.
+// The `Last-Modified` time is the time the articles were updated, if not zero, which `If-Modified-Since` is compared
+// with only without `If-None-Match`, as RFC 9110 requires, since the time does not cover the reactions or the authors.
+func respondConditional(c *gin.Context, data interface{}, lastModified time.Time) {
+	body, err := json.Marshal(gin.H{
+		"status":  200,
+		"message": "Success",
+		"data":    data,
+	})
+	if err != nil {
+		respondErr(c, err)
+		return
+	}
+	hash := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(hash[:16]) + `"`
+	c.Header("ETag", etag)
+	if !lastModified.IsZero() {
+		c.Header("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+	if notModified(c.Request, etag, lastModified) {
+		c.Status(http.StatusNotModified)
+		c.Writer.WriteHeaderNow()
+		return
+	}
+	c.Data(200, "application/json; charset=utf-8", body)
+}
+
+// notModified returns whether the client has the response with the ETag already, by `If-None-Match` if present,
+// otherwise by `If-Modified-Since`, which is not after the time in seconds, the precision of the HTTP dates.
+func notModified(req *http.Request, etag string, lastModified time.Time) bool {
+	if ifNoneMatch := req.Header.Get("If-None-Match"); ifNoneMatch != "" {
+		return etagMatches(ifNoneMatch, etag)
+	}
+	if lastModified.IsZero() {
+		return false
+	}
+	since, err := http.ParseTime(req.Header.Get("If-Modified-Since"))
+	return err == nil && !lastModified.Truncate(time.Second).After(since)
+}
+
+// etagMatches returns whether the `If-None-Match` header matches the ETag, comparing the weak ETags
+// like the strong ones, as required for `If-None-Match`.
+func etagMatches(ifNoneMatch string, etag string) bool {
+	for _, tag := range strings.Split(ifNoneMatch, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,55 @@
+package controller
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// conditionalRequest responds the data updated at the time to a GET request with the headers.
+func conditionalRequest(headers map[string]string, updatedAt time.Time) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+	c.Request = httptest.NewRequest(http.MethodGet, "/articles/1", nil)
+	for name, value := range headers {
+		c.Request.Header.Set(name, value)
+	}
+	respondConditional(c, gin.H{"title": "title"}, updatedAt)
+	return recorder
+}
+
+func Test_RespondConditional(t *testing.T) {
+	assert := assert.New(t)
+	updatedAt := time.Date(2024, 3, 1, 12, 0, 0, 500, time.UTC)
+
+	first := conditionalRequest(nil, updatedAt)
+	assert.Equal(200, first.Code)
+	etag := first.Header().Get("ETag")
+	assert.Regexp(`^"[0-9a-f]{32}"$`, etag)
+	assert.Equal("Fri, 01 Mar 2024 12:00:00 GMT", first.Header().Get("Last-Modified"))
+
+	resp := conditionalRequest(map[string]string{"If-None-Match": `"other", ` + etag}, updatedAt)
+	assert.Equal(304, resp.Code, "a matching ETag should not be modified")
+	assert.Empty(resp.Body.String())
+	resp = conditionalRequest(map[string]string{"If-None-Match": `"other"`}, updatedAt)
+	assert.Equal(200, resp.Code, "another ETag should be modified")
+
+	lastModified := first.Header().Get("Last-Modified")
+	resp = conditionalRequest(map[string]string{"If-Modified-Since": lastModified}, updatedAt)
+	assert.Equal(304, resp.Code, "a date not before the update should not be modified")
+	resp = conditionalRequest(map[string]string{"If-Modified-Since": lastModified}, updatedAt.Add(time.Second))
+	assert.Equal(200, resp.Code, "a date before the update should be modified")
+	resp = conditionalRequest(map[string]string{"If-Modified-Since": "yesterday"}, updatedAt)
+	assert.Equal(200, resp.Code, "an invalid date should be ignored")
+	resp = conditionalRequest(map[string]string{"If-Modified-Since": lastModified, "If-None-Match": `"other"`}, updatedAt)
+	assert.Equal(200, resp.Code, "If-Modified-Since should be ignored with If-None-Match")
+
+	resp = conditionalRequest(map[string]string{"If-Modified-Since": lastModified}, time.Time{})
+	assert.Equal(200, resp.Code, "without time, If-Modified-Since should be ignored")
+	assert.Empty(resp.Header().Get("Last-Modified"))
+}
@@ -9,51 +9,63 @@ import (
 
 // CreateArticleRequest is the request body for creating an article.
 type CreateArticleRequest struct {
-	Title   *string `json:"title"`
-	Content *string `json:"content"`
-	Author  *string `json:"author"`
+	Title     *string  `json:"title"`
+	Content   *string  `json:"content"`
+	AuthorIDs []string `json:"author_ids"`
 }
 
-// NewCreateArticleController creates a new controller for creating an article.
-func NewCreateArticleController(articleRepo repository.ArticleRepository) func(*gin.Context) {
-	return func(c *gin.Context) {
-		var err error
-		var req CreateArticleRequest
-		if err = c.ShouldBindJSON(&req); err != nil {
-			respondErr(c, err)
-			return
-		}
-		if req.Title == nil {
-			respond(c, 400, "title is required", nil)
-			return
-		}
-		if req.Content == nil {
-			respond(c, 400, "content is required", nil)
-			return
-		}
-		if req.Author == nil {
-			respond(c, 400, "author is required", nil)
-			return
-		}
+// parseArticleInfo validates the request body of creating an article.
+// It responds with the error and returns nil if the request is invalid.
+func parseArticleInfo(c *gin.Context) *data.ArticleInfo {
+	var req CreateArticleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondErr(c, err)
+		return nil
+	}
+	return newArticleInfo(c, &req)
+}
 
-		article := &data.ArticleInfo{}
-		article.Title, err = data.NewArticleTitle(*req.Title)
-		if err != nil {
-			respondErr(c, err)
-			return
-		}
-		article.Content, err = data.NewArticleContent(*req.Content)
-		if err != nil {
-			respondErr(c, err)
-			return
-		}
-		article.Author, err = data.NewArticleAuthor(*req.Author)
-		if err != nil {
-			respondErr(c, err)
+// newArticleInfo validates the article of the request body of creating or updating an article.
+// It responds with the error and returns nil if the article is invalid.
+func newArticleInfo(c *gin.Context, req *CreateArticleRequest) *data.ArticleInfo {
+	var err error
+	if req.Title == nil {
+		respond(c, 400, "title is required", nil)
+		return nil
+	}
+	if req.Content == nil {
+		respond(c, 400, "content is required", nil)
+		return nil
+	}
+
+	article := &data.ArticleInfo{}
+	article.Title, err = data.NewArticleTitle(*req.Title)
+	if err != nil {
+		respondErr(c, err)
+		return nil
+	}
+	article.Content, err = data.NewArticleContent(*req.Content)
+	if err != nil {
+		respondErr(c, err)
+		return nil
+	}
+	article.AuthorIDs, err = data.NewArticleAuthors(req.AuthorIDs)
+	if err != nil {
+		respondErr(c, err)
+		return nil
+	}
+	return article
+}
+
+// NewCreateArticleController creates a new controller for creating an article, allowed to its authors.
+func NewCreateArticleController(articleRepo repository.ArticleRepository, authorRepo repository.AuthorRepository) func(*gin.Context) {
+	return func(c *gin.Context) {
+		article := parseArticleInfo(c)
+		if article == nil {
 			return
 		}
 
-		id, err := usecase.CreateArticle(c, articleRepo, article)
+		id, err := usecase.CreateArticle(c, articleRepo, authorRepo, getActor(c), article)
 		if err != nil {
 			respondErr(c, err)
 			return
@@ -0,0 +1,54 @@
+package controller
+
+import (
+	"github.com/Jason5Lee/simple-blog/core/data"
+	"github.com/Jason5Lee/simple-blog/core/moderation"
+	"github.com/Jason5Lee/simple-blog/core/repository"
+	"github.com/Jason5Lee/simple-blog/core/usecase"
+	"github.com/gin-gonic/gin"
+)
+
+// CreateCommentRequest is the request body for creating a comment.
+type CreateCommentRequest struct {
+	Author  *string `json:"author"`
+	Content *string `json:"content"`
+}
+
+// NewCreateCommentController creates a new controller for commenting on an article.
+func NewCreateCommentController(articleRepo repository.ArticleRepository, commentRepo repository.CommentRepository, moderator *moderation.Moderator) func(*gin.Context) {
+	return func(c *gin.Context) {
+		var err error
+		var req CreateCommentRequest
+		if err = c.ShouldBindJSON(&req); err != nil {
+			respondErr(c, err)
+			return
+		}
+		if req.Author == nil {
+			respond(c, 400, "author is required", nil)
+			return
+		}
+		if req.Content == nil {
+			respond(c, 400, "content is required", nil)
+			return
+		}
+
+		comment := &data.CommentInfo{ArticleID: data.ArticleID(c.Param("article_id"))}
+		comment.Author, err = data.NewCommentAuthor(*req.Author)
+		if err != nil {
+			respondErr(c, err)
+			return
+		}
+		comment.Content, err = data.NewCommentContent(*req.Content)
+		if err != nil {
+			respondErr(c, err)
+			return
+		}
+
+		id, status, err := usecase.CreateComment(c, articleRepo, commentRepo, moderator, getActor(c), comment)
+		if err != nil {
+			respondErr(c, err)
+			return
+		}
+		respond(c, 201, "Success", gin.H{"id": id, "status": status})
+	}
+}
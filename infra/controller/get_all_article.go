@@ -1,28 +1,61 @@
 package controller
 
 import (
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"github.com/Jason5Lee/simple-blog/core/data"
+	"github.com/Jason5Lee/simple-blog/core/errors"
 	"github.com/Jason5Lee/simple-blog/core/repository"
 	"github.com/Jason5Lee/simple-blog/core/usecase"
 	"github.com/gin-gonic/gin"
 )
 
-// NewGetAllArticlesController creates a new controller for getting all articles.
-func NewGetAllArticlesController(articleRepo repository.ArticleRepository) func(*gin.Context) {
+// NewGetAllArticlesController creates a new controller for getting all articles,
+// only the ones of an author with the `author_id` query parameter, or the ones matching the `q` search query.
+// With the `limit` query parameter, it gets a page of the articles following the `after` article ID,
+// linking to the next page with the `Link` header if the page is full.
+func NewGetAllArticlesController(articleRepo repository.ArticleRepository, authorRepo repository.AuthorRepository, reactionRepo repository.ReactionRepository) func(*gin.Context) {
 	return func(c *gin.Context) {
-		article, err := usecase.GetAllArticles(c, articleRepo)
+		var articles []*data.Article
+		var err error
+		if query, ok := c.GetQuery("q"); ok {
+			var searchQuery data.SearchQuery
+			if searchQuery, err = data.NewSearchQuery(query); err == nil {
+				articles, err = usecase.SearchArticles(c, articleRepo, searchQuery)
+			}
+		} else if authorID := c.Query("author_id"); authorID != "" {
+			articles, err = usecase.GetAuthorArticles(c, articleRepo, authorRepo, data.AuthorID(authorID))
+		} else if limit, ok := c.GetQuery("limit"); ok {
+			articles, err = getArticlePage(c, articleRepo, limit)
+		} else {
+			articles, err = usecase.GetAllArticles(c, articleRepo)
+		}
 		if err != nil {
 			respondErr(c, err)
 			return
 		}
-		response := make([]gin.H, len(article))
-		for i, a := range article {
-			response[i] = gin.H{
-				"id":      a.ID,
-				"title":   string(a.Title),
-				"content": string(a.Content),
-				"author":  string(a.Author),
-			}
-		}
-		respond(c, 200, "Success", response)
+		respondArticles(c, authorRepo, reactionRepo, articles)
+	}
+}
+
+func getArticlePage(c *gin.Context, articleRepo repository.ArticleRepository, rawLimit string) ([]*data.Article, error) {
+	n, err := strconv.Atoi(rawLimit)
+	if err != nil {
+		return nil, errors.ErrInvalidPageLimit
+	}
+	limit, err := data.NewPageLimit(n)
+	if err != nil {
+		return nil, err
+	}
+	articles, err := usecase.GetArticlePage(c, articleRepo, data.ArticleID(c.Query("after")), limit)
+	if err != nil {
+		return nil, err
+	}
+	if len(articles) == int(limit) {
+		next := url.Values{"limit": {strconv.Itoa(n)}, "after": {string(articles[len(articles)-1].ID)}}
+		c.Header("Link", fmt.Sprintf(`<%s?%s>; rel="next"`, c.Request.URL.Path, next.Encode()))
 	}
+	return articles, nil
 }
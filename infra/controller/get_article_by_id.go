@@ -1,14 +1,19 @@
 package controller
 
 import (
+	"github.com/Jason5Lee/simple-blog/core/analytics"
 	"github.com/Jason5Lee/simple-blog/core/data"
 	"github.com/Jason5Lee/simple-blog/core/repository"
 	"github.com/Jason5Lee/simple-blog/core/usecase"
+	"github.com/Jason5Lee/simple-blog/infra/markdown"
 	"github.com/gin-gonic/gin"
 )
 
 // NewGetArticleByIDController creates a controller for getting an article by ID.
-func NewGetArticleByIDController(articleRepo repository.ArticleRepository) func(c *gin.Context) {
+// Each successful request is recorded as a view of the article.
+// The response contains the navigation to the previous and next parts of each series containing the article,
+// and the content rendered into HTML. It responds 304 if the client has the same response already.
+func NewGetArticleByIDController(articleRepo repository.ArticleRepository, authorRepo repository.AuthorRepository, reactionRepo repository.ReactionRepository, seriesRepo repository.SeriesRepository, viewCounter *analytics.ViewCounter, renderer *markdown.Renderer) func(c *gin.Context) {
 	return func(c *gin.Context) {
 		var err error
 
@@ -23,13 +28,27 @@ func NewGetArticleByIDController(articleRepo repository.ArticleRepository) func(
 			respondErr(c, err)
 			return
 		}
-		respond(c, 200, "Success", []gin.H{
-			{
-				"id":      id,
-				"title":   string(article.Title),
-				"content": string(article.Content),
-				"author":  string(article.Author),
-			},
-		})
+		viewCounter.Record(article.ID, c.ClientIP(), c.Request.UserAgent())
+
+		response, err := articlesResponse(c, authorRepo, reactionRepo, []*data.Article{article})
+		if err != nil {
+			respondErr(c, err)
+			return
+		}
+		navigations, err := usecase.GetSeriesNavigation(c, seriesRepo, articleRepo, article.ID)
+		if err != nil {
+			respondErr(c, err)
+			return
+		}
+		series := make([]gin.H, len(navigations))
+		for i, navigation := range navigations {
+			series[i] = seriesNavigationResponse(navigation)
+		}
+		response[0]["series"] = series
+		if response[0]["content_html"], err = renderer.Render(article.Content); err != nil {
+			respondErr(c, err)
+			return
+		}
+		respondConditional(c, response, article.UpdatedAt)
 	}
 }
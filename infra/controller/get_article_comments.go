@@ -0,0 +1,29 @@
+package controller
+
+import (
+	"github.com/Jason5Lee/simple-blog/core/data"
+	"github.com/Jason5Lee/simple-blog/core/repository"
+	"github.com/Jason5Lee/simple-blog/core/usecase"
+	"github.com/gin-gonic/gin"
+)
+
+// NewGetArticleCommentsController creates a controller for getting the approved comments of an article.
+func NewGetArticleCommentsController(commentRepo repository.CommentRepository) func(*gin.Context) {
+	return func(c *gin.Context) {
+		comments, err := usecase.GetArticleComments(c, commentRepo, data.ArticleID(c.Param("article_id")))
+		if err != nil {
+			respondErr(c, err)
+			return
+		}
+		response := make([]gin.H, len(comments))
+		for i, comment := range comments {
+			response[i] = gin.H{
+				"id":         comment.ID,
+				"author":     string(comment.Author),
+				"content":    string(comment.Content),
+				"created_at": comment.CreatedAt,
+			}
+		}
+		respond(c, 200, "Success", response)
+	}
+}
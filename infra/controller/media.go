@@ -0,0 +1,131 @@
+package controller
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Jason5Lee/simple-blog/core/data"
+	"github.com/Jason5Lee/simple-blog/core/errors"
+	"github.com/Jason5Lee/simple-blog/core/repository"
+	"github.com/Jason5Lee/simple-blog/core/usecase"
+	"github.com/gin-gonic/gin"
+)
+
+// multipartOverhead is the room for the multipart boundaries and headers when limiting the request body size.
+const multipartOverhead = 64 * 1024
+
+// Media is immutable as its ID is the hash of the content, so it can be cached forever
+// unless another policy is configured for the route.
+const mediaCacheControl = "public, max-age=31536000, immutable"
+
+// mediaResponse converts a media into the response data.
+func mediaResponse(media *data.Media) gin.H {
+	return gin.H{
+		"id":         media.ID,
+		"url":        "/media/" + string(media.ID),
+		"type":       string(media.Type),
+		"size":       media.Size,
+		"created_at": media.CreatedAt,
+	}
+}
+
+// NewUploadMediaController creates a controller for uploading a media as the `file` field of a multipart form.
+// Only authors and the admin can upload. Uploading a stored content responds 200 with the existing media.
+func NewUploadMediaController(store repository.MediaStore, maxSize int64) func(*gin.Context) {
+	return func(c *gin.Context) {
+		if getActor(c).IsAnonymous() {
+			respondErr(c, errors.ErrUnauthorized)
+			return
+		}
+		maxBodySize := maxSize + multipartOverhead
+		if c.Request.ContentLength > maxBodySize {
+			respondErr(c, errors.ErrMediaTooLarge)
+			return
+		}
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBodySize)
+
+		fileHeader, err := c.FormFile("file")
+		if err != nil {
+			respond(c, 400, "file is required: "+err.Error(), nil)
+			return
+		}
+		file, err := fileHeader.Open()
+		if err != nil {
+			respondErr(c, err)
+			return
+		}
+		defer file.Close()
+
+		media, created, err := usecase.UploadMedia(c, store, file, fileHeader.Size, maxSize)
+		if err != nil {
+			respondErr(c, err)
+			return
+		}
+		status := 200
+		if created {
+			status = 201
+		}
+		respond(c, status, "Success", mediaResponse(media))
+	}
+}
+
+// NewGetMediaController creates a controller serving the content of a media.
+// A resized variant of an image is served with the `width` query, in one of the image widths,
+// and the optional `format` query, which is `jpeg`, `png` or `webp`.
+// Conditional and range requests are supported.
+func NewGetMediaController(store repository.MediaStore, imageWidths []int) func(*gin.Context) {
+	return func(c *gin.Context) {
+		id := data.MediaID(c.Param("media_id"))
+		var media *data.Media
+		var content io.ReadSeekCloser
+		var err error
+		if widthQuery, formatQuery := c.Query("width"), c.Query("format"); widthQuery == "" && formatQuery == "" {
+			media, content, err = usecase.GetMedia(c, store, id)
+		} else {
+			var width int
+			var format data.ImageFormat
+			width, err = strconv.Atoi(widthQuery)
+			if err != nil {
+				respondErr(c, errors.ErrInvalidImageVariant)
+				return
+			}
+			if formatQuery != "" {
+				if format, err = data.NewImageFormat(formatQuery); err != nil {
+					respondErr(c, err)
+					return
+				}
+			}
+			media, content, err = usecase.GetImageVariant(c, store, id, width, format, imageWidths)
+		}
+		if err != nil {
+			respondErr(c, err)
+			return
+		}
+		defer content.Close()
+
+		header := c.Writer.Header()
+		header.Set("Content-Type", string(media.Type))
+		if header.Get("Cache-Control") == "" {
+			header.Set("Cache-Control", mediaCacheControl)
+		}
+		header.Set("ETag", `"`+string(media.ID)+`"`)
+		header.Set("X-Content-Type-Options", "nosniff")
+		http.ServeContent(c.Writer, c.Request, "", media.CreatedAt, content)
+	}
+}
+
+// NewCollectMediaGarbageController creates a controller deleting the media not referenced by any article or author.
+// With the `dry_run=true` query, it only responds the media that would be deleted.
+func NewCollectMediaGarbageController(store repository.MediaStore, articleRepo repository.ArticleRepository, authorRepo repository.AuthorRepository, imageWidths []int, gracePeriod time.Duration) func(*gin.Context) {
+	return func(c *gin.Context) {
+		dryRun := c.Query("dry_run") == "true"
+		garbage, err := usecase.CollectMediaGarbage(c, store, articleRepo, authorRepo, imageWidths, gracePeriod, dryRun)
+		if err != nil {
+			respondErr(c, err)
+			return
+		}
+		respond(c, 200, "Success", gin.H{"deleted": garbage, "dry_run": dryRun})
+	}
+}
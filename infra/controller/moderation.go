@@ -0,0 +1,92 @@
+package controller
+
+import (
+	"github.com/Jason5Lee/simple-blog/core/data"
+	"github.com/Jason5Lee/simple-blog/core/moderation"
+	"github.com/Jason5Lee/simple-blog/core/repository"
+	"github.com/Jason5Lee/simple-blog/core/usecase"
+	"github.com/gin-gonic/gin"
+)
+
+// NewGetModerationQueueController creates a controller for listing comments by status, pending by default.
+func NewGetModerationQueueController(commentRepo repository.CommentRepository) func(*gin.Context) {
+	return func(c *gin.Context) {
+		status, err := data.NewCommentStatus(c.DefaultQuery("status", string(data.CommentPending)))
+		if err != nil {
+			respondErr(c, err)
+			return
+		}
+		comments, err := usecase.GetCommentsByStatus(c, commentRepo, status)
+		if err != nil {
+			respondErr(c, err)
+			return
+		}
+		response := make([]gin.H, len(comments))
+		for i, comment := range comments {
+			response[i] = gin.H{
+				"id":         comment.ID,
+				"article_id": comment.ArticleID,
+				"author":     string(comment.Author),
+				"content":    string(comment.Content),
+				"status":     comment.Status,
+				"spam_score": comment.SpamScore,
+				"created_at": comment.CreatedAt,
+			}
+		}
+		respond(c, 200, "Success", response)
+	}
+}
+
+// ModerateCommentsRequest is the request body for moderating comments in bulk.
+type ModerateCommentsRequest struct {
+	IDs    []string `json:"ids"`
+	Status *string  `json:"status"`
+}
+
+// NewModerateCommentsController creates a controller for setting the status of comments in bulk.
+func NewModerateCommentsController(commentRepo repository.CommentRepository, moderator *moderation.Moderator) func(*gin.Context) {
+	return func(c *gin.Context) {
+		var req ModerateCommentsRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondErr(c, err)
+			return
+		}
+		if req.Status == nil {
+			respond(c, 400, "status is required", nil)
+			return
+		}
+		status, err := data.NewCommentStatus(*req.Status)
+		if err != nil {
+			respondErr(c, err)
+			return
+		}
+		ids := make([]data.CommentID, len(req.IDs))
+		for i, id := range req.IDs {
+			ids[i] = data.CommentID(id)
+		}
+
+		updated, err := usecase.ModerateComments(c, commentRepo, moderator, ids, status)
+		if err != nil {
+			respondErr(c, err)
+			return
+		}
+		respond(c, 200, "Success", gin.H{"updated": updated})
+	}
+}
+
+// NewPurgeCommentsController creates a controller for deleting all comments with a status, spam by default.
+func NewPurgeCommentsController(commentRepo repository.CommentRepository) func(*gin.Context) {
+	return func(c *gin.Context) {
+		status, err := data.NewCommentStatus(c.DefaultQuery("status", string(data.CommentSpam)))
+		if err != nil {
+			respondErr(c, err)
+			return
+		}
+		deleted, err := usecase.PurgeComments(c, commentRepo, status)
+		if err != nil {
+			respondErr(c, err)
+			return
+		}
+		respond(c, 200, "Success", gin.H{"deleted": deleted})
+	}
+}
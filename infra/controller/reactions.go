@@ -0,0 +1,80 @@
+package controller
+
+import (
+	"github.com/Jason5Lee/simple-blog/core/data"
+	"github.com/Jason5Lee/simple-blog/core/repository"
+	"github.com/Jason5Lee/simple-blog/core/usecase"
+	"github.com/gin-gonic/gin"
+)
+
+// ReactionRequest is the request body for adding or removing a reaction.
+type ReactionRequest struct {
+	User *string `json:"user"`
+}
+
+// parseReaction parses the article ID, the reaction kind and the user of a reaction request.
+// It responds with the error and returns false if the request is invalid.
+func parseReaction(c *gin.Context, kinds data.ReactionKinds) (data.ArticleID, data.ReactionUser, data.ReactionKind, bool) {
+	var req ReactionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondErr(c, err)
+		return "", "", "", false
+	}
+	if req.User == nil {
+		respond(c, 400, "user is required", nil)
+		return "", "", "", false
+	}
+	user, err := data.NewReactionUser(*req.User)
+	if err != nil {
+		respondErr(c, err)
+		return "", "", "", false
+	}
+	kind, err := kinds.NewReactionKind(c.Param("kind"))
+	if err != nil {
+		respondErr(c, err)
+		return "", "", "", false
+	}
+	return data.ArticleID(c.Param("article_id")), user, kind, true
+}
+
+// respondReactions responds whether the reaction changed, with the updated reaction counts of the article.
+func respondReactions(c *gin.Context, reactionRepo repository.ReactionRepository, articleID data.ArticleID, changed bool) {
+	counts, err := usecase.GetReactionCounts(c, reactionRepo, []data.ArticleID{articleID})
+	if err != nil {
+		respondErr(c, err)
+		return
+	}
+	respond(c, 200, "Success", gin.H{"changed": changed, "reactions": counts[articleID]})
+}
+
+// NewAddReactionController creates a controller for adding a reaction to an article.
+func NewAddReactionController(articleRepo repository.ArticleRepository, reactionRepo repository.ReactionRepository, kinds data.ReactionKinds) func(*gin.Context) {
+	return func(c *gin.Context) {
+		articleID, user, kind, ok := parseReaction(c, kinds)
+		if !ok {
+			return
+		}
+		added, err := usecase.AddReaction(c, articleRepo, reactionRepo, articleID, user, kind)
+		if err != nil {
+			respondErr(c, err)
+			return
+		}
+		respondReactions(c, reactionRepo, articleID, added)
+	}
+}
+
+// NewRemoveReactionController creates a controller for removing a reaction from an article.
+func NewRemoveReactionController(reactionRepo repository.ReactionRepository, kinds data.ReactionKinds) func(*gin.Context) {
+	return func(c *gin.Context) {
+		articleID, user, kind, ok := parseReaction(c, kinds)
+		if !ok {
+			return
+		}
+		removed, err := usecase.RemoveReaction(c, reactionRepo, articleID, user, kind)
+		if err != nil {
+			respondErr(c, err)
+			return
+		}
+		respondReactions(c, reactionRepo, articleID, removed)
+	}
+}
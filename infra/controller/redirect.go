@@ -0,0 +1,57 @@
+package controller
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/Jason5Lee/simple-blog/core/data"
+	"github.com/Jason5Lee/simple-blog/core/repository"
+	"github.com/Jason5Lee/simple-blog/core/usecase"
+	"github.com/gin-gonic/gin"
+)
+
+// redirectedParams are the path parameters whose IDs are redirected, with the kind of record they identify.
+var redirectedParams = map[string]data.RecordKind{
+	"article_id": data.RecordArticle,
+	"author_id":  data.RecordAuthor,
+	"series_id":  data.RecordSeries,
+}
+
+// NewIDRedirectMiddleware creates a middleware permanently redirecting the GET requests using the IDs the records had
+// before being migrated to the storage, to the same route with their new IDs.
+func NewIDRedirectMiddleware(ids repository.IDMapping, articleRepo repository.ArticleRepository, authorRepo repository.AuthorRepository, seriesRepo repository.SeriesRepository) func(*gin.Context) {
+	storage := &usecase.MigrationStorage{ArticleRepo: articleRepo, AuthorRepo: authorRepo, SeriesRepo: seriesRepo}
+	return func(c *gin.Context) {
+		if c.Request.Method != http.MethodGet {
+			c.Next()
+			return
+		}
+		redirected := false
+		path := c.FullPath()
+		for _, param := range c.Params {
+			id := param.Value
+			if kind, ok := redirectedParams[param.Key]; ok {
+				newID, ok, err := usecase.GetMigratedID(c, ids, storage, kind, id)
+				if err != nil {
+					respondErr(c, err)
+					c.Abort()
+					return
+				}
+				if ok {
+					id, redirected = newID, true
+				}
+			}
+			path = strings.Replace(path, ":"+param.Key, url.PathEscape(id), 1)
+		}
+		if !redirected {
+			c.Next()
+			return
+		}
+		if c.Request.URL.RawQuery != "" {
+			path += "?" + c.Request.URL.RawQuery
+		}
+		c.Redirect(http.StatusMovedPermanently, path)
+		c.Abort()
+	}
+}
@@ -0,0 +1,179 @@
+package controller
+
+import (
+	"github.com/Jason5Lee/simple-blog/core/data"
+	"github.com/Jason5Lee/simple-blog/core/repository"
+	"github.com/Jason5Lee/simple-blog/core/usecase"
+	"github.com/gin-gonic/gin"
+)
+
+// CreateSeriesRequest is the request body for creating a series.
+type CreateSeriesRequest struct {
+	Title       *string `json:"title"`
+	Description string  `json:"description"`
+}
+
+// AddSeriesArticleRequest is the request body for appending an article to a series.
+type AddSeriesArticleRequest struct {
+	ArticleID *string `json:"article_id"`
+}
+
+// ReorderSeriesRequest is the request body for reordering the parts of a series.
+type ReorderSeriesRequest struct {
+	ArticleIDs []string `json:"article_ids"`
+}
+
+// seriesPartResponse converts a part of a series into the response data, nil if there is no such part.
+func seriesPartResponse(article *data.Article) gin.H {
+	if article == nil {
+		return nil
+	}
+	return gin.H{
+		"id":    article.ID,
+		"title": string(article.Title),
+		"link":  "/articles/" + string(article.ID),
+	}
+}
+
+// seriesResponse converts a series into the response data, with its parts in order.
+func seriesResponse(series *usecase.SeriesWithParts) gin.H {
+	parts := make([]gin.H, len(series.Parts))
+	for i, part := range series.Parts {
+		parts[i] = seriesPartResponse(part)
+	}
+	return gin.H{
+		"id":          series.ID,
+		"title":       string(series.Title),
+		"description": string(series.Description),
+		"parts":       parts,
+	}
+}
+
+// seriesNavigationResponse converts the position of an article in a series into the response data.
+func seriesNavigationResponse(navigation *data.SeriesNavigation) gin.H {
+	return gin.H{
+		"id":       navigation.Series.ID,
+		"title":    string(navigation.Series.Title),
+		"position": navigation.Position,
+		"previous": seriesPartResponse(navigation.Previous),
+		"next":     seriesPartResponse(navigation.Next),
+	}
+}
+
+// NewCreateSeriesController creates a controller for creating an empty series.
+func NewCreateSeriesController(seriesRepo repository.SeriesRepository) func(*gin.Context) {
+	return func(c *gin.Context) {
+		var err error
+		var req CreateSeriesRequest
+		if err = c.ShouldBindJSON(&req); err != nil {
+			respondErr(c, err)
+			return
+		}
+		if req.Title == nil {
+			respond(c, 400, "title is required", nil)
+			return
+		}
+
+		series := &data.SeriesInfo{}
+		series.Title, err = data.NewSeriesTitle(*req.Title)
+		if err != nil {
+			respondErr(c, err)
+			return
+		}
+		series.Description, err = data.NewSeriesDescription(req.Description)
+		if err != nil {
+			respondErr(c, err)
+			return
+		}
+
+		id, err := usecase.CreateSeries(c, seriesRepo, series)
+		if err != nil {
+			respondErr(c, err)
+			return
+		}
+		respond(c, 201, "Success", gin.H{"id": id})
+	}
+}
+
+// NewGetAllSeriesController creates a controller for getting all series with their parts.
+func NewGetAllSeriesController(seriesRepo repository.SeriesRepository, articleRepo repository.ArticleRepository) func(*gin.Context) {
+	return func(c *gin.Context) {
+		allSeries, err := usecase.GetAllSeries(c, seriesRepo, articleRepo)
+		if err != nil {
+			respondErr(c, err)
+			return
+		}
+		response := make([]gin.H, len(allSeries))
+		for i, series := range allSeries {
+			response[i] = seriesResponse(series)
+		}
+		respond(c, 200, "Success", response)
+	}
+}
+
+// NewGetSeriesByIDController creates a controller for getting a series with its parts.
+func NewGetSeriesByIDController(seriesRepo repository.SeriesRepository, articleRepo repository.ArticleRepository) func(*gin.Context) {
+	return func(c *gin.Context) {
+		series, err := usecase.GetSeriesByID(c, seriesRepo, articleRepo, data.SeriesID(c.Param("series_id")))
+		if err != nil {
+			respondErr(c, err)
+			return
+		}
+		respond(c, 200, "Success", seriesResponse(series))
+	}
+}
+
+// NewAddSeriesArticleController creates a controller for appending an article to a series.
+func NewAddSeriesArticleController(seriesRepo repository.SeriesRepository, articleRepo repository.ArticleRepository) func(*gin.Context) {
+	return func(c *gin.Context) {
+		var req AddSeriesArticleRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondErr(c, err)
+			return
+		}
+		if req.ArticleID == nil {
+			respond(c, 400, "article_id is required", nil)
+			return
+		}
+		err := usecase.AddArticleToSeries(c, seriesRepo, articleRepo, data.SeriesID(c.Param("series_id")), data.ArticleID(*req.ArticleID))
+		if err != nil {
+			respondErr(c, err)
+			return
+		}
+		respond(c, 200, "Success", nil)
+	}
+}
+
+// NewRemoveSeriesArticleController creates a controller for removing an article from a series.
+func NewRemoveSeriesArticleController(seriesRepo repository.SeriesRepository) func(*gin.Context) {
+	return func(c *gin.Context) {
+		err := usecase.RemoveArticleFromSeries(c, seriesRepo, data.SeriesID(c.Param("series_id")), data.ArticleID(c.Param("article_id")))
+		if err != nil {
+			respondErr(c, err)
+			return
+		}
+		respond(c, 200, "Success", nil)
+	}
+}
+
+// NewReorderSeriesController creates a controller for reordering the parts of a series.
+// The order must contain exactly the current parts, otherwise it responds 409.
+func NewReorderSeriesController(seriesRepo repository.SeriesRepository) func(*gin.Context) {
+	return func(c *gin.Context) {
+		var req ReorderSeriesRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondErr(c, err)
+			return
+		}
+		articleIDs, err := data.NewSeriesOrder(req.ArticleIDs)
+		if err != nil {
+			respondErr(c, err)
+			return
+		}
+		if err = usecase.ReorderSeries(c, seriesRepo, data.SeriesID(c.Param("series_id")), articleIDs); err != nil {
+			respondErr(c, err)
+			return
+		}
+		respond(c, 200, "Success", nil)
+	}
+}
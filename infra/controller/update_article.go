@@ -0,0 +1,175 @@
+package controller
+
+import (
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/Jason5Lee/simple-blog/core/data"
+	"github.com/Jason5Lee/simple-blog/core/errors"
+	"github.com/Jason5Lee/simple-blog/core/repository"
+	"github.com/Jason5Lee/simple-blog/core/usecase"
+	"github.com/gin-gonic/gin"
+)
+
+// UpdateArticleRequest is the request body for updating an article, the same as creating one
+// with the version it was read, unless given by the If-Match header.
+type UpdateArticleRequest struct {
+	CreateArticleRequest
+	Version *int64 `json:"version"`
+}
+
+// DeleteArticleRequest is the optional request body for deleting an article,
+// with the version it was read, unless given by the If-Match header.
+type DeleteArticleRequest struct {
+	Version *int64 `json:"version"`
+}
+
+// parseIfMatchVersions parses the versions listed by the If-Match header, each quoted like "3".
+// It returns ErrVersionRequired for "*", which would change whatever version is current, and ErrInvalidVersion if
+// the header is not a list of entity tags. The tags of any other form, like the weak ones or the ETags of the
+// responses, can never match, so they are skipped.
+func parseIfMatchVersions(ifMatch string) ([]data.ArticleVersion, error) {
+	var versions []data.ArticleVersion
+	for _, tag := range strings.Split(ifMatch, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" {
+			return nil, errors.ErrVersionRequired
+		}
+		weak := strings.HasPrefix(tag, "W/")
+		tag = strings.TrimPrefix(tag, "W/")
+		if len(tag) < 2 || tag[0] != '"' || tag[len(tag)-1] != '"' {
+			return nil, errors.ErrInvalidVersion
+		}
+		if weak {
+			continue
+		}
+		version, err := strconv.ParseInt(tag[1:len(tag)-1], 10, 64)
+		if err != nil {
+			continue
+		}
+		if version, err := data.NewArticleVersion(version); err == nil {
+			versions = append(versions, version)
+		}
+	}
+	return versions, nil
+}
+
+// parseArticleVersions gets the versions the article can be changed as of, from the If-Match header if present,
+// otherwise the version field of the request body, and whether they are from the header.
+// It responds with the error and returns false if the version is missing or invalid.
+func parseArticleVersions(c *gin.Context, field *int64) ([]data.ArticleVersion, bool, bool) {
+	var versions []data.ArticleVersion
+	var err error
+	ifMatch := c.GetHeader("If-Match")
+	switch {
+	case ifMatch != "":
+		versions, err = parseIfMatchVersions(ifMatch)
+	case field != nil:
+		var version data.ArticleVersion
+		version, err = data.NewArticleVersion(*field)
+		versions = []data.ArticleVersion{version}
+	default:
+		err = errors.ErrVersionRequired
+	}
+	if err != nil {
+		respondErr(c, err)
+		return nil, false, false
+	}
+	return versions, ifMatch != "", true
+}
+
+// changeAsOfAny changes the article as of each version until one is current, and returns ErrConflict if none is.
+// The repository compares each with the current version, so no stale version read can match.
+func changeAsOfAny(versions []data.ArticleVersion, change func(data.ArticleVersion) error) error {
+	err := errors.ErrConflict
+	for _, version := range versions {
+		if err = change(version); err != errors.ErrConflict {
+			return err
+		}
+	}
+	return err
+}
+
+// respondConflict responds that the article has been changed since the version, with its current version,
+// 412 if the version was given by the If-Match header, otherwise 409.
+func respondConflict(c *gin.Context, articleRepo repository.ArticleRepository, id data.ArticleID, ifMatch bool) {
+	article, err := usecase.GetArticleByID(c, articleRepo, id)
+	if err != nil {
+		// The article has been deleted since.
+		respondErr(c, err)
+		return
+	}
+	status := getStatusCode(errors.ErrConflict)
+	if ifMatch {
+		status = 412
+	}
+	respond(c, status, errors.ErrConflict.Error(), gin.H{"version": article.Version})
+}
+
+// NewUpdateArticleController creates a controller for replacing an article, allowed to its co-authors.
+// The request body is the same as creating an article, and the version it was read is required.
+func NewUpdateArticleController(articleRepo repository.ArticleRepository, authorRepo repository.AuthorRepository) func(*gin.Context) {
+	return func(c *gin.Context) {
+		var req UpdateArticleRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondErr(c, err)
+			return
+		}
+		article := newArticleInfo(c, &req.CreateArticleRequest)
+		if article == nil {
+			return
+		}
+		versions, ifMatch, ok := parseArticleVersions(c, req.Version)
+		if !ok {
+			return
+		}
+
+		id := data.ArticleID(c.Param("article_id"))
+		var updated data.ArticleVersion
+		err := changeAsOfAny(versions, func(version data.ArticleVersion) (err error) {
+			updated, err = usecase.UpdateArticle(c, articleRepo, authorRepo, getActor(c), id, version, article)
+			return err
+		})
+		if err != nil {
+			if err == errors.ErrConflict {
+				respondConflict(c, articleRepo, id, ifMatch)
+				return
+			}
+			respondErr(c, err)
+			return
+		}
+		respond(c, 200, "Success", gin.H{"id": id, "version": updated})
+	}
+}
+
+// NewDeleteArticleController creates a controller for deleting an article, allowed to its co-authors.
+// The version it was read is required.
+func NewDeleteArticleController(articleRepo repository.ArticleRepository) func(*gin.Context) {
+	return func(c *gin.Context) {
+		var req DeleteArticleRequest
+		// The body can be omitted if the version is given by the If-Match header.
+		if err := c.ShouldBindJSON(&req); err != nil && err != io.EOF {
+			respondErr(c, err)
+			return
+		}
+		versions, ifMatch, ok := parseArticleVersions(c, req.Version)
+		if !ok {
+			return
+		}
+
+		id := data.ArticleID(c.Param("article_id"))
+		err := changeAsOfAny(versions, func(version data.ArticleVersion) error {
+			return usecase.DeleteArticle(c, articleRepo, getActor(c), id, version)
+		})
+		if err != nil {
+			if err == errors.ErrConflict {
+				respondConflict(c, articleRepo, id, ifMatch)
+				return
+			}
+			respondErr(c, err)
+			return
+		}
+		respond(c, 200, "Success", nil)
+	}
+}
@@ -0,0 +1,77 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Jason5Lee/simple-blog/core/data"
+	"github.com/Jason5Lee/simple-blog/core/errors"
+	"github.com/Jason5Lee/simple-blog/infra/repository"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ParseIfMatchVersions(t *testing.T) {
+	assert := assert.New(t)
+	for ifMatch, expected := range map[string][]data.ArticleVersion{
+		`"3"`:                   {3},
+		` "3" , "5"`:            {3, 5},
+		`W/"3"`:                 nil,
+		`"3-0123456789abcdef"`:  nil,
+		`"0", W/"2", "2", "-1"`: {2},
+	} {
+		versions, err := parseIfMatchVersions(ifMatch)
+		assert.Nil(err, "%s should be parsed", ifMatch)
+		assert.Equal(expected, versions, "%s should list the versions", ifMatch)
+	}
+	for ifMatch, expected := range map[string]error{
+		`*`:        errors.ErrVersionRequired,
+		`"3", *`:   errors.ErrVersionRequired,
+		`3`:        errors.ErrInvalidVersion,
+		`"3", "4`:  errors.ErrInvalidVersion,
+		`"3",, "4`: errors.ErrInvalidVersion,
+	} {
+		_, err := parseIfMatchVersions(ifMatch)
+		assert.Equal(expected, err, "%s should be rejected", ifMatch)
+	}
+}
+
+func Test_UpdateArticle_IfMatch(t *testing.T) {
+	assert := assert.New(t)
+	gin.SetMode(gin.TestMode)
+	articleRepo := repository.NewArticleRepositoryInMemory()
+	authorRepo := repository.NewAuthorRepositoryInMemory()
+	authorID, err := authorRepo.Create(context.Background(), &data.AuthorInfo{DisplayName: "author"})
+	require.Nil(t, err)
+	id, err := articleRepo.Create(context.Background(), &data.ArticleInfo{Title: "title", Content: "content", AuthorIDs: []data.AuthorID{authorID}})
+	require.Nil(t, err)
+	router := gin.New()
+	router.Use(NewActorMiddleware(authorRepo, "admin"))
+	router.PUT("/articles/:article_id", NewUpdateArticleController(articleRepo, authorRepo))
+	router.DELETE("/articles/:article_id", NewDeleteArticleController(articleRepo))
+
+	request := func(method string, ifMatch string) int {
+		body := `{"title": "edited", "content": "content", "author_ids": ["` + string(authorID) + `"]}`
+		if method == http.MethodDelete {
+			body = ""
+		}
+		req := httptest.NewRequest(method, "/articles/"+string(id), strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer admin")
+		req.Header.Set("If-Match", ifMatch)
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, req)
+		return recorder.Code
+	}
+
+	assert.Equal(428, request(http.MethodPut, "*"), "any version should not be accepted")
+	assert.Equal(412, request(http.MethodPut, `"1-forged"`), "a tag with a hash should not match")
+	assert.Equal(412, request(http.MethodPut, `W/"1"`), "a weak tag should not match")
+	assert.Equal(200, request(http.MethodPut, `"3", "1"`), "any listed version should match")
+	assert.Equal(412, request(http.MethodPut, `"1"`), "a stale version should not match")
+	assert.Equal(412, request(http.MethodDelete, `"1", "3"`), "no listed version is current")
+	assert.Equal(200, request(http.MethodDelete, `"1", "2"`), "the current version should be deleted")
+}
@@ -0,0 +1,80 @@
+package controller
+
+import (
+	"strconv"
+
+	"github.com/Jason5Lee/simple-blog/core/data"
+	"github.com/Jason5Lee/simple-blog/core/repository"
+	"github.com/Jason5Lee/simple-blog/core/usecase"
+	"github.com/gin-gonic/gin"
+)
+
+const maxViewDays = 366
+const maxTopArticles = 100
+
+// queryInt gets a query parameter as an integer between 1 and max.
+// It responds with the error and returns false if the parameter is invalid.
+func queryInt(c *gin.Context, name string, defaultValue int, max int) (int, bool) {
+	value := c.Query(name)
+	if value == "" {
+		return defaultValue, true
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 || n > max {
+		respond(c, 400, name+" must be an integer between 1 and "+strconv.Itoa(max), nil)
+		return 0, false
+	}
+	return n, true
+}
+
+// NewGetArticleViewsController creates a controller for getting the daily views of an article.
+func NewGetArticleViewsController(articleRepo repository.ArticleRepository, viewRepo repository.ViewRepository) func(*gin.Context) {
+	return func(c *gin.Context) {
+		days, ok := queryInt(c, "days", 30, maxViewDays)
+		if !ok {
+			return
+		}
+		daily, err := usecase.GetDailyViews(c, articleRepo, viewRepo, data.ArticleID(c.Param("article_id")), days)
+		if err != nil {
+			respondErr(c, err)
+			return
+		}
+		response := make([]gin.H, len(daily))
+		for i, d := range daily {
+			response[i] = gin.H{
+				"date":  d.Day.Format("2006-01-02"),
+				"views": d.Views,
+			}
+		}
+		respond(c, 200, "Success", response)
+	}
+}
+
+// NewGetTopArticlesController creates a controller for getting the most viewed articles.
+func NewGetTopArticlesController(articleRepo repository.ArticleRepository, viewRepo repository.ViewRepository) func(*gin.Context) {
+	return func(c *gin.Context) {
+		days, ok := queryInt(c, "days", 30, maxViewDays)
+		if !ok {
+			return
+		}
+		limit, ok := queryInt(c, "limit", 10, maxTopArticles)
+		if !ok {
+			return
+		}
+		top, err := usecase.GetTopArticles(c, articleRepo, viewRepo, days, limit)
+		if err != nil {
+			respondErr(c, err)
+			return
+		}
+		response := make([]gin.H, len(top))
+		for i, a := range top {
+			response[i] = gin.H{
+				"id":         a.ID,
+				"title":      string(a.Title),
+				"author_ids": a.AuthorIDs,
+				"views":      a.Views,
+			}
+		}
+		respond(c, 200, "Success", response)
+	}
+}
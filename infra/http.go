@@ -1,16 +1,113 @@
 package infra
 
 import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/Jason5Lee/simple-blog/core/analytics"
+	"github.com/Jason5Lee/simple-blog/core/data"
+	"github.com/Jason5Lee/simple-blog/core/events"
+	"github.com/Jason5Lee/simple-blog/core/moderation"
 	"github.com/Jason5Lee/simple-blog/core/repository"
 	"github.com/Jason5Lee/simple-blog/infra/controller"
+	"github.com/Jason5Lee/simple-blog/infra/markdown"
 	"github.com/gin-gonic/gin"
 )
 
-// StartHttpServer starts the HTTP server.
-func StartHttpServer(articleRepo repository.ArticleRepository, listenAddr string) error {
+// Services holds what the HTTP handlers depend on.
+type Services struct {
+	ArticleRepo   repository.ArticleRepository
+	AuthorRepo    repository.AuthorRepository
+	CommentRepo   repository.CommentRepository
+	ReactionRepo  repository.ReactionRepository
+	ViewRepo      repository.ViewRepository
+	SeriesRepo    repository.SeriesRepository
+	MediaStore    repository.MediaStore
+	ViewCounter   *analytics.ViewCounter
+	Moderator     *moderation.Moderator
+	ReactionKinds data.ReactionKinds
+	AdminToken    string
+	// CacheControl is the Cache-Control policy of the GET routes, keyed by their path.
+	CacheControl map[string]string
+	// MediaMaxSize is the maximum size of an uploaded media in bytes.
+	MediaMaxSize int64
+	// MediaImageWidths are the widths of the image variants.
+	MediaImageWidths []int
+	// MediaGCGracePeriod is how long an uploaded media is kept before it is referenced.
+	MediaGCGracePeriod time.Duration
+	// BackupDir is the directory of the backup files.
+	BackupDir string
+	// IDMapping maps the IDs before a migration to the storage to the current ones, nil if there was none.
+	IDMapping repository.IDMapping
+	// ArticleEvents is the bus of the article events, nil if the storage does not publish them.
+	ArticleEvents *events.ArticleBus
+	// ArticleCacheStats gets the hits and misses of the article cache, nil if it is disabled.
+	ArticleCacheStats func() data.CacheStats
+}
+
+// NewRouter creates the HTTP router serving all endpoints.
+func NewRouter(s *Services) *gin.Engine {
 	r := gin.Default()
-	r.POST("/articles", controller.NewCreateArticleController(articleRepo))
-	r.GET("/articles/:article_id", controller.NewGetArticleByIDController(articleRepo))
-	r.GET("/articles", controller.NewGetAllArticlesController(articleRepo))
-	return r.Run(listenAddr)
+	r.Use(controller.NewActorMiddleware(s.AuthorRepo, s.AdminToken))
+	r.Use(controller.NewCacheControlMiddleware(s.CacheControl))
+	if s.IDMapping != nil {
+		r.Use(controller.NewIDRedirectMiddleware(s.IDMapping, s.ArticleRepo, s.AuthorRepo, s.SeriesRepo))
+	}
+	r.POST("/articles", controller.NewCreateArticleController(s.ArticleRepo, s.AuthorRepo))
+	r.PUT("/articles/:article_id", controller.NewUpdateArticleController(s.ArticleRepo, s.AuthorRepo))
+	r.DELETE("/articles/:article_id", controller.NewDeleteArticleController(s.ArticleRepo))
+	if s.ArticleEvents != nil {
+		r.GET("/articles/events", controller.NewArticleEventsController(s.ArticleEvents))
+	}
+	r.GET("/articles/:article_id", controller.NewGetArticleByIDController(s.ArticleRepo, s.AuthorRepo, s.ReactionRepo, s.SeriesRepo, s.ViewCounter, markdown.NewRenderer(s.MediaImageWidths)))
+	r.GET("/articles", controller.NewGetAllArticlesController(s.ArticleRepo, s.AuthorRepo, s.ReactionRepo))
+	r.GET("/articles/:article_id/revisions", controller.NewGetArticleRevisionsController(s.ArticleRepo))
+	r.GET("/articles/:article_id/revisions/:revision_id", controller.NewGetArticleRevisionController(s.ArticleRepo, s.AuthorRepo))
+	r.POST("/articles/:article_id/comments", controller.NewCreateCommentController(s.ArticleRepo, s.CommentRepo, s.Moderator))
+	r.GET("/articles/:article_id/comments", controller.NewGetArticleCommentsController(s.CommentRepo))
+	r.POST("/articles/:article_id/reactions/:kind", controller.NewAddReactionController(s.ArticleRepo, s.ReactionRepo, s.ReactionKinds))
+	r.DELETE("/articles/:article_id/reactions/:kind", controller.NewRemoveReactionController(s.ReactionRepo, s.ReactionKinds))
+	r.GET("/articles/:article_id/views", controller.NewGetArticleViewsController(s.ArticleRepo, s.ViewRepo))
+	r.GET("/analytics/top", controller.NewGetTopArticlesController(s.ArticleRepo, s.ViewRepo))
+	r.GET("/series", controller.NewGetAllSeriesController(s.SeriesRepo, s.ArticleRepo))
+	r.GET("/series/:series_id", controller.NewGetSeriesByIDController(s.SeriesRepo, s.ArticleRepo))
+	r.POST("/media", controller.NewUploadMediaController(s.MediaStore, s.MediaMaxSize))
+	r.GET("/media/:media_id", controller.NewGetMediaController(s.MediaStore, s.MediaImageWidths))
+	r.GET("/authors", controller.NewGetAllAuthorsController(s.AuthorRepo))
+	r.GET("/authors/:author_id", controller.NewGetAuthorByIDController(s.AuthorRepo))
+	r.GET("/authors/:author_id/articles", controller.NewGetAuthorArticlesController(s.ArticleRepo, s.AuthorRepo, s.ReactionRepo))
+
+	admin := r.Group("/", controller.NewAdminAuthMiddleware(s.AdminToken))
+	admin.POST("/authors", controller.NewCreateAuthorController(s.AuthorRepo))
+	admin.POST("/authors/:author_id/token", controller.NewIssueAuthorTokenController(s.AuthorRepo))
+	admin.POST("/series", controller.NewCreateSeriesController(s.SeriesRepo))
+	admin.POST("/series/:series_id/articles", controller.NewAddSeriesArticleController(s.SeriesRepo, s.ArticleRepo))
+	admin.DELETE("/series/:series_id/articles/:article_id", controller.NewRemoveSeriesArticleController(s.SeriesRepo))
+	admin.PUT("/series/:series_id/order", controller.NewReorderSeriesController(s.SeriesRepo))
+	admin.POST("/media/gc", controller.NewCollectMediaGarbageController(s.MediaStore, s.ArticleRepo, s.AuthorRepo, s.MediaImageWidths, s.MediaGCGracePeriod))
+	admin.GET("/moderation/comments", controller.NewGetModerationQueueController(s.CommentRepo))
+	admin.POST("/moderation/comments", controller.NewModerateCommentsController(s.CommentRepo, s.Moderator))
+	admin.DELETE("/moderation/comments", controller.NewPurgeCommentsController(s.CommentRepo))
+	admin.POST("/backup", controller.NewBackupController(s.ArticleRepo, s.BackupDir))
+	if s.ArticleCacheStats != nil {
+		admin.GET("/cache/stats", controller.NewGetCacheStatsController(s.ArticleCacheStats))
+	}
+	return r
+}
+
+// StartHttpServer starts the HTTP server, which is gracefully shut down when the context is done.
+func StartHttpServer(ctx context.Context, s *Services, listenAddr string) error {
+	server := &http.Server{Addr: listenAddr, Handler: NewRouter(s)}
+	shutdownErr := make(chan error, 1)
+	go func() {
+		<-ctx.Done()
+		shutdownErr <- server.Shutdown(context.Background())
+	}()
+
+	if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return <-shutdownErr
 }
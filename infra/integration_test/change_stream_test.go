@@ -0,0 +1,100 @@
+//go:build integration
+// +build integration
+
+package integrationtest_test
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Jason5Lee/simple-blog/core/data"
+	"github.com/Jason5Lee/simple-blog/core/events"
+	infra_repository "github.com/Jason5Lee/simple-blog/infra/repository"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const testConsumer = "integration-test"
+
+// The change streams require a replica set, which can have a single node, e.g. `mongod --replSet rs0`
+// initiated with `rs.initiate()`.
+func TestMongoDBChangeStream(t *testing.T) {
+	require := require.New(t)
+	uri := os.Getenv("MONGODB_URI")
+	if uri == "" {
+		t.Skip("MONGODB_URI is not set")
+	}
+	ctx := context.Background()
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	require.NoError(err)
+	defer client.Disconnect(ctx)
+	var hello struct {
+		SetName string `bson:"setName"`
+	}
+	require.NoError(client.Database("admin").RunCommand(ctx, bson.D{{Key: "hello", Value: 1}}).Decode(&hello))
+	if hello.SetName == "" {
+		t.Skip("MongoDB is not a replica set")
+	}
+
+	repo, err := infra_repository.NewArticleRepositoryMongoDB(ctx, uri, &infra_repository.MongoDBOptions{})
+	require.NoError(err)
+	defer repo.Close()
+	require.NoError(repo.Drop())
+	defer repo.Drop()
+	tokens := client.Database("simple-blog").Collection("change_stream_tokens")
+	_, err = tokens.DeleteOne(ctx, bson.M{"_id": testConsumer})
+	require.NoError(err)
+
+	bus := events.NewArticleBus()
+	subscription := bus.Subscribe(16)
+	watch := func() (stop func()) {
+		watchCtx, cancel := context.WithCancel(ctx)
+		done := make(chan error)
+		go func() { done <- infra_repository.WatchArticlesMongoDB(watchCtx, repo, testConsumer, bus.Publish) }()
+		// The change stream starts from when it is opened the first time.
+		time.Sleep(time.Second)
+		return func() {
+			cancel()
+			require.NoError(<-done)
+		}
+	}
+	next := func() *data.ArticleEvent {
+		select {
+		case event := <-subscription.C:
+			return event
+		case <-time.After(10 * time.Second):
+			require.FailNow("no event was published")
+			return nil
+		}
+	}
+
+	stop := watch()
+	info := &data.ArticleInfo{Title: "title", Content: "content", AuthorIDs: []data.AuthorID{"1"}}
+	id, err := repo.Create(ctx, info)
+	require.NoError(err)
+	event := next()
+	require.Equal(data.ArticleCreated, event.Kind)
+	require.Equal(id, event.ArticleID)
+	require.NotNil(event.Article)
+	require.Equal(info.Title, event.Article.Title)
+
+	info.Title = "new title"
+	require.NoError(repo.Update(ctx, id, data.FirstArticleVersion, info))
+	event = next()
+	require.Equal(data.ArticleUpdated, event.Kind)
+	require.Equal(info.Title, event.Article.Title, "the event should have the article after the change")
+	stop()
+
+	// The changes while not watching are published once watching again.
+	require.NoError(repo.Delete(ctx, id, data.FirstArticleVersion+1))
+	stop = watch()
+	defer stop()
+	event = next()
+	require.Equal(data.ArticleDeleted, event.Kind)
+	require.Equal(id, event.ArticleID)
+	require.Nil(event.Article)
+}
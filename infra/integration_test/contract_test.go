@@ -0,0 +1,47 @@
+//go:build integration
+// +build integration
+
+package integrationtest_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/Jason5Lee/simple-blog/core/repository"
+	"github.com/Jason5Lee/simple-blog/core/repository/repositorytest"
+	infra_repository "github.com/Jason5Lee/simple-blog/infra/repository"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMongoDBContract(t *testing.T) {
+	if os.Getenv("MONGODB_URI") == "" {
+		t.Skip("MONGODB_URI is not set")
+	}
+	repositorytest.TestArticleRepository(t, func(t *testing.T) repository.ArticleRepository {
+		repo, err := infra_repository.NewArticleRepositoryMongoDB(context.Background(), os.Getenv("MONGODB_URI"), &infra_repository.MongoDBOptions{})
+		require.NoError(t, err)
+		require.NoError(t, repo.Drop())
+		t.Cleanup(func() {
+			_ = repo.Drop()
+			_ = repo.Close()
+		})
+		return repo
+	})
+}
+
+func TestPostgresContract(t *testing.T) {
+	if os.Getenv("POSTGRES_URI") == "" {
+		t.Skip("POSTGRES_URI is not set")
+	}
+	repositorytest.TestArticleRepository(t, func(t *testing.T) repository.ArticleRepository {
+		repo, err := infra_repository.NewArticleRepositoryPostgres(os.Getenv("POSTGRES_URI"))
+		require.NoError(t, err)
+		require.NoError(t, repo.Truncate())
+		t.Cleanup(func() {
+			_ = repo.Truncate()
+			_ = repo.Close()
+		})
+		return repo
+	})
+}
@@ -4,30 +4,60 @@
 package integrationtest_test
 
 import (
+	"bytes"
+	"context"
+	"crypto/sha256"
 	"encoding/json"
 	"fmt"
+	"image"
+	"image/png"
+	"io"
 	"io/ioutil"
+	"mime/multipart"
 	"net/http"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/Jason5Lee/simple-blog/core/analytics"
+	"github.com/Jason5Lee/simple-blog/core/data"
+	"github.com/Jason5Lee/simple-blog/core/errors"
 	"github.com/Jason5Lee/simple-blog/core/repository"
 	"github.com/Jason5Lee/simple-blog/infra"
 	infra_repository "github.com/Jason5Lee/simple-blog/infra/repository"
 	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type integrationTestSuite struct {
 	suite.Suite
-	port       int
-	repo       repository.ArticleRepository
-	httpClient *http.Client
-	onTearDown func()
+	port        int
+	repo        repository.ArticleRepository
+	viewCounter *analytics.ViewCounter
+	gridFS      *infra_repository.MediaStoreGridFS
+	httpClient  *http.Client
+	onSetupTest func()
+	onTearDown  func()
 }
 
+const testAdminToken = "integration-test-admin-token"
+
 // Making a request to the testing server.
 func (s *integrationTestSuite) request(method string, path string, body string, resp interface{}) error {
+	return s.requestWithToken(method, path, body, "", resp)
+}
+
+// Making a request to the testing server with a bearer token.
+func (s *integrationTestSuite) requestWithToken(method string, path string, body string, token string, resp interface{}) error {
+	return s.requestWithIfMatch(method, path, body, token, "", resp)
+}
+
+// Making a request to the testing server with a bearer token and an If-Match header.
+func (s *integrationTestSuite) requestWithIfMatch(method string, path string, body string, token string, ifMatch string, resp interface{}) error {
 	req, err := http.NewRequest(method, fmt.Sprintf("http://localhost:%d%s", s.port, path), strings.NewReader(body))
 	if err != nil {
 		return err
@@ -35,6 +65,14 @@ func (s *integrationTestSuite) request(method string, path string, body string,
 	if body != "" {
 		req.Header.Set("Content-Type", "application/json")
 	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	if ifMatch != "" {
+		req.Header.Set("If-Match", ifMatch)
+	}
+	// The default user agent of the Go HTTP client is filtered out as a bot when counting views.
+	req.Header.Set("User-Agent", "Mozilla/5.0 (integration test)")
 	response, err := s.httpClient.Do(req)
 	if err != nil {
 		return err
@@ -48,6 +86,30 @@ func (s *integrationTestSuite) request(method string, path string, body string,
 	return err
 }
 
+type CreateAuthorResp struct {
+	Status  int    `json:"status"`
+	Message string `json:"message"`
+	Data    struct {
+		ID    string `json:"id"`
+		Token string `json:"token"`
+	} `json:"data"`
+}
+
+// Creating an author profile, returning its ID.
+func (s *integrationTestSuite) createAuthor(displayName string) string {
+	id, _ := s.createAuthorWithToken(displayName)
+	return id
+}
+
+// Creating an author profile, returning its ID and token.
+func (s *integrationTestSuite) createAuthorWithToken(displayName string) (string, string) {
+	resp := CreateAuthorResp{}
+	err := s.requestWithToken("POST", "/authors", fmt.Sprintf(`{"display_name": %q}`, displayName), testAdminToken, &resp)
+	s.Require().NoError(err)
+	s.Require().Equal(201, resp.Status)
+	return resp.Data.ID, resp.Data.Token
+}
+
 func TestIntegration(t *testing.T) {
 	suite.Run(t, &integrationTestSuite{})
 }
@@ -55,25 +117,74 @@ func TestIntegration(t *testing.T) {
 func (s *integrationTestSuite) SetupSuite() {
 	config, err := infra.LoadConfig()
 	s.Require().NoError(err)
-	repo, err := infra_repository.NewArticleRepositoryMongoDB(config.MongoDBUri)
+	repo, err := infra_repository.NewArticleRepositoryMongoDB(context.Background(), config.MongoDBUri, &infra_repository.MongoDBOptions{})
+	s.Require().NoError(err)
+
+	commentRepo := infra_repository.NewCommentRepositoryMongoDB(repo)
+	reactionRepo := infra_repository.NewReactionRepositoryMongoDB(repo)
+	authorRepo := infra_repository.NewAuthorRepositoryMongoDB(repo)
+	viewRepo := infra_repository.NewViewRepositoryMongoDB(repo)
+	seriesRepo := infra_repository.NewSeriesRepositoryMongoDB(repo)
+	s.gridFS = infra_repository.NewMediaStoreGridFS(repo)
+	mediaStore, err := infra_repository.NewMediaStoreLocal(s.T().TempDir())
 	s.Require().NoError(err)
+	s.viewCounter = analytics.NewViewCounter(viewRepo, config.ViewDedupWindow)
+	ctx, cancel := context.WithCancel(context.Background())
 
 	s.port = 8080
 	s.repo = repo
 	err = repo.Drop()
 	s.Require().NoError(err)
+	err = commentRepo.Drop()
+	s.Require().NoError(err)
 
+	s.onSetupTest = func() {
+		s.Require().NoError(repo.Drop())
+		s.Require().NoError(commentRepo.Drop())
+		s.Require().NoError(reactionRepo.Drop())
+		s.Require().NoError(viewRepo.Drop())
+		s.Require().NoError(authorRepo.Drop())
+		s.Require().NoError(seriesRepo.Drop())
+		s.Require().NoError(s.gridFS.Drop())
+	}
 	s.onTearDown = func() {
+		cancel()
+		_ = seriesRepo.Drop()
+		_ = s.gridFS.Drop()
+		_ = viewRepo.Drop()
+		_ = authorRepo.Drop()
+		_ = commentRepo.Drop()
+		_ = reactionRepo.Drop()
 		_ = repo.Drop()
 		_ = repo.Close()
 	}
-	go infra.StartHttpServer(repo, "localhost:8080")
+	go infra.StartHttpServer(ctx, &infra.Services{
+		ArticleRepo:      repo,
+		AuthorRepo:       authorRepo,
+		CommentRepo:      commentRepo,
+		ReactionRepo:     reactionRepo,
+		ViewRepo:         viewRepo,
+		SeriesRepo:       seriesRepo,
+		MediaStore:       mediaStore,
+		ViewCounter:      s.viewCounter,
+		Moderator:        infra.NewModerator(&config.Moderation),
+		ReactionKinds:    config.ReactionKinds,
+		AdminToken:       testAdminToken,
+		CacheControl:     map[string]string{"/articles/:article_id": "no-cache"},
+		MediaMaxSize:     1024,
+		MediaImageWidths: []int{4},
+	}, "localhost:8080")
 	s.httpClient = &http.Client{}
 
 	// Wait for the http server to start.
 	time.Sleep(1 * time.Second)
 }
 
+// Each test starts with empty collections.
+func (s *integrationTestSuite) SetupTest() {
+	s.onSetupTest()
+}
+
 func (s *integrationTestSuite) TearDownSuite() {
 	if s.onTearDown != nil {
 		s.onTearDown()
@@ -87,9 +198,10 @@ type ErrorResp struct {
 }
 
 func (s *integrationTestSuite) Test_CreateArticle_Invalid() {
+	authorID := s.createAuthor("author")
 	s.Run("NoTitle", func() {
 		resp := ErrorResp{}
-		err := s.request("POST", "/articles", `{"content": "content", "author": "author"}`, &resp)
+		err := s.requestWithToken("POST", "/articles", fmt.Sprintf(`{"content": "content", "author_ids": [%q]}`, authorID), testAdminToken, &resp)
 		s.Require().NoError(err)
 		s.Equal(400, resp.Status)
 		s.Equal("title is required", resp.Message)
@@ -97,7 +209,7 @@ func (s *integrationTestSuite) Test_CreateArticle_Invalid() {
 	})
 	s.Run("NoContent", func() {
 		resp := ErrorResp{}
-		err := s.request("POST", "/articles", `{"title": "title", "author": "author"}`, &resp)
+		err := s.requestWithToken("POST", "/articles", fmt.Sprintf(`{"title": "title", "author_ids": [%q]}`, authorID), testAdminToken, &resp)
 		s.Require().NoError(err)
 		s.Equal(400, resp.Status)
 		s.Equal("content is required", resp.Message)
@@ -105,31 +217,31 @@ func (s *integrationTestSuite) Test_CreateArticle_Invalid() {
 	})
 	s.Run("NoAuthor", func() {
 		resp := ErrorResp{}
-		err := s.request("POST", "/articles", `{"title": "title", "content": "content"}`, &resp)
+		err := s.requestWithToken("POST", "/articles", `{"title": "title", "content": "content"}`, testAdminToken, &resp)
 		s.Require().NoError(err)
 		s.Equal(400, resp.Status)
-		s.Equal("author is required", resp.Message)
+		s.Equal("at least one author is required", resp.Message)
 		s.Nil(resp.Data)
 	})
 	s.Run("EmptyContent", func() {
 		resp := ErrorResp{}
-		err := s.request("POST", "/articles", `{"title": "title", "content": "", "author": "author"}`, &resp)
+		err := s.requestWithToken("POST", "/articles", fmt.Sprintf(`{"title": "title", "content": "", "author_ids": [%q]}`, authorID), testAdminToken, &resp)
 		s.Require().NoError(err)
 		s.Equal(400, resp.Status)
 		s.Equal("content is empty", resp.Message)
 		s.Nil(resp.Data)
 	})
-	s.Run("EmptyAuthor", func() {
+	s.Run("UnknownAuthor", func() {
 		resp := ErrorResp{}
-		err := s.request("POST", "/articles", `{"title": "title", "content": "content", "author": ""}`, &resp)
+		err := s.requestWithToken("POST", "/articles", `{"title": "title", "content": "content", "author_ids": ["unknown"]}`, testAdminToken, &resp)
 		s.Require().NoError(err)
-		s.Equal(400, resp.Status)
-		s.Equal("author is empty", resp.Message)
+		s.Equal(404, resp.Status)
+		s.Equal("author not found", resp.Message)
 		s.Nil(resp.Data)
 	})
 	s.Run("EmptyTitle", func() {
 		resp := ErrorResp{}
-		err := s.request("POST", "/articles", `{"title": "", "content": "content", "author": "author"}`, &resp)
+		err := s.requestWithToken("POST", "/articles", fmt.Sprintf(`{"title": "", "content": "content", "author_ids": [%q]}`, authorID), testAdminToken, &resp)
 		s.Require().NoError(err)
 		s.Equal(400, resp.Status)
 		s.Equal("title is empty", resp.Message)
@@ -148,16 +260,24 @@ type GetArticleResp struct {
 	Status  int    `json:"status"`
 	Message string `json:"message"`
 	Data    []struct {
-		ID      string `json:"id"`
-		Title   string `json:"title"`
-		Content string `json:"content"`
-		Author  string `json:"author"`
+		ID          string `json:"id"`
+		Title       string `json:"title"`
+		Content     string `json:"content"`
+		ContentHTML string `json:"content_html"`
+		Version     int64  `json:"version"`
+		Authors     []struct {
+			ID          string `json:"id"`
+			DisplayName string `json:"display_name"`
+		} `json:"authors"`
 	} `json:"data"`
 }
 
 func (s *integrationTestSuite) Test_Two_Articles() {
+	authorID1 := s.createAuthor("author1")
+	authorID2 := s.createAuthor("author2")
+
 	createResp := CreateArticleResp{}
-	err := s.request("POST", "/articles", `{"title": "title1", "content": "content1", "author": "author1"}`, &createResp)
+	err := s.requestWithToken("POST", "/articles", fmt.Sprintf(`{"title": "title1", "content": "content1", "author_ids": [%q]}`, authorID1), testAdminToken, &createResp)
 	s.Require().NoError(err)
 	s.Equal(201, createResp.Status)
 	s.Equal("Success", createResp.Message)
@@ -165,7 +285,7 @@ func (s *integrationTestSuite) Test_Two_Articles() {
 	id1 := createResp.Data.ID
 
 	createResp = CreateArticleResp{}
-	err = s.request("POST", "/articles", `{"title": "title2", "content": "content2", "author": "author2"}`, &createResp)
+	err = s.requestWithToken("POST", "/articles", fmt.Sprintf(`{"title": "title2", "content": "content2", "author_ids": [%q]}`, authorID2), testAdminToken, &createResp)
 	s.Require().NoError(err)
 	s.Equal(201, createResp.Status)
 	s.Equal("Success", createResp.Message)
@@ -181,7 +301,7 @@ func (s *integrationTestSuite) Test_Two_Articles() {
 	s.Equal(id1, getResp.Data[0].ID)
 	s.Equal("title1", getResp.Data[0].Title)
 	s.Equal("content1", getResp.Data[0].Content)
-	s.Equal("author1", getResp.Data[0].Author)
+	s.Equal("author1", getResp.Data[0].Authors[0].DisplayName)
 
 	getResp = GetArticleResp{}
 	err = s.request("GET", "/articles/"+id2, "", &getResp)
@@ -192,7 +312,7 @@ func (s *integrationTestSuite) Test_Two_Articles() {
 	s.Equal(id2, getResp.Data[0].ID)
 	s.Equal("title2", getResp.Data[0].Title)
 	s.Equal("content2", getResp.Data[0].Content)
-	s.Equal("author2", getResp.Data[0].Author)
+	s.Equal("author2", getResp.Data[0].Authors[0].DisplayName)
 
 	getResp = GetArticleResp{}
 	err = s.request("GET", "/articles/"+id1+id2, "", &getResp)
@@ -215,9 +335,873 @@ func (s *integrationTestSuite) Test_Two_Articles() {
 	s.Equal(id1, getResp.Data[0].ID)
 	s.Equal("title1", getResp.Data[0].Title)
 	s.Equal("content1", getResp.Data[0].Content)
-	s.Equal("author1", getResp.Data[0].Author)
+	s.Equal("author1", getResp.Data[0].Authors[0].DisplayName)
 	s.Equal(id2, getResp.Data[1].ID)
 	s.Equal("title2", getResp.Data[1].Title)
 	s.Equal("content2", getResp.Data[1].Content)
-	s.Equal("author2", getResp.Data[1].Author)
+	s.Equal("author2", getResp.Data[1].Authors[0].DisplayName)
+}
+
+type CommentsResp struct {
+	Status  int    `json:"status"`
+	Message string `json:"message"`
+	Data    []struct {
+		ID      string `json:"id"`
+		Author  string `json:"author"`
+		Content string `json:"content"`
+		Status  string `json:"status"`
+	} `json:"data"`
+}
+
+type ModerateResp struct {
+	Status  int    `json:"status"`
+	Message string `json:"message"`
+	Data    struct {
+		Updated int `json:"updated"`
+	} `json:"data"`
+}
+
+func (s *integrationTestSuite) Test_Comment_Moderation() {
+	createResp := CreateArticleResp{}
+	err := s.requestWithToken("POST", "/articles", fmt.Sprintf(`{"title": "commented", "content": "content", "author_ids": [%q]}`, s.createAuthor("author")), testAdminToken, &createResp)
+	s.Require().NoError(err)
+	articleID := createResp.Data.ID
+
+	commentResp := CreateArticleResp{}
+	err = s.request("POST", "/articles/"+articleID+"/comments", `{"author": "reader", "content": "nice post"}`, &commentResp)
+	s.Require().NoError(err)
+	s.Equal(201, commentResp.Status)
+	commentID := commentResp.Data.ID
+
+	commentsResp := CommentsResp{}
+	err = s.request("GET", "/articles/"+articleID+"/comments", "", &commentsResp)
+	s.Require().NoError(err)
+	s.Equal(200, commentsResp.Status)
+	s.Empty(commentsResp.Data, "pending comments should not be listed")
+
+	errResp := ErrorResp{}
+	err = s.request("GET", "/moderation/comments", "", &errResp)
+	s.Require().NoError(err)
+	s.Equal(401, errResp.Status)
+
+	commentsResp = CommentsResp{}
+	err = s.requestWithToken("GET", "/moderation/comments?status=pending", "", testAdminToken, &commentsResp)
+	s.Require().NoError(err)
+	s.Equal(200, commentsResp.Status)
+	s.Require().Len(commentsResp.Data, 1)
+	s.Equal(commentID, commentsResp.Data[0].ID)
+
+	moderateResp := ModerateResp{}
+	err = s.requestWithToken("POST", "/moderation/comments", `{"ids": ["`+commentID+`"], "status": "approved"}`, testAdminToken, &moderateResp)
+	s.Require().NoError(err)
+	s.Equal(200, moderateResp.Status)
+	s.Equal(1, moderateResp.Data.Updated)
+
+	commentsResp = CommentsResp{}
+	err = s.request("GET", "/articles/"+articleID+"/comments", "", &commentsResp)
+	s.Require().NoError(err)
+	s.Require().Len(commentsResp.Data, 1)
+	s.Equal("nice post", commentsResp.Data[0].Content)
+}
+
+type ReactionResp struct {
+	Status  int    `json:"status"`
+	Message string `json:"message"`
+	Data    struct {
+		Changed   bool             `json:"changed"`
+		Reactions map[string]int64 `json:"reactions"`
+	} `json:"data"`
+}
+
+func (s *integrationTestSuite) Test_Reactions() {
+	createResp := CreateArticleResp{}
+	err := s.requestWithToken("POST", "/articles", fmt.Sprintf(`{"title": "liked", "content": "content", "author_ids": [%q]}`, s.createAuthor("author")), testAdminToken, &createResp)
+	s.Require().NoError(err)
+	articleID := createResp.Data.ID
+
+	reactionResp := ReactionResp{}
+	err = s.request("POST", "/articles/"+articleID+"/reactions/like", `{"user": "alice"}`, &reactionResp)
+	s.Require().NoError(err)
+	s.Equal(200, reactionResp.Status)
+	s.True(reactionResp.Data.Changed)
+	s.Equal(int64(1), reactionResp.Data.Reactions["like"])
+
+	reactionResp = ReactionResp{}
+	err = s.request("POST", "/articles/"+articleID+"/reactions/like", `{"user": "alice"}`, &reactionResp)
+	s.Require().NoError(err)
+	s.False(reactionResp.Data.Changed, "reacting twice with the same kind should not change anything")
+	s.Equal(int64(1), reactionResp.Data.Reactions["like"])
+
+	reactionResp = ReactionResp{}
+	err = s.request("POST", "/articles/"+articleID+"/reactions/like", `{"user": "bob"}`, &reactionResp)
+	s.Require().NoError(err)
+	s.Equal(int64(2), reactionResp.Data.Reactions["like"])
+
+	reactionResp = ReactionResp{}
+	err = s.request("DELETE", "/articles/"+articleID+"/reactions/like", `{"user": "alice"}`, &reactionResp)
+	s.Require().NoError(err)
+	s.True(reactionResp.Data.Changed)
+	s.Equal(int64(1), reactionResp.Data.Reactions["like"])
+
+	errResp := ErrorResp{}
+	err = s.request("POST", "/articles/"+articleID+"/reactions/unknown", `{"user": "alice"}`, &errResp)
+	s.Require().NoError(err)
+	s.Equal(400, errResp.Status)
+	s.Equal("invalid reaction kind", errResp.Message)
+}
+
+type ViewsResp struct {
+	Status  int    `json:"status"`
+	Message string `json:"message"`
+	Data    []struct {
+		ID    string `json:"id"`
+		Date  string `json:"date"`
+		Views int64  `json:"views"`
+	} `json:"data"`
+}
+
+// Getting the path with the header, returning the response whose body is closed.
+func (s *integrationTestSuite) conditionalGet(path string, header string, value string) *http.Response {
+	req, err := http.NewRequest("GET", fmt.Sprintf("http://localhost:%d%s", s.port, path), nil)
+	s.Require().NoError(err)
+	req.Header.Set(header, value)
+	response, err := s.httpClient.Do(req)
+	s.Require().NoError(err)
+	response.Body.Close()
+	return response
+}
+
+func (s *integrationTestSuite) Test_Conditional_Get() {
+	createResp := CreateArticleResp{}
+	err := s.requestWithToken("POST", "/articles", fmt.Sprintf(`{"title": "cached", "content": "content", "author_ids": [%q]}`, s.createAuthor("author")), testAdminToken, &createResp)
+	s.Require().NoError(err)
+	path := "/articles/" + createResp.Data.ID
+
+	response, err := s.httpClient.Get(fmt.Sprintf("http://localhost:%d%s", s.port, path))
+	s.Require().NoError(err)
+	response.Body.Close()
+	s.Require().Equal(200, response.StatusCode)
+	etag := response.Header.Get("ETag")
+	s.NotEmpty(etag)
+	lastModified := response.Header.Get("Last-Modified")
+	s.NotEmpty(lastModified, "the update time should be the modification time")
+	s.Equal("no-cache", response.Header.Get("Cache-Control"), "the configured policy should be set")
+
+	s.Equal(304, s.conditionalGet(path, "If-None-Match", etag).StatusCode, "matching ETag should respond not modified")
+	s.Equal(304, s.conditionalGet(path, "If-None-Match", `"other", W/`+etag).StatusCode, "any weakly matching ETag should respond not modified")
+	s.Equal(304, s.conditionalGet(path, "If-Modified-Since", lastModified).StatusCode, "unmodified since the update should respond not modified")
+	s.Equal(200, s.conditionalGet(path, "If-Modified-Since", time.Unix(0, 0).UTC().Format(http.TimeFormat)).StatusCode,
+		"modified since should respond the article")
+	s.Equal(200, s.conditionalGet(path, "Range", "bytes=0-9").StatusCode, "a range of the response should not be served")
+
+	reactionResp := ReactionResp{}
+	err = s.request("POST", path+"/reactions/like", `{"user": "alice"}`, &reactionResp)
+	s.Require().NoError(err)
+	response = s.conditionalGet(path, "If-None-Match", etag)
+	s.Equal(200, response.StatusCode, "a new reaction should change the ETag")
+	s.NotEqual(etag, response.Header.Get("ETag"))
+
+	response, err = s.httpClient.Get(fmt.Sprintf("http://localhost:%d/articles", s.port))
+	s.Require().NoError(err)
+	response.Body.Close()
+	s.Require().Equal(200, response.StatusCode)
+	etag = response.Header.Get("ETag")
+	s.Equal(304, s.conditionalGet("/articles", "If-None-Match", etag).StatusCode)
+	s.Empty(response.Header.Get("Cache-Control"), "a route without policy should have no Cache-Control")
+
+	err = s.requestWithToken("POST", "/articles", fmt.Sprintf(`{"title": "new", "content": "content", "author_ids": [%q]}`, s.createAuthor("other")), testAdminToken, &createResp)
+	s.Require().NoError(err)
+	s.Equal(200, s.conditionalGet("/articles", "If-None-Match", etag).StatusCode, "a new article should change the list")
+
+	s.Empty(s.conditionalGet("/articles/missing", "If-None-Match", etag).Header.Get("Cache-Control"), "an error should not be cached")
+}
+
+func (s *integrationTestSuite) Test_Views() {
+	createResp := CreateArticleResp{}
+	err := s.requestWithToken("POST", "/articles", fmt.Sprintf(`{"title": "viewed", "content": "content", "author_ids": [%q]}`, s.createAuthor("author")), testAdminToken, &createResp)
+	s.Require().NoError(err)
+	articleID := createResp.Data.ID
+
+	for i := 0; i < 2; i++ {
+		getResp := GetArticleResp{}
+		err = s.request("GET", "/articles/"+articleID, "", &getResp)
+		s.Require().NoError(err)
+		s.Equal(200, getResp.Status)
+	}
+	s.Require().NoError(s.viewCounter.Flush(context.Background()))
+
+	viewsResp := ViewsResp{}
+	err = s.request("GET", "/articles/"+articleID+"/views?days=7", "", &viewsResp)
+	s.Require().NoError(err)
+	s.Equal(200, viewsResp.Status)
+	s.Require().Len(viewsResp.Data, 7)
+	s.Equal(int64(1), viewsResp.Data[6].Views, "repeated views of the same visitor should be counted once")
+
+	viewsResp = ViewsResp{}
+	err = s.request("GET", "/analytics/top?days=1&limit=5", "", &viewsResp)
+	s.Require().NoError(err)
+	s.Equal(200, viewsResp.Status)
+	s.Require().Len(viewsResp.Data, 1)
+	s.Equal(articleID, viewsResp.Data[0].ID)
+	s.Equal(int64(1), viewsResp.Data[0].Views)
+}
+
+type AuthorsResp struct {
+	Status  int    `json:"status"`
+	Message string `json:"message"`
+	Data    []struct {
+		ID          string   `json:"id"`
+		DisplayName string   `json:"display_name"`
+		Bio         string   `json:"bio"`
+		Links       []string `json:"links"`
+	} `json:"data"`
+}
+
+func (s *integrationTestSuite) Test_Authors() {
+	resp := CreateArticleResp{}
+	err := s.requestWithToken("POST", "/authors", `{"display_name": "John", "bio": "Writes things.", "links": ["https://john.example"]}`, testAdminToken, &resp)
+	s.Require().NoError(err)
+	s.Require().Equal(201, resp.Status)
+	authorID := resp.Data.ID
+
+	errResp := ErrorResp{}
+	err = s.requestWithToken("POST", "/authors", `{"display_name": "John", "links": ["not a url"]}`, testAdminToken, &errResp)
+	s.Require().NoError(err)
+	s.Equal(400, errResp.Status)
+	s.Equal("invalid author URL", errResp.Message)
+
+	createResp := CreateArticleResp{}
+	err = s.requestWithToken("POST", "/articles", fmt.Sprintf(`{"title": "by john", "content": "content", "author_ids": [%q]}`, authorID), testAdminToken, &createResp)
+	s.Require().NoError(err)
+	s.Require().Equal(201, createResp.Status)
+
+	authorsResp := AuthorsResp{}
+	err = s.request("GET", "/authors", "", &authorsResp)
+	s.Require().NoError(err)
+	s.Equal(200, authorsResp.Status)
+	s.Require().Len(authorsResp.Data, 1)
+	s.Equal("John", authorsResp.Data[0].DisplayName)
+	s.Equal("Writes things.", authorsResp.Data[0].Bio)
+	s.Equal([]string{"https://john.example"}, authorsResp.Data[0].Links)
+
+	getResp := GetArticleResp{}
+	err = s.request("GET", "/authors/"+authorID+"/articles", "", &getResp)
+	s.Require().NoError(err)
+	s.Equal(200, getResp.Status)
+	s.Require().Len(getResp.Data, 1)
+	s.Equal(createResp.Data.ID, getResp.Data[0].ID)
+	s.Require().Len(getResp.Data[0].Authors, 1)
+	s.Equal(authorID, getResp.Data[0].Authors[0].ID)
+	s.Equal("John", getResp.Data[0].Authors[0].DisplayName)
+
+	errResp = ErrorResp{}
+	err = s.request("GET", "/authors/unknown", "", &errResp)
+	s.Require().NoError(err)
+	s.Equal(404, errResp.Status)
+	s.Equal("author not found", errResp.Message)
+}
+
+func (s *integrationTestSuite) Test_CoAuthors() {
+	aliceID, aliceToken := s.createAuthorWithToken("alice")
+	bobID, bobToken := s.createAuthorWithToken("bob")
+	_, carolToken := s.createAuthorWithToken("carol")
+
+	createBody := fmt.Sprintf(`{"title": "co-authored", "content": "content", "author_ids": [%q, %q]}`, aliceID, bobID)
+	errResp := ErrorResp{}
+	err := s.request("POST", "/articles", createBody, &errResp)
+	s.Require().NoError(err)
+	s.Equal(401, errResp.Status, "anonymous should not create an article")
+
+	errResp = ErrorResp{}
+	err = s.requestWithToken("POST", "/articles", createBody, carolToken, &errResp)
+	s.Require().NoError(err)
+	s.Equal(403, errResp.Status, "an author should not create an article credited only to others")
+
+	createResp := CreateArticleResp{}
+	err = s.requestWithToken("POST", "/articles", createBody, aliceToken, &createResp)
+	s.Require().NoError(err)
+	s.Require().Equal(201, createResp.Status)
+	articleID := createResp.Data.ID
+
+	getResp := GetArticleResp{}
+	err = s.request("GET", "/articles?author_id="+bobID, "", &getResp)
+	s.Require().NoError(err)
+	s.Require().Len(getResp.Data, 1)
+	s.Require().Len(getResp.Data[0].Authors, 2)
+	s.Equal("alice", getResp.Data[0].Authors[0].DisplayName)
+	s.Equal("bob", getResp.Data[0].Authors[1].DisplayName)
+
+	updateBody := fmt.Sprintf(`{"title": "edited", "content": "content", "author_ids": [%q, %q], "version": 1}`, bobID, aliceID)
+	errResp = ErrorResp{}
+	err = s.request("PUT", "/articles/"+articleID, updateBody, &errResp)
+	s.Require().NoError(err)
+	s.Equal(401, errResp.Status)
+
+	errResp = ErrorResp{}
+	err = s.requestWithToken("PUT", "/articles/"+articleID, updateBody, carolToken, &errResp)
+	s.Require().NoError(err)
+	s.Equal(403, errResp.Status)
+
+	errResp = ErrorResp{}
+	err = s.requestWithToken("PUT", "/articles/"+articleID, updateBody, "wrong token", &errResp)
+	s.Require().NoError(err)
+	s.Equal(401, errResp.Status)
+
+	updateResp := CreateArticleResp{}
+	err = s.requestWithToken("PUT", "/articles/"+articleID, updateBody, bobToken, &updateResp)
+	s.Require().NoError(err)
+	s.Equal(200, updateResp.Status)
+
+	getResp = GetArticleResp{}
+	err = s.request("GET", "/articles/"+articleID, "", &getResp)
+	s.Require().NoError(err)
+	s.Require().Len(getResp.Data, 1)
+	s.Equal("edited", getResp.Data[0].Title)
+	s.Equal("bob", getResp.Data[0].Authors[0].DisplayName)
+	s.Equal(int64(2), getResp.Data[0].Version)
+
+	errResp = ErrorResp{}
+	err = s.requestWithToken("DELETE", "/articles/"+articleID, `{"version": 2}`, aliceToken, &errResp)
+	s.Require().NoError(err)
+	s.Equal(200, errResp.Status)
+
+	errResp = ErrorResp{}
+	err = s.request("GET", "/articles/"+articleID, "", &errResp)
+	s.Require().NoError(err)
+	s.Equal(404, errResp.Status)
+}
+
+type VersionResp struct {
+	Status  int    `json:"status"`
+	Message string `json:"message"`
+	Data    struct {
+		Version int64 `json:"version"`
+	} `json:"data"`
+}
+
+func (s *integrationTestSuite) Test_Article_Versions() {
+	authorID, token := s.createAuthorWithToken("author")
+	createResp := CreateArticleResp{}
+	err := s.requestWithToken("POST", "/articles", fmt.Sprintf(`{"title": "versioned", "content": "content", "author_ids": [%q]}`, authorID), testAdminToken, &createResp)
+	s.Require().NoError(err)
+	path := "/articles/" + createResp.Data.ID
+
+	response, err := s.httpClient.Get(fmt.Sprintf("http://localhost:%d%s", s.port, path))
+	s.Require().NoError(err)
+	response.Body.Close()
+	etag := response.Header.Get("ETag")
+
+	updateBody := fmt.Sprintf(`{"title": "edited", "content": "content", "author_ids": [%q]}`, authorID)
+	errResp := ErrorResp{}
+	err = s.requestWithToken("PUT", path, updateBody, token, &errResp)
+	s.Require().NoError(err)
+	s.Equal(428, errResp.Status, "the version should be required")
+
+	errResp = ErrorResp{}
+	err = s.requestWithIfMatch("PUT", path, updateBody, token, "*", &errResp)
+	s.Require().NoError(err)
+	s.Equal(428, errResp.Status, "any version should not be accepted")
+
+	for _, ifMatch := range []string{`W/"1"`, etag, `"1-forged"`} {
+		versionResp := VersionResp{}
+		err = s.requestWithIfMatch("PUT", path, updateBody, token, ifMatch, &versionResp)
+		s.Require().NoError(err)
+		s.Equal(412, versionResp.Status, "%s should not match the version", ifMatch)
+	}
+
+	versionResp := VersionResp{}
+	err = s.requestWithIfMatch("PUT", path, updateBody, token, `"3", "1"`, &versionResp)
+	s.Require().NoError(err)
+	s.Require().Equal(200, versionResp.Status, "any listed version should match")
+	s.Equal(int64(2), versionResp.Data.Version, "the update should respond the new version")
+
+	versionResp = VersionResp{}
+	err = s.requestWithIfMatch("PUT", path, updateBody, token, `"1"`, &versionResp)
+	s.Require().NoError(err)
+	s.Equal(412, versionResp.Status, "a stale If-Match should fail the precondition")
+	s.Equal(int64(2), versionResp.Data.Version, "the conflict should respond the current version")
+
+	versionResp = VersionResp{}
+	err = s.requestWithToken("DELETE", path, `{"version": 1}`, token, &versionResp)
+	s.Require().NoError(err)
+	s.Equal(409, versionResp.Status, "a stale version field should conflict")
+	s.Equal(int64(2), versionResp.Data.Version)
+
+	errResp = ErrorResp{}
+	err = s.requestWithIfMatch("DELETE", path, "", token, `"2"`, &errResp)
+	s.Require().NoError(err)
+	s.Equal(200, errResp.Status, "the current version should be deleted")
+}
+
+func (s *integrationTestSuite) Test_Search() {
+	authorID := s.createAuthor("author")
+	for _, article := range []struct{ title, content string }{
+		{"Learning Go", "Goroutines and channels."},
+		{"Learning Rust", "Ownership and channels."},
+	} {
+		createResp := CreateArticleResp{}
+		err := s.requestWithToken("POST", "/articles", fmt.Sprintf(`{"title": %q, "content": %q, "author_ids": [%q]}`, article.title, article.content, authorID), testAdminToken, &createResp)
+		s.Require().NoError(err)
+		s.Require().Equal(201, createResp.Status)
+	}
+
+	getResp := GetArticleResp{}
+	err := s.request("GET", "/articles?q=channels", "", &getResp)
+	s.Require().NoError(err)
+	s.Equal(200, getResp.Status)
+	s.Len(getResp.Data, 2)
+
+	getResp = GetArticleResp{}
+	err = s.request("GET", "/articles?q=learning+goroutines", "", &getResp)
+	s.Require().NoError(err)
+	s.Require().Len(getResp.Data, 1, "only the articles containing all the terms should match")
+	s.Equal("Learning Go", getResp.Data[0].Title)
+
+	errResp := ErrorResp{}
+	err = s.request("GET", "/articles?q=+", "", &errResp)
+	s.Require().NoError(err)
+	s.Equal(400, errResp.Status)
+}
+
+func (s *integrationTestSuite) Test_Pagination() {
+	authorID := s.createAuthor("author")
+	var ids []string
+	for i := 0; i < 3; i++ {
+		createResp := CreateArticleResp{}
+		err := s.requestWithToken("POST", "/articles", fmt.Sprintf(`{"title": "title %d", "content": "content", "author_ids": [%q]}`, i, authorID), testAdminToken, &createResp)
+		s.Require().NoError(err)
+		s.Require().Equal(201, createResp.Status)
+		ids = append(ids, createResp.Data.ID)
+	}
+
+	resp, err := s.httpClient.Get(fmt.Sprintf("http://localhost:%d/articles?limit=2", s.port))
+	s.Require().NoError(err)
+	defer resp.Body.Close()
+	getResp := GetArticleResp{}
+	s.Require().NoError(json.NewDecoder(resp.Body).Decode(&getResp))
+	s.Require().Len(getResp.Data, 2)
+	s.Equal(ids[0], getResp.Data[0].ID)
+	s.Equal(ids[1], getResp.Data[1].ID)
+	s.Equal(fmt.Sprintf(`</articles?after=%s&limit=2>; rel="next"`, ids[1]), resp.Header.Get("Link"))
+
+	getResp = GetArticleResp{}
+	err = s.request("GET", "/articles?limit=2&after="+ids[1], "", &getResp)
+	s.Require().NoError(err)
+	s.Require().Len(getResp.Data, 1)
+	s.Equal(ids[2], getResp.Data[0].ID)
+
+	errResp := ErrorResp{}
+	err = s.request("GET", "/articles?limit=0", "", &errResp)
+	s.Require().NoError(err)
+	s.Equal(400, errResp.Status)
+	errResp = ErrorResp{}
+	err = s.request("GET", "/articles?limit=2&after=abc", "", &errResp)
+	s.Require().NoError(err)
+	s.Equal(400, errResp.Status)
+}
+
+type SeriesNavigationResp struct {
+	Status  int    `json:"status"`
+	Message string `json:"message"`
+	Data    []struct {
+		ID     string `json:"id"`
+		Series []struct {
+			ID       string `json:"id"`
+			Position int    `json:"position"`
+			Previous *struct {
+				ID string `json:"id"`
+			} `json:"previous"`
+			Next *struct {
+				ID string `json:"id"`
+			} `json:"next"`
+		} `json:"series"`
+	} `json:"data"`
+}
+
+func (s *integrationTestSuite) Test_Series() {
+	authorID := s.createAuthor("author")
+	articleIDs := make([]string, 3)
+	for i := range articleIDs {
+		createResp := CreateArticleResp{}
+		err := s.requestWithToken("POST", "/articles", fmt.Sprintf(`{"title": "part %d", "content": "content", "author_ids": [%q]}`, i, authorID), testAdminToken, &createResp)
+		s.Require().NoError(err)
+		s.Require().Equal(201, createResp.Status)
+		articleIDs[i] = createResp.Data.ID
+	}
+
+	errResp := ErrorResp{}
+	err := s.request("POST", "/series", `{"title": "tutorial"}`, &errResp)
+	s.Require().NoError(err)
+	s.Equal(401, errResp.Status)
+
+	createResp := CreateArticleResp{}
+	err = s.requestWithToken("POST", "/series", `{"title": "tutorial"}`, testAdminToken, &createResp)
+	s.Require().NoError(err)
+	s.Require().Equal(201, createResp.Status)
+	seriesID := createResp.Data.ID
+
+	errResp = ErrorResp{}
+	err = s.requestWithToken("PUT", "/series/"+seriesID+"/order", `{"article_ids": []}`, testAdminToken, &errResp)
+	s.Require().NoError(err)
+	s.Equal(200, errResp.Status, "an empty order should match an empty series")
+
+	for _, id := range articleIDs {
+		errResp = ErrorResp{}
+		err = s.requestWithToken("POST", "/series/"+seriesID+"/articles", fmt.Sprintf(`{"article_id": %q}`, id), testAdminToken, &errResp)
+		s.Require().NoError(err)
+		s.Require().Equal(200, errResp.Status)
+	}
+
+	navResp := SeriesNavigationResp{}
+	err = s.request("GET", "/articles/"+articleIDs[1], "", &navResp)
+	s.Require().NoError(err)
+	s.Require().Len(navResp.Data, 1)
+	s.Require().Len(navResp.Data[0].Series, 1)
+	s.Equal(1, navResp.Data[0].Series[0].Position)
+	s.Equal(articleIDs[0], navResp.Data[0].Series[0].Previous.ID)
+	s.Equal(articleIDs[2], navResp.Data[0].Series[0].Next.ID)
+
+	errResp = ErrorResp{}
+	err = s.requestWithToken("PUT", "/series/"+seriesID+"/order", fmt.Sprintf(`{"article_ids": [%q, %q]}`, articleIDs[1], articleIDs[0]), testAdminToken, &errResp)
+	s.Require().NoError(err)
+	s.Equal(409, errResp.Status, "order missing a part should be rejected")
+
+	errResp = ErrorResp{}
+	err = s.requestWithToken("PUT", "/series/"+seriesID+"/order", fmt.Sprintf(`{"article_ids": [%q, %q, %q]}`, articleIDs[2], articleIDs[0], articleIDs[1]), testAdminToken, &errResp)
+	s.Require().NoError(err)
+	s.Equal(200, errResp.Status)
+
+	navResp = SeriesNavigationResp{}
+	err = s.request("GET", "/articles/"+articleIDs[2], "", &navResp)
+	s.Require().NoError(err)
+	s.Equal(0, navResp.Data[0].Series[0].Position)
+	s.Nil(navResp.Data[0].Series[0].Previous)
+	s.Equal(articleIDs[0], navResp.Data[0].Series[0].Next.ID)
+
+	listResp := struct {
+		Status int `json:"status"`
+		Data   []struct {
+			ID    string `json:"id"`
+			Parts []struct {
+				ID string `json:"id"`
+			} `json:"parts"`
+		} `json:"data"`
+	}{}
+	err = s.request("GET", "/series", "", &listResp)
+	s.Require().NoError(err)
+	s.Require().Len(listResp.Data, 1)
+	s.Require().Len(listResp.Data[0].Parts, 3)
+	s.Equal(articleIDs[2], listResp.Data[0].Parts[0].ID)
+}
+
+type UploadMediaResp struct {
+	Status  int    `json:"status"`
+	Message string `json:"message"`
+	Data    struct {
+		ID   string `json:"id"`
+		URL  string `json:"url"`
+		Type string `json:"type"`
+	} `json:"data"`
+}
+
+// Uploading a media as a multipart form.
+func (s *integrationTestSuite) uploadMedia(content []byte, token string) UploadMediaResp {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, err := writer.CreateFormFile("file", "upload")
+	s.Require().NoError(err)
+	_, err = part.Write(content)
+	s.Require().NoError(err)
+	s.Require().NoError(writer.Close())
+
+	req, err := http.NewRequest("POST", fmt.Sprintf("http://localhost:%d/media", s.port), body)
+	s.Require().NoError(err)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	response, err := s.httpClient.Do(req)
+	s.Require().NoError(err)
+	defer response.Body.Close()
+	resp := UploadMediaResp{}
+	s.Require().NoError(json.NewDecoder(response.Body).Decode(&resp))
+	return resp
+}
+
+func (s *integrationTestSuite) Test_Media() {
+	_, token := s.createAuthorWithToken("author")
+	gif := []byte("GIF89a\x01\x00\x01\x00\x00\x00\x00;")
+
+	s.Equal(401, s.uploadMedia(gif, "").Status, "anonymous upload should be rejected")
+	s.Equal(415, s.uploadMedia([]byte("<html></html>"), token).Status, "HTML should be rejected")
+	s.Equal(413, s.uploadMedia(bytes.Repeat(gif, 1024), token).Status, "media over the limit should be rejected")
+
+	uploadResp := s.uploadMedia(gif, token)
+	s.Require().Equal(201, uploadResp.Status)
+	s.Equal("image/gif", uploadResp.Data.Type)
+	againResp := s.uploadMedia(gif, token)
+	s.Equal(200, againResp.Status, "same content should be deduplicated")
+	s.Equal(uploadResp.Data.ID, againResp.Data.ID)
+
+	response, err := s.httpClient.Get(fmt.Sprintf("http://localhost:%d%s", s.port, uploadResp.Data.URL))
+	s.Require().NoError(err)
+	content, err := ioutil.ReadAll(response.Body)
+	response.Body.Close()
+	s.Require().NoError(err)
+	s.Equal(200, response.StatusCode)
+	s.Equal(gif, content)
+	s.Equal("image/gif", response.Header.Get("Content-Type"))
+	s.Contains(response.Header.Get("Cache-Control"), "immutable")
+
+	req, err := http.NewRequest("GET", fmt.Sprintf("http://localhost:%d%s", s.port, uploadResp.Data.URL), nil)
+	s.Require().NoError(err)
+	req.Header.Set("If-None-Match", response.Header.Get("ETag"))
+	response, err = s.httpClient.Do(req)
+	s.Require().NoError(err)
+	response.Body.Close()
+	s.Equal(304, response.StatusCode, "matching ETag should respond not modified")
+}
+
+func (s *integrationTestSuite) Test_Image_Variants() {
+	authorID, token := s.createAuthorWithToken("author")
+	var encoded bytes.Buffer
+	s.Require().NoError(png.Encode(&encoded, image.NewNRGBA(image.Rect(0, 0, 8, 8))))
+	uploadResp := s.uploadMedia(encoded.Bytes(), token)
+	s.Require().Equal(201, uploadResp.Status)
+
+	response, err := s.httpClient.Get(fmt.Sprintf("http://localhost:%d%s?width=4&format=webp", s.port, uploadResp.Data.URL))
+	s.Require().NoError(err)
+	response.Body.Close()
+	s.Equal(200, response.StatusCode)
+	s.Equal("image/webp", response.Header.Get("Content-Type"))
+
+	errResp := ErrorResp{}
+	err = s.request("GET", uploadResp.Data.URL+"?width=5", "", &errResp)
+	s.Require().NoError(err)
+	s.Equal(400, errResp.Status, "width not configured should be rejected")
+
+	createResp := CreateArticleResp{}
+	content := fmt.Sprintf("![image](%s)", uploadResp.Data.URL)
+	err = s.requestWithToken("POST", "/articles", fmt.Sprintf(`{"title": "title", "content": %q, "author_ids": [%q]}`, content, authorID), testAdminToken, &createResp)
+	s.Require().NoError(err)
+	s.Require().Equal(201, createResp.Status)
+	getResp := GetArticleResp{}
+	err = s.request("GET", "/articles/"+createResp.Data.ID, "", &getResp)
+	s.Require().NoError(err)
+	s.Require().Len(getResp.Data, 1)
+	s.Contains(getResp.Data[0].ContentHTML, fmt.Sprintf(`srcset="%s?width=4 4w"`, uploadResp.Data.URL))
+}
+
+func (s *integrationTestSuite) Test_MediaStoreGridFS() {
+	ctx := context.Background()
+	// Larger than a GridFS chunk, so reading from an offset starts in the middle of the file.
+	content := bytes.Repeat([]byte("0123456789"), 60*1024)
+	hash := sha256.Sum256(content)
+	media := &data.Media{
+		ID:        data.NewMediaID(hash[:]),
+		Type:      "video/mp4",
+		Size:      int64(len(content)),
+		CreatedAt: time.Now().UTC().Truncate(time.Millisecond),
+	}
+
+	created, err := s.gridFS.Put(ctx, media, bytes.NewReader(content))
+	s.Require().NoError(err)
+	s.True(created)
+	created, err = s.gridFS.Put(ctx, media, bytes.NewReader(content))
+	s.Require().NoError(err)
+	s.False(created, "stored media should not be uploaded again")
+
+	got, object, err := s.gridFS.Get(ctx, media.ID)
+	s.Require().NoError(err)
+	s.Equal(media, got)
+	offset := int64(300*1024 + 7)
+	_, err = object.Seek(offset, io.SeekStart)
+	s.Require().NoError(err)
+	rest, err := io.ReadAll(object)
+	s.Require().NoError(err)
+	s.Equal(content[offset:], rest, "content should be read from the offset")
+	s.Require().NoError(object.Close())
+
+	listed, err := s.gridFS.List(ctx)
+	s.Require().NoError(err)
+	s.Len(listed, 1)
+
+	s.Require().NoError(s.gridFS.Delete(ctx, media.ID))
+	_, _, err = s.gridFS.Get(ctx, media.ID)
+	s.Equal(errors.ErrMediaNotFound, err)
+}
+
+func (s *integrationTestSuite) Test_MongoDBSchema() {
+	ctx := context.Background()
+	repo := s.repo.(*infra_repository.ArticleRepositoryMongoDB)
+	_, err := infra_repository.EnsureSchemaMongoDB(ctx, repo, false)
+	s.Require().NoError(err)
+	changes, err := infra_repository.EnsureSchemaMongoDB(ctx, repo, true)
+	s.Require().NoError(err)
+	s.Empty(changes, "the applied schema should match the declared one")
+
+	_, err = repo.Create(ctx, &data.ArticleInfo{
+		Title:     data.ArticleTitle(strings.Repeat("a", data.MAX_ARTICLE_TITLE_LENGTH+1)),
+		Content:   "content",
+		AuthorIDs: []data.AuthorID{"1"},
+	})
+	s.Error(err, "the validator should reject a too long title")
+	_, err = repo.Create(ctx, &data.ArticleInfo{Title: "title", Content: "content", AuthorIDs: []data.AuthorID{}})
+	s.Error(err, "the validator should reject an article without author")
+
+	config, err := infra.LoadConfig()
+	s.Require().NoError(err)
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(config.MongoDBUri))
+	s.Require().NoError(err)
+	defer client.Disconnect(ctx)
+	_, err = client.Database("simple-blog").Collection("articles").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "title", Value: 1}},
+		Options: options.Index().SetName("obsolete"),
+	})
+	s.Require().NoError(err)
+
+	changes, err = infra_repository.EnsureSchemaMongoDB(ctx, repo, true)
+	s.Require().NoError(err)
+	s.Equal([]infra_repository.MongoDBSchemaChange{{Collection: "articles", Action: "drop index", Index: "obsolete"}}, changes,
+		"the dry run should report the obsolete index")
+	changes, err = infra_repository.EnsureSchemaMongoDB(ctx, repo, true)
+	s.Require().NoError(err)
+	s.Len(changes, 1, "the dry run should not change the schema")
+	_, err = infra_repository.EnsureSchemaMongoDB(ctx, repo, false)
+	s.Require().NoError(err)
+	changes, err = infra_repository.EnsureSchemaMongoDB(ctx, repo, true)
+	s.Require().NoError(err)
+	s.Empty(changes, "the obsolete index should be dropped")
+}
+
+func (s *integrationTestSuite) Test_MongoDBMigrations() {
+	ctx := context.Background()
+	repo := s.repo.(*infra_repository.ArticleRepositoryMongoDB)
+	config, err := infra.LoadConfig()
+	s.Require().NoError(err)
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(config.MongoDBUri))
+	s.Require().NoError(err)
+	defer client.Disconnect(ctx)
+	db := client.Database("simple-blog")
+	s.Require().NoError(db.Collection("schema_migrations").Drop(ctx))
+	s.Require().NoError(db.Collection("locks").Drop(ctx))
+	defer db.Collection("schema_migrations").Drop(ctx)
+
+	// The documents of the first version, which the validator would reject.
+	insertLegacy := func(title string, author string) string {
+		result, err := db.Collection("articles").InsertOne(ctx, bson.M{"title": title, "content": "content", "author": author},
+			options.InsertOne().SetBypassDocumentValidation(true))
+		s.Require().NoError(err)
+		return result.InsertedID.(primitive.ObjectID).Hex()
+	}
+	readRaw := func(title string) bson.M {
+		var doc bson.M
+		s.Require().NoError(db.Collection("articles").FindOne(ctx, bson.M{"title": title}).Decode(&doc))
+		return doc
+	}
+
+	lazyID := insertLegacy("lazy", " Legacy ")
+	article, err := repo.GetByID(ctx, data.ArticleID(lazyID))
+	s.Require().NoError(err)
+	s.Require().Len(article.AuthorIDs, 1, "the document should be upgraded when read")
+	s.False(article.CreatedAt.IsZero())
+	doc := readRaw("lazy")
+	s.EqualValues(4, doc["schema_version"], "the upgraded document should be stored")
+	s.EqualValues(data.FirstArticleVersion, doc["version"], "the upgraded document should have the first version")
+	s.NotContains(doc, "author")
+
+	// Concurrent reads of the articles of the same author string create a single profile.
+	concurrentIDs := []string{insertLegacy("concurrent 1", "Concurrent"), insertLegacy("concurrent 2", "concurrent ")}
+	var wg sync.WaitGroup
+	concurrentAuthors := make([]data.AuthorID, len(concurrentIDs))
+	for i, id := range concurrentIDs {
+		wg.Add(1)
+		go func(i int, id string) {
+			defer wg.Done()
+			if article, err := repo.GetByID(ctx, data.ArticleID(id)); err == nil {
+				concurrentAuthors[i] = article.AuthorIDs[0]
+			}
+		}(i, id)
+	}
+	wg.Wait()
+	s.NotEmpty(concurrentAuthors[0])
+	s.Equal(concurrentAuthors[0], concurrentAuthors[1], "the concurrent upgrades should reuse the same author")
+	profiles, err := db.Collection("authors").CountDocuments(ctx, bson.M{"name_key": "concurrent"})
+	s.Require().NoError(err)
+	s.EqualValues(1, profiles, "the concurrent upgrades should create a single profile")
+
+	insertLegacy("batch", " Legacy ")
+	_, err = db.Collection("locks").InsertOne(ctx, bson.M{"_id": "schema_migrations", "owner": "other", "expires_at": time.Now().Add(time.Minute)})
+	s.Require().NoError(err)
+	s.Require().NoError(infra_repository.MigrateMongoDB(ctx, repo))
+	s.NotContains(readRaw("batch"), "schema_version", "the migrations should be skipped while another process holds the lock")
+
+	_, err = db.Collection("locks").DeleteOne(ctx, bson.M{"_id": "schema_migrations"})
+	s.Require().NoError(err)
+	s.Require().NoError(infra_repository.MigrateMongoDB(ctx, repo))
+	doc = readRaw("batch")
+	s.EqualValues(4, doc["schema_version"])
+	s.Equal(bson.A{article.AuthorIDs[0]}, doc["author_ids"], "the same author should be reused")
+	applied, err := db.Collection("schema_migrations").CountDocuments(ctx, bson.M{})
+	s.Require().NoError(err)
+	s.EqualValues(4, applied)
+	locks, err := db.Collection("locks").CountDocuments(ctx, bson.M{})
+	s.Require().NoError(err)
+	s.Zero(locks, "the lock should be released")
+}
+
+func (s *integrationTestSuite) Test_CorruptDocuments() {
+	ctx := context.Background()
+	repo := s.repo.(*infra_repository.ArticleRepositoryMongoDB)
+	config, err := infra.LoadConfig()
+	s.Require().NoError(err)
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(config.MongoDBUri))
+	s.Require().NoError(err)
+	defer client.Disconnect(ctx)
+	db := client.Database("simple-blog")
+	s.Require().NoError(db.Collection("articles_quarantine").Drop(ctx))
+	defer db.Collection("articles_quarantine").Drop(ctx)
+
+	validID, err := repo.Create(ctx, &data.ArticleInfo{Title: "valid", Content: "content", AuthorIDs: []data.AuthorID{"1"}})
+	s.Require().NoError(err)
+	// Written by another tool, bypassing the validator.
+	now := time.Now()
+	result, err := db.Collection("articles").InsertOne(ctx, bson.M{
+		"title": "", "content": "content", "author_ids": bson.A{"1"}, "version": int64(1), "created_at": now, "updated_at": now, "schema_version": 4,
+	}, options.InsertOne().SetBypassDocumentValidation(true))
+	s.Require().NoError(err)
+	corruptID := result.InsertedID.(primitive.ObjectID).Hex()
+
+	_, err = repo.GetByID(ctx, data.ArticleID(corruptID))
+	corruptErr, ok := err.(*errors.CorruptDocumentError)
+	s.Require().True(ok, "the invalid document should be reported as corrupt, got %v", err)
+	s.Equal(corruptID, corruptErr.ID)
+	s.Equal(errors.ErrTitleEmpty, corruptErr.Err)
+	all, err := repo.GetAll(ctx)
+	s.Require().NoError(err, "a corrupt document should not fail the lists")
+	s.Require().Len(all, 1, "the corrupt document should be skipped")
+	s.Equal(validID, all[0].ID)
+	page, err := repo.GetPage(ctx, "", 2)
+	s.Require().NoError(err)
+	s.Len(page, 1, "the corrupt document should be skipped from the pages")
+
+	corrupt, err := infra_repository.VerifyArticlesMongoDB(ctx, repo, false)
+	s.Require().NoError(err)
+	s.Require().Len(corrupt, 1)
+	s.Equal(corruptID, corrupt[0].ID)
+	_, err = repo.GetByID(ctx, data.ArticleID(corruptID))
+	s.Error(err, "reporting should not move the document")
+
+	corrupt, err = infra_repository.VerifyArticlesMongoDB(ctx, repo, true)
+	s.Require().NoError(err)
+	s.Len(corrupt, 1)
+	_, err = repo.GetByID(ctx, data.ArticleID(corruptID))
+	s.Equal(errors.ErrNotFound, err, "the document should be quarantined")
+	quarantined, err := db.Collection("articles_quarantine").CountDocuments(ctx, bson.M{"_id": result.InsertedID})
+	s.Require().NoError(err)
+	s.EqualValues(1, quarantined)
+	articles, err := repo.GetAll(ctx)
+	s.Require().NoError(err)
+	s.Require().Len(articles, 1)
+	s.Equal(validID, articles[0].ID)
 }
@@ -0,0 +1,228 @@
+//go:build integration
+// +build integration
+
+package integrationtest_test
+
+import (
+	"context"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Jason5Lee/simple-blog/core/data"
+	"github.com/Jason5Lee/simple-blog/core/errors"
+	infra_repository "github.com/Jason5Lee/simple-blog/infra/repository"
+	"github.com/stretchr/testify/suite"
+)
+
+// postgresTestSuite tests the PostgreSQL repositories against the server at POSTGRES_URI.
+type postgresTestSuite struct {
+	suite.Suite
+	repo *infra_repository.ArticleRepositoryPostgres
+}
+
+func TestPostgres(t *testing.T) {
+	if os.Getenv("POSTGRES_URI") == "" {
+		t.Skip("POSTGRES_URI is not set")
+	}
+	suite.Run(t, &postgresTestSuite{})
+}
+
+func (s *postgresTestSuite) SetupSuite() {
+	var err error
+	s.repo, err = infra_repository.NewArticleRepositoryPostgres(os.Getenv("POSTGRES_URI"))
+	s.Require().NoError(err)
+}
+
+// Each test starts with empty tables.
+func (s *postgresTestSuite) SetupTest() {
+	s.Require().NoError(s.repo.Truncate())
+}
+
+func (s *postgresTestSuite) TearDownSuite() {
+	if s.repo != nil {
+		_ = s.repo.Truncate()
+		_ = s.repo.Close()
+	}
+}
+
+func (s *postgresTestSuite) Test_Migrations_Concurrent() {
+	// The server instances starting together apply the migrations once.
+	var wg sync.WaitGroup
+	errs := make([]error, 4)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			repo, err := infra_repository.NewArticleRepositoryPostgres(os.Getenv("POSTGRES_URI"))
+			if err == nil {
+				_ = repo.Close()
+			}
+			errs[i] = err
+		}(i)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		s.NoError(err)
+	}
+}
+
+func (s *postgresTestSuite) Test_Articles() {
+	ctx := context.Background()
+	id, err := s.repo.Create(ctx, &data.ArticleInfo{Title: "Hello", Content: "World", AuthorIDs: []data.AuthorID{"2", "1"}})
+	s.Require().NoError(err)
+
+	article, err := s.repo.GetByID(ctx, id)
+	s.Require().NoError(err)
+	s.Equal(data.ArticleTitle("Hello"), article.Title)
+	s.Equal([]data.AuthorID{"2", "1"}, article.AuthorIDs, "authors should keep their credit order")
+	s.Equal(article.CreatedAt, article.UpdatedAt)
+
+	s.Require().NoError(s.repo.Update(ctx, id, data.FirstArticleVersion, &data.ArticleInfo{Title: "Hi", Content: "There", AuthorIDs: []data.AuthorID{"1"}}))
+	byAuthor, err := s.repo.GetByAuthor(ctx, "1")
+	s.Require().NoError(err)
+	s.Require().Len(byAuthor, 1)
+	s.Equal(data.ArticleContent("There"), byAuthor[0].Content)
+	byAuthor, err = s.repo.GetByAuthor(ctx, "2")
+	s.Require().NoError(err)
+	s.Empty(byAuthor, "a removed author should no longer match")
+
+	for _, invalid := range []data.ArticleID{"", "abc", "01", "-1", "99999999999999999999", "999"} {
+		_, err = s.repo.GetByID(ctx, invalid)
+		s.Equal(errors.ErrNotFound, err, "get by id %q should return ErrNotFound", invalid)
+		s.Equal(errors.ErrNotFound, s.repo.Update(ctx, invalid, data.FirstArticleVersion, &article.ArticleInfo), "update %q should return ErrNotFound", invalid)
+		s.Equal(errors.ErrNotFound, s.repo.Delete(ctx, invalid, data.FirstArticleVersion), "delete %q should return ErrNotFound", invalid)
+	}
+
+	s.Require().NoError(s.repo.Delete(ctx, id, data.FirstArticleVersion+1))
+	_, err = s.repo.GetByID(ctx, id)
+	s.Equal(errors.ErrNotFound, err)
+}
+
+func (s *postgresTestSuite) Test_Search_And_Pages() {
+	ctx := context.Background()
+	authorIDs := []data.AuthorID{"1"}
+	var ids []data.ArticleID
+	for _, article := range []struct{ title, content string }{
+		{"Learning Go", "Goroutines and channels."},
+		{"Learning Rust", "Ownership and channels."},
+		{"Cooking", "A channel about cooking."},
+	} {
+		id, err := s.repo.Create(ctx, &data.ArticleInfo{Title: data.ArticleTitle(article.title), Content: data.ArticleContent(article.content), AuthorIDs: authorIDs})
+		s.Require().NoError(err)
+		ids = append(ids, id)
+	}
+
+	articles, err := s.repo.Search(ctx, "channels")
+	s.Require().NoError(err)
+	s.Len(articles, 3, "the terms should be stemmed")
+	articles, err = s.repo.Search(ctx, "learning goroutines")
+	s.Require().NoError(err)
+	s.Require().Len(articles, 1, "only the articles containing all the terms should match")
+	s.Equal(ids[0], articles[0].ID)
+	articles, err = s.repo.Search(ctx, "cooking & !(")
+	s.Require().NoError(err, "the syntax of tsquery should be ignored")
+	s.Require().Len(articles, 1)
+	s.Equal(ids[2], articles[0].ID)
+
+	page, err := s.repo.GetPage(ctx, "", 2)
+	s.Require().NoError(err)
+	s.Require().Len(page, 2)
+	s.Equal(ids[0], page[0].ID)
+	s.Equal(ids[1], page[1].ID)
+	// The page following a deleted article still starts after it.
+	s.Require().NoError(s.repo.Delete(ctx, ids[1], data.FirstArticleVersion))
+	page, err = s.repo.GetPage(ctx, ids[1], 2)
+	s.Require().NoError(err)
+	s.Require().Len(page, 1)
+	s.Equal(ids[2], page[0].ID)
+	_, err = s.repo.GetPage(ctx, "abc", 2)
+	s.Equal(errors.ErrInvalidPageCursor, err)
+}
+
+func (s *postgresTestSuite) Test_Series() {
+	ctx := context.Background()
+	repo := infra_repository.NewSeriesRepositoryPostgres(s.repo)
+	id, err := repo.Create(ctx, &data.SeriesInfo{Title: "Series"})
+	s.Require().NoError(err)
+	for _, articleID := range []data.ArticleID{"a", "b", "c"} {
+		s.Require().NoError(repo.AddArticle(ctx, id, articleID))
+	}
+	s.Equal(errors.ErrArticleAlreadyInSeries, repo.AddArticle(ctx, id, "a"))
+	s.Equal(errors.ErrSeriesNotFound, repo.AddArticle(ctx, "999", "a"))
+	s.Equal(errors.ErrSeriesNotFound, repo.AddArticle(ctx, "abc", "a"))
+	s.Require().NoError(repo.RemoveArticle(ctx, id, "b"))
+	s.Equal(errors.ErrArticleNotInSeries, repo.RemoveArticle(ctx, id, "b"))
+
+	s.Equal(errors.ErrInvalidSeriesOrder, repo.Reorder(ctx, id, []data.ArticleID{"c"}))
+	s.Equal(errors.ErrInvalidSeriesOrder, repo.Reorder(ctx, id, []data.ArticleID{"c", "b"}))
+	s.Require().NoError(repo.Reorder(ctx, id, []data.ArticleID{"c", "a"}))
+	byArticle, err := repo.GetByArticle(ctx, "a")
+	s.Require().NoError(err)
+	s.Require().Len(byArticle, 1)
+	s.Equal([]data.ArticleID{"c", "a"}, byArticle[0].ArticleIDs)
+}
+
+func (s *postgresTestSuite) Test_Authors_And_Comments() {
+	ctx := context.Background()
+	authorRepo := infra_repository.NewAuthorRepositoryPostgres(s.repo)
+	authorID, err := authorRepo.Create(ctx, &data.AuthorInfo{DisplayName: "alice", Links: []data.AuthorURL{"https://example.com"}})
+	s.Require().NoError(err)
+	s.Require().NoError(authorRepo.SetTokenHash(ctx, authorID, "hash"))
+	author, err := authorRepo.GetByTokenHash(ctx, "hash")
+	s.Require().NoError(err)
+	s.Equal(authorID, author.ID)
+	s.Equal([]data.AuthorURL{"https://example.com"}, author.Links)
+	authors, err := authorRepo.GetByIDs(ctx, []data.AuthorID{authorID, "abc", "999"})
+	s.Require().NoError(err)
+	s.Len(authors, 1)
+	s.Equal(errors.ErrAuthorNotFound, authorRepo.SetTokenHash(ctx, "999", "other"))
+
+	commentRepo := infra_repository.NewCommentRepositoryPostgres(s.repo)
+	first, err := commentRepo.Create(ctx, &data.CommentInfo{ArticleID: "1", Author: "bob", Content: "first"}, data.CommentPending, 0.1)
+	s.Require().NoError(err)
+	_, err = commentRepo.Create(ctx, &data.CommentInfo{ArticleID: "1", Author: "bob", Content: "second"}, data.CommentPending, 0.2)
+	s.Require().NoError(err)
+	updated, err := commentRepo.SetStatus(ctx, []data.CommentID{first, "abc"}, data.CommentApproved)
+	s.Require().NoError(err)
+	s.Equal(int64(1), updated)
+	approved, err := commentRepo.GetByArticle(ctx, "1", data.CommentApproved)
+	s.Require().NoError(err)
+	s.Require().Len(approved, 1)
+	s.Equal(data.CommentContent("first"), approved[0].Content)
+	deleted, err := commentRepo.DeleteByStatus(ctx, data.CommentPending)
+	s.Require().NoError(err)
+	s.Equal(int64(1), deleted)
+}
+
+func (s *postgresTestSuite) Test_Reactions_And_Views() {
+	ctx := context.Background()
+	reactionRepo := infra_repository.NewReactionRepositoryPostgres(s.repo)
+	added, err := reactionRepo.Add(ctx, "1", "alice", "like")
+	s.Require().NoError(err)
+	s.True(added)
+	added, err = reactionRepo.Add(ctx, "1", "alice", "like")
+	s.Require().NoError(err)
+	s.False(added)
+	removed, err := reactionRepo.Remove(ctx, "1", "bob", "like")
+	s.Require().NoError(err)
+	s.False(removed)
+	counts, err := reactionRepo.Counts(ctx, []data.ArticleID{"1", "2"})
+	s.Require().NoError(err)
+	s.Equal(map[data.ArticleID]data.ReactionCounts{"1": {"like": 1}}, counts)
+
+	viewRepo := infra_repository.NewViewRepositoryPostgres(s.repo)
+	day := data.Day(time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC))
+	s.Require().NoError(viewRepo.AddViews(ctx, []data.ViewIncrement{
+		{ArticleID: "1", Day: day, Views: 2},
+		{ArticleID: "1", Day: day, Views: 3},
+		{ArticleID: "2", Day: day.AddDate(0, 0, 1), Views: 4},
+	}))
+	daily, err := viewRepo.GetDaily(ctx, "1", day, day.AddDate(0, 0, 1))
+	s.Require().NoError(err)
+	s.Equal([]data.DailyViews{{Day: day, Views: 5}}, daily)
+	top, err := viewRepo.GetTop(ctx, day, day.AddDate(0, 0, 1), 1)
+	s.Require().NoError(err)
+	s.Equal([]data.ArticleViews{{ArticleID: "1", Views: 5}}, top)
+}
@@ -0,0 +1,68 @@
+package markdown
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/Jason5Lee/simple-blog/core/data"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// mediaPathPrefix is the path of the media served by the blog, whose images have resized variants.
+const mediaPathPrefix = "/media/"
+
+// Renderer renders the markdown content of articles into HTML.
+// Raw HTML and dangerous links in the content are not rendered.
+type Renderer struct {
+	md goldmark.Markdown
+}
+
+// NewRenderer creates a new Renderer. The images served by the blog get a `srcset` of their variants in the widths.
+func NewRenderer(imageWidths []int) *Renderer {
+	return &Renderer{
+		md: goldmark.New(goldmark.WithParserOptions(
+			parser.WithASTTransformers(util.Prioritized(&srcsetTransformer{widths: imageWidths}, 100)),
+		)),
+	}
+}
+
+// Render renders the content into HTML.
+func (r *Renderer) Render(content data.ArticleContent) (string, error) {
+	var result bytes.Buffer
+	if err := r.md.Convert([]byte(content), &result); err != nil {
+		return "", err
+	}
+	return result.String(), nil
+}
+
+// srcsetTransformer adds the `srcset` attribute to the images served by the blog.
+type srcsetTransformer struct {
+	widths []int
+}
+
+func (t *srcsetTransformer) Transform(doc *ast.Document, reader text.Reader, pc parser.Context) {
+	if len(t.widths) == 0 {
+		return
+	}
+	_ = ast.Walk(doc, func(node ast.Node, entering bool) (ast.WalkStatus, error) {
+		image, ok := node.(*ast.Image)
+		if !entering || !ok {
+			return ast.WalkContinue, nil
+		}
+		destination := string(image.Destination)
+		if !strings.HasPrefix(destination, mediaPathPrefix) || !data.IsValidMediaID(destination[len(mediaPathPrefix):]) {
+			return ast.WalkContinue, nil
+		}
+		candidates := make([]string, len(t.widths))
+		for i, width := range t.widths {
+			candidates[i] = fmt.Sprintf("%s?width=%d %dw", destination, width, width)
+		}
+		image.SetAttributeString("srcset", []byte(strings.Join(candidates, ", ")))
+		return ast.WalkContinue, nil
+	})
+}
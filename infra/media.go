@@ -0,0 +1,18 @@
+package infra
+
+import (
+	"github.com/Jason5Lee/simple-blog/core/repository"
+	infra_repository "github.com/Jason5Lee/simple-blog/infra/repository"
+)
+
+// NewMediaStore creates the media store selected by the configuration.
+// The GridFS store shares the connection of the article repository.
+func NewMediaStore(config *MediaConfig, articleRepo *infra_repository.ArticleRepositoryMongoDB) (repository.MediaStore, error) {
+	switch config.Store {
+	case "s3":
+		return infra_repository.NewMediaStoreS3(config.S3.Endpoint, config.S3.Region, config.S3.Bucket, config.S3.AccessKeyID, config.S3.SecretAccessKey), nil
+	case "gridfs":
+		return infra_repository.NewMediaStoreGridFS(articleRepo), nil
+	}
+	return infra_repository.NewMediaStoreLocal(config.Dir)
+}
@@ -0,0 +1,98 @@
+package infra
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/Jason5Lee/simple-blog/core/usecase"
+	infra_repository "github.com/Jason5Lee/simple-blog/infra/repository"
+)
+
+// StorageConfigFromURL gets the configuration of the storage at the URL, which is a MongoDB or PostgreSQL URI,
+// or the path of an SQLite or bbolt database file following "sqlite://" or "bolt://".
+// "mongo://" can be used instead of "mongodb://".
+func StorageConfigFromURL(url string) (*Config, error) {
+	scheme, rest, ok := strings.Cut(url, "://")
+	if !ok || rest == "" {
+		return nil, fmt.Errorf("invalid storage URL %q", url)
+	}
+	switch scheme {
+	case "mongo", "mongodb", "mongodb+srv":
+		if scheme == "mongo" {
+			url = "mongodb://" + rest
+		}
+		return &Config{Storage: "mongo", MongoDBUri: url}, nil
+	case "postgres", "postgresql":
+		return &Config{Storage: "postgres", PostgresUri: url}, nil
+	case "sqlite":
+		return &Config{Storage: "sqlite", SQLitePath: rest}, nil
+	case "bolt":
+		return &Config{Storage: "bolt", BoltPath: rest}, nil
+	}
+	return nil, fmt.Errorf("storage URL must start with mongo://, mongodb://, postgres://, sqlite:// or bolt://, got %q", url)
+}
+
+// RunMigrate runs the migrate command, copying the data from the storage at the URL `--from` to the one at `--to`,
+// writing the result to `out`.
+func RunMigrate(ctx context.Context, args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	from := flags.String("from", "", "URL of the storage to copy from")
+	to := flags.String("to", "", "URL of the storage to copy to")
+	idsPath := flags.String("ids", "migrate-ids.jsonl", "file mapping the old IDs to the new ones, to resume and redirect")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *from == "" || *to == "" {
+		return errors.New("both --from and --to are required")
+	}
+
+	source, err := openStorageURL(ctx, *from)
+	if err != nil {
+		return fmt.Errorf("open source: %w", err)
+	}
+	defer source.Close()
+	target, err := openStorageURL(ctx, *to)
+	if err != nil {
+		return fmt.Errorf("open target: %w", err)
+	}
+	defer target.Close()
+	ids, err := infra_repository.NewIDMappingFile(*idsPath)
+	if err != nil {
+		return err
+	}
+	defer ids.Close()
+
+	result, err := usecase.MigrateStorage(ctx, source.migrationStorage(), target.migrationStorage(), ids)
+	for _, records := range result {
+		status := "ok"
+		if !records.Verified() {
+			status = "MISMATCH"
+		}
+		fmt.Fprintf(out, "%-8s copied %d, skipped %d, source %d (%.12s), target %d (%.12s): %s\n", records.Kind,
+			records.Copied, records.Skipped, records.SourceCount, records.SourceChecksum, records.TargetCount, records.TargetChecksum, status)
+	}
+	return err
+}
+
+func openStorageURL(ctx context.Context, url string) (*Storage, error) {
+	config, err := StorageConfigFromURL(url)
+	if err != nil {
+		return nil, err
+	}
+	return OpenStorage(ctx, config)
+}
+
+func (s *Storage) migrationStorage() *usecase.MigrationStorage {
+	return &usecase.MigrationStorage{
+		ArticleRepo:  s.ArticleRepo,
+		AuthorRepo:   s.AuthorRepo,
+		CommentRepo:  s.CommentRepo,
+		ViewRepo:     s.ViewRepo,
+		SeriesRepo:   s.SeriesRepo,
+		ReactionRepo: s.ReactionRepo,
+	}
+}
@@ -0,0 +1,16 @@
+package infra
+
+import (
+	"github.com/Jason5Lee/simple-blog/core/moderation"
+)
+
+// NewModerator creates the comment moderator from the configuration, using the built-in naive Bayes classifier.
+func NewModerator(config *ModerationConfig) *moderation.Moderator {
+	return moderation.NewModerator(moderation.Policy{
+		DefaultStatus:  config.DefaultStatus,
+		TrustedAuthors: config.TrustedAuthors,
+		SpamThreshold:  config.SpamThreshold,
+		MaxLinks:       config.MaxLinks,
+		Blocklist:      config.Blocklist,
+	}, moderation.NewNaiveBayes())
+}
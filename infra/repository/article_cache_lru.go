@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Jason5Lee/simple-blog/core/data"
+	"github.com/Jason5Lee/simple-blog/core/repository"
+)
+
+// ArticleCacheLRU is an in-memory ArticleCache of a bounded number of IDs, evicting the least recently used one
+// when full. The entries expire after the TTL if it is positive.
+type ArticleCacheLRU struct {
+	size int
+	ttl  time.Duration
+
+	mu sync.Mutex
+	// order has the entries, most recently used first.
+	order   *list.List
+	entries map[data.ArticleID]*list.Element
+}
+
+type articleCacheEntry struct {
+	id data.ArticleID
+	// article is nil if no article has the ID.
+	article   *data.Article
+	expiresAt time.Time
+}
+
+func NewArticleCacheLRU(size int, ttl time.Duration) *ArticleCacheLRU {
+	return &ArticleCacheLRU{
+		size:    size,
+		ttl:     ttl,
+		order:   list.New(),
+		entries: make(map[data.ArticleID]*list.Element),
+	}
+}
+
+func (cache *ArticleCacheLRU) Get(ctx context.Context, id data.ArticleID) (*data.Article, bool, error) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	element, ok := cache.entries[id]
+	if !ok {
+		return nil, false, nil
+	}
+	entry := element.Value.(*articleCacheEntry)
+	if cache.ttl > 0 && time.Now().After(entry.expiresAt) {
+		cache.remove(element)
+		return nil, false, nil
+	}
+	cache.order.MoveToFront(element)
+	if entry.article == nil {
+		return nil, true, nil
+	}
+	// The callers may change the article, so they get a copy.
+	return copyArticle(entry.article), true, nil
+}
+
+func (cache *ArticleCacheLRU) Set(ctx context.Context, id data.ArticleID, article *data.Article) error {
+	if article != nil {
+		article = copyArticle(article)
+	}
+	entry := &articleCacheEntry{id: id, article: article, expiresAt: time.Now().Add(cache.ttl)}
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	if element, ok := cache.entries[id]; ok {
+		element.Value = entry
+		cache.order.MoveToFront(element)
+		return nil
+	}
+	cache.entries[id] = cache.order.PushFront(entry)
+	for cache.order.Len() > cache.size {
+		cache.remove(cache.order.Back())
+	}
+	return nil
+}
+
+func (cache *ArticleCacheLRU) Delete(ctx context.Context, id data.ArticleID) error {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	if element, ok := cache.entries[id]; ok {
+		cache.remove(element)
+	}
+	return nil
+}
+
+func (cache *ArticleCacheLRU) Clear(ctx context.Context) error {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	cache.order.Init()
+	cache.entries = make(map[data.ArticleID]*list.Element)
+	return nil
+}
+
+// Len returns the number of cached IDs, including the expired ones not evicted yet.
+func (cache *ArticleCacheLRU) Len() int {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	return cache.order.Len()
+}
+
+func (cache *ArticleCacheLRU) remove(element *list.Element) {
+	cache.order.Remove(element)
+	delete(cache.entries, element.Value.(*articleCacheEntry).id)
+}
+
+var _ repository.ArticleCache = (*ArticleCacheLRU)(nil)
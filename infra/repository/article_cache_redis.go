@@ -0,0 +1,317 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/Jason5Lee/simple-blog/core/data"
+	"github.com/Jason5Lee/simple-blog/core/repository"
+	"github.com/redis/go-redis/v9"
+)
+
+// redisArticleFormat is the version of the format of the cached articles, which is part of their keys.
+// It must be incremented when the format changes, so the replicas of different versions do not read the entries
+// of each other, and the entries of the previous format expire.
+const redisArticleFormat = 2
+
+const redisKeyPrefix = "simple-blog:"
+
+// redisInvalidationChannel is where the removed IDs are published, or an empty message when the cache is cleared.
+const redisInvalidationChannel = redisKeyPrefix + "article-invalidations"
+
+// redisClearsKey counts the times the cache has been cleared, and the keys following redisRemovalsPrefix count
+// the times each ID has been removed, so together they are the generation of an ID.
+const redisClearsKey = redisKeyPrefix + "article-clears"
+const redisRemovalsPrefix = redisKeyPrefix + "article-removals:"
+
+// redisRemovalsTTL is how long the count of the removals of an ID is kept, much longer than reading an article.
+const redisRemovalsTTL = 24 * time.Hour
+
+// redisSetIfGeneration sets the entry KEYS[1] to ARGV[2] expiring after ARGV[3] milliseconds if positive,
+// only if the generation, the counts KEYS[2] and KEYS[3], is still ARGV[1].
+var redisSetIfGeneration = redis.NewScript(`
+local generation = (redis.call("GET", KEYS[2]) or "0") .. ":" .. (redis.call("GET", KEYS[3]) or "0")
+if generation ~= ARGV[1] then
+	return 0
+end
+if tonumber(ARGV[3]) > 0 then
+	redis.call("SET", KEYS[1], ARGV[2], "PX", ARGV[3])
+else
+	redis.call("SET", KEYS[1], ARGV[2])
+end
+return 1
+`)
+
+// ArticleCacheRedis is an ArticleCache in Redis shared by the replicas, expiring the entries after the TTL
+// if it is positive. It can have a near cache in memory in front of it, from which the IDs removed by any replica
+// are removed by Listen. As a SharedArticleCache, it does not cache an article read before another replica
+// removed its ID.
+type ArticleCacheRedis struct {
+	client *redis.Client
+	ttl    time.Duration
+	// near is nil if there is no near cache.
+	near repository.ArticleCache
+}
+
+// RedisArticle is a cached article, serialized in JSON.
+type RedisArticle struct {
+	ID        string    `json:"id"`
+	Title     string    `json:"title"`
+	Content   string    `json:"content"`
+	AuthorIDs []string  `json:"author_ids"`
+	Version   int64     `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// RedisArticleEntry is a cache entry, whose article is null if no article has the ID.
+type RedisArticleEntry struct {
+	Article *RedisArticle `json:"article"`
+}
+
+// NewArticleCacheRedis creates an ArticleCacheRedis connecting to the Redis at the URL, failing if it cannot
+// be reached. The near cache can be nil.
+func NewArticleCacheRedis(ctx context.Context, url string, ttl time.Duration, near repository.ArticleCache) (*ArticleCacheRedis, error) {
+	options, err := redis.ParseURL(url)
+	if err != nil {
+		return nil, err
+	}
+	client := redis.NewClient(options)
+	if err := client.Ping(ctx).Err(); err != nil {
+		_ = client.Close()
+		return nil, fmt.Errorf("Redis is unreachable: %w", err)
+	}
+	return &ArticleCacheRedis{client: client, ttl: ttl, near: near}, nil
+}
+
+func (cache *ArticleCacheRedis) key(id data.ArticleID) string {
+	return fmt.Sprintf("%sarticle:v%d:%s", redisKeyPrefix, redisArticleFormat, id)
+}
+
+func (cache *ArticleCacheRedis) Get(ctx context.Context, id data.ArticleID) (*data.Article, bool, error) {
+	if cache.near != nil {
+		if article, ok, err := cache.near.Get(ctx, id); err != nil || ok {
+			return article, ok, err
+		}
+	}
+	value, err := cache.client.Get(ctx, cache.key(id)).Bytes()
+	if err == redis.Nil {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	article, err := decodeRedisArticle(value)
+	if err != nil {
+		// The entry is ignored, so it is replaced by the next read.
+		log.Printf("invalid cached article %s: %v", id, err)
+		return nil, false, nil
+	}
+	if cache.near != nil {
+		if err := cache.near.Set(ctx, id, article); err != nil {
+			return nil, false, err
+		}
+	}
+	return article, true, nil
+}
+
+func (cache *ArticleCacheRedis) Set(ctx context.Context, id data.ArticleID, article *data.Article) error {
+	value, err := encodeRedisArticle(article)
+	if err != nil {
+		return err
+	}
+	if err := cache.client.Set(ctx, cache.key(id), value, cache.ttl).Err(); err != nil {
+		return err
+	}
+	if cache.near != nil {
+		return cache.near.Set(ctx, id, article)
+	}
+	return nil
+}
+
+func (cache *ArticleCacheRedis) Generation(ctx context.Context, id data.ArticleID) (string, error) {
+	counts, err := cache.client.MGet(ctx, redisRemovalsPrefix+string(id), redisClearsKey).Result()
+	if err != nil {
+		return "", err
+	}
+	generation := make([]string, len(counts))
+	for i, count := range counts {
+		generation[i] = "0"
+		if count, ok := count.(string); ok {
+			generation[i] = count
+		}
+	}
+	return strings.Join(generation, ":"), nil
+}
+
+func (cache *ArticleCacheRedis) SetIfGeneration(ctx context.Context, id data.ArticleID, article *data.Article, generation string) error {
+	value, err := encodeRedisArticle(article)
+	if err != nil {
+		return err
+	}
+	set, err := redisSetIfGeneration.Run(ctx, cache.client, []string{cache.key(id), redisRemovalsPrefix + string(id), redisClearsKey},
+		generation, value, cache.ttl.Milliseconds()).Int()
+	if err != nil || set == 0 {
+		return err
+	}
+	if cache.near != nil {
+		return cache.near.Set(ctx, id, article)
+	}
+	return nil
+}
+
+// Delete removes the ID from Redis, and from the near caches of all the replicas.
+// The removal is counted with it, so the articles being read by the replicas are not cached after.
+func (cache *ArticleCacheRedis) Delete(ctx context.Context, id data.ArticleID) error {
+	if cache.near != nil {
+		if err := cache.near.Delete(ctx, id); err != nil {
+			return err
+		}
+	}
+	_, err := cache.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, cache.key(id))
+		pipe.Incr(ctx, redisRemovalsPrefix+string(id))
+		pipe.Expire(ctx, redisRemovalsPrefix+string(id), redisRemovalsTTL)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return cache.client.Publish(ctx, redisInvalidationChannel, string(id)).Err()
+}
+
+// Clear removes all the IDs of the current format from Redis, and from the near caches of all the replicas.
+func (cache *ArticleCacheRedis) Clear(ctx context.Context) error {
+	if cache.near != nil {
+		if err := cache.near.Clear(ctx); err != nil {
+			return err
+		}
+	}
+	// Counted first, so the articles being read are not cached after their entries are removed.
+	if err := cache.client.Incr(ctx, redisClearsKey).Err(); err != nil {
+		return err
+	}
+	keys := cache.client.Scan(ctx, 0, cache.key("*"), 100).Iterator()
+	for keys.Next(ctx) {
+		if err := cache.client.Del(ctx, keys.Val()).Err(); err != nil {
+			return err
+		}
+	}
+	if err := keys.Err(); err != nil {
+		return err
+	}
+	return cache.client.Publish(ctx, redisInvalidationChannel, "").Err()
+}
+
+// Listen removes the IDs removed by any replica from the near cache, until the context is done.
+// The near cache is cleared whenever the subscription is reconnected, as the messages in between are lost.
+// The function is called before removing IDs, so the articles being read are not cached in the near cache after,
+// like with ArticleRepositoryCached.Invalidated.
+func (cache *ArticleCacheRedis) Listen(ctx context.Context, invalidated func()) error {
+	if cache.near == nil {
+		return nil
+	}
+	subscription := cache.client.Subscribe(ctx, redisInvalidationChannel)
+	defer subscription.Close()
+	// Receive does not return once the context is done, but once the subscription is closed.
+	defer context.AfterFunc(ctx, func() { _ = subscription.Close() })()
+	for {
+		message, err := subscription.Receive(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			// The subscription reconnects on the next receive.
+			log.Printf("failed to receive the article invalidations: %v", err)
+			invalidated()
+			if err := cache.near.Clear(ctx); err != nil {
+				return err
+			}
+			continue
+		}
+		invalidated()
+		switch message := message.(type) {
+		case *redis.Subscription:
+			// Subscribed again after reconnecting.
+			err = cache.near.Clear(ctx)
+		case *redis.Message:
+			if message.Payload == "" {
+				err = cache.near.Clear(ctx)
+			} else {
+				err = cache.near.Delete(ctx, data.ArticleID(message.Payload))
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (cache *ArticleCacheRedis) Close() error {
+	return cache.client.Close()
+}
+
+func encodeRedisArticle(article *data.Article) ([]byte, error) {
+	var entry RedisArticleEntry
+	if article != nil {
+		authorIDs := make([]string, len(article.AuthorIDs))
+		for i, id := range article.AuthorIDs {
+			authorIDs[i] = string(id)
+		}
+		entry.Article = &RedisArticle{
+			ID:        string(article.ID),
+			Title:     string(article.Title),
+			Content:   string(article.Content),
+			AuthorIDs: authorIDs,
+			Version:   int64(article.Version),
+			CreatedAt: article.CreatedAt,
+			UpdatedAt: article.UpdatedAt,
+		}
+	}
+	return json.Marshal(&entry)
+}
+
+// decodeRedisArticle decodes a cache entry, validating the article like the input of Create,
+// as Redis may be written by other versions or tools.
+func decodeRedisArticle(value []byte) (*data.Article, error) {
+	var entry RedisArticleEntry
+	if err := json.Unmarshal(value, &entry); err != nil {
+		return nil, err
+	}
+	if entry.Article == nil {
+		return nil, nil
+	}
+	title, err := data.NewArticleTitle(entry.Article.Title)
+	if err != nil {
+		return nil, err
+	}
+	content, err := data.NewArticleContent(entry.Article.Content)
+	if err != nil {
+		return nil, err
+	}
+	authorIDs, err := data.NewArticleAuthors(entry.Article.AuthorIDs)
+	if err != nil {
+		return nil, err
+	}
+	version, err := data.NewArticleVersion(entry.Article.Version)
+	if err != nil {
+		return nil, err
+	}
+	return &data.Article{
+		ID: data.ArticleID(entry.Article.ID),
+		ArticleInfo: data.ArticleInfo{
+			Title:     title,
+			Content:   content,
+			AuthorIDs: authorIDs,
+		},
+		Version:   version,
+		CreatedAt: entry.Article.CreatedAt,
+		UpdatedAt: entry.Article.UpdatedAt,
+	}, nil
+}
+
+var _ repository.SharedArticleCache = (*ArticleCacheRedis)(nil)
@@ -0,0 +1,161 @@
+package repository_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Jason5Lee/simple-blog/core/data"
+	"github.com/Jason5Lee/simple-blog/core/repository"
+	infra_repository "github.com/Jason5Lee/simple-blog/infra/repository"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestArticleCacheRedis(t *testing.T, server *miniredis.Miniredis, near repository.ArticleCache) *infra_repository.ArticleCacheRedis {
+	cache, err := infra_repository.NewArticleCacheRedis(context.Background(), "redis://"+server.Addr(), time.Minute, near)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = cache.Close() })
+	return cache
+}
+
+func Test_ArticleCacheRedis(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+	cache := newTestArticleCacheRedis(t, server, nil)
+
+	article := &data.Article{
+		ID:          "1",
+		ArticleInfo: data.ArticleInfo{Title: "title", Content: "content", AuthorIDs: []data.AuthorID{"1", "2"}},
+		Version:     2,
+		CreatedAt:   time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		UpdatedAt:   time.Date(2024, 1, 3, 3, 4, 5, 0, time.UTC),
+	}
+	require.NoError(cache.Set(ctx, "1", article))
+	require.NoError(cache.Set(ctx, "missing", nil))
+	assert.True(server.Exists("simple-blog:article:v2:1"), "the key should contain the format version")
+	assert.Greater(server.TTL("simple-blog:article:v2:1"), time.Duration(0), "the entry should expire")
+
+	cached, ok, err := cache.Get(ctx, "1")
+	require.NoError(err)
+	assert.True(ok)
+	assert.Equal(article, cached)
+	cached, ok, err = cache.Get(ctx, "missing")
+	require.NoError(err)
+	assert.True(ok, "a missing article should be cached")
+	assert.Nil(cached)
+	_, ok, err = cache.Get(ctx, "unknown")
+	require.NoError(err)
+	assert.False(ok)
+
+	require.NoError(server.Set("simple-blog:article:v2:2", `{"article":{"id":"2","title":"","content":"content","author_ids":["1"],"version":1}}`))
+	_, ok, err = cache.Get(ctx, "2")
+	require.NoError(err)
+	assert.False(ok, "an invalid entry should be a miss")
+
+	require.NoError(server.Set("simple-blog:article:v1:3", `{}`))
+	require.NoError(cache.Delete(ctx, "1"))
+	_, ok, _ = cache.Get(ctx, "1")
+	assert.False(ok)
+	require.NoError(cache.Clear(ctx))
+	_, ok, _ = cache.Get(ctx, "missing")
+	assert.False(ok)
+	keys, err := cache.Generation(ctx, "1")
+	require.NoError(err)
+	assert.Equal("1:1", keys, "the removal and the clear should change the generation")
+	assert.False(server.Exists("simple-blog:article:v2:missing"))
+	assert.True(server.Exists("simple-blog:article:v1:3"), "only the entries of the current format should be cleared")
+}
+
+func Test_ArticleCacheRedis_Invalidation(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	server := miniredis.RunT(t)
+
+	// Two replicas, each with its near cache.
+	near := infra_repository.NewArticleCacheLRU(16, 0)
+	cache := newTestArticleCacheRedis(t, server, near)
+	other := newTestArticleCacheRedis(t, server, infra_repository.NewArticleCacheLRU(16, 0))
+	var invalidations atomic.Int64
+	listening := make(chan struct{})
+	go func() {
+		_ = cache.Listen(ctx, func() { invalidations.Add(1) })
+		close(listening)
+	}()
+	require.Eventually(func() bool { return len(server.PubSubChannels("*")) == 1 }, time.Second, 10*time.Millisecond)
+
+	article := &data.Article{ID: "1", ArticleInfo: data.ArticleInfo{Title: "title", Content: "content", AuthorIDs: []data.AuthorID{"1"}}}
+	require.NoError(cache.Set(ctx, "1", article))
+	require.NoError(cache.Set(ctx, "2", article))
+	server.FlushAll()
+	_, ok, _ := cache.Get(ctx, "1")
+	assert.True(ok, "the article should be in the near cache")
+
+	require.NoError(other.Delete(ctx, "1"))
+	assert.Eventually(func() bool { return near.Len() == 1 }, time.Second, 10*time.Millisecond,
+		"the ID removed by another replica should be removed from the near cache")
+	require.NoError(other.Clear(ctx))
+	assert.Eventually(func() bool { return near.Len() == 0 }, time.Second, 10*time.Millisecond,
+		"the cache cleared by another replica should clear the near cache")
+	assert.GreaterOrEqual(invalidations.Load(), int64(2), "the loads in progress should be told of the invalidations")
+
+	cancel()
+	<-listening
+}
+
+// pausedArticleRepository reads the articles by ID, then waits for `release` before returning them,
+// telling `read` once read.
+type pausedArticleRepository struct {
+	repository.ArticleRepository
+	read    chan struct{}
+	release chan struct{}
+}
+
+func (repo *pausedArticleRepository) GetByID(ctx context.Context, id data.ArticleID) (*data.Article, error) {
+	article, err := repo.ArticleRepository.GetByID(ctx, id)
+	repo.read <- struct{}{}
+	<-repo.release
+	return article, err
+}
+
+func Test_ArticleCacheRedis_StaleLoad(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+	backend := infra_repository.NewArticleRepositoryInMemory()
+	id, err := backend.Create(ctx, &data.ArticleInfo{Title: "old", Content: "content", AuthorIDs: []data.AuthorID{"1"}})
+	require.NoError(err)
+
+	// Two replicas of the same storage, the first one reading the article before the second updates it.
+	paused := &pausedArticleRepository{ArticleRepository: backend, read: make(chan struct{}), release: make(chan struct{})}
+	near := infra_repository.NewArticleCacheLRU(16, 0)
+	first := infra_repository.NewArticleRepositoryCached(paused, newTestArticleCacheRedis(t, server, near))
+	second := infra_repository.NewArticleRepositoryCached(backend, newTestArticleCacheRedis(t, server, nil))
+	loaded := make(chan *data.Article)
+	go func() {
+		article, err := first.GetByID(ctx, id)
+		assert.NoError(err)
+		loaded <- article
+	}()
+	<-paused.read
+	require.NoError(second.Update(ctx, id, data.FirstArticleVersion, &data.ArticleInfo{Title: "new", Content: "content", AuthorIDs: []data.AuthorID{"1"}}))
+	close(paused.release)
+	assert.Equal(data.ArticleTitle("old"), (<-loaded).Title, "the load should return what it read")
+	assert.False(server.Exists("simple-blog:article:v2:"+string(id)), "the article read before the update should not be cached")
+	assert.Zero(near.Len(), "the article read before the update should not be in the near cache")
+
+	go func() { <-paused.read }()
+	article, err := first.GetByID(ctx, id)
+	require.NoError(err)
+	assert.Equal(data.ArticleTitle("new"), article.Title)
+	article, err = second.GetByID(ctx, id)
+	require.NoError(err)
+	assert.Equal(data.ArticleTitle("new"), article.Title, "the updated article should be cached")
+}
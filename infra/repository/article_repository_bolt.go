@@ -0,0 +1,451 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/Jason5Lee/simple-blog/core/data"
+	"github.com/Jason5Lee/simple-blog/core/errors"
+	"github.com/Jason5Lee/simple-blog/core/repository"
+	bolt "go.etcd.io/bbolt"
+)
+
+// Data stored in bbolt, with the ID as the key.
+type BoltArticle struct {
+	Title     string   `json:"title"`
+	Content   string   `json:"content"`
+	AuthorIDs []string `json:"author_ids"`
+	// Version is 0 for the articles written before the versions, which have the first version.
+	Version   int64     `json:"version,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ArticleRepositoryBolt is a bbolt implementation of ArticleRepository, storing the blog in a single file.
+// The articles are indexed by author and by creation time in separate buckets,
+// updated in the same transaction as the articles.
+type ArticleRepositoryBolt struct {
+	db *bolt.DB
+}
+
+var (
+	boltArticlesBucket          = []byte("articles")
+	boltArticlesByAuthorBucket  = []byte("articles_by_author")
+	boltArticlesByCreatedBucket = []byte("articles_by_created")
+	// boltMetaBucket has the markers of the upgrades of the database.
+	boltMetaBucket = []byte("meta")
+	// boltSignedTimesKey marks that the times in the keys are encoded by boltTime keeping the sign.
+	boltSignedTimesKey = []byte("signed_times")
+)
+
+// boltBuckets are all the buckets of the database, created when it is opened.
+var boltBuckets = [][]byte{
+	boltArticlesBucket, boltArticlesByAuthorBucket, boltArticlesByCreatedBucket,
+	boltAuthorsBucket, boltAuthorsByTokenHashBucket,
+	boltCommentsBucket, boltCommentsByStatusBucket, boltCommentsByArticleBucket,
+	boltReactionsBucket, boltReactionCountsBucket,
+	boltViewsBucket, boltViewsByDayBucket,
+	boltSeriesBucket, boltSeriesByArticleBucket,
+	boltMetaBucket,
+}
+
+// NewArticleRepositoryBolt creates a new ArticleRepositoryBolt opening the database file at the path,
+// creating it and the buckets if needed.
+func NewArticleRepositoryBolt(path string) (*ArticleRepositoryBolt, error) {
+	// bbolt locks the file, so the timeout fails fast if another process has opened it.
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range boltBuckets {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return upgradeBoltTimes(tx)
+	})
+	if err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	return &ArticleRepositoryBolt{db: db}, nil
+}
+
+func (repo *ArticleRepositoryBolt) Create(ctx context.Context, article *data.ArticleInfo) (data.ArticleID, error) {
+	now := time.Now()
+	return repo.create(ctx, article, data.FirstArticleVersion, now, now)
+}
+
+func (repo *ArticleRepositoryBolt) Import(ctx context.Context, article *data.Article) (data.ArticleID, error) {
+	return repo.create(ctx, &article.ArticleInfo, article.Version, article.CreatedAt, article.UpdatedAt)
+}
+
+func (repo *ArticleRepositoryBolt) create(ctx context.Context, article *data.ArticleInfo, version data.ArticleVersion, createdAt time.Time, updatedAt time.Time) (data.ArticleID, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	var id data.ArticleID
+	err := repo.db.Update(func(tx *bolt.Tx) error {
+		seq, err := tx.Bucket(boltArticlesBucket).NextSequence()
+		if err != nil {
+			return err
+		}
+		id = data.ArticleID(strconv.FormatUint(seq, 10))
+		return putBoltArticle(tx, boltID(seq), &BoltArticle{
+			Title:     string(article.Title),
+			Content:   string(article.Content),
+			AuthorIDs: authorIDStrings(article.AuthorIDs),
+			Version:   int64(version),
+			CreatedAt: createdAt,
+			UpdatedAt: updatedAt,
+		})
+	})
+	return id, err
+}
+
+func (repo *ArticleRepositoryBolt) Update(ctx context.Context, id data.ArticleID, version data.ArticleVersion, article *data.ArticleInfo) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	key, ok := parseBoltID(string(id))
+	if !ok {
+		// Invalid ID does not match any record, so we return ErrNotFound.
+		return errors.ErrNotFound
+	}
+	return repo.db.Update(func(tx *bolt.Tx) error {
+		existing, err := getBoltArticleVersion(tx, key, version)
+		if err != nil {
+			return err
+		}
+		if err := deleteBoltArticleIndexes(tx, key, existing); err != nil {
+			return err
+		}
+		return putBoltArticle(tx, key, &BoltArticle{
+			Title:     string(article.Title),
+			Content:   string(article.Content),
+			AuthorIDs: authorIDStrings(article.AuthorIDs),
+			Version:   int64(version + 1),
+			CreatedAt: existing.CreatedAt,
+			UpdatedAt: time.Now(),
+		})
+	})
+}
+
+func (repo *ArticleRepositoryBolt) Delete(ctx context.Context, id data.ArticleID, version data.ArticleVersion) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	key, ok := parseBoltID(string(id))
+	if !ok {
+		// Invalid ID does not match any record, so we return ErrNotFound.
+		return errors.ErrNotFound
+	}
+	return repo.db.Update(func(tx *bolt.Tx) error {
+		existing, err := getBoltArticleVersion(tx, key, version)
+		if err != nil {
+			return err
+		}
+		if err := deleteBoltArticleIndexes(tx, key, existing); err != nil {
+			return err
+		}
+		return tx.Bucket(boltArticlesBucket).Delete(key)
+	})
+}
+
+// getBoltArticleVersion gets the article if it has the version.
+func getBoltArticleVersion(tx *bolt.Tx, key []byte, version data.ArticleVersion) (*BoltArticle, error) {
+	existing, err := getBoltArticle(tx, key)
+	if err != nil {
+		return nil, err
+	}
+	if existing.version() != version {
+		return nil, errors.ErrConflict
+	}
+	return existing, nil
+}
+
+func (repo *ArticleRepositoryBolt) GetByID(ctx context.Context, id data.ArticleID) (*data.Article, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	key, ok := parseBoltID(string(id))
+	if !ok {
+		// Invalid ID does not match any record, so we return ErrNotFound.
+		return nil, errors.ErrNotFound
+	}
+	var result *data.Article
+	err := repo.db.View(func(tx *bolt.Tx) error {
+		article, err := getBoltArticle(tx, key)
+		if err != nil {
+			return err
+		}
+		result = article.toArticle(key)
+		return nil
+	})
+	return result, err
+}
+
+// GetAll gets all articles by the creation time index, oldest first.
+func (repo *ArticleRepositoryBolt) GetAll(ctx context.Context) ([]*data.Article, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return repo.findIndexed(boltArticlesByCreatedBucket, nil)
+}
+
+func (repo *ArticleRepositoryBolt) GetByAuthor(ctx context.Context, authorID data.AuthorID) ([]*data.Article, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return repo.findIndexed(boltArticlesByAuthorBucket, boltKey([]byte(authorID)))
+}
+
+func (repo *ArticleRepositoryBolt) GetPage(ctx context.Context, after data.ArticleID, limit data.PageLimit) ([]*data.Article, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	start := boltID(1)
+	if after != "" {
+		key, ok := parseBoltID(string(after))
+		if !ok {
+			return nil, errors.ErrInvalidPageCursor
+		}
+		start = boltID(binary.BigEndian.Uint64(key) + 1)
+	}
+	result := []*data.Article{}
+	err := repo.db.View(func(tx *bolt.Tx) error {
+		// The IDs are a sequence, so the order of the keys is the creation order.
+		cursor := tx.Bucket(boltArticlesBucket).Cursor()
+		for key, value := cursor.Seek(start); key != nil && len(result) < int(limit); key, value = cursor.Next() {
+			var article BoltArticle
+			if err := json.Unmarshal(value, &article); err != nil {
+				return err
+			}
+			result = append(result, article.toArticle(key))
+		}
+		return nil
+	})
+	return result, err
+}
+
+// findIndexed gets the articles referenced by the keys of the index with the prefix, in the order of the keys.
+// The keys of an index end with the key of the article.
+func (repo *ArticleRepositoryBolt) findIndexed(index []byte, prefix []byte) ([]*data.Article, error) {
+	result := []*data.Article{}
+	err := repo.db.View(func(tx *bolt.Tx) error {
+		cursor := tx.Bucket(index).Cursor()
+		for indexKey, _ := cursor.Seek(prefix); indexKey != nil && bytes.HasPrefix(indexKey, prefix); indexKey, _ = cursor.Next() {
+			key := indexKey[len(indexKey)-8:]
+			article, err := getBoltArticle(tx, key)
+			if err != nil {
+				return err
+			}
+			result = append(result, article.toArticle(key))
+		}
+		return nil
+	})
+	return result, err
+}
+
+func getBoltArticle(tx *bolt.Tx, key []byte) (*BoltArticle, error) {
+	value := tx.Bucket(boltArticlesBucket).Get(key)
+	if value == nil {
+		return nil, errors.ErrNotFound
+	}
+	var article BoltArticle
+	if err := json.Unmarshal(value, &article); err != nil {
+		return nil, err
+	}
+	return &article, nil
+}
+
+// putBoltArticle puts the article and its index entries.
+func putBoltArticle(tx *bolt.Tx, key []byte, article *BoltArticle) error {
+	if err := putBoltJSON(tx.Bucket(boltArticlesBucket), key, article); err != nil {
+		return err
+	}
+	for _, authorID := range article.AuthorIDs {
+		if err := tx.Bucket(boltArticlesByAuthorBucket).Put(boltKey([]byte(authorID), key), nil); err != nil {
+			return err
+		}
+	}
+	return tx.Bucket(boltArticlesByCreatedBucket).Put(boltKey(boltTime(article.CreatedAt), key), nil)
+}
+
+func deleteBoltArticleIndexes(tx *bolt.Tx, key []byte, article *BoltArticle) error {
+	for _, authorID := range article.AuthorIDs {
+		if err := tx.Bucket(boltArticlesByAuthorBucket).Delete(boltKey([]byte(authorID), key)); err != nil {
+			return err
+		}
+	}
+	return tx.Bucket(boltArticlesByCreatedBucket).Delete(boltKey(boltTime(article.CreatedAt), key))
+}
+
+func (article *BoltArticle) toArticle(key []byte) *data.Article {
+	authorIDs := make([]data.AuthorID, len(article.AuthorIDs))
+	for i, id := range article.AuthorIDs {
+		authorIDs[i] = data.AuthorID(id)
+	}
+	return &data.Article{
+		ID: data.ArticleID(strconv.FormatUint(binary.BigEndian.Uint64(key), 10)),
+		// Assume the data in bbolt is valid.
+		ArticleInfo: data.ArticleInfo{
+			Title:     data.ArticleTitle(article.Title),
+			Content:   data.ArticleContent(article.Content),
+			AuthorIDs: authorIDs,
+		},
+		Version:   article.version(),
+		CreatedAt: article.CreatedAt,
+		UpdatedAt: article.UpdatedAt,
+	}
+}
+
+func (article *BoltArticle) version() data.ArticleVersion {
+	if article.Version == 0 {
+		return data.FirstArticleVersion
+	}
+	return data.ArticleVersion(article.Version)
+}
+
+// Backup writes a consistent copy of the database file. It runs in a read transaction,
+// so the writes are not blocked while the copy is written.
+func (repo *ArticleRepositoryBolt) Backup(ctx context.Context, w io.Writer) (int64, error) {
+	var n int64
+	err := repo.db.View(func(tx *bolt.Tx) error {
+		var err error
+		n, err = tx.WriteTo(&contextWriter{ctx: ctx, w: w})
+		return err
+	})
+	if err != nil && ctx.Err() != nil {
+		// bbolt does not wrap the error of the writer.
+		return n, ctx.Err()
+	}
+	return n, err
+}
+
+// contextWriter stops writing once the context is done.
+type contextWriter struct {
+	ctx context.Context
+	w   io.Writer
+}
+
+func (w *contextWriter) Write(p []byte) (int, error) {
+	if err := w.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return w.w.Write(p)
+}
+
+// boltID encodes a sequence number as a big-endian key, so the keys sort in the order of the sequence.
+func boltID(seq uint64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, seq)
+	return key
+}
+
+// parseBoltID parses an ID into its key, returning false if it is not the canonical form of a sequence number.
+func parseBoltID(id string) ([]byte, bool) {
+	seq, ok := parseRowID(id)
+	if !ok {
+		return nil, false
+	}
+	return boltID(uint64(seq)), true
+}
+
+// boltTime encodes a time as a big-endian key, so the keys sort in chronological order.
+// The sign bit is flipped, so the times before 1970 sort first.
+func boltTime(t time.Time) []byte {
+	return boltID(uint64(t.UnixNano()) ^ (1 << 63))
+}
+
+// parseBoltTime decodes a time encoded by boltTime, in UTC.
+func parseBoltTime(key []byte) time.Time {
+	return time.Unix(0, int64(binary.BigEndian.Uint64(key)^(1<<63))).UTC()
+}
+
+// upgradeBoltTimes flips the sign bit of the times in the keys written before boltTime kept the sign,
+// which is done once, when the database is opened.
+func upgradeBoltTimes(tx *bolt.Tx) error {
+	meta := tx.Bucket(boltMetaBucket)
+	if meta.Get(boltSignedTimesKey) != nil {
+		return nil
+	}
+	for _, index := range []struct {
+		bucket []byte
+		// timeAt gets the position of the time in a key.
+		timeAt func(key []byte) int
+	}{
+		// Keyed by time then article.
+		{boltArticlesByCreatedBucket, func([]byte) int { return 2 }},
+		{boltViewsByDayBucket, func([]byte) int { return 2 }},
+		// Keyed by article then day.
+		{boltViewsBucket, func(key []byte) int { return len(key) - 8 }},
+	} {
+		bucket := tx.Bucket(index.bucket)
+		// The keys are collected first, as a bucket cannot be changed while iterating over it.
+		var keys, values [][]byte
+		err := bucket.ForEach(func(key []byte, value []byte) error {
+			keys = append(keys, append([]byte{}, key...))
+			values = append(values, append([]byte{}, value...))
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, key := range keys {
+			if err := bucket.Delete(key); err != nil {
+				return err
+			}
+		}
+		for i, key := range keys {
+			key[index.timeAt(key)] ^= 0x80
+			if err := bucket.Put(key, values[i]); err != nil {
+				return err
+			}
+		}
+	}
+	return meta.Put(boltSignedTimesKey, []byte{1})
+}
+
+// boltKey joins the parts of a composite key, each prefixed with its length,
+// so a part cannot be confused with the following ones, and the keys with the same leading parts share a prefix.
+func boltKey(parts ...[]byte) []byte {
+	var key []byte
+	for _, part := range parts {
+		key = binary.BigEndian.AppendUint16(key, uint16(len(part)))
+		key = append(key, part...)
+	}
+	return key
+}
+
+// splitBoltKey splits the key made by boltKey into its parts, returning false if it is malformed.
+func splitBoltKey(key []byte) ([][]byte, bool) {
+	var parts [][]byte
+	for len(key) > 0 {
+		if len(key) < 2 {
+			return nil, false
+		}
+		size := int(binary.BigEndian.Uint16(key))
+		if len(key) < 2+size {
+			return nil, false
+		}
+		parts = append(parts, key[2:2+size])
+		key = key[2+size:]
+	}
+	return parts, true
+}
+
+func (repo *ArticleRepositoryBolt) Close() error {
+	return repo.db.Close()
+}
+
+var _ repository.ArticleRepository = (*ArticleRepositoryBolt)(nil)
+var _ repository.ArticlePager = (*ArticleRepositoryBolt)(nil)
+var _ repository.BackupWriter = (*ArticleRepositoryBolt)(nil)
+var _ repository.ArticleImporter = (*ArticleRepositoryBolt)(nil)
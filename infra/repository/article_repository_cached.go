@@ -0,0 +1,195 @@
+package repository
+
+import (
+	"context"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Jason5Lee/simple-blog/core/data"
+	"github.com/Jason5Lee/simple-blog/core/errors"
+	"github.com/Jason5Lee/simple-blog/core/repository"
+	"golang.org/x/sync/singleflight"
+)
+
+// ArticleRepositoryCached is an ArticleRepository getting the articles by ID through a cache in front of another
+// repository, including the IDs without article. The writes through it remove the article from the cache, and the
+// changes made by other processes can be removed with Invalidate.
+// The lists are read from the wrapped repository, as are the optional capabilities, such as Search.
+// A failing cache is logged and bypassed, so it only makes the reads slower.
+type ArticleRepositoryCached struct {
+	repo  repository.ArticleRepository
+	cache repository.ArticleCache
+	// loads makes concurrent misses of the same ID read the repository once.
+	loads singleflight.Group
+
+	// generation is incremented by each invalidation, so a load started before it does not cache the article
+	// read before the change. The invalidations hold the lock while incrementing it, and the loads hold the
+	// read lock while comparing it and caching.
+	generationMu sync.RWMutex
+	generation   uint64
+
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+// articleLoadTimeout limits a load of an article missing from the cache.
+const articleLoadTimeout = 10 * time.Second
+
+func NewArticleRepositoryCached(repo repository.ArticleRepository, cache repository.ArticleCache) *ArticleRepositoryCached {
+	return &ArticleRepositoryCached{repo: repo, cache: cache}
+}
+
+func (repo *ArticleRepositoryCached) Unwrap() repository.ArticleRepository {
+	return repo.repo
+}
+
+func (repo *ArticleRepositoryCached) Create(ctx context.Context, article *data.ArticleInfo) (data.ArticleID, error) {
+	id, err := repo.repo.Create(ctx, article)
+	if err != nil {
+		return "", err
+	}
+	// The ID may be cached as without article.
+	repo.Invalidate(ctx, id)
+	return id, nil
+}
+
+// Update updates the article, invalidating it even on a conflict, as the cached version may be the stale one.
+func (repo *ArticleRepositoryCached) Update(ctx context.Context, id data.ArticleID, version data.ArticleVersion, article *data.ArticleInfo) error {
+	err := repo.repo.Update(ctx, id, version, article)
+	if err == nil || err == errors.ErrConflict {
+		repo.Invalidate(ctx, id)
+	}
+	return err
+}
+
+func (repo *ArticleRepositoryCached) Delete(ctx context.Context, id data.ArticleID, version data.ArticleVersion) error {
+	err := repo.repo.Delete(ctx, id, version)
+	if err == nil || err == errors.ErrConflict {
+		repo.Invalidate(ctx, id)
+	}
+	return err
+}
+
+func (repo *ArticleRepositoryCached) GetByID(ctx context.Context, id data.ArticleID) (*data.Article, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	article, ok, err := repo.cache.Get(ctx, id)
+	if err != nil {
+		log.Printf("failed to get article %s from the cache: %v", id, err)
+	}
+	if ok {
+		repo.hits.Add(1)
+		if article == nil {
+			return nil, errors.ErrNotFound
+		}
+		return article, nil
+	}
+
+	repo.misses.Add(1)
+	loaded, err, shared := repo.loads.Do(string(id), func() (interface{}, error) {
+		// The load is shared by the callers missing the article meanwhile, so it is not canceled with the first.
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), articleLoadTimeout)
+		defer cancel()
+		return repo.load(ctx, id)
+	})
+	if err != nil {
+		return nil, err
+	}
+	article = loaded.(*data.Article)
+	if shared {
+		// The callers sharing the load must not share the article, as they may change it.
+		article = copyArticle(article)
+	}
+	return article, nil
+}
+
+// load reads the article from the repository and caches it, or that it does not exist.
+// It is not cached if the ID has been invalidated meanwhile, by this process or, with a shared cache, by another.
+func (repo *ArticleRepositoryCached) load(ctx context.Context, id data.ArticleID) (*data.Article, error) {
+	repo.generationMu.RLock()
+	generation := repo.generation
+	repo.generationMu.RUnlock()
+	cache := repo.cacheSince(ctx, id)
+
+	article, err := repo.repo.GetByID(ctx, id)
+	if err != nil && err != errors.ErrNotFound {
+		return nil, err
+	}
+	repo.generationMu.RLock()
+	defer repo.generationMu.RUnlock()
+	if repo.generation == generation && cache != nil {
+		if err := cache(article); err != nil {
+			log.Printf("failed to cache article %s: %v", id, err)
+		}
+	}
+	if article == nil {
+		return nil, errors.ErrNotFound
+	}
+	return article, nil
+}
+
+// cacheSince returns the function caching the article with the ID read from now on, which does nothing if
+// the ID is removed from the shared cache before, or nil if the generation of the ID in the shared cache fails.
+func (repo *ArticleRepositoryCached) cacheSince(ctx context.Context, id data.ArticleID) func(*data.Article) error {
+	shared, ok := repo.cache.(repository.SharedArticleCache)
+	if !ok {
+		return func(article *data.Article) error {
+			return repo.cache.Set(ctx, id, article)
+		}
+	}
+	generation, err := shared.Generation(ctx, id)
+	if err != nil {
+		log.Printf("failed to get the generation of article %s in the cache: %v", id, err)
+		return nil
+	}
+	return func(article *data.Article) error {
+		return shared.SetIfGeneration(ctx, id, article, generation)
+	}
+}
+
+func (repo *ArticleRepositoryCached) GetAll(ctx context.Context) ([]*data.Article, error) {
+	return repo.repo.GetAll(ctx)
+}
+
+func (repo *ArticleRepositoryCached) GetByAuthor(ctx context.Context, authorID data.AuthorID) ([]*data.Article, error) {
+	return repo.repo.GetByAuthor(ctx, authorID)
+}
+
+// Invalidate removes the article from the cache after it has been changed.
+func (repo *ArticleRepositoryCached) Invalidate(ctx context.Context, id data.ArticleID) {
+	repo.nextGeneration()
+	if err := repo.cache.Delete(context.WithoutCancel(ctx), id); err != nil {
+		log.Printf("failed to remove article %s from the cache: %v", id, err)
+	}
+}
+
+// InvalidateAll clears the cache after any article may have been changed.
+func (repo *ArticleRepositoryCached) InvalidateAll(ctx context.Context) {
+	repo.nextGeneration()
+	if err := repo.cache.Clear(context.WithoutCancel(ctx)); err != nil {
+		log.Printf("failed to clear the article cache: %v", err)
+	}
+}
+
+// Invalidated makes the loads in progress not cache what they read, after another process has removed articles
+// from the cache, as they may have read them before.
+func (repo *ArticleRepositoryCached) Invalidated() {
+	repo.nextGeneration()
+}
+
+func (repo *ArticleRepositoryCached) nextGeneration() {
+	repo.generationMu.Lock()
+	repo.generation++
+	repo.generationMu.Unlock()
+}
+
+// Stats returns the number of reads by ID served from the cache and from the repository since it was created.
+func (repo *ArticleRepositoryCached) Stats() data.CacheStats {
+	return data.CacheStats{Hits: repo.hits.Load(), Misses: repo.misses.Load()}
+}
+
+var _ repository.ArticleRepository = (*ArticleRepositoryCached)(nil)
+var _ repository.ArticleRepositoryWrapper = (*ArticleRepositoryCached)(nil)
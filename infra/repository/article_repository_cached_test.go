@@ -0,0 +1,167 @@
+package repository_test
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Jason5Lee/simple-blog/core/data"
+	"github.com/Jason5Lee/simple-blog/core/errors"
+	"github.com/Jason5Lee/simple-blog/core/repository"
+	infra_repository "github.com/Jason5Lee/simple-blog/infra/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingArticleRepository counts the reads by ID, which wait for `release` if it is not nil and then fail if
+// the context is done.
+type countingArticleRepository struct {
+	repository.ArticleRepository
+	reads   atomic.Int64
+	release chan struct{}
+}
+
+func (repo *countingArticleRepository) GetByID(ctx context.Context, id data.ArticleID) (*data.Article, error) {
+	repo.reads.Add(1)
+	if repo.release != nil {
+		<-repo.release
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+	}
+	return repo.ArticleRepository.GetByID(ctx, id)
+}
+
+func Test_ArticleRepositoryCached(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	ctx := context.Background()
+	backend := &countingArticleRepository{ArticleRepository: infra_repository.NewArticleRepositoryInMemory()}
+	repo := infra_repository.NewArticleRepositoryCached(backend, infra_repository.NewArticleCacheLRU(2, 0))
+
+	info := &data.ArticleInfo{Title: "title", Content: "content", AuthorIDs: []data.AuthorID{"1"}}
+	id, err := repo.Create(ctx, info)
+	require.NoError(err)
+	article, err := repo.GetByID(ctx, id)
+	require.NoError(err)
+	article.Title = "changed by the caller"
+	article, err = repo.GetByID(ctx, id)
+	require.NoError(err)
+	assert.Equal(info.Title, article.Title, "the cached article should not be changed by the callers")
+	assert.EqualValues(1, backend.reads.Load(), "the second read should hit the cache")
+
+	_, err = repo.GetByID(ctx, "missing")
+	assert.Equal(errors.ErrNotFound, err)
+	_, err = repo.GetByID(ctx, "missing")
+	assert.Equal(errors.ErrNotFound, err)
+	assert.EqualValues(2, backend.reads.Load(), "a missing article should be cached")
+	assert.Equal(data.CacheStats{Hits: 2, Misses: 2}, repo.Stats())
+
+	info.Title = "new title"
+	require.NoError(repo.Update(ctx, id, data.FirstArticleVersion, info))
+	article, err = repo.GetByID(ctx, id)
+	require.NoError(err)
+	assert.Equal(info.Title, article.Title, "updating should invalidate the article")
+	require.NoError(repo.Delete(ctx, id, article.Version))
+	_, err = repo.GetByID(ctx, id)
+	assert.Equal(errors.ErrNotFound, err, "deleting should invalidate the article")
+
+	// A change by another process is only seen once invalidated.
+	otherID, err := backend.Create(ctx, info)
+	require.NoError(err)
+	_, err = repo.GetByID(ctx, otherID)
+	require.NoError(err)
+	require.NoError(backend.Delete(ctx, otherID, data.FirstArticleVersion))
+	_, err = repo.GetByID(ctx, otherID)
+	assert.NoError(err)
+	repo.Invalidate(ctx, otherID)
+	_, err = repo.GetByID(ctx, otherID)
+	assert.Equal(errors.ErrNotFound, err)
+
+	_, ok := repository.ArticleCapability[repository.ArticleSearcher](repo)
+	assert.False(ok)
+	repo = infra_repository.NewArticleRepositoryCached(infra_repository.NewArticleRepositoryInMemory(), infra_repository.NewArticleCacheLRU(2, 0))
+	_, ok = repository.ArticleCapability[repository.ArticleSearcher](repo)
+	assert.True(ok, "the capabilities of the wrapped repository should be found")
+}
+
+func Test_ArticleRepositoryCached_ConcurrentMisses(t *testing.T) {
+	ctx := context.Background()
+	backend := &countingArticleRepository{ArticleRepository: infra_repository.NewArticleRepositoryInMemory()}
+	id, err := backend.Create(ctx, &data.ArticleInfo{Title: "title", Content: "content", AuthorIDs: []data.AuthorID{"1"}})
+	require.NoError(t, err)
+	backend.release = make(chan struct{})
+	repo := infra_repository.NewArticleRepositoryCached(backend, infra_repository.NewArticleCacheLRU(2, 0))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := repo.GetByID(ctx, id)
+			assert.NoError(t, err)
+		}()
+	}
+	// Let the misses wait for the first read before it completes.
+	time.Sleep(50 * time.Millisecond)
+	close(backend.release)
+	wg.Wait()
+	assert.EqualValues(t, 1, backend.reads.Load(), "the concurrent misses should read the repository once")
+}
+
+func Test_ArticleRepositoryCached_CanceledSharedMiss(t *testing.T) {
+	ctx := context.Background()
+	backend := &countingArticleRepository{ArticleRepository: infra_repository.NewArticleRepositoryInMemory()}
+	id, err := backend.Create(ctx, &data.ArticleInfo{Title: "title", Content: "content", AuthorIDs: []data.AuthorID{"1"}})
+	require.NoError(t, err)
+	backend.release = make(chan struct{})
+	repo := infra_repository.NewArticleRepositoryCached(backend, infra_repository.NewArticleCacheLRU(2, 0))
+
+	firstCtx, cancel := context.WithCancel(ctx)
+	first := make(chan error)
+	go func() {
+		_, err := repo.GetByID(firstCtx, id)
+		first <- err
+	}()
+	for backend.reads.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	second := make(chan error)
+	go func() {
+		_, err := repo.GetByID(ctx, id)
+		second <- err
+	}()
+	// Let the second miss wait for the first read before canceling the first caller.
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	close(backend.release)
+	assert.NoError(t, <-first)
+	assert.NoError(t, <-second, "the caller sharing the load should not fail with the cancellation of the first")
+	assert.EqualValues(t, 1, backend.reads.Load())
+}
+
+func Test_ArticleCacheLRU(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+	cache := infra_repository.NewArticleCacheLRU(2, 50*time.Millisecond)
+	first := &data.Article{ID: "1"}
+	assert.NoError(cache.Set(ctx, "1", first))
+	assert.NoError(cache.Set(ctx, "2", nil))
+	_, ok, _ := cache.Get(ctx, "1")
+	assert.True(ok)
+	assert.NoError(cache.Set(ctx, "3", &data.Article{ID: "3"}))
+	_, ok, _ = cache.Get(ctx, "2")
+	assert.False(ok, "the least recently used ID should be evicted")
+	article, ok, _ := cache.Get(ctx, "1")
+	assert.True(ok)
+	assert.Equal(first, article)
+	assert.Equal(2, cache.Len())
+
+	time.Sleep(60 * time.Millisecond)
+	_, ok, _ = cache.Get(ctx, "1")
+	assert.False(ok, "an entry should expire after the TTL")
+	assert.NoError(cache.Clear(ctx))
+	assert.Zero(cache.Len())
+}
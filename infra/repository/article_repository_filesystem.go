@@ -0,0 +1,458 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Jason5Lee/simple-blog/core/data"
+	"github.com/Jason5Lee/simple-blog/core/errors"
+	"github.com/Jason5Lee/simple-blog/core/repository"
+	"github.com/fsnotify/fsnotify"
+	"gopkg.in/yaml.v3"
+)
+
+// MarkdownFrontMatter is the YAML front matter of an article file.
+type MarkdownFrontMatter struct {
+	Title string `yaml:"title"`
+	// Author is a shorthand for a single author in the files written by hand, preceding the Authors.
+	Author  string    `yaml:"author,omitempty"`
+	Authors []string  `yaml:"authors,omitempty"`
+	Tags    []string  `yaml:"tags,omitempty"`
+	Date    time.Time `yaml:"date"`
+	Updated time.Time `yaml:"updated,omitempty"`
+	// Version is the version of the article, the first one if omitted. An editor changing a file by hand
+	// should increment it, so the changes made as of the previous version conflict with it.
+	Version int64 `yaml:"version,omitempty"`
+}
+
+// markdownFrontMatterDelimiter opens and closes the front matter.
+const markdownFrontMatterDelimiter = "---\n"
+
+// maxSlugLength is the maximum length of the slug generated from a title, before the suffix making it unique.
+const maxSlugLength = 64
+
+// ArticleRepositoryFilesystem is an implementation of ArticleRepository storing each article
+// as a Markdown file with YAML front matter in a directory, so the posts can be written in an editor and committed.
+// The name of a file without the `.md` extension is the slug, which is the ID of the article.
+// The articles are cached in memory and the directory is watched, so the external edits are picked up.
+type ArticleRepositoryFilesystem struct {
+	dir     string
+	watcher *fsnotify.Watcher
+
+	mu       sync.RWMutex
+	articles map[data.ArticleID]*filesystemArticle
+	// invalid has why each skipped file could not be loaded.
+	invalid map[data.ArticleID]error
+}
+
+type filesystemArticle struct {
+	article data.Article
+	// Tags are not part of the article, but kept when the file is rewritten.
+	tags []string
+}
+
+// NewArticleRepositoryFilesystem creates a new ArticleRepositoryFilesystem loading the articles in the directory,
+// which is created if missing, and watching it for changes until closed.
+// The files that cannot be loaded are logged and skipped.
+func NewArticleRepositoryFilesystem(dir string) (*ArticleRepositoryFilesystem, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	// The directory is watched before it is loaded, so no change is missed in between.
+	if err := watcher.Add(dir); err != nil {
+		_ = watcher.Close()
+		return nil, err
+	}
+	repo := &ArticleRepositoryFilesystem{
+		dir:      dir,
+		watcher:  watcher,
+		articles: make(map[data.ArticleID]*filesystemArticle),
+		invalid:  make(map[data.ArticleID]error),
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		_ = watcher.Close()
+		return nil, err
+	}
+	for _, entry := range entries {
+		if slug, ok := markdownSlug(entry.Name()); ok && !entry.IsDir() {
+			repo.reload(slug)
+		}
+	}
+	go repo.watch()
+	return repo, nil
+}
+
+func (repo *ArticleRepositoryFilesystem) Create(ctx context.Context, article *data.ArticleInfo) (data.ArticleID, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	base := slugify(string(article.Title))
+	slug := base
+	for i := 2; ; i++ {
+		if _, exists := repo.articles[data.ArticleID(slug)]; !exists {
+			// A file may exist without being loaded, such as an invalid one.
+			if _, err := os.Stat(repo.path(slug)); os.IsNotExist(err) {
+				break
+			} else if err != nil {
+				return "", err
+			}
+		}
+		slug = fmt.Sprintf("%s-%d", base, i)
+	}
+
+	now := time.Now().UTC()
+	entry := &filesystemArticle{article: data.Article{
+		ID:          data.ArticleID(slug),
+		ArticleInfo: copyArticleInfo(article),
+		Version:     data.FirstArticleVersion,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}}
+	if err := repo.write(entry); err != nil {
+		return "", err
+	}
+	repo.articles[entry.article.ID] = entry
+	return entry.article.ID, nil
+}
+
+func (repo *ArticleRepositoryFilesystem) Update(ctx context.Context, id data.ArticleID, version data.ArticleVersion, article *data.ArticleInfo) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	existing, err := getFilesystemArticleVersion(repo.articles, id, version)
+	if err != nil {
+		return err
+	}
+	entry := &filesystemArticle{
+		article: data.Article{
+			ID:          id,
+			ArticleInfo: copyArticleInfo(article),
+			Version:     version + 1,
+			CreatedAt:   existing.article.CreatedAt,
+			UpdatedAt:   time.Now().UTC(),
+		},
+		tags: existing.tags,
+	}
+	if err := repo.write(entry); err != nil {
+		return err
+	}
+	repo.articles[id] = entry
+	return nil
+}
+
+func (repo *ArticleRepositoryFilesystem) Delete(ctx context.Context, id data.ArticleID, version data.ArticleVersion) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	if _, err := getFilesystemArticleVersion(repo.articles, id, version); err != nil {
+		return err
+	}
+	if err := os.Remove(repo.path(string(id))); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	delete(repo.articles, id)
+	return nil
+}
+
+func (repo *ArticleRepositoryFilesystem) GetByID(ctx context.Context, id data.ArticleID) (*data.Article, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+	entry, ok := repo.articles[id]
+	if !ok {
+		return nil, errors.ErrNotFound
+	}
+	return copyArticle(&entry.article), nil
+}
+
+// GetAll gets all articles, oldest first.
+func (repo *ArticleRepositoryFilesystem) GetAll(ctx context.Context) ([]*data.Article, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return repo.filter(func(*data.Article) bool { return true }), nil
+}
+
+// GetAllStrict returns a CorruptDocumentError with the slug of a file that could not be loaded, if any.
+func (repo *ArticleRepositoryFilesystem) GetAllStrict(ctx context.Context) ([]*data.Article, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+	for id, err := range repo.invalid {
+		return nil, &errors.CorruptDocumentError{ID: string(id), Err: err}
+	}
+	return filterFilesystemArticles(repo.articles, func(*data.Article) bool { return true }), nil
+}
+
+func (repo *ArticleRepositoryFilesystem) GetByAuthor(ctx context.Context, authorID data.AuthorID) ([]*data.Article, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return repo.filter(func(article *data.Article) bool { return article.HasAuthor(authorID) }), nil
+}
+
+// getFilesystemArticleVersion gets the loaded article if it has the version. The caller must hold the lock.
+func getFilesystemArticleVersion(entries map[data.ArticleID]*filesystemArticle, id data.ArticleID, version data.ArticleVersion) (*filesystemArticle, error) {
+	existing, ok := entries[id]
+	if !ok {
+		return nil, errors.ErrNotFound
+	}
+	if existing.article.Version != version {
+		return nil, errors.ErrConflict
+	}
+	return existing, nil
+}
+
+// filter gets the articles matching the predicate, ordered by creation time then slug.
+func (repo *ArticleRepositoryFilesystem) filter(pred func(*data.Article) bool) []*data.Article {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+	return filterFilesystemArticles(repo.articles, pred)
+}
+
+// filterFilesystemArticles copies the articles matching the predicate, ordered by creation time then slug.
+func filterFilesystemArticles(entries map[data.ArticleID]*filesystemArticle, pred func(*data.Article) bool) []*data.Article {
+	articles := make([]*data.Article, 0, len(entries))
+	for _, entry := range entries {
+		if pred(&entry.article) {
+			articles = append(articles, copyArticle(&entry.article))
+		}
+	}
+	sort.Slice(articles, func(i, j int) bool {
+		if !articles[i].CreatedAt.Equal(articles[j].CreatedAt) {
+			return articles[i].CreatedAt.Before(articles[j].CreatedAt)
+		}
+		return articles[i].ID < articles[j].ID
+	})
+	return articles
+}
+
+// watch reloads the files changed outside of the repository until the watcher is closed.
+// The changes made by the repository itself are reloaded as well, which is harmless.
+func (repo *ArticleRepositoryFilesystem) watch() {
+	for {
+		select {
+		case event, ok := <-repo.watcher.Events:
+			if !ok {
+				return
+			}
+			if slug, ok := markdownSlug(filepath.Base(event.Name)); ok {
+				repo.mu.Lock()
+				repo.reload(slug)
+				repo.mu.Unlock()
+			}
+		case err, ok := <-repo.watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Printf("failed to watch the articles in %s: %v", repo.dir, err)
+		}
+	}
+}
+
+// reload loads the file of the slug into the cache, or removes the article if the file is missing or invalid.
+// The caller must hold the lock, except while the repository is created.
+func (repo *ArticleRepositoryFilesystem) reload(slug string) {
+	id := data.ArticleID(slug)
+	raw, err := os.ReadFile(repo.path(slug))
+	delete(repo.invalid, id)
+	if os.IsNotExist(err) {
+		delete(repo.articles, id)
+		return
+	}
+	var entry *filesystemArticle
+	if err == nil {
+		entry, err = parseMarkdownArticle(id, raw)
+	}
+	if err != nil {
+		log.Printf("skipping the article file %s: %v", repo.path(slug), err)
+		delete(repo.articles, id)
+		repo.invalid[id] = err
+		return
+	}
+	repo.articles[id] = entry
+}
+
+// write atomically replaces the file of the article, so the watcher and the editors never see a partial file.
+func (repo *ArticleRepositoryFilesystem) write(entry *filesystemArticle) error {
+	content, err := formatMarkdownArticle(entry)
+	if err != nil {
+		return err
+	}
+	// The temporary file is hidden, so the watcher ignores it.
+	tmp, err := os.CreateTemp(repo.dir, ".article-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err = tmp.Write(content); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), repo.path(string(entry.article.ID)))
+}
+
+func (repo *ArticleRepositoryFilesystem) path(slug string) string {
+	return filepath.Join(repo.dir, slug+".md")
+}
+
+func (repo *ArticleRepositoryFilesystem) Close() error {
+	return repo.watcher.Close()
+}
+
+// formatMarkdownArticle formats the article as a file with YAML front matter.
+func formatMarkdownArticle(entry *filesystemArticle) ([]byte, error) {
+	frontMatter, err := yaml.Marshal(&MarkdownFrontMatter{
+		Title:   string(entry.article.Title),
+		Authors: authorIDStrings(entry.article.AuthorIDs),
+		Tags:    entry.tags,
+		Date:    entry.article.CreatedAt,
+		Updated: entry.article.UpdatedAt,
+		Version: int64(entry.article.Version),
+	})
+	if err != nil {
+		return nil, err
+	}
+	var content bytes.Buffer
+	content.WriteString(markdownFrontMatterDelimiter)
+	content.Write(frontMatter)
+	content.WriteString(markdownFrontMatterDelimiter)
+	content.WriteString("\n")
+	content.WriteString(string(entry.article.Content))
+	return content.Bytes(), nil
+}
+
+// parseMarkdownArticle parses a file with YAML front matter.
+// Unlike the other repositories, the files are written by hand, so the article is validated.
+func parseMarkdownArticle(id data.ArticleID, raw []byte) (*filesystemArticle, error) {
+	text := strings.ReplaceAll(string(raw), "\r\n", "\n")
+	if !strings.HasPrefix(text, markdownFrontMatterDelimiter) {
+		return nil, fmt.Errorf("missing front matter")
+	}
+	text = text[len(markdownFrontMatterDelimiter):]
+	var frontMatterText, content string
+	if strings.HasPrefix(text, markdownFrontMatterDelimiter) {
+		// The front matter is empty.
+		content = text[len(markdownFrontMatterDelimiter):]
+	} else {
+		end := strings.Index(text, "\n"+markdownFrontMatterDelimiter)
+		if end < 0 {
+			return nil, fmt.Errorf("unclosed front matter")
+		}
+		frontMatterText = text[:end+1]
+		content = text[end+1+len(markdownFrontMatterDelimiter):]
+	}
+	// A blank line usually separates the front matter and the content.
+	content = strings.TrimPrefix(content, "\n")
+
+	var frontMatter MarkdownFrontMatter
+	if err := yaml.Unmarshal([]byte(frontMatterText), &frontMatter); err != nil {
+		return nil, err
+	}
+	title, err := data.NewArticleTitle(frontMatter.Title)
+	if err != nil {
+		return nil, err
+	}
+	articleContent, err := data.NewArticleContent(content)
+	if err != nil {
+		return nil, err
+	}
+	authors := frontMatter.Authors
+	if frontMatter.Author != "" {
+		authors = append([]string{frontMatter.Author}, authors...)
+	}
+	authorIDs, err := data.NewArticleAuthors(authors)
+	if err != nil {
+		return nil, err
+	}
+	if frontMatter.Date.IsZero() {
+		return nil, fmt.Errorf("missing date")
+	}
+	updated := frontMatter.Updated
+	if updated.IsZero() {
+		updated = frontMatter.Date
+	}
+	version := data.ArticleVersion(frontMatter.Version)
+	if version == 0 {
+		version = data.FirstArticleVersion
+	} else if version < 0 {
+		return nil, fmt.Errorf("invalid version %d", version)
+	}
+	return &filesystemArticle{
+		article: data.Article{
+			ID: id,
+			ArticleInfo: data.ArticleInfo{
+				Title:     title,
+				Content:   articleContent,
+				AuthorIDs: authorIDs,
+			},
+			Version:   version,
+			CreatedAt: frontMatter.Date.UTC(),
+			UpdatedAt: updated.UTC(),
+		},
+		tags: frontMatter.Tags,
+	}, nil
+}
+
+// markdownSlug returns the slug of an article file name, returning false if it is not an article file.
+// The hidden files are not articles, which includes the temporary files.
+func markdownSlug(name string) (string, bool) {
+	slug := strings.TrimSuffix(name, ".md")
+	if slug == name || slug == "" || strings.HasPrefix(name, ".") {
+		return "", false
+	}
+	return slug, true
+}
+
+// slugify converts a title into a slug of lowercase ASCII letters and digits separated by hyphens.
+func slugify(title string) string {
+	var slug strings.Builder
+	hyphen := false
+	for _, r := range strings.ToLower(title) {
+		if ('a' <= r && r <= 'z') || ('0' <= r && r <= '9') {
+			if hyphen && slug.Len() > 0 {
+				slug.WriteByte('-')
+			}
+			hyphen = false
+			slug.WriteRune(r)
+			if slug.Len() >= maxSlugLength {
+				break
+			}
+		} else {
+			hyphen = true
+		}
+	}
+	if slug.Len() == 0 {
+		return "article"
+	}
+	return slug.String()
+}
+
+var _ repository.ArticleRepository = (*ArticleRepositoryFilesystem)(nil)
+var _ repository.ArticleStrictLister = (*ArticleRepositoryFilesystem)(nil)
@@ -0,0 +1,403 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Jason5Lee/simple-blog/core/data"
+	"github.com/Jason5Lee/simple-blog/core/errors"
+	"github.com/Jason5Lee/simple-blog/core/repository"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// gitArticlesDir is the directory of the article files in the git repository.
+const gitArticlesDir = "articles"
+
+// gitEmailDomain is the reserved domain of the emails in the commit signatures, which are not real addresses.
+const gitEmailDomain = "simple-blog.invalid"
+
+// ArticleRepositoryGit is an implementation of ArticleRepository storing each article as a Markdown file
+// with YAML front matter in a git repository, in the same format as ArticleRepositoryFilesystem.
+// Each create, update and delete is a commit authored by the actor carried by the context,
+// so the history can be browsed with `git log` and `git blame`, and the revisions are served from it.
+// A slug reused after a deletion continues the history of the deleted article.
+type ArticleRepositoryGit struct {
+	dir string
+	// mu guards the cache and the git repository, which is not safe for concurrent use,
+	// so the history is read under the write lock.
+	mu       sync.RWMutex
+	repo     *git.Repository
+	articles map[data.ArticleID]*filesystemArticle
+}
+
+// NewArticleRepositoryGit creates a new ArticleRepositoryGit opening the git repository in the directory,
+// which is initialized if missing, and loading the articles committed in HEAD.
+// The files that cannot be loaded are logged and skipped.
+func NewArticleRepositoryGit(dir string) (*ArticleRepositoryGit, error) {
+	repo, err := git.PlainOpen(dir)
+	if err == git.ErrRepositoryNotExists {
+		repo, err = git.PlainInit(dir, false)
+	}
+	if err != nil {
+		return nil, err
+	}
+	result := &ArticleRepositoryGit{
+		dir:      dir,
+		repo:     repo,
+		articles: make(map[data.ArticleID]*filesystemArticle),
+	}
+	head, err := result.head()
+	if err != nil || head == nil {
+		return result, err
+	}
+	tree, err := head.Tree()
+	if err != nil {
+		return nil, err
+	}
+	articlesTree, err := tree.Tree(gitArticlesDir)
+	if err == object.ErrDirectoryNotFound {
+		return result, nil
+	} else if err != nil {
+		return nil, err
+	}
+	err = articlesTree.Files().ForEach(func(file *object.File) error {
+		slug, ok := markdownSlug(file.Name)
+		if !ok || strings.Contains(file.Name, "/") {
+			return nil
+		}
+		entry, err := parseGitArticle(data.ArticleID(slug), file)
+		if err != nil {
+			log.Printf("skipping the article file %s: %v", path.Join(gitArticlesDir, file.Name), err)
+			return nil
+		}
+		result.articles[entry.article.ID] = entry
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (repo *ArticleRepositoryGit) Create(ctx context.Context, article *data.ArticleInfo) (data.ArticleID, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	base := slugify(string(article.Title))
+	slug := base
+	for i := 2; ; i++ {
+		if _, exists := repo.articles[data.ArticleID(slug)]; !exists {
+			// A file may exist without being loaded, such as an invalid one.
+			if _, err := os.Stat(filepath.Join(repo.dir, gitArticlesDir, slug+".md")); os.IsNotExist(err) {
+				break
+			} else if err != nil {
+				return "", err
+			}
+		}
+		slug = fmt.Sprintf("%s-%d", base, i)
+	}
+
+	now := time.Now().UTC()
+	entry := &filesystemArticle{article: data.Article{
+		ID:          data.ArticleID(slug),
+		ArticleInfo: copyArticleInfo(article),
+		Version:     data.FirstArticleVersion,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}}
+	if err := repo.commitArticle(ctx, entry, "Create "+slug); err != nil {
+		return "", err
+	}
+	repo.articles[entry.article.ID] = entry
+	return entry.article.ID, nil
+}
+
+func (repo *ArticleRepositoryGit) Update(ctx context.Context, id data.ArticleID, version data.ArticleVersion, article *data.ArticleInfo) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	existing, err := getFilesystemArticleVersion(repo.articles, id, version)
+	if err != nil {
+		return err
+	}
+	entry := &filesystemArticle{
+		article: data.Article{
+			ID:          id,
+			ArticleInfo: copyArticleInfo(article),
+			Version:     version + 1,
+			CreatedAt:   existing.article.CreatedAt,
+			UpdatedAt:   time.Now().UTC(),
+		},
+		tags: existing.tags,
+	}
+	if err := repo.commitArticle(ctx, entry, "Update "+string(id)); err != nil {
+		return err
+	}
+	repo.articles[id] = entry
+	return nil
+}
+
+func (repo *ArticleRepositoryGit) Delete(ctx context.Context, id data.ArticleID, version data.ArticleVersion) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	if _, err := getFilesystemArticleVersion(repo.articles, id, version); err != nil {
+		return err
+	}
+	worktree, err := repo.repo.Worktree()
+	if err != nil {
+		return err
+	}
+	if _, err := worktree.Remove(gitArticlePath(id)); err != nil {
+		return err
+	}
+	if err := repo.commit(ctx, worktree, "Delete "+string(id), time.Now().UTC()); err != nil {
+		return err
+	}
+	delete(repo.articles, id)
+	return nil
+}
+
+func (repo *ArticleRepositoryGit) GetByID(ctx context.Context, id data.ArticleID) (*data.Article, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+	entry, ok := repo.articles[id]
+	if !ok {
+		return nil, errors.ErrNotFound
+	}
+	return copyArticle(&entry.article), nil
+}
+
+// GetAll gets all articles, oldest first.
+func (repo *ArticleRepositoryGit) GetAll(ctx context.Context) ([]*data.Article, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return repo.filter(func(*data.Article) bool { return true }), nil
+}
+
+func (repo *ArticleRepositoryGit) GetByAuthor(ctx context.Context, authorID data.AuthorID) ([]*data.Article, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return repo.filter(func(article *data.Article) bool { return article.HasAuthor(authorID) }), nil
+}
+
+// filter gets the articles matching the predicate, ordered by creation time then slug.
+func (repo *ArticleRepositoryGit) filter(pred func(*data.Article) bool) []*data.Article {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+	return filterFilesystemArticles(repo.articles, pred)
+}
+
+func (repo *ArticleRepositoryGit) GetRevisions(ctx context.Context, id data.ArticleID) ([]*data.ArticleRevision, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if !isGitSlug(string(id)) {
+		// Invalid ID does not match any file, so we return ErrNotFound.
+		return nil, errors.ErrNotFound
+	}
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	head, err := repo.head()
+	if err != nil {
+		return nil, err
+	}
+	if head == nil {
+		return nil, errors.ErrNotFound
+	}
+	filePath := gitArticlePath(id)
+	commits, err := repo.repo.Log(&git.LogOptions{From: head.Hash, FileName: &filePath})
+	if err != nil {
+		return nil, err
+	}
+	result := []*data.ArticleRevision{}
+	err = commits.ForEach(func(commit *object.Commit) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		_, err := commit.File(filePath)
+		if err != nil && err != object.ErrFileNotFound {
+			return err
+		}
+		result = append(result, &data.ArticleRevision{
+			ID:        data.RevisionID(commit.Hash.String()),
+			Author:    commit.Author.Name,
+			Message:   commit.Message,
+			CreatedAt: commit.Author.When.UTC(),
+			Deleted:   err == object.ErrFileNotFound,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(result) == 0 {
+		return nil, errors.ErrNotFound
+	}
+	return result, nil
+}
+
+func (repo *ArticleRepositoryGit) GetRevision(ctx context.Context, id data.ArticleID, revision data.RevisionID) (*data.Article, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if !isGitSlug(string(id)) || !plumbing.IsHash(string(revision)) {
+		// Invalid ID or revision does not match any file, so we return ErrRevisionNotFound.
+		return nil, errors.ErrRevisionNotFound
+	}
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	commit, err := repo.repo.CommitObject(plumbing.NewHash(string(revision)))
+	if err == plumbing.ErrObjectNotFound {
+		return nil, errors.ErrRevisionNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	file, err := commit.File(gitArticlePath(id))
+	if err == object.ErrFileNotFound {
+		return nil, errors.ErrRevisionNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	entry, err := parseGitArticle(id, file)
+	if err != nil {
+		return nil, err
+	}
+	return &entry.article, nil
+}
+
+func (repo *ArticleRepositoryGit) EachRevisionText(ctx context.Context, f func(text string) error) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	head, err := repo.head()
+	if err != nil || head == nil {
+		return err
+	}
+	commits, err := repo.repo.Log(&git.LogOptions{From: head.Hash})
+	if err != nil {
+		return err
+	}
+	// Most commits change a single file, so the unchanged ones are only read once.
+	seen := make(map[plumbing.Hash]bool)
+	return commits.ForEach(func(commit *object.Commit) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		tree, err := commit.Tree()
+		if err != nil {
+			return err
+		}
+		articlesTree, err := tree.Tree(gitArticlesDir)
+		if err == object.ErrDirectoryNotFound {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		return articlesTree.Files().ForEach(func(file *object.File) error {
+			if seen[file.Hash] {
+				return nil
+			}
+			seen[file.Hash] = true
+			text, err := file.Contents()
+			if err != nil {
+				return err
+			}
+			return f(text)
+		})
+	})
+}
+
+// commitArticle writes the file of the article and commits it.
+func (repo *ArticleRepositoryGit) commitArticle(ctx context.Context, entry *filesystemArticle, message string) error {
+	content, err := formatMarkdownArticle(entry)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Join(repo.dir, gitArticlesDir), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(repo.dir, filepath.FromSlash(gitArticlePath(entry.article.ID))), content, 0644); err != nil {
+		return err
+	}
+	worktree, err := repo.repo.Worktree()
+	if err != nil {
+		return err
+	}
+	if _, err := worktree.Add(gitArticlePath(entry.article.ID)); err != nil {
+		return err
+	}
+	return repo.commit(ctx, worktree, message, entry.article.UpdatedAt)
+}
+
+// commit commits the staged change, authored by the actor carried by the context and committed by the blog.
+func (repo *ArticleRepositoryGit) commit(ctx context.Context, worktree *git.Worktree, message string, when time.Time) error {
+	committer := &object.Signature{Name: "simple-blog", Email: "simple-blog@" + gitEmailDomain, When: when}
+	author := committer
+	if actor := data.ActorFromContext(ctx); actor.Admin {
+		author = &object.Signature{Name: "admin", Email: "admin@" + gitEmailDomain, When: when}
+	} else if actor.AuthorID != "" {
+		name := "author-" + string(actor.AuthorID)
+		author = &object.Signature{Name: name, Email: name + "@" + gitEmailDomain, When: when}
+	}
+	// Every change stages a difference, but go-git reports a clean tree once the last article is removed.
+	_, err := worktree.Commit(message, &git.CommitOptions{Author: author, Committer: committer, AllowEmptyCommits: true})
+	return err
+}
+
+// head gets the commit of HEAD, or nil if there is no commit yet.
+func (repo *ArticleRepositoryGit) head() (*object.Commit, error) {
+	ref, err := repo.repo.Head()
+	if err == plumbing.ErrReferenceNotFound {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return repo.repo.CommitObject(ref.Hash())
+}
+
+func (repo *ArticleRepositoryGit) Close() error {
+	return nil
+}
+
+// parseGitArticle parses an article file in a commit.
+func parseGitArticle(id data.ArticleID, file *object.File) (*filesystemArticle, error) {
+	content, err := file.Contents()
+	if err != nil {
+		return nil, err
+	}
+	return parseMarkdownArticle(id, []byte(content))
+}
+
+// gitArticlePath returns the path of the article file in the git repository, which uses slashes.
+func gitArticlePath(id data.ArticleID) string {
+	return path.Join(gitArticlesDir, string(id)+".md")
+}
+
+// isGitSlug returns whether the slug is the name of an article file in the articles directory, without escaping it.
+func isGitSlug(slug string) bool {
+	_, ok := markdownSlug(slug + ".md")
+	return ok && !strings.ContainsAny(slug, `/\`)
+}
+
+var _ repository.ArticleRepository = (*ArticleRepositoryGit)(nil)
+var _ repository.ArticleHistory = (*ArticleRepositoryGit)(nil)
@@ -3,6 +3,11 @@ package repository
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/Jason5Lee/simple-blog/core/data"
 	"github.com/Jason5Lee/simple-blog/core/errors"
@@ -11,41 +16,188 @@ import (
 
 // In-memory implementation of ArticleRepository.
 type ArticleRepositoryInMemory struct {
-	articles map[data.ArticleID]*data.ArticleInfo
+	mu       sync.RWMutex
+	nextID   int
+	articles map[data.ArticleID]*data.Article
 }
 
 func NewArticleRepositoryInMemory() *ArticleRepositoryInMemory {
 	return &ArticleRepositoryInMemory{
-		articles: make(map[data.ArticleID]*data.ArticleInfo),
+		articles: make(map[data.ArticleID]*data.Article),
 	}
 }
 
 func (r *ArticleRepositoryInMemory) Create(ctx context.Context, article *data.ArticleInfo) (data.ArticleID, error) {
-	id := data.ArticleID(fmt.Sprint(len(r.articles) + 1))
-	r.articles[id] = article
+	now := time.Now()
+	return r.create(ctx, article, data.FirstArticleVersion, now, now)
+}
+
+func (r *ArticleRepositoryInMemory) Import(ctx context.Context, article *data.Article) (data.ArticleID, error) {
+	return r.create(ctx, &article.ArticleInfo, article.Version, article.CreatedAt, article.UpdatedAt)
+}
+
+func (r *ArticleRepositoryInMemory) create(ctx context.Context, article *data.ArticleInfo, version data.ArticleVersion, createdAt time.Time, updatedAt time.Time) (data.ArticleID, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.nextID++
+	id := data.ArticleID(fmt.Sprint(r.nextID))
+	r.articles[id] = &data.Article{
+		ID:          id,
+		ArticleInfo: copyArticleInfo(article),
+		Version:     version,
+		CreatedAt:   createdAt,
+		UpdatedAt:   updatedAt,
+	}
 	return id, nil
 }
 
+func (r *ArticleRepositoryInMemory) Update(ctx context.Context, id data.ArticleID, version data.ArticleVersion, article *data.ArticleInfo) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	existing, err := r.getVersion(id, version)
+	if err != nil {
+		return err
+	}
+	existing.ArticleInfo = copyArticleInfo(article)
+	existing.Version++
+	existing.UpdatedAt = time.Now()
+	return nil
+}
+
+func (r *ArticleRepositoryInMemory) Delete(ctx context.Context, id data.ArticleID, version data.ArticleVersion) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, err := r.getVersion(id, version); err != nil {
+		return err
+	}
+	delete(r.articles, id)
+	return nil
+}
+
+// getVersion gets the stored article if it has the version. The caller must hold the lock.
+func (r *ArticleRepositoryInMemory) getVersion(id data.ArticleID, version data.ArticleVersion) (*data.Article, error) {
+	existing, ok := r.articles[id]
+	if !ok {
+		return nil, errors.ErrNotFound
+	}
+	if existing.Version != version {
+		return nil, errors.ErrConflict
+	}
+	return existing, nil
+}
+
 func (r *ArticleRepositoryInMemory) GetByID(ctx context.Context, id data.ArticleID) (*data.Article, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
 	article, ok := r.articles[id]
 	if !ok {
 		return nil, errors.ErrNotFound
 	}
-	return &data.Article{
-		ID:          id,
-		ArticleInfo: *article,
-	}, nil
+	return copyArticle(article), nil
 }
 
 func (r *ArticleRepositoryInMemory) GetAll(ctx context.Context) ([]*data.Article, error) {
-	articles := make([]*data.Article, 0, len(r.articles))
-	for id, article := range r.articles {
-		articles = append(articles, &data.Article{
-			ID:          id,
-			ArticleInfo: *article,
-		})
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return r.filter(func(*data.Article) bool { return true }), nil
+}
+
+func (r *ArticleRepositoryInMemory) GetByAuthor(ctx context.Context, authorID data.AuthorID) ([]*data.Article, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return r.filter(func(article *data.Article) bool { return article.HasAuthor(authorID) }), nil
+}
+
+// Search matches the articles whose title or content contains all the terms, ignoring case.
+// It does not rank the articles.
+func (r *ArticleRepositoryInMemory) Search(ctx context.Context, query data.SearchQuery) ([]*data.Article, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	terms := query.Terms()
+	for i, term := range terms {
+		terms[i] = strings.ToLower(term)
+	}
+	return r.filter(func(article *data.Article) bool {
+		title := strings.ToLower(string(article.Title))
+		content := strings.ToLower(string(article.Content))
+		for _, term := range terms {
+			if !strings.Contains(title, term) && !strings.Contains(content, term) {
+				return false
+			}
+		}
+		return true
+	}), nil
+}
+
+func (r *ArticleRepositoryInMemory) GetPage(ctx context.Context, after data.ArticleID, limit data.PageLimit) ([]*data.Article, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	afterID := 0
+	if after != "" {
+		var err error
+		if afterID, err = strconv.Atoi(string(after)); err != nil {
+			return nil, errors.ErrInvalidPageCursor
+		}
+	}
+	articles := r.filter(func(article *data.Article) bool {
+		id, _ := strconv.Atoi(string(article.ID))
+		return id > afterID
+	})
+	if len(articles) > int(limit) {
+		articles = articles[:limit]
 	}
 	return articles, nil
 }
 
+// filter gets the articles matching the predicate, oldest first.
+func (r *ArticleRepositoryInMemory) filter(pred func(*data.Article) bool) []*data.Article {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	articles := make([]*data.Article, 0, len(r.articles))
+	for _, article := range r.articles {
+		if pred(article) {
+			articles = append(articles, copyArticle(article))
+		}
+	}
+	// The IDs are sequence numbers, so their numeric order is the creation order.
+	sort.Slice(articles, func(i, j int) bool {
+		a, _ := strconv.Atoi(string(articles[i].ID))
+		b, _ := strconv.Atoi(string(articles[j].ID))
+		return a < b
+	})
+	return articles
+}
+
+// copyArticleInfo copies the article information so the stored article is not shared with the caller.
+func copyArticleInfo(article *data.ArticleInfo) data.ArticleInfo {
+	result := *article
+	result.AuthorIDs = append([]data.AuthorID(nil), article.AuthorIDs...)
+	return result
+}
+
+func copyArticle(article *data.Article) *data.Article {
+	result := *article
+	result.ArticleInfo = copyArticleInfo(&article.ArticleInfo)
+	return &result
+}
+
 var _ repository.ArticleRepository = (*ArticleRepositoryInMemory)(nil)
+var _ repository.ArticleSearcher = (*ArticleRepositoryInMemory)(nil)
+var _ repository.ArticlePager = (*ArticleRepositoryInMemory)(nil)
+var _ repository.ArticleImporter = (*ArticleRepositoryInMemory)(nil)
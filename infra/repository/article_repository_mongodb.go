@@ -2,56 +2,141 @@ package repository
 
 import (
 	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
 
 	"github.com/Jason5Lee/simple-blog/core/data"
 	"github.com/Jason5Lee/simple-blog/core/errors"
 	"github.com/Jason5Lee/simple-blog/core/repository"
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
 )
 
 // Data for inserting into MongoDB.
 type DBArticleInfo struct {
-	Title   string `bson:"title"`
-	Content string `bson:"content"`
-	Author  string `bson:"author"`
+	Title     string    `bson:"title"`
+	Content   string    `bson:"content"`
+	AuthorIDs []string  `bson:"author_ids"`
+	Version   int64     `bson:"version"`
+	CreatedAt time.Time `bson:"created_at"`
+	UpdatedAt time.Time `bson:"updated_at"`
+	// SchemaVersion is the version of the migrations applied to the document, 0 for those written before them.
+	SchemaVersion int `bson:"schema_version"`
 }
 
 // Data for reading from MongoDB, with extra field "_id".
 type DBArticle struct {
-	ID      primitive.ObjectID `bson:"_id"`
-	Title   string             `bson:"title"`
-	Content string             `bson:"content"`
-	Author  string             `bson:"author"`
+	ID            primitive.ObjectID `bson:"_id"`
+	DBArticleInfo `bson:",inline"`
 }
 
 // ArticleRepositoryMongoDB is a MongoDB implementation of ArticleRepository.
 type ArticleRepositoryMongoDB struct {
-	client *mongo.Client
+	client            *mongo.Client
+	db                *mongo.Database
+	articleCollection string
+}
+
+// MongoDBOptions configures the connection shared by the MongoDB repositories. The zero values keep the defaults
+// of the driver, or of the URI.
+type MongoDBOptions struct {
+	// Database is the name of the database, "simple-blog" if empty.
+	Database string
+	// ArticleCollection is the name of the article collection, "articles" if empty.
+	ArticleCollection string
+	MinPoolSize       uint64
+	MaxPoolSize       uint64
+	ReadPreference    *readpref.ReadPref
+	WriteConcern      *writeconcern.WriteConcern
+	// ServerSelectionTimeout is how long an operation waits for a suitable server, including the ping at startup.
+	ServerSelectionTimeout time.Duration
+	ConnectTimeout         time.Duration
+	// OperationTimeout bounds each operation, including its retries.
+	OperationTimeout time.Duration
 }
 
 const dbName = "simple-blog"
+
+// collectionName is the default name of the article collection, and the name referring to it
+// in the schema and the migrations.
 const collectionName = "articles"
 
-// NewArticleRepositoryMongoDB creates a new ArticleRepositoryMongoDB connecting to a MongoDB.
-func NewArticleRepositoryMongoDB(mongoUri string) (*ArticleRepositoryMongoDB, error) {
-	client, err := mongo.NewClient(options.Client().ApplyURI(mongoUri))
+// NewArticleRepositoryMongoDB creates a new ArticleRepositoryMongoDB connecting to a MongoDB, failing
+// if it cannot be reached.
+// The indexes, including the text index used by Search, are created by EnsureSchemaMongoDB.
+func NewArticleRepositoryMongoDB(ctx context.Context, mongoUri string, opts *MongoDBOptions) (*ArticleRepositoryMongoDB, error) {
+	clientOptions := options.Client().ApplyURI(mongoUri)
+	if opts.MinPoolSize > 0 {
+		clientOptions.SetMinPoolSize(opts.MinPoolSize)
+	}
+	if opts.MaxPoolSize > 0 {
+		clientOptions.SetMaxPoolSize(opts.MaxPoolSize)
+	}
+	if opts.ReadPreference != nil {
+		clientOptions.SetReadPreference(opts.ReadPreference)
+	}
+	if opts.WriteConcern != nil {
+		clientOptions.SetWriteConcern(opts.WriteConcern)
+	}
+	if opts.ServerSelectionTimeout > 0 {
+		clientOptions.SetServerSelectionTimeout(opts.ServerSelectionTimeout)
+	}
+	if opts.ConnectTimeout > 0 {
+		clientOptions.SetConnectTimeout(opts.ConnectTimeout)
+	}
+	if opts.OperationTimeout > 0 {
+		clientOptions.SetTimeout(opts.OperationTimeout)
+	}
+	client, err := mongo.Connect(ctx, clientOptions)
 	if err != nil {
 		return nil, err
 	}
-	if err := client.Connect(context.Background()); err != nil {
-		return nil, err
+	// Connect does not wait for the servers, so an unreachable database would only fail the first requests.
+	if err := client.Ping(ctx, nil); err != nil {
+		_ = client.Disconnect(context.Background())
+		return nil, fmt.Errorf("MongoDB is unreachable: %w", err)
+	}
+
+	database := opts.Database
+	if database == "" {
+		database = dbName
+	}
+	articleCollection := opts.ArticleCollection
+	if articleCollection == "" {
+		articleCollection = collectionName
 	}
+	return &ArticleRepositoryMongoDB{client: client, db: client.Database(database), articleCollection: articleCollection}, nil
+}
+
+func (repo *ArticleRepositoryMongoDB) collection() *mongo.Collection {
+	return repo.db.Collection(repo.articleCollection)
+}
 
-	return &ArticleRepositoryMongoDB{client: client}, nil
+// collectionNamed returns the collection with the name used by the schema and the migrations,
+// which is the configured one for the articles.
+func (repo *ArticleRepositoryMongoDB) collectionNamed(name string) *mongo.Collection {
+	if name == collectionName {
+		return repo.collection()
+	}
+	return repo.db.Collection(name)
 }
 
 func (repo *ArticleRepositoryMongoDB) Create(ctx context.Context, article *data.ArticleInfo) (data.ArticleID, error) {
-	insertResult, err := repo.client.Database(dbName).Collection(collectionName).InsertOne(ctx, DBArticleInfo{
-		Title:   string(article.Title),
-		Content: string(article.Content),
-		Author:  string(article.Author),
+	now := time.Now()
+	insertResult, err := repo.collection().InsertOne(ctx, DBArticleInfo{
+		Title:         string(article.Title),
+		Content:       string(article.Content),
+		AuthorIDs:     authorIDStrings(article.AuthorIDs),
+		Version:       int64(data.FirstArticleVersion),
+		CreatedAt:     now,
+		UpdatedAt:     now,
+		SchemaVersion: articleSchemaVersion,
 	})
 	if err != nil {
 		return "", err
@@ -59,61 +144,315 @@ func (repo *ArticleRepositoryMongoDB) Create(ctx context.Context, article *data.
 	return data.ArticleID(insertResult.InsertedID.(primitive.ObjectID).Hex()), nil
 }
 
-func (repo *ArticleRepositoryMongoDB) GetByID(ctx context.Context, id data.ArticleID) (*data.Article, error) {
-	docID, err := primitive.ObjectIDFromHex(string(id))
+func (repo *ArticleRepositoryMongoDB) Import(ctx context.Context, article *data.Article) (data.ArticleID, error) {
+	// The ObjectID starts with the creation time, so the creation order is kept.
+	docID := primitive.NewObjectIDFromTimestamp(article.CreatedAt)
+	_, err := repo.collection().InsertOne(ctx, DBArticle{
+		ID: docID,
+		DBArticleInfo: DBArticleInfo{
+			Title:         string(article.Title),
+			Content:       string(article.Content),
+			AuthorIDs:     authorIDStrings(article.AuthorIDs),
+			Version:       int64(article.Version),
+			CreatedAt:     article.CreatedAt,
+			UpdatedAt:     article.UpdatedAt,
+			SchemaVersion: articleSchemaVersion,
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+	return data.ArticleID(docID.Hex()), nil
+}
+
+func (repo *ArticleRepositoryMongoDB) Update(ctx context.Context, id data.ArticleID, version data.ArticleVersion, article *data.ArticleInfo) error {
+	docID, ok := parseObjectID(string(id))
+	if !ok {
+		// Invalid ID does not match any document, so we return ErrNotFound.
+		return errors.ErrNotFound
+	}
+	// The version is set rather than incremented, as it is missing from a document not upgraded yet.
+	updateResult, err := repo.collection().UpdateOne(ctx, mongoDBVersionFilter(docID, version), bson.M{"$set": bson.M{
+		"title":      string(article.Title),
+		"content":    string(article.Content),
+		"author_ids": authorIDStrings(article.AuthorIDs),
+		"version":    int64(version + 1),
+		"updated_at": time.Now(),
+	}})
+	if err != nil {
+		return err
+	}
+	if updateResult.MatchedCount == 0 {
+		return repo.notChanged(ctx, docID)
+	}
+	return nil
+}
+
+func (repo *ArticleRepositoryMongoDB) Delete(ctx context.Context, id data.ArticleID, version data.ArticleVersion) error {
+	docID, ok := parseObjectID(string(id))
+	if !ok {
+		// Invalid ID does not match any document, so we return ErrNotFound.
+		return errors.ErrNotFound
+	}
+	deleteResult, err := repo.collection().DeleteOne(ctx, mongoDBVersionFilter(docID, version))
+	if err != nil {
+		return err
+	}
+	if deleteResult.DeletedCount == 0 {
+		return repo.notChanged(ctx, docID)
+	}
+	return nil
+}
+
+// mongoDBVersionFilter matches the article document of the version. A document without version,
+// written before the versions and not upgraded yet, has the first version.
+func mongoDBVersionFilter(docID primitive.ObjectID, version data.ArticleVersion) bson.M {
+	if version == data.FirstArticleVersion {
+		return bson.M{"_id": docID, "$or": bson.A{
+			bson.M{"version": int64(version)},
+			bson.M{"version": bson.M{"$exists": false}},
+		}}
+	}
+	return bson.M{"_id": docID, "version": int64(version)}
+}
+
+// notChanged returns why the article document of the version was not changed, i.e. it does not exist
+// or it has another version.
+func (repo *ArticleRepositoryMongoDB) notChanged(ctx context.Context, docID primitive.ObjectID) error {
+	n, err := repo.collection().CountDocuments(ctx, bson.M{"_id": docID}, options.Count().SetLimit(1))
 	if err != nil {
+		return err
+	}
+	if n > 0 {
+		return errors.ErrConflict
+	}
+	return errors.ErrNotFound
+}
+
+func (repo *ArticleRepositoryMongoDB) GetByID(ctx context.Context, id data.ArticleID) (*data.Article, error) {
+	docID, ok := parseObjectID(string(id))
+	if !ok {
 		// Invalid ID does not match any document, so we return ErrNotFound.
 		return nil, errors.ErrNotFound
 	}
-	findResult := repo.client.Database(dbName).Collection(collectionName).FindOne(ctx, map[string]interface{}{"_id": docID})
+	findResult := repo.collection().FindOne(ctx, bson.M{"_id": docID})
 	if err := findResult.Err(); err != nil {
 		if err == mongo.ErrNoDocuments {
 			return nil, errors.ErrNotFound
 		}
 		return nil, err
 	}
-	var article DBArticle
-	if err := findResult.Decode(&article); err != nil {
+	raw, err := findResult.DecodeBytes()
+	if err != nil {
 		return nil, err
 	}
-	return &data.Article{
-		ID: id,
-		// Assume the data in MongoDB is valid.
-		ArticleInfo: data.ArticleInfo{
-			Title:   data.ArticleTitle(article.Title),
-			Content: data.ArticleContent(article.Content),
-			Author:  data.ArticleAuthor(article.Author),
-		},
-	}, nil
+	return repo.decode(ctx, raw)
 }
 
+// GetAll gets all articles, oldest first.
 func (repo *ArticleRepositoryMongoDB) GetAll(ctx context.Context) ([]*data.Article, error) {
-	cursor, err := repo.client.Database(dbName).Collection(collectionName).Find(ctx, map[string]interface{}{})
+	return repo.find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+}
+
+func (repo *ArticleRepositoryMongoDB) GetAllStrict(ctx context.Context) ([]*data.Article, error) {
+	docs, err := repo.findRaw(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
 	if err != nil {
 		return nil, err
 	}
-	var articles []*DBArticle
-	if err := cursor.All(ctx, &articles); err != nil {
+	result := make([]*data.Article, len(docs))
+	for i, doc := range docs {
+		if result[i], err = repo.decode(ctx, doc); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+func (repo *ArticleRepositoryMongoDB) GetByAuthor(ctx context.Context, authorID data.AuthorID) ([]*data.Article, error) {
+	// Matching a value against an array field matches the documents whose array contains it.
+	return repo.find(ctx, bson.M{"author_ids": string(authorID)}, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+}
+
+func (repo *ArticleRepositoryMongoDB) GetPage(ctx context.Context, after data.ArticleID, limit data.PageLimit) ([]*data.Article, error) {
+	filter := bson.M{}
+	if after != "" {
+		afterID, ok := parseObjectID(string(after))
+		if !ok {
+			return nil, errors.ErrInvalidPageCursor
+		}
+		filter["_id"] = bson.M{"$gt": afterID}
+	}
+	// The documents following the skipped corrupt ones are read, so a page is only short if it is the last one.
+	result := make([]*data.Article, 0, int(limit))
+	for len(result) < int(limit) {
+		missing := int(limit) - len(result)
+		// ObjectIDs start with their creation time, so their order is the creation order.
+		docs, err := repo.findRaw(ctx, filter, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}).SetLimit(int64(missing)))
+		if err != nil {
+			return nil, err
+		}
+		articles, err := repo.decodeAll(ctx, docs)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, articles...)
+		if len(docs) < missing {
+			break
+		}
+		filter["_id"] = bson.M{"$gt": docs[len(docs)-1].Lookup("_id")}
+	}
+	return result, nil
+}
+
+// Search matches the articles containing all the terms with the text index, highest text score first.
+func (repo *ArticleRepositoryMongoDB) Search(ctx context.Context, query data.SearchQuery) ([]*data.Article, error) {
+	// Quoting every term makes `$text` match all of them instead of any.
+	terms := query.Terms()
+	for i, term := range terms {
+		terms[i] = `"` + strings.ReplaceAll(term, `"`, "") + `"`
+	}
+	return repo.find(ctx, bson.M{"$text": bson.M{"$search": strings.Join(terms, " ")}},
+		options.Find().SetSort(bson.M{"score": bson.M{"$meta": "textScore"}}))
+}
+
+func (repo *ArticleRepositoryMongoDB) find(ctx context.Context, filter interface{}, opts ...*options.FindOptions) ([]*data.Article, error) {
+	docs, err := repo.findRaw(ctx, filter, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return repo.decodeAll(ctx, docs)
+}
+
+func (repo *ArticleRepositoryMongoDB) findRaw(ctx context.Context, filter interface{}, opts ...*options.FindOptions) ([]bson.Raw, error) {
+	cursor, err := repo.collection().Find(ctx, filter, opts...)
+	if err != nil {
+		return nil, err
+	}
+	var docs []bson.Raw
+	if err := cursor.All(ctx, &docs); err != nil {
 		return nil, err
 	}
-	result := make([]*data.Article, len(articles))
-	for i, article := range articles {
-		result[i] = &data.Article{
-			ID: data.ArticleID(article.ID.Hex()),
-			// Assume the data in MongoDB is valid.
-			ArticleInfo: data.ArticleInfo{
-				Title:   data.ArticleTitle(article.Title),
-				Content: data.ArticleContent(article.Content),
-				Author:  data.ArticleAuthor(article.Author),
-			},
+	return docs, nil
+}
+
+// decodeAll decodes the article documents, logging and skipping the corrupt ones, so a single corrupt document
+// does not fail the lists of articles. Only reading it by ID fails.
+func (repo *ArticleRepositoryMongoDB) decodeAll(ctx context.Context, docs []bson.Raw) ([]*data.Article, error) {
+	result := make([]*data.Article, 0, len(docs))
+	for _, doc := range docs {
+		article, err := repo.decode(ctx, doc)
+		if corruptErr, ok := err.(*errors.CorruptDocumentError); ok {
+			log.Printf("skipping the article: %v", corruptErr)
+			continue
+		}
+		if err != nil {
+			return nil, err
 		}
+		result = append(result, article)
 	}
 	return result, nil
 }
 
-// Dropping the collection for integration testing.
+// decode decodes an article document, upgrading it first if it was written by an older version
+// and not migrated yet, and storing the upgraded document.
+func (repo *ArticleRepositoryMongoDB) decode(ctx context.Context, raw bson.Raw) (*data.Article, error) {
+	var article DBArticle
+	if err := bson.Unmarshal(raw, &article); err != nil {
+		return nil, &errors.CorruptDocumentError{ID: mongoDBDocumentID(raw), Err: err}
+	}
+	if article.SchemaVersion >= articleSchemaVersion {
+		return article.toArticle()
+	}
+
+	var doc bson.M
+	if err := bson.Unmarshal(raw, &doc); err != nil {
+		return nil, err
+	}
+	update, err := upgradeMongoDBDocument(ctx, repo.db, collectionName, articleSchemaVersion, doc)
+	if err != nil {
+		return nil, err
+	}
+	// If the document has been upgraded concurrently, the update matches nothing and the result is the same.
+	if _, err := repo.collection().UpdateOne(ctx, update.filter, update.update); err != nil {
+		return nil, err
+	}
+	upgraded, err := bson.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	article = DBArticle{}
+	if err := bson.Unmarshal(upgraded, &article); err != nil {
+		return nil, &errors.CorruptDocumentError{ID: mongoDBDocumentID(raw), Err: err}
+	}
+	return article.toArticle()
+}
+
+// mongoDBDocumentID returns the ID of the document as a string, the hex of an ObjectID.
+func mongoDBDocumentID(raw bson.Raw) string {
+	id, err := raw.LookupErr("_id")
+	if err != nil {
+		return ""
+	}
+	if objectID, ok := id.ObjectIDOK(); ok {
+		return objectID.Hex()
+	}
+	return id.String()
+}
+
+// parseObjectID parses an ID into an ObjectID, returning false unless it is the lowercase hex returned by Create,
+// so an article has a single ID.
+func parseObjectID(id string) (primitive.ObjectID, bool) {
+	docID, err := primitive.ObjectIDFromHex(id)
+	if err != nil || docID.Hex() != id {
+		return primitive.NilObjectID, false
+	}
+	return docID, true
+}
+
+// toArticle validates the document like the input of Create, as it may have been written bypassing the validation,
+// returning a CorruptDocumentError if it is invalid.
+func (article *DBArticle) toArticle() (*data.Article, error) {
+	id := article.ID.Hex()
+	title, err := data.NewArticleTitle(article.Title)
+	if err != nil {
+		return nil, &errors.CorruptDocumentError{ID: id, Err: err}
+	}
+	content, err := data.NewArticleContent(article.Content)
+	if err != nil {
+		return nil, &errors.CorruptDocumentError{ID: id, Err: err}
+	}
+	authorIDs, err := data.NewArticleAuthors(article.AuthorIDs)
+	if err != nil {
+		return nil, &errors.CorruptDocumentError{ID: id, Err: err}
+	}
+	return &data.Article{
+		ID: data.ArticleID(id),
+		ArticleInfo: data.ArticleInfo{
+			Title:     title,
+			Content:   content,
+			AuthorIDs: authorIDs,
+		},
+		Version:   data.ArticleVersion(article.Version),
+		CreatedAt: article.CreatedAt,
+		UpdatedAt: article.UpdatedAt,
+	}, nil
+}
+
+func authorIDStrings(ids []data.AuthorID) []string {
+	result := make([]string, len(ids))
+	for i, id := range ids {
+		result[i] = string(id)
+	}
+	return result
+}
+
+// Dropping the collection for integration testing. The schema is recreated, as Search requires the text index.
 func (repo *ArticleRepositoryMongoDB) Drop() error {
-	return repo.client.Database(dbName).Collection(collectionName).Drop(context.Background())
+	if err := repo.collection().Drop(context.Background()); err != nil {
+		return err
+	}
+	_, err := EnsureSchemaMongoDB(context.Background(), repo, false)
+	return err
 }
 
 func (repo *ArticleRepositoryMongoDB) Close() error {
@@ -121,3 +460,7 @@ func (repo *ArticleRepositoryMongoDB) Close() error {
 }
 
 var _ repository.ArticleRepository = (*ArticleRepositoryMongoDB)(nil)
+var _ repository.ArticleSearcher = (*ArticleRepositoryMongoDB)(nil)
+var _ repository.ArticlePager = (*ArticleRepositoryMongoDB)(nil)
+var _ repository.ArticleImporter = (*ArticleRepositoryMongoDB)(nil)
+var _ repository.ArticleStrictLister = (*ArticleRepositoryMongoDB)(nil)
@@ -0,0 +1,195 @@
+package repository
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/Jason5Lee/simple-blog/core/data"
+	"github.com/Jason5Lee/simple-blog/core/errors"
+	"github.com/Jason5Lee/simple-blog/core/repository"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ArticleRepositoryPostgres is a PostgreSQL implementation of ArticleRepository.
+// The authors of an article are an array column, and the articles are indexed by a generated tsvector column for Search.
+type ArticleRepositoryPostgres struct {
+	pool *pgxpool.Pool
+}
+
+// NewArticleRepositoryPostgres creates a new ArticleRepositoryPostgres with a connection pool to a PostgreSQL,
+// applying the schema migrations if needed. The pool is configured with the `pool_*` parameters of the URI.
+func NewArticleRepositoryPostgres(postgresUri string) (*ArticleRepositoryPostgres, error) {
+	pool, err := pgxpool.New(context.Background(), postgresUri)
+	if err != nil {
+		return nil, err
+	}
+	if err := migratePostgres(context.Background(), pool); err != nil {
+		pool.Close()
+		return nil, err
+	}
+	return &ArticleRepositoryPostgres{pool: pool}, nil
+}
+
+func (repo *ArticleRepositoryPostgres) Create(ctx context.Context, article *data.ArticleInfo) (data.ArticleID, error) {
+	now := time.Now()
+	return repo.create(ctx, article, data.FirstArticleVersion, now, now)
+}
+
+func (repo *ArticleRepositoryPostgres) Import(ctx context.Context, article *data.Article) (data.ArticleID, error) {
+	return repo.create(ctx, &article.ArticleInfo, article.Version, article.CreatedAt, article.UpdatedAt)
+}
+
+func (repo *ArticleRepositoryPostgres) create(ctx context.Context, article *data.ArticleInfo, version data.ArticleVersion, createdAt time.Time, updatedAt time.Time) (data.ArticleID, error) {
+	var rowID int64
+	err := repo.pool.QueryRow(ctx,
+		"INSERT INTO articles (title, content, author_ids, version, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id",
+		string(article.Title), string(article.Content), authorIDStrings(article.AuthorIDs), int64(version), createdAt, updatedAt,
+	).Scan(&rowID)
+	if err != nil {
+		return "", err
+	}
+	return data.ArticleID(strconv.FormatInt(rowID, 10)), nil
+}
+
+func (repo *ArticleRepositoryPostgres) Update(ctx context.Context, id data.ArticleID, version data.ArticleVersion, article *data.ArticleInfo) error {
+	rowID, ok := parseRowID(string(id))
+	if !ok {
+		// Invalid ID does not match any row, so we return ErrNotFound.
+		return errors.ErrNotFound
+	}
+	tag, err := repo.pool.Exec(ctx,
+		"UPDATE articles SET title = $1, content = $2, author_ids = $3, version = version + 1, updated_at = $4 WHERE id = $5 AND version = $6",
+		string(article.Title), string(article.Content), authorIDStrings(article.AuthorIDs), time.Now(), rowID, int64(version))
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return repo.notChanged(ctx, rowID)
+	}
+	return nil
+}
+
+func (repo *ArticleRepositoryPostgres) Delete(ctx context.Context, id data.ArticleID, version data.ArticleVersion) error {
+	rowID, ok := parseRowID(string(id))
+	if !ok {
+		// Invalid ID does not match any row, so we return ErrNotFound.
+		return errors.ErrNotFound
+	}
+	tag, err := repo.pool.Exec(ctx, "DELETE FROM articles WHERE id = $1 AND version = $2", rowID, int64(version))
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return repo.notChanged(ctx, rowID)
+	}
+	return nil
+}
+
+// notChanged returns why the article of the version was not changed, i.e. it does not exist or it has another version.
+// The article may have been deleted since, in which case it is not found.
+func (repo *ArticleRepositoryPostgres) notChanged(ctx context.Context, rowID int64) error {
+	var exists bool
+	if err := repo.pool.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM articles WHERE id = $1)", rowID).Scan(&exists); err != nil {
+		return err
+	}
+	if exists {
+		return errors.ErrConflict
+	}
+	return errors.ErrNotFound
+}
+
+func (repo *ArticleRepositoryPostgres) GetByID(ctx context.Context, id data.ArticleID) (*data.Article, error) {
+	rowID, ok := parseRowID(string(id))
+	if !ok {
+		// Invalid ID does not match any row, so we return ErrNotFound.
+		return nil, errors.ErrNotFound
+	}
+	articles, err := repo.find(ctx, "WHERE id = $1", rowID)
+	if err != nil {
+		return nil, err
+	}
+	if len(articles) == 0 {
+		return nil, errors.ErrNotFound
+	}
+	return articles[0], nil
+}
+
+func (repo *ArticleRepositoryPostgres) GetAll(ctx context.Context) ([]*data.Article, error) {
+	return repo.find(ctx, "ORDER BY id")
+}
+
+func (repo *ArticleRepositoryPostgres) GetByAuthor(ctx context.Context, authorID data.AuthorID) ([]*data.Article, error) {
+	// The containment operator uses the GIN index of the array.
+	return repo.find(ctx, "WHERE author_ids @> ARRAY[$1::TEXT] ORDER BY id", string(authorID))
+}
+
+func (repo *ArticleRepositoryPostgres) GetPage(ctx context.Context, after data.ArticleID, limit data.PageLimit) ([]*data.Article, error) {
+	var afterID int64
+	if after != "" {
+		var ok bool
+		if afterID, ok = parseRowID(string(after)); !ok {
+			return nil, errors.ErrInvalidPageCursor
+		}
+	}
+	// The identity IDs are increasing, so their order is the creation order.
+	return repo.find(ctx, "WHERE id > $1 ORDER BY id LIMIT $2", afterID, int(limit))
+}
+
+// Search matches the articles containing all the terms with the tsvector index, highest rank first.
+// The terms are stemmed and the stop words are ignored.
+func (repo *ArticleRepositoryPostgres) Search(ctx context.Context, query data.SearchQuery) ([]*data.Article, error) {
+	// plainto_tsquery ignores the syntax of tsquery, so the query cannot fail to parse.
+	return repo.find(ctx, "WHERE search @@ plainto_tsquery('english', $1) ORDER BY ts_rank(search, plainto_tsquery('english', $1)) DESC, id",
+		string(query))
+}
+
+// find gets the articles with the clause following "FROM articles", such as the conditions and the order.
+func (repo *ArticleRepositoryPostgres) find(ctx context.Context, clause string, args ...interface{}) ([]*data.Article, error) {
+	rows, err := repo.pool.Query(ctx, "SELECT id, title, content, author_ids, version, created_at, updated_at FROM articles "+clause, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []*data.Article{}
+	for rows.Next() {
+		var rowID, version int64
+		var title, content string
+		var authorIDs []string
+		article := &data.Article{}
+		if err := rows.Scan(&rowID, &title, &content, &authorIDs, &version, &article.CreatedAt, &article.UpdatedAt); err != nil {
+			return nil, err
+		}
+		article.Version = data.ArticleVersion(version)
+		article.ID = data.ArticleID(strconv.FormatInt(rowID, 10))
+		// Assume the data in PostgreSQL is valid.
+		article.ArticleInfo = data.ArticleInfo{
+			Title:     data.ArticleTitle(title),
+			Content:   data.ArticleContent(content),
+			AuthorIDs: make([]data.AuthorID, len(authorIDs)),
+		}
+		for i, authorID := range authorIDs {
+			article.AuthorIDs[i] = data.AuthorID(authorID)
+		}
+		result = append(result, article)
+	}
+	return result, rows.Err()
+}
+
+// Truncating the tables for integration testing.
+func (repo *ArticleRepositoryPostgres) Truncate() error {
+	_, err := repo.pool.Exec(context.Background(),
+		"TRUNCATE articles, authors, comments, reactions, article_views, series RESTART IDENTITY")
+	return err
+}
+
+func (repo *ArticleRepositoryPostgres) Close() error {
+	repo.pool.Close()
+	return nil
+}
+
+var _ repository.ArticleRepository = (*ArticleRepositoryPostgres)(nil)
+var _ repository.ArticleSearcher = (*ArticleRepositoryPostgres)(nil)
+var _ repository.ArticlePager = (*ArticleRepositoryPostgres)(nil)
+var _ repository.ArticleImporter = (*ArticleRepositoryPostgres)(nil)
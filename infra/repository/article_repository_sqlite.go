@@ -0,0 +1,270 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Jason5Lee/simple-blog/core/data"
+	"github.com/Jason5Lee/simple-blog/core/errors"
+	"github.com/Jason5Lee/simple-blog/core/repository"
+	_ "modernc.org/sqlite"
+)
+
+// ArticleRepositorySQLite is a SQLite implementation of ArticleRepository.
+// The authors of an article are rows of a separate table, and the articles are indexed by an FTS5 table for Search.
+type ArticleRepositorySQLite struct {
+	db *sql.DB
+}
+
+// The layout of the times stored in SQLite. Being fixed-width in UTC, the times sort correctly as strings.
+const sqliteTimeLayout = "2006-01-02T15:04:05.000000000Z"
+
+// NewArticleRepositorySQLite creates a new ArticleRepositorySQLite opening the database file at the path,
+// creating it and applying the schema migrations if needed.
+func NewArticleRepositorySQLite(path string) (*ArticleRepositorySQLite, error) {
+	// WAL lets the reads run concurrently with a write. Transactions take the write lock immediately,
+	// so two transactions upgrading from read to write do not fail with SQLITE_BUSY, but wait on the busy timeout.
+	query := url.Values{}
+	query.Add("_pragma", "journal_mode(WAL)")
+	query.Add("_pragma", "busy_timeout(5000)")
+	query.Add("_pragma", "foreign_keys(1)")
+	query.Set("_txlock", "immediate")
+	db, err := sql.Open("sqlite", "file:"+path+"?"+query.Encode())
+	if err != nil {
+		return nil, err
+	}
+	if err := migrateSQLite(context.Background(), db); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	return &ArticleRepositorySQLite{db: db}, nil
+}
+
+func (repo *ArticleRepositorySQLite) Create(ctx context.Context, article *data.ArticleInfo) (data.ArticleID, error) {
+	now := time.Now()
+	return repo.create(ctx, article, data.FirstArticleVersion, now, now)
+}
+
+func (repo *ArticleRepositorySQLite) Import(ctx context.Context, article *data.Article) (data.ArticleID, error) {
+	return repo.create(ctx, &article.ArticleInfo, article.Version, article.CreatedAt, article.UpdatedAt)
+}
+
+func (repo *ArticleRepositorySQLite) create(ctx context.Context, article *data.ArticleInfo, version data.ArticleVersion, createdAt time.Time, updatedAt time.Time) (data.ArticleID, error) {
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, "INSERT INTO articles (title, content, version, created_at, updated_at) VALUES (?, ?, ?, ?, ?)",
+		string(article.Title), string(article.Content), int64(version), formatSQLiteTime(createdAt), formatSQLiteTime(updatedAt))
+	if err != nil {
+		return "", err
+	}
+	rowID, err := result.LastInsertId()
+	if err != nil {
+		return "", err
+	}
+	if err := insertArticleAuthors(ctx, tx, rowID, article.AuthorIDs); err != nil {
+		return "", err
+	}
+	if err := tx.Commit(); err != nil {
+		return "", err
+	}
+	return data.ArticleID(strconv.FormatInt(rowID, 10)), nil
+}
+
+func (repo *ArticleRepositorySQLite) Update(ctx context.Context, id data.ArticleID, version data.ArticleVersion, article *data.ArticleInfo) error {
+	rowID, ok := parseRowID(string(id))
+	if !ok {
+		// Invalid ID does not match any row, so we return ErrNotFound.
+		return errors.ErrNotFound
+	}
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, "UPDATE articles SET title = ?, content = ?, version = version + 1, updated_at = ? WHERE id = ? AND version = ?",
+		string(article.Title), string(article.Content), formatSQLiteTime(time.Now()), rowID, int64(version))
+	if err != nil {
+		return err
+	}
+	if updated, err := result.RowsAffected(); err != nil {
+		return err
+	} else if updated == 0 {
+		return sqliteNotChanged(ctx, tx, rowID)
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM article_authors WHERE article_id = ?", rowID); err != nil {
+		return err
+	}
+	if err := insertArticleAuthors(ctx, tx, rowID, article.AuthorIDs); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func insertArticleAuthors(ctx context.Context, tx *sql.Tx, rowID int64, authorIDs []data.AuthorID) error {
+	for i, authorID := range authorIDs {
+		if _, err := tx.ExecContext(ctx, "INSERT INTO article_authors (article_id, position, author_id) VALUES (?, ?, ?)",
+			rowID, i, string(authorID)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (repo *ArticleRepositorySQLite) Delete(ctx context.Context, id data.ArticleID, version data.ArticleVersion) error {
+	rowID, ok := parseRowID(string(id))
+	if !ok {
+		// Invalid ID does not match any row, so we return ErrNotFound.
+		return errors.ErrNotFound
+	}
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// The authors are deleted by the foreign key cascade, and the full-text index by the trigger.
+	result, err := tx.ExecContext(ctx, "DELETE FROM articles WHERE id = ? AND version = ?", rowID, int64(version))
+	if err != nil {
+		return err
+	}
+	if deleted, err := result.RowsAffected(); err != nil {
+		return err
+	} else if deleted == 0 {
+		return sqliteNotChanged(ctx, tx, rowID)
+	}
+	return tx.Commit()
+}
+
+// sqliteNotChanged returns why the article of the version was not changed, i.e. it does not exist
+// or it has another version.
+func sqliteNotChanged(ctx context.Context, tx *sql.Tx, rowID int64) error {
+	var exists bool
+	if err := tx.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM articles WHERE id = ?)", rowID).Scan(&exists); err != nil {
+		return err
+	}
+	if exists {
+		return errors.ErrConflict
+	}
+	return errors.ErrNotFound
+}
+
+func (repo *ArticleRepositorySQLite) GetByID(ctx context.Context, id data.ArticleID) (*data.Article, error) {
+	rowID, ok := parseRowID(string(id))
+	if !ok {
+		// Invalid ID does not match any row, so we return ErrNotFound.
+		return nil, errors.ErrNotFound
+	}
+	articles, err := repo.find(ctx, "WHERE a.id = ?", rowID)
+	if err != nil {
+		return nil, err
+	}
+	if len(articles) == 0 {
+		return nil, errors.ErrNotFound
+	}
+	return articles[0], nil
+}
+
+func (repo *ArticleRepositorySQLite) GetAll(ctx context.Context) ([]*data.Article, error) {
+	return repo.find(ctx, "ORDER BY a.id")
+}
+
+func (repo *ArticleRepositorySQLite) GetByAuthor(ctx context.Context, authorID data.AuthorID) ([]*data.Article, error) {
+	return repo.find(ctx, "WHERE a.id IN (SELECT article_id FROM article_authors WHERE author_id = ?) ORDER BY a.id", string(authorID))
+}
+
+func (repo *ArticleRepositorySQLite) GetPage(ctx context.Context, after data.ArticleID, limit data.PageLimit) ([]*data.Article, error) {
+	var afterID int64
+	if after != "" {
+		var ok bool
+		if afterID, ok = parseRowID(string(after)); !ok {
+			return nil, errors.ErrInvalidPageCursor
+		}
+	}
+	// The row IDs are increasing, so their order is the creation order.
+	return repo.find(ctx, "WHERE a.id > ? ORDER BY a.id LIMIT ?", afterID, int(limit))
+}
+
+// Search matches the articles containing all the terms with the FTS5 index, best BM25 rank first.
+func (repo *ArticleRepositorySQLite) Search(ctx context.Context, query data.SearchQuery) ([]*data.Article, error) {
+	// Quoting every term makes it a string in the FTS5 query syntax, so the characters of the syntax match literally.
+	terms := query.Terms()
+	for i, term := range terms {
+		terms[i] = `"` + strings.ReplaceAll(term, `"`, `""`) + `"`
+	}
+	return repo.find(ctx, "JOIN articles_fts ON articles_fts.rowid = a.id WHERE articles_fts MATCH ? ORDER BY articles_fts.rank",
+		strings.Join(terms, " "))
+}
+
+// find gets the articles with the clause following "FROM articles a", such as the conditions and the order.
+func (repo *ArticleRepositorySQLite) find(ctx context.Context, clause string, args ...interface{}) ([]*data.Article, error) {
+	rows, err := repo.db.QueryContext(ctx, `SELECT a.id, a.title, a.content, a.version, a.created_at, a.updated_at,
+		(SELECT json_group_array(author_id ORDER BY position) FROM article_authors WHERE article_id = a.id)
+		FROM articles a `+clause, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []*data.Article{}
+	for rows.Next() {
+		var rowID, version int64
+		var title, content, createdAt, updatedAt, authorIDs string
+		if err := rows.Scan(&rowID, &title, &content, &version, &createdAt, &updatedAt, &authorIDs); err != nil {
+			return nil, err
+		}
+		article := &data.Article{
+			ID: data.ArticleID(strconv.FormatInt(rowID, 10)),
+			// Assume the data in SQLite is valid.
+			ArticleInfo: data.ArticleInfo{
+				Title:   data.ArticleTitle(title),
+				Content: data.ArticleContent(content),
+			},
+			Version: data.ArticleVersion(version),
+		}
+		if err := json.Unmarshal([]byte(authorIDs), &article.AuthorIDs); err != nil {
+			return nil, err
+		}
+		if article.CreatedAt, err = parseSQLiteTime(createdAt); err != nil {
+			return nil, err
+		}
+		if article.UpdatedAt, err = parseSQLiteTime(updatedAt); err != nil {
+			return nil, err
+		}
+		result = append(result, article)
+	}
+	return result, rows.Err()
+}
+
+// parseRowID parses a sequential integer ID, returning false if it is not the canonical form of a positive integer,
+// so a record has a single ID.
+func parseRowID(id string) (int64, bool) {
+	rowID, err := strconv.ParseInt(id, 10, 64)
+	return rowID, err == nil && rowID > 0 && strconv.FormatInt(rowID, 10) == id
+}
+
+func formatSQLiteTime(t time.Time) string {
+	return t.UTC().Format(sqliteTimeLayout)
+}
+
+func parseSQLiteTime(s string) (time.Time, error) {
+	return time.Parse(sqliteTimeLayout, s)
+}
+
+func (repo *ArticleRepositorySQLite) Close() error {
+	return repo.db.Close()
+}
+
+var _ repository.ArticleRepository = (*ArticleRepositorySQLite)(nil)
+var _ repository.ArticleSearcher = (*ArticleRepositorySQLite)(nil)
+var _ repository.ArticlePager = (*ArticleRepositorySQLite)(nil)
+var _ repository.ArticleImporter = (*ArticleRepositorySQLite)(nil)
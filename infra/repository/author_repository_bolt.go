@@ -0,0 +1,216 @@
+package repository
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"strconv"
+
+	"github.com/Jason5Lee/simple-blog/core/data"
+	"github.com/Jason5Lee/simple-blog/core/errors"
+	"github.com/Jason5Lee/simple-blog/core/repository"
+	bolt "go.etcd.io/bbolt"
+)
+
+// Data stored in bbolt, with the ID as the key.
+type BoltAuthor struct {
+	DisplayName string   `json:"display_name"`
+	Bio         string   `json:"bio"`
+	Avatar      string   `json:"avatar"`
+	Links       []string `json:"links"`
+	// TokenHash is the hash of the token authenticating the author, empty if no token has been issued.
+	TokenHash string `json:"token_hash,omitempty"`
+}
+
+// AuthorRepositoryBolt is a bbolt implementation of AuthorRepository.
+// The authors are indexed by token hash in a separate bucket.
+type AuthorRepositoryBolt struct {
+	db *bolt.DB
+}
+
+var (
+	boltAuthorsBucket            = []byte("authors")
+	boltAuthorsByTokenHashBucket = []byte("authors_by_token_hash")
+)
+
+// NewAuthorRepositoryBolt creates a new AuthorRepositoryBolt sharing the database of the article repository.
+func NewAuthorRepositoryBolt(articleRepo *ArticleRepositoryBolt) *AuthorRepositoryBolt {
+	return &AuthorRepositoryBolt{db: articleRepo.db}
+}
+
+func (repo *AuthorRepositoryBolt) Create(ctx context.Context, author *data.AuthorInfo) (data.AuthorID, error) {
+	links := make([]string, len(author.Links))
+	for i, link := range author.Links {
+		links[i] = string(link)
+	}
+	var id data.AuthorID
+	err := repo.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(boltAuthorsBucket)
+		seq, err := bucket.NextSequence()
+		if err != nil {
+			return err
+		}
+		id = data.AuthorID(strconv.FormatUint(seq, 10))
+		return putBoltJSON(bucket, boltID(seq), &BoltAuthor{
+			DisplayName: string(author.DisplayName),
+			Bio:         string(author.Bio),
+			Avatar:      string(author.Avatar),
+			Links:       links,
+		})
+	})
+	return id, err
+}
+
+func (repo *AuthorRepositoryBolt) GetByID(ctx context.Context, id data.AuthorID) (*data.Author, error) {
+	key, ok := parseBoltID(string(id))
+	if !ok {
+		// Invalid ID does not match any record, so we return ErrAuthorNotFound.
+		return nil, errors.ErrAuthorNotFound
+	}
+	var result *data.Author
+	err := repo.db.View(func(tx *bolt.Tx) error {
+		author, err := getBoltAuthor(tx, key)
+		if err != nil {
+			return err
+		}
+		result = author.toAuthor(key)
+		return nil
+	})
+	return result, err
+}
+
+func (repo *AuthorRepositoryBolt) GetByIDs(ctx context.Context, ids []data.AuthorID) (map[data.AuthorID]*data.Author, error) {
+	result := make(map[data.AuthorID]*data.Author, len(ids))
+	err := repo.db.View(func(tx *bolt.Tx) error {
+		for _, id := range ids {
+			key, ok := parseBoltID(string(id))
+			if !ok {
+				// Invalid IDs do not match any record, so they are skipped.
+				continue
+			}
+			author, err := getBoltAuthor(tx, key)
+			if err == errors.ErrAuthorNotFound {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			result[id] = author.toAuthor(key)
+		}
+		return nil
+	})
+	return result, err
+}
+
+func (repo *AuthorRepositoryBolt) GetAll(ctx context.Context) ([]*data.Author, error) {
+	result := []*data.Author{}
+	err := repo.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltAuthorsBucket).ForEach(func(key, value []byte) error {
+			var author BoltAuthor
+			if err := json.Unmarshal(value, &author); err != nil {
+				return err
+			}
+			result = append(result, author.toAuthor(key))
+			return nil
+		})
+	})
+	return result, err
+}
+
+func (repo *AuthorRepositoryBolt) SetTokenHash(ctx context.Context, id data.AuthorID, tokenHash string) error {
+	key, ok := parseBoltID(string(id))
+	if !ok {
+		return errors.ErrAuthorNotFound
+	}
+	return repo.db.Update(func(tx *bolt.Tx) error {
+		author, err := getBoltAuthor(tx, key)
+		if err != nil {
+			return err
+		}
+		index := tx.Bucket(boltAuthorsByTokenHashBucket)
+		if author.TokenHash != "" {
+			if err := index.Delete([]byte(author.TokenHash)); err != nil {
+				return err
+			}
+		}
+		author.TokenHash = tokenHash
+		if err := index.Put([]byte(tokenHash), key); err != nil {
+			return err
+		}
+		return putBoltJSON(tx.Bucket(boltAuthorsBucket), key, author)
+	})
+}
+
+func (repo *AuthorRepositoryBolt) GetByTokenHash(ctx context.Context, tokenHash string) (*data.Author, error) {
+	var result *data.Author
+	err := repo.db.View(func(tx *bolt.Tx) error {
+		key := tx.Bucket(boltAuthorsByTokenHashBucket).Get([]byte(tokenHash))
+		if key == nil {
+			return errors.ErrAuthorNotFound
+		}
+		author, err := getBoltAuthor(tx, key)
+		if err != nil {
+			return err
+		}
+		result = author.toAuthor(key)
+		return nil
+	})
+	return result, err
+}
+
+func (repo *AuthorRepositoryBolt) GetTokenHash(ctx context.Context, id data.AuthorID) (string, error) {
+	key, ok := parseBoltID(string(id))
+	if !ok {
+		return "", errors.ErrAuthorNotFound
+	}
+	var tokenHash string
+	err := repo.db.View(func(tx *bolt.Tx) error {
+		author, err := getBoltAuthor(tx, key)
+		if err != nil {
+			return err
+		}
+		tokenHash = author.TokenHash
+		return nil
+	})
+	return tokenHash, err
+}
+
+func getBoltAuthor(tx *bolt.Tx, key []byte) (*BoltAuthor, error) {
+	value := tx.Bucket(boltAuthorsBucket).Get(key)
+	if value == nil {
+		return nil, errors.ErrAuthorNotFound
+	}
+	var author BoltAuthor
+	if err := json.Unmarshal(value, &author); err != nil {
+		return nil, err
+	}
+	return &author, nil
+}
+
+func (author *BoltAuthor) toAuthor(key []byte) *data.Author {
+	links := make([]data.AuthorURL, len(author.Links))
+	for i, link := range author.Links {
+		links[i] = data.AuthorURL(link)
+	}
+	return &data.Author{
+		ID: data.AuthorID(strconv.FormatUint(binary.BigEndian.Uint64(key), 10)),
+		// Assume the data in bbolt is valid.
+		AuthorInfo: data.AuthorInfo{
+			DisplayName: data.AuthorName(author.DisplayName),
+			Bio:         data.AuthorBio(author.Bio),
+			Avatar:      data.AuthorURL(author.Avatar),
+			Links:       links,
+		},
+	}
+}
+
+// putBoltJSON puts the value encoded in JSON.
+func putBoltJSON(bucket *bolt.Bucket, key []byte, value interface{}) error {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return bucket.Put(key, encoded)
+}
+
+var _ repository.AuthorRepository = (*AuthorRepositoryBolt)(nil)
@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/Jason5Lee/simple-blog/core/data"
+	"github.com/Jason5Lee/simple-blog/core/errors"
+	"github.com/Jason5Lee/simple-blog/core/repository"
+)
+
+// In-memory implementation of AuthorRepository.
+type AuthorRepositoryInMemory struct {
+	mu      sync.RWMutex
+	authors []*data.Author
+	// tokenHashes maps the token hashes to the authors they authenticate.
+	tokenHashes map[string]data.AuthorID
+}
+
+func NewAuthorRepositoryInMemory() *AuthorRepositoryInMemory {
+	return &AuthorRepositoryInMemory{
+		tokenHashes: make(map[string]data.AuthorID),
+	}
+}
+
+func (r *AuthorRepositoryInMemory) Create(ctx context.Context, author *data.AuthorInfo) (data.AuthorID, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	id := data.AuthorID(fmt.Sprint(len(r.authors) + 1))
+	r.authors = append(r.authors, &data.Author{ID: id, AuthorInfo: *author})
+	return id, nil
+}
+
+func (r *AuthorRepositoryInMemory) GetByID(ctx context.Context, id data.AuthorID) (*data.Author, error) {
+	authors, _ := r.GetByIDs(ctx, []data.AuthorID{id})
+	author, ok := authors[id]
+	if !ok {
+		return nil, errors.ErrAuthorNotFound
+	}
+	return author, nil
+}
+
+func (r *AuthorRepositoryInMemory) GetByIDs(ctx context.Context, ids []data.AuthorID) (map[data.AuthorID]*data.Author, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	result := make(map[data.AuthorID]*data.Author, len(ids))
+	for _, author := range r.authors {
+		for _, id := range ids {
+			if author.ID == id {
+				copied := *author
+				result[id] = &copied
+			}
+		}
+	}
+	return result, nil
+}
+
+func (r *AuthorRepositoryInMemory) GetAll(ctx context.Context) ([]*data.Author, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	result := make([]*data.Author, len(r.authors))
+	for i, author := range r.authors {
+		copied := *author
+		result[i] = &copied
+	}
+	return result, nil
+}
+
+func (r *AuthorRepositoryInMemory) SetTokenHash(ctx context.Context, id data.AuthorID, tokenHash string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for hash, authorID := range r.tokenHashes {
+		if authorID == id {
+			delete(r.tokenHashes, hash)
+		}
+	}
+	r.tokenHashes[tokenHash] = id
+	return nil
+}
+
+func (r *AuthorRepositoryInMemory) GetByTokenHash(ctx context.Context, tokenHash string) (*data.Author, error) {
+	r.mu.RLock()
+	id, ok := r.tokenHashes[tokenHash]
+	r.mu.RUnlock()
+	if !ok {
+		return nil, errors.ErrAuthorNotFound
+	}
+	return r.GetByID(ctx, id)
+}
+
+func (r *AuthorRepositoryInMemory) GetTokenHash(ctx context.Context, id data.AuthorID) (string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for hash, authorID := range r.tokenHashes {
+		if authorID == id {
+			return hash, nil
+		}
+	}
+	return "", nil
+}
+
+var _ repository.AuthorRepository = (*AuthorRepositoryInMemory)(nil)
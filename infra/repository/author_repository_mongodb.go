@@ -0,0 +1,185 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Jason5Lee/simple-blog/core/data"
+	"github.com/Jason5Lee/simple-blog/core/errors"
+	"github.com/Jason5Lee/simple-blog/core/repository"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Data for inserting into MongoDB.
+type DBAuthorInfo struct {
+	DisplayName string `bson:"display_name"`
+	// NameKey is the normalized display name, used to match the author strings when migrating.
+	NameKey string   `bson:"name_key"`
+	Bio     string   `bson:"bio"`
+	Avatar  string   `bson:"avatar"`
+	Links   []string `bson:"links"`
+	// TokenHash is the hash of the token authenticating the author, omitted if no token has been issued.
+	TokenHash string `bson:"token_hash,omitempty"`
+}
+
+// Data for reading from MongoDB, with extra field "_id".
+type DBAuthor struct {
+	ID           primitive.ObjectID `bson:"_id"`
+	DBAuthorInfo `bson:",inline"`
+}
+
+// AuthorRepositoryMongoDB is a MongoDB implementation of AuthorRepository.
+type AuthorRepositoryMongoDB struct {
+	db *mongo.Database
+}
+
+const authorCollectionName = "authors"
+
+// NewAuthorRepositoryMongoDB creates a new AuthorRepositoryMongoDB sharing the connection of the article repository.
+func NewAuthorRepositoryMongoDB(articleRepo *ArticleRepositoryMongoDB) *AuthorRepositoryMongoDB {
+	return &AuthorRepositoryMongoDB{db: articleRepo.db}
+}
+
+func (repo *AuthorRepositoryMongoDB) collection() *mongo.Collection {
+	return repo.db.Collection(authorCollectionName)
+}
+
+func (repo *AuthorRepositoryMongoDB) Create(ctx context.Context, author *data.AuthorInfo) (data.AuthorID, error) {
+	links := make([]string, len(author.Links))
+	for i, link := range author.Links {
+		links[i] = string(link)
+	}
+	insertResult, err := repo.collection().InsertOne(ctx, DBAuthorInfo{
+		DisplayName: string(author.DisplayName),
+		NameKey:     data.AuthorNameKey(author.DisplayName),
+		Bio:         string(author.Bio),
+		Avatar:      string(author.Avatar),
+		Links:       links,
+	})
+	if err != nil {
+		return "", err
+	}
+	return data.AuthorID(insertResult.InsertedID.(primitive.ObjectID).Hex()), nil
+}
+
+func (repo *AuthorRepositoryMongoDB) GetByID(ctx context.Context, id data.AuthorID) (*data.Author, error) {
+	docID, err := primitive.ObjectIDFromHex(string(id))
+	if err != nil {
+		// Invalid ID does not match any document, so we return ErrAuthorNotFound.
+		return nil, errors.ErrAuthorNotFound
+	}
+	var author DBAuthor
+	if err := repo.collection().FindOne(ctx, bson.M{"_id": docID}).Decode(&author); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.ErrAuthorNotFound
+		}
+		return nil, err
+	}
+	return author.toAuthor(), nil
+}
+
+func (repo *AuthorRepositoryMongoDB) GetByIDs(ctx context.Context, ids []data.AuthorID) (map[data.AuthorID]*data.Author, error) {
+	docIDs := make([]primitive.ObjectID, 0, len(ids))
+	for _, id := range ids {
+		// Invalid IDs do not match any document, so they are skipped.
+		if docID, err := primitive.ObjectIDFromHex(string(id)); err == nil {
+			docIDs = append(docIDs, docID)
+		}
+	}
+	authors, err := repo.find(ctx, bson.M{"_id": bson.M{"$in": docIDs}})
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[data.AuthorID]*data.Author, len(authors))
+	for _, author := range authors {
+		result[author.ID] = author
+	}
+	return result, nil
+}
+
+func (repo *AuthorRepositoryMongoDB) GetAll(ctx context.Context) ([]*data.Author, error) {
+	return repo.find(ctx, bson.M{})
+}
+
+func (repo *AuthorRepositoryMongoDB) SetTokenHash(ctx context.Context, id data.AuthorID, tokenHash string) error {
+	docID, err := primitive.ObjectIDFromHex(string(id))
+	if err != nil {
+		return errors.ErrAuthorNotFound
+	}
+	updateResult, err := repo.collection().UpdateOne(ctx, bson.M{"_id": docID}, bson.M{"$set": bson.M{"token_hash": tokenHash}})
+	if err != nil {
+		return err
+	}
+	if updateResult.MatchedCount == 0 {
+		return errors.ErrAuthorNotFound
+	}
+	return nil
+}
+
+func (repo *AuthorRepositoryMongoDB) GetByTokenHash(ctx context.Context, tokenHash string) (*data.Author, error) {
+	var author DBAuthor
+	if err := repo.collection().FindOne(ctx, bson.M{"token_hash": tokenHash}).Decode(&author); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.ErrAuthorNotFound
+		}
+		return nil, err
+	}
+	return author.toAuthor(), nil
+}
+
+func (repo *AuthorRepositoryMongoDB) GetTokenHash(ctx context.Context, id data.AuthorID) (string, error) {
+	docID, err := primitive.ObjectIDFromHex(string(id))
+	if err != nil {
+		return "", errors.ErrAuthorNotFound
+	}
+	var author DBAuthor
+	if err := repo.collection().FindOne(ctx, bson.M{"_id": docID}).Decode(&author); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return "", errors.ErrAuthorNotFound
+		}
+		return "", err
+	}
+	return author.TokenHash, nil
+}
+
+func (repo *AuthorRepositoryMongoDB) find(ctx context.Context, filter bson.M) ([]*data.Author, error) {
+	cursor, err := repo.collection().Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	var authors []*DBAuthor
+	if err := cursor.All(ctx, &authors); err != nil {
+		return nil, err
+	}
+	result := make([]*data.Author, len(authors))
+	for i, author := range authors {
+		result[i] = author.toAuthor()
+	}
+	return result, nil
+}
+
+func (author *DBAuthor) toAuthor() *data.Author {
+	links := make([]data.AuthorURL, len(author.Links))
+	for i, link := range author.Links {
+		links[i] = data.AuthorURL(link)
+	}
+	return &data.Author{
+		ID: data.AuthorID(author.ID.Hex()),
+		// Assume the data in MongoDB is valid.
+		AuthorInfo: data.AuthorInfo{
+			DisplayName: data.AuthorName(author.DisplayName),
+			Bio:         data.AuthorBio(author.Bio),
+			Avatar:      data.AuthorURL(author.Avatar),
+			Links:       links,
+		},
+	}
+}
+
+// Dropping the collection for integration testing.
+func (repo *AuthorRepositoryMongoDB) Drop() error {
+	return repo.collection().Drop(context.Background())
+}
+
+var _ repository.AuthorRepository = (*AuthorRepositoryMongoDB)(nil)
@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/Jason5Lee/simple-blog/core/data"
+	"github.com/Jason5Lee/simple-blog/core/errors"
+	"github.com/Jason5Lee/simple-blog/core/repository"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// AuthorRepositoryPostgres is a PostgreSQL implementation of AuthorRepository.
+type AuthorRepositoryPostgres struct {
+	pool *pgxpool.Pool
+}
+
+// NewAuthorRepositoryPostgres creates a new AuthorRepositoryPostgres sharing the pool of the article repository.
+func NewAuthorRepositoryPostgres(articleRepo *ArticleRepositoryPostgres) *AuthorRepositoryPostgres {
+	return &AuthorRepositoryPostgres{pool: articleRepo.pool}
+}
+
+func (repo *AuthorRepositoryPostgres) Create(ctx context.Context, author *data.AuthorInfo) (data.AuthorID, error) {
+	links := make([]string, len(author.Links))
+	for i, link := range author.Links {
+		links[i] = string(link)
+	}
+	var rowID int64
+	err := repo.pool.QueryRow(ctx, "INSERT INTO authors (display_name, bio, avatar, links) VALUES ($1, $2, $3, $4) RETURNING id",
+		string(author.DisplayName), string(author.Bio), string(author.Avatar), links).Scan(&rowID)
+	if err != nil {
+		return "", err
+	}
+	return data.AuthorID(strconv.FormatInt(rowID, 10)), nil
+}
+
+func (repo *AuthorRepositoryPostgres) GetByID(ctx context.Context, id data.AuthorID) (*data.Author, error) {
+	rowID, ok := parseRowID(string(id))
+	if !ok {
+		// Invalid ID does not match any row, so we return ErrAuthorNotFound.
+		return nil, errors.ErrAuthorNotFound
+	}
+	return repo.findOne(ctx, "WHERE id = $1", rowID)
+}
+
+func (repo *AuthorRepositoryPostgres) GetByIDs(ctx context.Context, ids []data.AuthorID) (map[data.AuthorID]*data.Author, error) {
+	rowIDs := make([]int64, 0, len(ids))
+	for _, id := range ids {
+		// Invalid IDs do not match any row, so they are skipped.
+		if rowID, ok := parseRowID(string(id)); ok {
+			rowIDs = append(rowIDs, rowID)
+		}
+	}
+	authors, err := repo.find(ctx, "WHERE id = ANY($1)", rowIDs)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[data.AuthorID]*data.Author, len(authors))
+	for _, author := range authors {
+		result[author.ID] = author
+	}
+	return result, nil
+}
+
+func (repo *AuthorRepositoryPostgres) GetAll(ctx context.Context) ([]*data.Author, error) {
+	return repo.find(ctx, "ORDER BY id")
+}
+
+func (repo *AuthorRepositoryPostgres) SetTokenHash(ctx context.Context, id data.AuthorID, tokenHash string) error {
+	rowID, ok := parseRowID(string(id))
+	if !ok {
+		return errors.ErrAuthorNotFound
+	}
+	tag, err := repo.pool.Exec(ctx, "UPDATE authors SET token_hash = $1 WHERE id = $2", tokenHash, rowID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return errors.ErrAuthorNotFound
+	}
+	return nil
+}
+
+func (repo *AuthorRepositoryPostgres) GetByTokenHash(ctx context.Context, tokenHash string) (*data.Author, error) {
+	return repo.findOne(ctx, "WHERE token_hash = $1", tokenHash)
+}
+
+func (repo *AuthorRepositoryPostgres) GetTokenHash(ctx context.Context, id data.AuthorID) (string, error) {
+	rowID, ok := parseRowID(string(id))
+	if !ok {
+		return "", errors.ErrAuthorNotFound
+	}
+	var tokenHash string
+	err := repo.pool.QueryRow(ctx, "SELECT COALESCE(token_hash, '') FROM authors WHERE id = $1", rowID).Scan(&tokenHash)
+	if err == pgx.ErrNoRows {
+		return "", errors.ErrAuthorNotFound
+	}
+	return tokenHash, err
+}
+
+func (repo *AuthorRepositoryPostgres) findOne(ctx context.Context, clause string, args ...interface{}) (*data.Author, error) {
+	authors, err := repo.find(ctx, clause, args...)
+	if err != nil {
+		return nil, err
+	}
+	if len(authors) == 0 {
+		return nil, errors.ErrAuthorNotFound
+	}
+	return authors[0], nil
+}
+
+// find gets the authors with the clause following "FROM authors".
+func (repo *AuthorRepositoryPostgres) find(ctx context.Context, clause string, args ...interface{}) ([]*data.Author, error) {
+	rows, err := repo.pool.Query(ctx, "SELECT id, display_name, bio, avatar, links FROM authors "+clause, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []*data.Author{}
+	for rows.Next() {
+		var rowID int64
+		var displayName, bio, avatar string
+		var links []string
+		if err := rows.Scan(&rowID, &displayName, &bio, &avatar, &links); err != nil {
+			return nil, err
+		}
+		author := &data.Author{
+			ID: data.AuthorID(strconv.FormatInt(rowID, 10)),
+			// Assume the data in PostgreSQL is valid.
+			AuthorInfo: data.AuthorInfo{
+				DisplayName: data.AuthorName(displayName),
+				Bio:         data.AuthorBio(bio),
+				Avatar:      data.AuthorURL(avatar),
+				Links:       make([]data.AuthorURL, len(links)),
+			},
+		}
+		for i, link := range links {
+			author.Links[i] = data.AuthorURL(link)
+		}
+		result = append(result, author)
+	}
+	return result, rows.Err()
+}
+
+var _ repository.AuthorRepository = (*AuthorRepositoryPostgres)(nil)
@@ -0,0 +1,162 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/Jason5Lee/simple-blog/core/data"
+	"github.com/Jason5Lee/simple-blog/core/errors"
+	"github.com/Jason5Lee/simple-blog/core/repository"
+)
+
+// AuthorRepositorySQLite is a SQLite implementation of AuthorRepository.
+type AuthorRepositorySQLite struct {
+	db *sql.DB
+}
+
+// NewAuthorRepositorySQLite creates a new AuthorRepositorySQLite sharing the database of the article repository.
+func NewAuthorRepositorySQLite(articleRepo *ArticleRepositorySQLite) *AuthorRepositorySQLite {
+	return &AuthorRepositorySQLite{db: articleRepo.db}
+}
+
+func (repo *AuthorRepositorySQLite) Create(ctx context.Context, author *data.AuthorInfo) (data.AuthorID, error) {
+	links, err := json.Marshal(author.Links)
+	if err != nil {
+		return "", err
+	}
+	if author.Links == nil {
+		links = []byte("[]")
+	}
+	result, err := repo.db.ExecContext(ctx, "INSERT INTO authors (display_name, bio, avatar, links) VALUES (?, ?, ?, ?)",
+		string(author.DisplayName), string(author.Bio), string(author.Avatar), string(links))
+	if err != nil {
+		return "", err
+	}
+	rowID, err := result.LastInsertId()
+	if err != nil {
+		return "", err
+	}
+	return data.AuthorID(strconv.FormatInt(rowID, 10)), nil
+}
+
+func (repo *AuthorRepositorySQLite) GetByID(ctx context.Context, id data.AuthorID) (*data.Author, error) {
+	rowID, ok := parseRowID(string(id))
+	if !ok {
+		// Invalid ID does not match any row, so we return ErrAuthorNotFound.
+		return nil, errors.ErrAuthorNotFound
+	}
+	return repo.findOne(ctx, "WHERE id = ?", rowID)
+}
+
+func (repo *AuthorRepositorySQLite) GetByIDs(ctx context.Context, ids []data.AuthorID) (map[data.AuthorID]*data.Author, error) {
+	rowIDs := make([]interface{}, 0, len(ids))
+	for _, id := range ids {
+		// Invalid IDs do not match any row, so they are skipped.
+		if rowID, ok := parseRowID(string(id)); ok {
+			rowIDs = append(rowIDs, rowID)
+		}
+	}
+	result := make(map[data.AuthorID]*data.Author, len(rowIDs))
+	if len(rowIDs) == 0 {
+		return result, nil
+	}
+	authors, err := repo.find(ctx, "WHERE id IN ("+sqlitePlaceholders(len(rowIDs))+")", rowIDs...)
+	if err != nil {
+		return nil, err
+	}
+	for _, author := range authors {
+		result[author.ID] = author
+	}
+	return result, nil
+}
+
+func (repo *AuthorRepositorySQLite) GetAll(ctx context.Context) ([]*data.Author, error) {
+	return repo.find(ctx, "ORDER BY id")
+}
+
+func (repo *AuthorRepositorySQLite) SetTokenHash(ctx context.Context, id data.AuthorID, tokenHash string) error {
+	rowID, ok := parseRowID(string(id))
+	if !ok {
+		return errors.ErrAuthorNotFound
+	}
+	result, err := repo.db.ExecContext(ctx, "UPDATE authors SET token_hash = ? WHERE id = ?", tokenHash, rowID)
+	if err != nil {
+		return err
+	}
+	if updated, err := result.RowsAffected(); err != nil {
+		return err
+	} else if updated == 0 {
+		return errors.ErrAuthorNotFound
+	}
+	return nil
+}
+
+func (repo *AuthorRepositorySQLite) GetByTokenHash(ctx context.Context, tokenHash string) (*data.Author, error) {
+	return repo.findOne(ctx, "WHERE token_hash = ?", tokenHash)
+}
+
+func (repo *AuthorRepositorySQLite) GetTokenHash(ctx context.Context, id data.AuthorID) (string, error) {
+	rowID, ok := parseRowID(string(id))
+	if !ok {
+		return "", errors.ErrAuthorNotFound
+	}
+	var tokenHash string
+	err := repo.db.QueryRowContext(ctx, "SELECT COALESCE(token_hash, '') FROM authors WHERE id = ?", rowID).Scan(&tokenHash)
+	if err == sql.ErrNoRows {
+		return "", errors.ErrAuthorNotFound
+	}
+	return tokenHash, err
+}
+
+func (repo *AuthorRepositorySQLite) findOne(ctx context.Context, clause string, args ...interface{}) (*data.Author, error) {
+	authors, err := repo.find(ctx, clause, args...)
+	if err != nil {
+		return nil, err
+	}
+	if len(authors) == 0 {
+		return nil, errors.ErrAuthorNotFound
+	}
+	return authors[0], nil
+}
+
+// find gets the authors with the clause following "FROM authors".
+func (repo *AuthorRepositorySQLite) find(ctx context.Context, clause string, args ...interface{}) ([]*data.Author, error) {
+	rows, err := repo.db.QueryContext(ctx, "SELECT id, display_name, bio, avatar, links FROM authors "+clause, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []*data.Author{}
+	for rows.Next() {
+		var rowID int64
+		var displayName, bio, avatar, links string
+		if err := rows.Scan(&rowID, &displayName, &bio, &avatar, &links); err != nil {
+			return nil, err
+		}
+		author := &data.Author{
+			ID: data.AuthorID(strconv.FormatInt(rowID, 10)),
+			// Assume the data in SQLite is valid.
+			AuthorInfo: data.AuthorInfo{
+				DisplayName: data.AuthorName(displayName),
+				Bio:         data.AuthorBio(bio),
+				Avatar:      data.AuthorURL(avatar),
+			},
+		}
+		if err := json.Unmarshal([]byte(links), &author.Links); err != nil {
+			return nil, err
+		}
+		result = append(result, author)
+	}
+	return result, rows.Err()
+}
+
+// sqlitePlaceholders returns n comma-separated placeholders for an "IN" list.
+func sqlitePlaceholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
+
+var _ repository.AuthorRepository = (*AuthorRepositorySQLite)(nil)
@@ -0,0 +1,320 @@
+package repository_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Jason5Lee/simple-blog/core/data"
+	"github.com/Jason5Lee/simple-blog/core/errors"
+	"github.com/Jason5Lee/simple-blog/infra/repository"
+	"github.com/stretchr/testify/assert"
+	bolt "go.etcd.io/bbolt"
+)
+
+func newTestBolt(t *testing.T) *repository.ArticleRepositoryBolt {
+	repo, err := repository.NewArticleRepositoryBolt(filepath.Join(t.TempDir(), "blog.bolt"))
+	assert.Nil(t, err, "opening a new database should not return error")
+	t.Cleanup(func() { _ = repo.Close() })
+	return repo
+}
+
+func Test_ArticleRepositoryBolt(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+	repo := newTestBolt(t)
+
+	firstID, err := repo.Create(ctx, &data.ArticleInfo{Title: "Hello", Content: "World", AuthorIDs: []data.AuthorID{"2", "1"}})
+	assert.Nil(err, "create should not return error")
+	secondID, _ := repo.Create(ctx, &data.ArticleInfo{Title: "Second", Content: "Article", AuthorIDs: []data.AuthorID{"1"}})
+	article, err := repo.GetByID(ctx, firstID)
+	assert.Nil(err, "get by id should not return error")
+	assert.Equal([]data.AuthorID{"2", "1"}, article.AuthorIDs, "authors should keep their credit order")
+	assert.Equal(article.CreatedAt, article.UpdatedAt, "a new article should not be updated")
+
+	assert.Nil(repo.Update(ctx, firstID, data.FirstArticleVersion, &data.ArticleInfo{Title: "Hi", Content: "There", AuthorIDs: []data.AuthorID{"1", "3"}}), "update should not return error")
+	byAuthor, err := repo.GetByAuthor(ctx, "2")
+	assert.Nil(err, "get by author should not return error")
+	assert.Empty(byAuthor, "the index should follow the removed author")
+	byAuthor, _ = repo.GetByAuthor(ctx, "3")
+	assert.Len(byAuthor, 1, "the index should follow the added author")
+	all, err := repo.GetAll(ctx)
+	assert.Nil(err, "get all should not return error")
+	assert.Equal([]data.ArticleID{firstID, secondID}, []data.ArticleID{all[0].ID, all[1].ID}, "an update should keep the creation order")
+	assert.Equal(data.ArticleContent("There"), all[0].Content, "content should be updated")
+
+	for _, invalid := range []data.ArticleID{"", "abc", "01", "-1", "999"} {
+		_, err = repo.GetByID(ctx, invalid)
+		assert.Equal(errors.ErrNotFound, err, "get by id %q should return ErrNotFound", invalid)
+		assert.Equal(errors.ErrNotFound, repo.Update(ctx, invalid, data.FirstArticleVersion, &article.ArticleInfo), "update %q should return ErrNotFound", invalid)
+		assert.Equal(errors.ErrNotFound, repo.Delete(ctx, invalid, data.FirstArticleVersion), "delete %q should return ErrNotFound", invalid)
+	}
+
+	assert.Nil(repo.Delete(ctx, firstID, data.FirstArticleVersion+1), "delete should not return error")
+	_, err = repo.GetByID(ctx, firstID)
+	assert.Equal(errors.ErrNotFound, err, "deleted article should not be found")
+	byAuthor, _ = repo.GetByAuthor(ctx, "1")
+	assert.Len(byAuthor, 1, "the index should follow the deletion")
+	all, _ = repo.GetAll(ctx)
+	assert.Len(all, 1, "the creation index should follow the deletion")
+}
+
+func Test_ArticleRepositoryBolt_GetPage(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+	repo := newTestBolt(t)
+
+	var ids []data.ArticleID
+	for i := 0; i < 3; i++ {
+		id, err := repo.Create(ctx, &data.ArticleInfo{Title: "Title", Content: "Content", AuthorIDs: []data.AuthorID{"1"}})
+		assert.Nil(err, "create should not return error")
+		ids = append(ids, id)
+	}
+
+	page, err := repo.GetPage(ctx, "", 2)
+	assert.Nil(err, "get page should not return error")
+	assert.Equal(ids[:2], []data.ArticleID{page[0].ID, page[1].ID}, "the first page should start from the first article")
+
+	assert.Nil(repo.Delete(ctx, ids[1], data.FirstArticleVersion), "delete should not return error")
+	page, err = repo.GetPage(ctx, ids[1], 2)
+	assert.Nil(err, "the cursor of a deleted article should still be valid")
+	assert.Len(page, 1, "the last page should not be full")
+	assert.Equal(ids[2], page[0].ID, "the page should follow the cursor")
+
+	_, err = repo.GetPage(ctx, "abc", 2)
+	assert.Equal(errors.ErrInvalidPageCursor, err, "a malformed cursor should return ErrInvalidPageCursor")
+}
+
+func Test_ArticleRepositoryBolt_Backup(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+	repo := newTestBolt(t)
+	id, _ := repo.Create(ctx, &data.ArticleInfo{Title: "Hello", Content: "World", AuthorIDs: []data.AuthorID{"1"}})
+
+	var backup bytes.Buffer
+	n, err := repo.Backup(ctx, &backup)
+	assert.Nil(err, "backup should not return error")
+	assert.Equal(int64(backup.Len()), n, "the written size should be returned")
+	// The backup is not changed by the following writes.
+	assert.Nil(repo.Delete(ctx, id, data.FirstArticleVersion), "delete should not return error")
+
+	path := filepath.Join(t.TempDir(), "backup.bolt")
+	assert.Nil(os.WriteFile(path, backup.Bytes(), 0600))
+	restored, err := repository.NewArticleRepositoryBolt(path)
+	assert.Nil(err, "the backup should be a valid database")
+	defer restored.Close()
+	byAuthor, err := restored.GetByAuthor(ctx, "1")
+	assert.Nil(err, "get by author should not return error")
+	assert.Len(byAuthor, 1, "the backup should contain the data and the indexes")
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = repo.Backup(canceled, &bytes.Buffer{})
+	assert.Equal(context.Canceled, err, "a canceled backup should stop")
+}
+
+func Test_AuthorAndCommentRepositoryBolt(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+	articleRepo := newTestBolt(t)
+	authorRepo := repository.NewAuthorRepositoryBolt(articleRepo)
+	commentRepo := repository.NewCommentRepositoryBolt(articleRepo)
+
+	authorID, err := authorRepo.Create(ctx, &data.AuthorInfo{DisplayName: "alice", Links: []data.AuthorURL{"https://example.com"}})
+	assert.Nil(err, "create should not return error")
+	assert.Nil(authorRepo.SetTokenHash(ctx, authorID, "old"), "set token hash should not return error")
+	assert.Nil(authorRepo.SetTokenHash(ctx, authorID, "new"), "set token hash should not return error")
+	_, err = authorRepo.GetByTokenHash(ctx, "old")
+	assert.Equal(errors.ErrAuthorNotFound, err, "a replaced token should no longer match")
+	author, err := authorRepo.GetByTokenHash(ctx, "new")
+	assert.Nil(err, "get by token hash should not return error")
+	assert.Equal([]data.AuthorURL{"https://example.com"}, author.Links, "links should be stored")
+	authors, _ := authorRepo.GetByIDs(ctx, []data.AuthorID{authorID, "abc", "999"})
+	assert.Len(authors, 1, "invalid and missing IDs should be skipped")
+	assert.Equal(errors.ErrAuthorNotFound, authorRepo.SetTokenHash(ctx, "999", "other"), "a missing author should not be found")
+	tokenHash, err := authorRepo.GetTokenHash(ctx, authorID)
+	assert.Nil(err, "get token hash should not return error")
+	assert.Equal("new", tokenHash)
+
+	first, err := commentRepo.Create(ctx, &data.CommentInfo{ArticleID: "1", Author: "bob", Content: "first"}, data.CommentPending, 0.1)
+	assert.Nil(err, "create should not return error")
+	_, _ = commentRepo.Create(ctx, &data.CommentInfo{ArticleID: "1", Author: "bob", Content: "second"}, data.CommentPending, 0.2)
+	updated, err := commentRepo.SetStatus(ctx, []data.CommentID{first, first, "abc"}, data.CommentApproved)
+	assert.Nil(err, "set status should not return error")
+	assert.Equal(int64(1), updated, "a comment should be counted once")
+	approved, _ := commentRepo.GetByArticle(ctx, "1", data.CommentApproved)
+	assert.Len(approved, 1, "the index should follow the status")
+	pending, _ := commentRepo.GetByStatus(ctx, data.CommentPending)
+	assert.Len(pending, 1, "the status index should follow the status")
+	deleted, err := commentRepo.DeleteByStatus(ctx, data.CommentPending)
+	assert.Nil(err, "delete by status should not return error")
+	assert.Equal(int64(1), deleted, "only the pending comment should be deleted")
+	pending, _ = commentRepo.GetByArticle(ctx, "1", data.CommentPending)
+	assert.Empty(pending, "the index should follow the deletion")
+}
+
+func Test_SeriesRepositoryBolt(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+	repo := repository.NewSeriesRepositoryBolt(newTestBolt(t))
+
+	id, err := repo.Create(ctx, &data.SeriesInfo{Title: "Series"})
+	assert.Nil(err, "create should not return error")
+	for _, articleID := range []data.ArticleID{"a", "b", "c"} {
+		assert.Nil(repo.AddArticle(ctx, id, articleID), "add should not return error")
+	}
+	assert.Equal(errors.ErrArticleAlreadyInSeries, repo.AddArticle(ctx, id, "a"), "adding a part twice should fail")
+	assert.Equal(errors.ErrSeriesNotFound, repo.AddArticle(ctx, "999", "a"), "adding to a missing series should fail")
+	assert.Nil(repo.RemoveArticle(ctx, id, "b"), "remove should not return error")
+	assert.Equal(errors.ErrArticleNotInSeries, repo.RemoveArticle(ctx, id, "b"), "removing a missing part should fail")
+	byArticle, _ := repo.GetByArticle(ctx, "b")
+	assert.Empty(byArticle, "the index should follow the removal")
+
+	assert.Equal(errors.ErrInvalidSeriesOrder, repo.Reorder(ctx, id, []data.ArticleID{"c"}), "an order missing a part should fail")
+	assert.Equal(errors.ErrInvalidSeriesOrder, repo.Reorder(ctx, id, []data.ArticleID{"c", "c"}), "an order repeating a part should fail")
+	assert.Nil(repo.Reorder(ctx, id, []data.ArticleID{"c", "a"}), "reorder should not return error")
+	byArticle, err = repo.GetByArticle(ctx, "a")
+	assert.Nil(err, "get by article should not return error")
+	assert.Len(byArticle, 1, "the series should contain the article")
+	assert.Equal([]data.ArticleID{"c", "a"}, byArticle[0].ArticleIDs, "the order should be replaced")
+}
+
+func Test_ReactionAndViewRepositoryBolt(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+	articleRepo := newTestBolt(t)
+	reactionRepo := repository.NewReactionRepositoryBolt(articleRepo)
+	viewRepo := repository.NewViewRepositoryBolt(articleRepo)
+
+	added, err := reactionRepo.Add(ctx, "1", "alice", "like")
+	assert.True(added, "the first reaction should be added")
+	assert.Nil(err, "add should not return error")
+	added, _ = reactionRepo.Add(ctx, "1", "alice", "like")
+	assert.False(added, "a repeated reaction should not be added")
+	_, _ = reactionRepo.Add(ctx, "1", "bob", "like")
+	_, _ = reactionRepo.Add(ctx, "2", "bob", "🎉")
+	removed, _ := reactionRepo.Remove(ctx, "2", "bob", "🎉")
+	assert.True(removed, "an existing reaction should be removed")
+	counts, err := reactionRepo.Counts(ctx, []data.ArticleID{"1", "2"})
+	assert.Nil(err, "counts should not return error")
+	assert.Equal(map[data.ArticleID]data.ReactionCounts{"1": {"like": 2}}, counts, "only the remaining reactions should be counted")
+	reactions, err := reactionRepo.GetByArticle(ctx, "1")
+	assert.Nil(err, "get by article should not return error")
+	assert.ElementsMatch([]data.Reaction{{User: "alice", Kind: "like"}, {User: "bob", Kind: "like"}}, reactions)
+
+	day := data.Day(time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC))
+	assert.Nil(viewRepo.AddViews(ctx, []data.ViewIncrement{
+		{ArticleID: "1", Day: day, Views: 2},
+		{ArticleID: "1", Day: day.Add(time.Hour), Views: 3},
+		{ArticleID: "2", Day: day.AddDate(0, 0, 1), Views: 4},
+		{ArticleID: "2", Day: day.AddDate(0, 0, 2), Views: 4},
+	}), "add views should not return error")
+	daily, err := viewRepo.GetDaily(ctx, "1", day, day.AddDate(0, 0, 1))
+	assert.Nil(err, "get daily should not return error")
+	assert.Equal([]data.DailyViews{{Day: day, Views: 5}}, daily, "the views of the same day should be summed")
+	top, err := viewRepo.GetTop(ctx, day, day.AddDate(0, 0, 1), 2)
+	assert.Nil(err, "get top should not return error")
+	assert.Equal([]data.ArticleViews{{ArticleID: "1", Views: 5}, {ArticleID: "2", Views: 4}}, top, "the views after the range should not be counted")
+}
+
+func Test_ArticleRepositoryBolt_TimesBefore1970(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+	repo := newTestBolt(t)
+	viewRepo := repository.NewViewRepositoryBolt(repo)
+
+	dates := []time.Time{time.Date(1969, 7, 20, 0, 0, 0, 0, time.UTC), time.Date(1960, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)}
+	for _, date := range dates {
+		_, err := repo.Import(ctx, &data.Article{ArticleInfo: data.ArticleInfo{Title: "title", Content: "content", AuthorIDs: []data.AuthorID{"1"}}, Version: data.FirstArticleVersion, CreatedAt: date, UpdatedAt: date})
+		assert.Nil(err, "import should not return error")
+	}
+	all, err := repo.GetAll(ctx)
+	assert.Nil(err)
+	assert.Len(all, 3)
+	for i, expected := range []time.Time{dates[1], dates[0], dates[2]} {
+		assert.True(expected.Equal(all[i].CreatedAt), "the articles created before 1970 should sort first")
+	}
+
+	assert.Nil(viewRepo.AddViews(ctx, []data.ViewIncrement{{ArticleID: "1", Day: dates[0], Views: 1}, {ArticleID: "1", Day: dates[2], Views: 2}}))
+	daily, err := viewRepo.GetDaily(ctx, "1", dates[1], dates[2])
+	assert.Nil(err)
+	assert.Equal([]data.DailyViews{{Day: dates[0], Views: 1}, {Day: dates[2], Views: 2}}, daily, "the days before 1970 should be decoded")
+}
+
+// oldBoltTimeKey builds a key like the repositories, with a time encoded before the sign was kept.
+func oldBoltTimeKey(timeFirst bool, t time.Time, other []byte) []byte {
+	encoded := make([]byte, 8)
+	binary.BigEndian.PutUint64(encoded, uint64(t.UnixNano()))
+	parts := [][]byte{other, encoded}
+	if timeFirst {
+		parts = [][]byte{encoded, other}
+	}
+	var key []byte
+	for _, part := range parts {
+		key = binary.BigEndian.AppendUint16(key, uint16(len(part)))
+		key = append(key, part...)
+	}
+	return key
+}
+
+func Test_ArticleRepositoryBolt_UpgradeTimes(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "blog.bolt")
+	old := time.Date(1969, 7, 20, 0, 0, 0, 0, time.UTC)
+	recent := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	db, err := bolt.Open(path, 0600, nil)
+	assert.Nil(err)
+	err = db.Update(func(tx *bolt.Tx) error {
+		buckets := map[string]*bolt.Bucket{}
+		for _, name := range []string{"articles", "articles_by_created", "article_views", "article_views_by_day"} {
+			bucket, err := tx.CreateBucket([]byte(name))
+			if err != nil {
+				return err
+			}
+			buckets[name] = bucket
+		}
+		for i, createdAt := range []time.Time{recent, old} {
+			key := binary.BigEndian.AppendUint64(nil, uint64(i+1))
+			value, err := json.Marshal(&repository.BoltArticle{Title: "title", Content: "content", AuthorIDs: []string{"1"}, CreatedAt: createdAt, UpdatedAt: createdAt})
+			if err != nil {
+				return err
+			}
+			if err := buckets["articles"].Put(key, value); err != nil {
+				return err
+			}
+			if err := buckets["articles_by_created"].Put(oldBoltTimeKey(true, createdAt, key), nil); err != nil {
+				return err
+			}
+		}
+		count := binary.BigEndian.AppendUint64(nil, 3)
+		if err := buckets["article_views"].Put(oldBoltTimeKey(false, recent, []byte("1")), count); err != nil {
+			return err
+		}
+		return buckets["article_views_by_day"].Put(oldBoltTimeKey(true, recent, []byte("1")), count)
+	})
+	assert.Nil(err)
+	assert.Nil(db.Close())
+
+	for i := 0; i < 2; i++ {
+		repo, err := repository.NewArticleRepositoryBolt(path)
+		assert.Nil(err, "opening an old database should not return error")
+		all, err := repo.GetAll(ctx)
+		assert.Nil(err)
+		assert.Equal([]data.ArticleID{"2", "1"}, []data.ArticleID{all[0].ID, all[1].ID}, "the upgraded keys should sort in chronological order")
+		viewRepo := repository.NewViewRepositoryBolt(repo)
+		daily, err := viewRepo.GetDaily(ctx, "1", old, recent)
+		assert.Nil(err)
+		assert.Equal([]data.DailyViews{{Day: recent, Views: 3}}, daily, "the days should be upgraded")
+		top, err := viewRepo.GetTop(ctx, old, recent, 1)
+		assert.Nil(err)
+		assert.Equal([]data.ArticleViews{{ArticleID: "1", Views: 3}}, top, "the days should be upgraded once")
+		assert.Nil(repo.Close())
+	}
+}
@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/Jason5Lee/simple-blog/core/data"
+	"github.com/Jason5Lee/simple-blog/core/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const resumeTokenCollectionName = "change_stream_tokens"
+
+// changeStreamRetryDelay is how long to wait before watching again after the change stream failed.
+const changeStreamRetryDelay = 5 * time.Second
+
+// The server error codes of the change streams.
+const (
+	// changeStreamNotSupportedCode is returned by a standalone server, as only replica sets and sharded clusters
+	// have change streams.
+	changeStreamNotSupportedCode = 40573
+	changeStreamFatalErrorCode   = 280
+	// changeStreamHistoryLostCode is returned when resuming after a change no longer in the oplog.
+	changeStreamHistoryLostCode = 286
+)
+
+// DBResumeToken is the resume token of the last change published to a consumer.
+type DBResumeToken struct {
+	Consumer  string    `bson:"_id"`
+	Token     bson.Raw  `bson:"token"`
+	UpdatedAt time.Time `bson:"updated_at"`
+}
+
+// DBArticleChange is a change event of the article collection.
+type DBArticleChange struct {
+	OperationType string   `bson:"operationType"`
+	DocumentKey   bson.Raw `bson:"documentKey"`
+	// FullDocument is the current document looked up when reading the change, null if it has been deleted since.
+	FullDocument bson.RawValue `bson:"fullDocument"`
+}
+
+// WatchArticlesMongoDB publishes the changes of the articles made by any replica, until the context is done.
+// The resume token of the last change published is stored for the consumer, so the changes made while it was
+// stopped are published when it watches again, at least once. If they are no longer in the oplog,
+// ArticlesReset is published instead. It returns ErrChangeStreamNotSupported if MongoDB is not a replica set.
+func WatchArticlesMongoDB(ctx context.Context, articleRepo *ArticleRepositoryMongoDB, consumer string, publish func(*data.ArticleEvent)) error {
+	for {
+		err := articleRepo.watch(ctx, consumer, publish)
+		if ctx.Err() != nil {
+			return nil
+		}
+		// The change stream ends without error when invalidated, e.g. by dropping the collection,
+		// and the stored token starts after it.
+		if err == nil {
+			continue
+		}
+		if serverErr, ok := err.(mongo.ServerError); ok {
+			if serverErr.HasErrorCode(changeStreamNotSupportedCode) {
+				return errors.ErrChangeStreamNotSupported
+			}
+			if serverErr.HasErrorCode(changeStreamHistoryLostCode) || serverErr.HasErrorCode(changeStreamFatalErrorCode) {
+				log.Printf("the changes of the articles since %s last watched are lost, watching from now: %v", consumer, err)
+				if _, err := articleRepo.db.Collection(resumeTokenCollectionName).DeleteOne(ctx, bson.M{"_id": consumer}); err != nil {
+					log.Printf("failed to delete the resume token of %s: %v", consumer, err)
+				}
+				publish(&data.ArticleEvent{Kind: data.ArticlesReset})
+				continue
+			}
+		}
+		log.Printf("failed to watch the articles, retrying in %v: %v", changeStreamRetryDelay, err)
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(changeStreamRetryDelay):
+		}
+	}
+}
+
+// watch publishes the changes after the stored resume token until the change stream ends,
+// storing the resume token after each change.
+func (repo *ArticleRepositoryMongoDB) watch(ctx context.Context, consumer string, publish func(*data.ArticleEvent)) error {
+	tokens := repo.db.Collection(resumeTokenCollectionName)
+	opts := options.ChangeStream().SetFullDocument(options.UpdateLookup)
+	var saved DBResumeToken
+	switch err := tokens.FindOne(ctx, bson.M{"_id": consumer}).Decode(&saved); err {
+	case nil:
+		// Unlike resumeAfter, startAfter also starts after an invalidate event.
+		opts.SetStartAfter(saved.Token)
+	case mongo.ErrNoDocuments:
+	default:
+		return err
+	}
+
+	stream, err := repo.collection().Watch(ctx, mongo.Pipeline{}, opts)
+	if err != nil {
+		return err
+	}
+	defer stream.Close(context.Background())
+	for stream.Next(ctx) {
+		var change DBArticleChange
+		if err := stream.Decode(&change); err != nil {
+			return err
+		}
+		if event := repo.articleEvent(ctx, &change); event != nil {
+			publish(event)
+		}
+		_, err := tokens.UpdateOne(ctx, bson.M{"_id": consumer},
+			bson.M{"$set": bson.M{"token": stream.ResumeToken(), "updated_at": time.Now()}},
+			options.Update().SetUpsert(true))
+		if err != nil {
+			return err
+		}
+	}
+	return stream.Err()
+}
+
+// articleEvent converts a change into an event, or nil if it does not change the articles.
+func (repo *ArticleRepositoryMongoDB) articleEvent(ctx context.Context, change *DBArticleChange) *data.ArticleEvent {
+	event := &data.ArticleEvent{ArticleID: data.ArticleID(mongoDBDocumentID(change.DocumentKey))}
+	switch change.OperationType {
+	case "insert":
+		event.Kind = data.ArticleCreated
+	case "update", "replace":
+		event.Kind = data.ArticleUpdated
+	case "delete":
+		event.Kind = data.ArticleDeleted
+		return event
+	case "drop", "rename", "dropDatabase":
+		return &data.ArticleEvent{Kind: data.ArticlesReset}
+	default:
+		return nil
+	}
+	if change.FullDocument.Type != bsontype.EmbeddedDocument {
+		return event
+	}
+	article, err := repo.decode(ctx, change.FullDocument.Document())
+	if err != nil {
+		log.Printf("failed to read the changed article %s: %v", event.ArticleID, err)
+		return event
+	}
+	event.Article = article
+	return event
+}
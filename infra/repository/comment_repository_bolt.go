@@ -0,0 +1,216 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/Jason5Lee/simple-blog/core/data"
+	"github.com/Jason5Lee/simple-blog/core/errors"
+	"github.com/Jason5Lee/simple-blog/core/repository"
+	bolt "go.etcd.io/bbolt"
+)
+
+// Data stored in bbolt, with the ID as the key.
+type BoltComment struct {
+	ArticleID string    `json:"article_id"`
+	Author    string    `json:"author"`
+	Content   string    `json:"content"`
+	Status    string    `json:"status"`
+	SpamScore float64   `json:"spam_score"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// CommentRepositoryBolt is a bbolt implementation of CommentRepository.
+// The comments are indexed by status and by article and status in separate buckets.
+// As the writes are serialized, the order of the sequential IDs is the creation order.
+type CommentRepositoryBolt struct {
+	db *bolt.DB
+}
+
+var (
+	boltCommentsBucket          = []byte("comments")
+	boltCommentsByStatusBucket  = []byte("comments_by_status")
+	boltCommentsByArticleBucket = []byte("comments_by_article")
+)
+
+// NewCommentRepositoryBolt creates a new CommentRepositoryBolt sharing the database of the article repository.
+func NewCommentRepositoryBolt(articleRepo *ArticleRepositoryBolt) *CommentRepositoryBolt {
+	return &CommentRepositoryBolt{db: articleRepo.db}
+}
+
+func (repo *CommentRepositoryBolt) Create(ctx context.Context, comment *data.CommentInfo, status data.CommentStatus, spamScore float64) (data.CommentID, error) {
+	var id data.CommentID
+	err := repo.db.Update(func(tx *bolt.Tx) error {
+		seq, err := tx.Bucket(boltCommentsBucket).NextSequence()
+		if err != nil {
+			return err
+		}
+		id = data.CommentID(strconv.FormatUint(seq, 10))
+		return putBoltComment(tx, boltID(seq), &BoltComment{
+			ArticleID: string(comment.ArticleID),
+			Author:    string(comment.Author),
+			Content:   string(comment.Content),
+			Status:    string(status),
+			SpamScore: spamScore,
+			CreatedAt: time.Now(),
+		})
+	})
+	return id, err
+}
+
+func (repo *CommentRepositoryBolt) GetByID(ctx context.Context, id data.CommentID) (*data.Comment, error) {
+	key, ok := parseBoltID(string(id))
+	if !ok {
+		// Invalid ID does not match any record, so we return ErrCommentNotFound.
+		return nil, errors.ErrCommentNotFound
+	}
+	var result *data.Comment
+	err := repo.db.View(func(tx *bolt.Tx) error {
+		comment, err := getBoltComment(tx, key)
+		if err != nil {
+			return err
+		}
+		result = comment.toComment(key)
+		return nil
+	})
+	return result, err
+}
+
+func (repo *CommentRepositoryBolt) GetByArticle(ctx context.Context, articleID data.ArticleID, status data.CommentStatus) ([]*data.Comment, error) {
+	return repo.findIndexed(boltCommentsByArticleBucket, boltKey([]byte(articleID), []byte(status)))
+}
+
+func (repo *CommentRepositoryBolt) GetByStatus(ctx context.Context, status data.CommentStatus) ([]*data.Comment, error) {
+	return repo.findIndexed(boltCommentsByStatusBucket, boltKey([]byte(status)))
+}
+
+func (repo *CommentRepositoryBolt) SetStatus(ctx context.Context, ids []data.CommentID, status data.CommentStatus) (int64, error) {
+	var updated int64
+	err := repo.db.Update(func(tx *bolt.Tx) error {
+		updated = 0
+		seen := make(map[data.CommentID]bool, len(ids))
+		for _, id := range ids {
+			key, ok := parseBoltID(string(id))
+			if !ok || seen[id] {
+				// Invalid IDs do not match any record, so they are skipped.
+				continue
+			}
+			seen[id] = true
+			comment, err := getBoltComment(tx, key)
+			if err == errors.ErrCommentNotFound {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			if err := deleteBoltCommentIndexes(tx, key, comment); err != nil {
+				return err
+			}
+			comment.Status = string(status)
+			if err := putBoltComment(tx, key, comment); err != nil {
+				return err
+			}
+			updated++
+		}
+		return nil
+	})
+	return updated, err
+}
+
+func (repo *CommentRepositoryBolt) DeleteByStatus(ctx context.Context, status data.CommentStatus) (int64, error) {
+	var deleted int64
+	err := repo.db.Update(func(tx *bolt.Tx) error {
+		deleted = 0
+		// The keys are collected first, as a bucket must not be modified while iterating it.
+		prefix := boltKey([]byte(status))
+		var keys [][]byte
+		cursor := tx.Bucket(boltCommentsByStatusBucket).Cursor()
+		for indexKey, _ := cursor.Seek(prefix); indexKey != nil && bytes.HasPrefix(indexKey, prefix); indexKey, _ = cursor.Next() {
+			keys = append(keys, append([]byte(nil), indexKey[len(indexKey)-8:]...))
+		}
+		for _, key := range keys {
+			comment, err := getBoltComment(tx, key)
+			if err != nil {
+				return err
+			}
+			if err := deleteBoltCommentIndexes(tx, key, comment); err != nil {
+				return err
+			}
+			if err := tx.Bucket(boltCommentsBucket).Delete(key); err != nil {
+				return err
+			}
+			deleted++
+		}
+		return nil
+	})
+	return deleted, err
+}
+
+// findIndexed gets the comments referenced by the keys of the index with the prefix, oldest first.
+func (repo *CommentRepositoryBolt) findIndexed(index []byte, prefix []byte) ([]*data.Comment, error) {
+	result := []*data.Comment{}
+	err := repo.db.View(func(tx *bolt.Tx) error {
+		cursor := tx.Bucket(index).Cursor()
+		for indexKey, _ := cursor.Seek(prefix); indexKey != nil && bytes.HasPrefix(indexKey, prefix); indexKey, _ = cursor.Next() {
+			key := indexKey[len(indexKey)-8:]
+			comment, err := getBoltComment(tx, key)
+			if err != nil {
+				return err
+			}
+			result = append(result, comment.toComment(key))
+		}
+		return nil
+	})
+	return result, err
+}
+
+func getBoltComment(tx *bolt.Tx, key []byte) (*BoltComment, error) {
+	value := tx.Bucket(boltCommentsBucket).Get(key)
+	if value == nil {
+		return nil, errors.ErrCommentNotFound
+	}
+	var comment BoltComment
+	if err := json.Unmarshal(value, &comment); err != nil {
+		return nil, err
+	}
+	return &comment, nil
+}
+
+// putBoltComment puts the comment and its index entries.
+func putBoltComment(tx *bolt.Tx, key []byte, comment *BoltComment) error {
+	if err := putBoltJSON(tx.Bucket(boltCommentsBucket), key, comment); err != nil {
+		return err
+	}
+	if err := tx.Bucket(boltCommentsByStatusBucket).Put(boltKey([]byte(comment.Status), key), nil); err != nil {
+		return err
+	}
+	return tx.Bucket(boltCommentsByArticleBucket).Put(boltKey([]byte(comment.ArticleID), []byte(comment.Status), key), nil)
+}
+
+func deleteBoltCommentIndexes(tx *bolt.Tx, key []byte, comment *BoltComment) error {
+	if err := tx.Bucket(boltCommentsByStatusBucket).Delete(boltKey([]byte(comment.Status), key)); err != nil {
+		return err
+	}
+	return tx.Bucket(boltCommentsByArticleBucket).Delete(boltKey([]byte(comment.ArticleID), []byte(comment.Status), key))
+}
+
+func (comment *BoltComment) toComment(key []byte) *data.Comment {
+	return &data.Comment{
+		ID: data.CommentID(strconv.FormatUint(binary.BigEndian.Uint64(key), 10)),
+		// Assume the data in bbolt is valid.
+		CommentInfo: data.CommentInfo{
+			ArticleID: data.ArticleID(comment.ArticleID),
+			Author:    data.CommentAuthor(comment.Author),
+			Content:   data.CommentContent(comment.Content),
+		},
+		Status:    data.CommentStatus(comment.Status),
+		SpamScore: comment.SpamScore,
+		CreatedAt: comment.CreatedAt,
+	}
+}
+
+var _ repository.CommentRepository = (*CommentRepositoryBolt)(nil)
@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Jason5Lee/simple-blog/core/data"
+	"github.com/Jason5Lee/simple-blog/core/errors"
+	"github.com/Jason5Lee/simple-blog/core/repository"
+)
+
+// In-memory implementation of CommentRepository.
+type CommentRepositoryInMemory struct {
+	mu       sync.RWMutex
+	nextID   int
+	comments map[data.CommentID]*data.Comment
+	// order keeps the IDs in creation order.
+	order []data.CommentID
+}
+
+func NewCommentRepositoryInMemory() *CommentRepositoryInMemory {
+	return &CommentRepositoryInMemory{
+		comments: make(map[data.CommentID]*data.Comment),
+	}
+}
+
+func (r *CommentRepositoryInMemory) Create(ctx context.Context, comment *data.CommentInfo, status data.CommentStatus, spamScore float64) (data.CommentID, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.nextID++
+	id := data.CommentID(fmt.Sprint(r.nextID))
+	r.comments[id] = &data.Comment{
+		ID:          id,
+		CommentInfo: *comment,
+		Status:      status,
+		SpamScore:   spamScore,
+		CreatedAt:   time.Now(),
+	}
+	r.order = append(r.order, id)
+	return id, nil
+}
+
+func (r *CommentRepositoryInMemory) GetByID(ctx context.Context, id data.CommentID) (*data.Comment, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	comment, ok := r.comments[id]
+	if !ok {
+		return nil, errors.ErrCommentNotFound
+	}
+	result := *comment
+	return &result, nil
+}
+
+func (r *CommentRepositoryInMemory) GetByArticle(ctx context.Context, articleID data.ArticleID, status data.CommentStatus) ([]*data.Comment, error) {
+	return r.filter(func(c *data.Comment) bool {
+		return c.ArticleID == articleID && c.Status == status
+	}), nil
+}
+
+func (r *CommentRepositoryInMemory) GetByStatus(ctx context.Context, status data.CommentStatus) ([]*data.Comment, error) {
+	return r.filter(func(c *data.Comment) bool {
+		return c.Status == status
+	}), nil
+}
+
+func (r *CommentRepositoryInMemory) SetStatus(ctx context.Context, ids []data.CommentID, status data.CommentStatus) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var updated int64
+	for _, id := range ids {
+		if comment, ok := r.comments[id]; ok {
+			comment.Status = status
+			updated++
+		}
+	}
+	return updated, nil
+}
+
+func (r *CommentRepositoryInMemory) DeleteByStatus(ctx context.Context, status data.CommentStatus) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var deleted int64
+	order := r.order[:0]
+	for _, id := range r.order {
+		if r.comments[id].Status == status {
+			delete(r.comments, id)
+			deleted++
+		} else {
+			order = append(order, id)
+		}
+	}
+	r.order = order
+	return deleted, nil
+}
+
+func (r *CommentRepositoryInMemory) filter(pred func(*data.Comment) bool) []*data.Comment {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	result := make([]*data.Comment, 0)
+	for _, id := range r.order {
+		if comment := r.comments[id]; pred(comment) {
+			c := *comment
+			result = append(result, &c)
+		}
+	}
+	return result
+}
+
+var _ repository.CommentRepository = (*CommentRepositoryInMemory)(nil)
@@ -0,0 +1,152 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Jason5Lee/simple-blog/core/data"
+	"github.com/Jason5Lee/simple-blog/core/errors"
+	"github.com/Jason5Lee/simple-blog/core/repository"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Data for inserting into MongoDB.
+type DBCommentInfo struct {
+	ArticleID string    `bson:"article_id"`
+	Author    string    `bson:"author"`
+	Content   string    `bson:"content"`
+	Status    string    `bson:"status"`
+	SpamScore float64   `bson:"spam_score"`
+	CreatedAt time.Time `bson:"created_at"`
+}
+
+// Data for reading from MongoDB, with extra field "_id".
+type DBComment struct {
+	ID            primitive.ObjectID `bson:"_id"`
+	DBCommentInfo `bson:",inline"`
+}
+
+// CommentRepositoryMongoDB is a MongoDB implementation of CommentRepository.
+type CommentRepositoryMongoDB struct {
+	db *mongo.Database
+}
+
+const commentCollectionName = "comments"
+
+// NewCommentRepositoryMongoDB creates a new CommentRepositoryMongoDB sharing the connection of the article repository.
+func NewCommentRepositoryMongoDB(articleRepo *ArticleRepositoryMongoDB) *CommentRepositoryMongoDB {
+	return &CommentRepositoryMongoDB{db: articleRepo.db}
+}
+
+func (repo *CommentRepositoryMongoDB) collection() *mongo.Collection {
+	return repo.db.Collection(commentCollectionName)
+}
+
+func (repo *CommentRepositoryMongoDB) Create(ctx context.Context, comment *data.CommentInfo, status data.CommentStatus, spamScore float64) (data.CommentID, error) {
+	insertResult, err := repo.collection().InsertOne(ctx, DBCommentInfo{
+		ArticleID: string(comment.ArticleID),
+		Author:    string(comment.Author),
+		Content:   string(comment.Content),
+		Status:    string(status),
+		SpamScore: spamScore,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		return "", err
+	}
+	return data.CommentID(insertResult.InsertedID.(primitive.ObjectID).Hex()), nil
+}
+
+func (repo *CommentRepositoryMongoDB) GetByID(ctx context.Context, id data.CommentID) (*data.Comment, error) {
+	docID, err := primitive.ObjectIDFromHex(string(id))
+	if err != nil {
+		// Invalid ID does not match any document, so we return ErrCommentNotFound.
+		return nil, errors.ErrCommentNotFound
+	}
+	var comment DBComment
+	if err := repo.collection().FindOne(ctx, bson.M{"_id": docID}).Decode(&comment); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.ErrCommentNotFound
+		}
+		return nil, err
+	}
+	return comment.toComment(), nil
+}
+
+func (repo *CommentRepositoryMongoDB) GetByArticle(ctx context.Context, articleID data.ArticleID, status data.CommentStatus) ([]*data.Comment, error) {
+	return repo.find(ctx, bson.M{"article_id": string(articleID), "status": string(status)})
+}
+
+func (repo *CommentRepositoryMongoDB) GetByStatus(ctx context.Context, status data.CommentStatus) ([]*data.Comment, error) {
+	return repo.find(ctx, bson.M{"status": string(status)})
+}
+
+func (repo *CommentRepositoryMongoDB) SetStatus(ctx context.Context, ids []data.CommentID, status data.CommentStatus) (int64, error) {
+	docIDs := make([]primitive.ObjectID, 0, len(ids))
+	for _, id := range ids {
+		// Invalid IDs do not match any document, so they are skipped.
+		if docID, err := primitive.ObjectIDFromHex(string(id)); err == nil {
+			docIDs = append(docIDs, docID)
+		}
+	}
+	if len(docIDs) == 0 {
+		return 0, nil
+	}
+	updateResult, err := repo.collection().UpdateMany(ctx,
+		bson.M{"_id": bson.M{"$in": docIDs}},
+		bson.M{"$set": bson.M{"status": string(status)}},
+	)
+	if err != nil {
+		return 0, err
+	}
+	return updateResult.MatchedCount, nil
+}
+
+func (repo *CommentRepositoryMongoDB) DeleteByStatus(ctx context.Context, status data.CommentStatus) (int64, error) {
+	deleteResult, err := repo.collection().DeleteMany(ctx, bson.M{"status": string(status)})
+	if err != nil {
+		return 0, err
+	}
+	return deleteResult.DeletedCount, nil
+}
+
+func (repo *CommentRepositoryMongoDB) find(ctx context.Context, filter bson.M) ([]*data.Comment, error) {
+	cursor, err := repo.collection().Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	var comments []*DBComment
+	if err := cursor.All(ctx, &comments); err != nil {
+		return nil, err
+	}
+	result := make([]*data.Comment, len(comments))
+	for i, comment := range comments {
+		result[i] = comment.toComment()
+	}
+	return result, nil
+}
+
+func (comment *DBComment) toComment() *data.Comment {
+	return &data.Comment{
+		ID: data.CommentID(comment.ID.Hex()),
+		// Assume the data in MongoDB is valid.
+		CommentInfo: data.CommentInfo{
+			ArticleID: data.ArticleID(comment.ArticleID),
+			Author:    data.CommentAuthor(comment.Author),
+			Content:   data.CommentContent(comment.Content),
+		},
+		Status:    data.CommentStatus(comment.Status),
+		SpamScore: comment.SpamScore,
+		CreatedAt: comment.CreatedAt,
+	}
+}
+
+// Dropping the collection for integration testing.
+func (repo *CommentRepositoryMongoDB) Drop() error {
+	return repo.collection().Drop(context.Background())
+}
+
+var _ repository.CommentRepository = (*CommentRepositoryMongoDB)(nil)
@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/Jason5Lee/simple-blog/core/data"
+	"github.com/Jason5Lee/simple-blog/core/errors"
+	"github.com/Jason5Lee/simple-blog/core/repository"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// CommentRepositoryPostgres is a PostgreSQL implementation of CommentRepository.
+type CommentRepositoryPostgres struct {
+	pool *pgxpool.Pool
+}
+
+// NewCommentRepositoryPostgres creates a new CommentRepositoryPostgres sharing the pool of the article repository.
+func NewCommentRepositoryPostgres(articleRepo *ArticleRepositoryPostgres) *CommentRepositoryPostgres {
+	return &CommentRepositoryPostgres{pool: articleRepo.pool}
+}
+
+func (repo *CommentRepositoryPostgres) Create(ctx context.Context, comment *data.CommentInfo, status data.CommentStatus, spamScore float64) (data.CommentID, error) {
+	var rowID int64
+	err := repo.pool.QueryRow(ctx,
+		"INSERT INTO comments (article_id, author, content, status, spam_score, created_at) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id",
+		string(comment.ArticleID), string(comment.Author), string(comment.Content), string(status), spamScore, time.Now(),
+	).Scan(&rowID)
+	if err != nil {
+		return "", err
+	}
+	return data.CommentID(strconv.FormatInt(rowID, 10)), nil
+}
+
+func (repo *CommentRepositoryPostgres) GetByID(ctx context.Context, id data.CommentID) (*data.Comment, error) {
+	rowID, ok := parseRowID(string(id))
+	if !ok {
+		// Invalid ID does not match any row, so we return ErrCommentNotFound.
+		return nil, errors.ErrCommentNotFound
+	}
+	comments, err := repo.find(ctx, "WHERE id = $1", rowID)
+	if err != nil {
+		return nil, err
+	}
+	if len(comments) == 0 {
+		return nil, errors.ErrCommentNotFound
+	}
+	return comments[0], nil
+}
+
+func (repo *CommentRepositoryPostgres) GetByArticle(ctx context.Context, articleID data.ArticleID, status data.CommentStatus) ([]*data.Comment, error) {
+	return repo.find(ctx, "WHERE article_id = $1 AND status = $2 ORDER BY created_at, id", string(articleID), string(status))
+}
+
+func (repo *CommentRepositoryPostgres) GetByStatus(ctx context.Context, status data.CommentStatus) ([]*data.Comment, error) {
+	return repo.find(ctx, "WHERE status = $1 ORDER BY created_at, id", string(status))
+}
+
+func (repo *CommentRepositoryPostgres) SetStatus(ctx context.Context, ids []data.CommentID, status data.CommentStatus) (int64, error) {
+	rowIDs := make([]int64, 0, len(ids))
+	for _, id := range ids {
+		// Invalid IDs do not match any row, so they are skipped.
+		if rowID, ok := parseRowID(string(id)); ok {
+			rowIDs = append(rowIDs, rowID)
+		}
+	}
+	if len(rowIDs) == 0 {
+		return 0, nil
+	}
+	tag, err := repo.pool.Exec(ctx, "UPDATE comments SET status = $1 WHERE id = ANY($2)", string(status), rowIDs)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+func (repo *CommentRepositoryPostgres) DeleteByStatus(ctx context.Context, status data.CommentStatus) (int64, error) {
+	tag, err := repo.pool.Exec(ctx, "DELETE FROM comments WHERE status = $1", string(status))
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+// find gets the comments with the clause following "FROM comments".
+func (repo *CommentRepositoryPostgres) find(ctx context.Context, clause string, args ...interface{}) ([]*data.Comment, error) {
+	rows, err := repo.pool.Query(ctx,
+		"SELECT id, article_id, author, content, status, spam_score, created_at FROM comments "+clause, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []*data.Comment{}
+	for rows.Next() {
+		var rowID int64
+		var articleID, author, content, status string
+		comment := &data.Comment{}
+		if err := rows.Scan(&rowID, &articleID, &author, &content, &status, &comment.SpamScore, &comment.CreatedAt); err != nil {
+			return nil, err
+		}
+		comment.ID = data.CommentID(strconv.FormatInt(rowID, 10))
+		// Assume the data in PostgreSQL is valid.
+		comment.CommentInfo = data.CommentInfo{
+			ArticleID: data.ArticleID(articleID),
+			Author:    data.CommentAuthor(author),
+			Content:   data.CommentContent(content),
+		}
+		comment.Status = data.CommentStatus(status)
+		result = append(result, comment)
+	}
+	return result, rows.Err()
+}
+
+var _ repository.CommentRepository = (*CommentRepositoryPostgres)(nil)
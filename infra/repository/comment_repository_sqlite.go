@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"time"
+
+	"github.com/Jason5Lee/simple-blog/core/data"
+	"github.com/Jason5Lee/simple-blog/core/errors"
+	"github.com/Jason5Lee/simple-blog/core/repository"
+)
+
+// CommentRepositorySQLite is a SQLite implementation of CommentRepository.
+type CommentRepositorySQLite struct {
+	db *sql.DB
+}
+
+// NewCommentRepositorySQLite creates a new CommentRepositorySQLite sharing the database of the article repository.
+func NewCommentRepositorySQLite(articleRepo *ArticleRepositorySQLite) *CommentRepositorySQLite {
+	return &CommentRepositorySQLite{db: articleRepo.db}
+}
+
+func (repo *CommentRepositorySQLite) Create(ctx context.Context, comment *data.CommentInfo, status data.CommentStatus, spamScore float64) (data.CommentID, error) {
+	result, err := repo.db.ExecContext(ctx,
+		"INSERT INTO comments (article_id, author, content, status, spam_score, created_at) VALUES (?, ?, ?, ?, ?, ?)",
+		string(comment.ArticleID), string(comment.Author), string(comment.Content), string(status), spamScore, formatSQLiteTime(time.Now()))
+	if err != nil {
+		return "", err
+	}
+	rowID, err := result.LastInsertId()
+	if err != nil {
+		return "", err
+	}
+	return data.CommentID(strconv.FormatInt(rowID, 10)), nil
+}
+
+func (repo *CommentRepositorySQLite) GetByID(ctx context.Context, id data.CommentID) (*data.Comment, error) {
+	rowID, ok := parseRowID(string(id))
+	if !ok {
+		// Invalid ID does not match any row, so we return ErrCommentNotFound.
+		return nil, errors.ErrCommentNotFound
+	}
+	comments, err := repo.find(ctx, "WHERE id = ?", rowID)
+	if err != nil {
+		return nil, err
+	}
+	if len(comments) == 0 {
+		return nil, errors.ErrCommentNotFound
+	}
+	return comments[0], nil
+}
+
+func (repo *CommentRepositorySQLite) GetByArticle(ctx context.Context, articleID data.ArticleID, status data.CommentStatus) ([]*data.Comment, error) {
+	return repo.find(ctx, "WHERE article_id = ? AND status = ? ORDER BY created_at, id", string(articleID), string(status))
+}
+
+func (repo *CommentRepositorySQLite) GetByStatus(ctx context.Context, status data.CommentStatus) ([]*data.Comment, error) {
+	return repo.find(ctx, "WHERE status = ? ORDER BY created_at, id", string(status))
+}
+
+func (repo *CommentRepositorySQLite) SetStatus(ctx context.Context, ids []data.CommentID, status data.CommentStatus) (int64, error) {
+	args := make([]interface{}, 0, len(ids)+1)
+	args = append(args, string(status))
+	for _, id := range ids {
+		// Invalid IDs do not match any row, so they are skipped.
+		if rowID, ok := parseRowID(string(id)); ok {
+			args = append(args, rowID)
+		}
+	}
+	if len(args) == 1 {
+		return 0, nil
+	}
+	result, err := repo.db.ExecContext(ctx, "UPDATE comments SET status = ? WHERE id IN ("+sqlitePlaceholders(len(args)-1)+")", args...)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+func (repo *CommentRepositorySQLite) DeleteByStatus(ctx context.Context, status data.CommentStatus) (int64, error) {
+	result, err := repo.db.ExecContext(ctx, "DELETE FROM comments WHERE status = ?", string(status))
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// find gets the comments with the clause following "FROM comments".
+func (repo *CommentRepositorySQLite) find(ctx context.Context, clause string, args ...interface{}) ([]*data.Comment, error) {
+	rows, err := repo.db.QueryContext(ctx,
+		"SELECT id, article_id, author, content, status, spam_score, created_at FROM comments "+clause, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []*data.Comment{}
+	for rows.Next() {
+		var rowID int64
+		var articleID, author, content, status, createdAt string
+		var spamScore float64
+		if err := rows.Scan(&rowID, &articleID, &author, &content, &status, &spamScore, &createdAt); err != nil {
+			return nil, err
+		}
+		comment := &data.Comment{
+			ID: data.CommentID(strconv.FormatInt(rowID, 10)),
+			// Assume the data in SQLite is valid.
+			CommentInfo: data.CommentInfo{
+				ArticleID: data.ArticleID(articleID),
+				Author:    data.CommentAuthor(author),
+				Content:   data.CommentContent(content),
+			},
+			Status:    data.CommentStatus(status),
+			SpamScore: spamScore,
+		}
+		if comment.CreatedAt, err = parseSQLiteTime(createdAt); err != nil {
+			return nil, err
+		}
+		result = append(result, comment)
+	}
+	return result, rows.Err()
+}
+
+var _ repository.CommentRepository = (*CommentRepositorySQLite)(nil)
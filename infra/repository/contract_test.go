@@ -0,0 +1,52 @@
+package repository_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/Jason5Lee/simple-blog/core/repository"
+	"github.com/Jason5Lee/simple-blog/core/repository/repositorytest"
+	infra_repository "github.com/Jason5Lee/simple-blog/infra/repository"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ArticleRepositoryContract(t *testing.T) {
+	backends := []struct {
+		name    string
+		newRepo repositorytest.NewArticleRepository
+	}{
+		{"InMemory", func(t *testing.T) repository.ArticleRepository {
+			return infra_repository.NewArticleRepositoryInMemory()
+		}},
+		{"SQLite", func(t *testing.T) repository.ArticleRepository {
+			return newTestSQLite(t)
+		}},
+		{"Bolt", func(t *testing.T) repository.ArticleRepository {
+			return newTestBolt(t)
+		}},
+		{"Filesystem", func(t *testing.T) repository.ArticleRepository {
+			repo, err := infra_repository.NewArticleRepositoryFilesystem(t.TempDir())
+			require.NoError(t, err)
+			t.Cleanup(func() { _ = repo.Close() })
+			return repo
+		}},
+		{"Git", func(t *testing.T) repository.ArticleRepository {
+			repo, err := infra_repository.NewArticleRepositoryGit(filepath.Join(t.TempDir(), "blog"))
+			require.NoError(t, err)
+			return repo
+		}},
+		{"Cached", func(t *testing.T) repository.ArticleRepository {
+			return infra_repository.NewArticleRepositoryCached(infra_repository.NewArticleRepositoryInMemory(), infra_repository.NewArticleCacheLRU(16, 0))
+		}},
+		{"CachedRedis", func(t *testing.T) repository.ArticleRepository {
+			cache := newTestArticleCacheRedis(t, miniredis.RunT(t), nil)
+			return infra_repository.NewArticleRepositoryCached(infra_repository.NewArticleRepositoryInMemory(), cache)
+		}},
+	}
+	for _, backend := range backends {
+		t.Run(backend.name, func(t *testing.T) {
+			repositorytest.TestArticleRepository(t, backend.newRepo)
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package repository_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Jason5Lee/simple-blog/core/data"
+	"github.com/Jason5Lee/simple-blog/core/errors"
+	"github.com/Jason5Lee/simple-blog/infra/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ArticleRepositoryFilesystem(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+	dir := t.TempDir()
+	repo, err := repository.NewArticleRepositoryFilesystem(dir)
+	assert.Nil(err, "opening a new directory should not return error")
+
+	id, err := repo.Create(ctx, &data.ArticleInfo{Title: "Hello, World!", Content: "# Hi\n", AuthorIDs: []data.AuthorID{"2", "1"}})
+	assert.Nil(err, "create should not return error")
+	assert.Equal(data.ArticleID("hello-world"), id, "the slug should be generated from the title")
+	otherID, _ := repo.Create(ctx, &data.ArticleInfo{Title: "Hello world", Content: "Again", AuthorIDs: []data.AuthorID{"1"}})
+	assert.Equal(data.ArticleID("hello-world-2"), otherID, "the slug should be unique")
+
+	raw, err := os.ReadFile(filepath.Join(dir, "hello-world.md"))
+	assert.Nil(err, "the article should be stored as a file named by the slug")
+	assert.True(strings.HasPrefix(string(raw), "---\ntitle: Hello, World!\n"), "the file should start with the front matter")
+	assert.True(strings.HasSuffix(string(raw), "---\n\n# Hi\n"), "the content should follow the front matter")
+
+	assert.Nil(repo.Update(ctx, id, data.FirstArticleVersion, &data.ArticleInfo{Title: "Renamed", Content: "There", AuthorIDs: []data.AuthorID{"1"}}), "update should not return error")
+	article, err := repo.GetByID(ctx, id)
+	assert.Nil(err, "get by id should not return error")
+	assert.Equal(data.ArticleTitle("Renamed"), article.Title, "title should be updated")
+	assert.Equal([]data.AuthorID{"1"}, article.AuthorIDs, "authors should be replaced")
+	byAuthor, _ := repo.GetByAuthor(ctx, "2")
+	assert.Empty(byAuthor, "a removed author should no longer match")
+
+	// Reopening loads the same articles from the files.
+	assert.Nil(repo.Close(), "close should not return error")
+	repo, err = repository.NewArticleRepositoryFilesystem(dir)
+	assert.Nil(err, "reopening the directory should not return error")
+	defer repo.Close()
+	reloaded, err := repo.GetByID(ctx, id)
+	assert.Nil(err, "get by id should not return error")
+	assert.Equal(article, reloaded, "the article should survive reopening")
+	all, _ := repo.GetAll(ctx)
+	assert.Equal([]data.ArticleID{id, otherID}, []data.ArticleID{all[0].ID, all[1].ID}, "the articles should be ordered by creation time")
+
+	for _, invalid := range []data.ArticleID{"", "missing", "../hello-world", "hello-world.md"} {
+		_, err = repo.GetByID(ctx, invalid)
+		assert.Equal(errors.ErrNotFound, err, "get by id %q should return ErrNotFound", invalid)
+		assert.Equal(errors.ErrNotFound, repo.Update(ctx, invalid, data.FirstArticleVersion, &article.ArticleInfo), "update %q should return ErrNotFound", invalid)
+		assert.Equal(errors.ErrNotFound, repo.Delete(ctx, invalid, data.FirstArticleVersion), "delete %q should return ErrNotFound", invalid)
+	}
+
+	assert.Nil(repo.Delete(ctx, id, article.Version), "delete should not return error")
+	_, err = os.Stat(filepath.Join(dir, "hello-world.md"))
+	assert.True(os.IsNotExist(err), "the file should be removed")
+	_, err = repo.GetByID(ctx, id)
+	assert.Equal(errors.ErrNotFound, err, "deleted article should not be found")
+}
+
+func Test_ArticleRepositoryFilesystem_ExternalEdits(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+	dir := t.TempDir()
+	assert.Nil(os.WriteFile(filepath.Join(dir, "invalid.md"), []byte("No front matter"), 0644))
+	repo, err := repository.NewArticleRepositoryFilesystem(dir)
+	assert.Nil(err, "an invalid file should not prevent opening")
+	defer repo.Close()
+	all, _ := repo.GetAll(ctx)
+	assert.Empty(all, "an invalid file should be skipped")
+
+	path := filepath.Join(dir, "my-post.md")
+	assert.Nil(os.WriteFile(path, []byte("---\ntitle: My Post\nauthor: 1\ntags: [go, blog]\ndate: 2024-03-01\n---\nWritten in an editor.\n"), 0644))
+	assert.Eventually(func() bool {
+		_, err := repo.GetByID(ctx, "my-post")
+		return err == nil
+	}, 5*time.Second, 10*time.Millisecond, "a new file should be picked up")
+	article, _ := repo.GetByID(ctx, "my-post")
+	assert.Equal(data.ArticleTitle("My Post"), article.Title, "the title should be read from the front matter")
+	assert.Equal([]data.AuthorID{"1"}, article.AuthorIDs, "the single author should be read")
+	assert.Equal(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), article.CreatedAt, "the date should be read")
+	assert.Equal(article.CreatedAt, article.UpdatedAt, "the update date should default to the date")
+	assert.Equal(data.ArticleContent("Written in an editor.\n"), article.Content, "the content should follow the front matter")
+
+	// The tags are kept when the article is updated through the repository.
+	assert.Nil(repo.Update(ctx, "my-post", article.Version, &article.ArticleInfo), "update should not return error")
+	raw, _ := os.ReadFile(path)
+	assert.Contains(string(raw), "tags:\n    - go\n    - blog\n", "the tags should be kept")
+
+	assert.Nil(os.WriteFile(path, []byte("---\ntitle: Edited\nauthors: [\"1\"]\ndate: 2024-03-01T00:00:00Z\n---\n\nEdited.\n"), 0644))
+	assert.Eventually(func() bool {
+		article, err := repo.GetByID(ctx, "my-post")
+		return err == nil && article.Title == "Edited"
+	}, 5*time.Second, 10*time.Millisecond, "an edited file should be picked up")
+
+	assert.Nil(os.Remove(path))
+	assert.Eventually(func() bool {
+		_, err := repo.GetByID(ctx, "my-post")
+		return err == errors.ErrNotFound
+	}, 5*time.Second, 10*time.Millisecond, "a removed file should be picked up")
+}
@@ -0,0 +1,66 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Jason5Lee/simple-blog/core/data"
+	"github.com/Jason5Lee/simple-blog/core/errors"
+	"github.com/Jason5Lee/simple-blog/infra/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ArticleRepositoryGit(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+	dir := t.TempDir()
+	repo, err := repository.NewArticleRepositoryGit(dir)
+	assert.Nil(err, "initializing a new repository should not return error")
+	revisions, err := repo.GetRevisions(ctx, "hello")
+	assert.Equal(errors.ErrNotFound, err, "an empty repository should have no revision")
+	assert.Nil(revisions)
+
+	alice := data.WithActor(ctx, &data.Actor{AuthorID: "1"})
+	id, err := repo.Create(alice, &data.ArticleInfo{Title: "Hello", Content: "First", AuthorIDs: []data.AuthorID{"1"}})
+	assert.Nil(err, "create should not return error")
+	assert.Equal(data.ArticleID("hello"), id, "the slug should be generated from the title")
+	admin := data.WithActor(ctx, &data.Actor{Admin: true})
+	assert.Nil(repo.Update(admin, id, data.FirstArticleVersion, &data.ArticleInfo{Title: "Hello", Content: "Second", AuthorIDs: []data.AuthorID{"1", "2"}}), "update should not return error")
+	_, _ = repo.Create(alice, &data.ArticleInfo{Title: "Other", Content: "Unrelated", AuthorIDs: []data.AuthorID{"1"}})
+
+	// Reopening loads the committed articles.
+	repo, err = repository.NewArticleRepositoryGit(dir)
+	assert.Nil(err, "reopening the repository should not return error")
+	article, err := repo.GetByID(ctx, id)
+	assert.Nil(err, "get by id should not return error")
+	assert.Equal(data.ArticleContent("Second"), article.Content, "the last commit should be loaded")
+	byAuthor, _ := repo.GetByAuthor(ctx, "2")
+	assert.Len(byAuthor, 1, "the authors should be loaded")
+
+	assert.Nil(repo.Delete(alice, id, article.Version), "delete should not return error")
+	_, err = repo.GetByID(ctx, id)
+	assert.Equal(errors.ErrNotFound, err, "deleted article should not be found")
+	assert.Equal(errors.ErrNotFound, repo.Delete(alice, id, article.Version), "deleting twice should return ErrNotFound")
+
+	revisions, err = repo.GetRevisions(ctx, id)
+	assert.Nil(err, "the revisions of a deleted article should be kept")
+	assert.Len(revisions, 3, "only the changes of the article should be listed")
+	assert.Equal([]string{"author-1", "admin", "author-1"}, []string{revisions[0].Author, revisions[1].Author, revisions[2].Author}, "the actors should be the commit authors")
+	assert.Equal([]bool{true, false, false}, []bool{revisions[0].Deleted, revisions[1].Deleted, revisions[2].Deleted}, "the deletion should be the newest revision")
+
+	first, err := repo.GetRevision(ctx, id, revisions[2].ID)
+	assert.Nil(err, "get revision should not return error")
+	assert.Equal(data.ArticleContent("First"), first.Content, "the revision should be served from the history")
+	assert.Equal([]data.AuthorID{"1"}, first.AuthorIDs, "the authors of the revision should be served")
+	_, err = repo.GetRevision(ctx, id, revisions[0].ID)
+	assert.Equal(errors.ErrRevisionNotFound, err, "the article does not exist as of its deletion")
+
+	for _, invalid := range []data.RevisionID{"", "abc", "0000000000000000000000000000000000000000"} {
+		_, err = repo.GetRevision(ctx, id, invalid)
+		assert.Equal(errors.ErrRevisionNotFound, err, "get revision %q should return ErrRevisionNotFound", invalid)
+	}
+	_, err = repo.GetRevision(ctx, "../hello", revisions[1].ID)
+	assert.Equal(errors.ErrRevisionNotFound, err, "an invalid ID should return ErrRevisionNotFound")
+	_, err = repo.GetRevisions(ctx, "../hello")
+	assert.Equal(errors.ErrNotFound, err, "an invalid ID should return ErrNotFound")
+}
@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+
+	"github.com/Jason5Lee/simple-blog/core/data"
+	"github.com/Jason5Lee/simple-blog/core/repository"
+)
+
+// IDMappingFile is an implementation of IDMapping appending the IDs to a file of JSON lines,
+// which are all loaded in memory when the file is opened.
+type IDMappingFile struct {
+	IDMappingInMemory
+	file *os.File
+}
+
+// IDMappingLine is a line of the ID mapping file.
+// A pending line marks a record being copied, until a line with its new ID follows.
+type IDMappingLine struct {
+	Kind    data.RecordKind `json:"kind"`
+	OldID   string          `json:"old"`
+	NewID   string          `json:"new,omitempty"`
+	Pending bool            `json:"pending,omitempty"`
+}
+
+// NewIDMappingFile opens the ID mapping file, creating it if it does not exist.
+func NewIDMappingFile(path string) (*IDMappingFile, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	m := &IDMappingFile{IDMappingInMemory: IDMappingInMemory{ids: make(map[data.RecordKind]map[string]string)}, file: file}
+	if err := m.load(); err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	return m, nil
+}
+
+func (m *IDMappingFile) load() error {
+	reader := bufio.NewReader(m.file)
+	var size int64
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			// The last line is incomplete if the process was stopped while writing it,
+			// so it is dropped like the record was never copied.
+			if len(line) > 0 {
+				if err := m.file.Truncate(size); err != nil {
+					return err
+				}
+			}
+			break
+		}
+		if err != nil {
+			return err
+		}
+		size += int64(len(line))
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		var entry IDMappingLine
+		if err := json.Unmarshal(line, &entry); err != nil {
+			return err
+		}
+		if entry.Pending {
+			m.pend(entry.Kind, entry.OldID)
+		} else {
+			m.put(entry.Kind, entry.OldID, entry.NewID)
+		}
+	}
+	_, err := m.file.Seek(size, io.SeekStart)
+	return err
+}
+
+// Put appends the IDs to the file and syncs it, so they are kept if the process is stopped right after.
+func (m *IDMappingFile) Put(ctx context.Context, kind data.RecordKind, oldID string, newID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.append(IDMappingLine{Kind: kind, OldID: oldID, NewID: newID}); err != nil {
+		return err
+	}
+	m.put(kind, oldID, newID)
+	return nil
+}
+
+// Pend appends the pending line to the file and syncs it, so it is kept if the record is created right after.
+func (m *IDMappingFile) Pend(ctx context.Context, kind data.RecordKind, oldID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.append(IDMappingLine{Kind: kind, OldID: oldID, Pending: true}); err != nil {
+		return err
+	}
+	m.pend(kind, oldID)
+	return nil
+}
+
+func (m *IDMappingFile) append(entry IDMappingLine) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if _, err := m.file.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	return m.file.Sync()
+}
+
+func (m *IDMappingFile) Close() error {
+	return m.file.Close()
+}
+
+var _ repository.IDMapping = (*IDMappingFile)(nil)
@@ -0,0 +1,63 @@
+package repository_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Jason5Lee/simple-blog/core/data"
+	"github.com/Jason5Lee/simple-blog/infra/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_IDMappingFile(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "ids.jsonl")
+
+	ids, err := repository.NewIDMappingFile(path)
+	assert.Nil(err, "opening a new file should not return error")
+	assert.Nil(ids.Put(ctx, data.RecordArticle, "64b0c0ffee", "1"))
+	assert.Nil(ids.Put(ctx, data.RecordAuthor, "64b0c0ffee", "2"))
+	assert.Nil(ids.Close())
+
+	// A line cut by an interruption is dropped.
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	assert.Nil(err)
+	_, err = file.WriteString(`{"kind":"article","old":"64b0`)
+	assert.Nil(err)
+	assert.Nil(file.Close())
+
+	ids, err = repository.NewIDMappingFile(path)
+	assert.Nil(err, "reopening the file should not return error")
+	newID, ok, err := ids.Get(ctx, data.RecordArticle, "64b0c0ffee")
+	assert.Nil(err)
+	assert.True(ok, "the IDs should be kept after reopening")
+	assert.Equal("1", newID)
+	newID, _, _ = ids.Get(ctx, data.RecordAuthor, "64b0c0ffee")
+	assert.Equal("2", newID, "the kinds should be mapped separately")
+	_, ok, _ = ids.Get(ctx, data.RecordSeries, "64b0c0ffee")
+	assert.False(ok, "an ID not copied should not be found")
+
+	assert.Nil(ids.Pend(ctx, data.RecordArticle, "64b0c0ffef"))
+	assert.Nil(ids.Pend(ctx, data.RecordArticle, "64b0c0fff0"))
+	assert.Nil(ids.Put(ctx, data.RecordArticle, "64b0c0ffef", "3"))
+	assert.Nil(ids.Close())
+	ids, err = repository.NewIDMappingFile(path)
+	assert.Nil(err)
+	defer ids.Close()
+	newID, ok, _ = ids.Get(ctx, data.RecordArticle, "64b0c0ffef")
+	assert.True(ok, "the IDs put after the dropped line should be kept")
+	assert.Equal("3", newID)
+	pending, _ := ids.IsPending(ctx, data.RecordArticle, "64b0c0ffef")
+	assert.False(pending, "a record whose ID is put should not be pending")
+	pending, _ = ids.IsPending(ctx, data.RecordArticle, "64b0c0fff0")
+	assert.True(pending, "a record without ID should be kept pending")
+	_, ok, _ = ids.Get(ctx, data.RecordArticle, "64b0c0fff0")
+	assert.False(ok, "a pending record should not be found")
+	isNewID, _ := ids.IsNewID(ctx, data.RecordArticle, "3")
+	assert.True(isNewID)
+	isNewID, _ = ids.IsNewID(ctx, data.RecordAuthor, "3")
+	assert.False(isNewID, "the new IDs of the kinds should be separate")
+}
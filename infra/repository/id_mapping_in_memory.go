@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"context"
+	"sync"
+
+	"github.com/Jason5Lee/simple-blog/core/data"
+	"github.com/Jason5Lee/simple-blog/core/repository"
+)
+
+// In-memory implementation of IDMapping.
+type IDMappingInMemory struct {
+	mu      sync.RWMutex
+	ids     map[data.RecordKind]map[string]string
+	newIDs  map[data.RecordKind]map[string]bool
+	pending map[data.RecordKind]map[string]bool
+}
+
+func NewIDMappingInMemory() *IDMappingInMemory {
+	return &IDMappingInMemory{ids: make(map[data.RecordKind]map[string]string)}
+}
+
+func (m *IDMappingInMemory) Get(ctx context.Context, kind data.RecordKind, oldID string) (string, bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	newID, ok := m.ids[kind][oldID]
+	return newID, ok, nil
+}
+
+func (m *IDMappingInMemory) Put(ctx context.Context, kind data.RecordKind, oldID string, newID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.put(kind, oldID, newID)
+	return nil
+}
+
+func (m *IDMappingInMemory) Pend(ctx context.Context, kind data.RecordKind, oldID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.pend(kind, oldID)
+	return nil
+}
+
+func (m *IDMappingInMemory) IsPending(ctx context.Context, kind data.RecordKind, oldID string) (bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.pending[kind][oldID], nil
+}
+
+func (m *IDMappingInMemory) IsNewID(ctx context.Context, kind data.RecordKind, newID string) (bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.newIDs[kind][newID], nil
+}
+
+func (m *IDMappingInMemory) put(kind data.RecordKind, oldID string, newID string) {
+	if m.ids[kind] == nil {
+		m.ids[kind] = make(map[string]string)
+	}
+	m.ids[kind][oldID] = newID
+	addRecordID(&m.newIDs, kind, newID)
+	delete(m.pending[kind], oldID)
+}
+
+func (m *IDMappingInMemory) pend(kind data.RecordKind, oldID string) {
+	addRecordID(&m.pending, kind, oldID)
+}
+
+// addRecordID adds the ID to the set of the kind, creating the sets if needed.
+func addRecordID(sets *map[data.RecordKind]map[string]bool, kind data.RecordKind, id string) {
+	if *sets == nil {
+		*sets = make(map[data.RecordKind]map[string]bool)
+	}
+	if (*sets)[kind] == nil {
+		(*sets)[kind] = make(map[string]bool)
+	}
+	(*sets)[kind][id] = true
+}
+
+var _ repository.IDMapping = (*IDMappingInMemory)(nil)
@@ -0,0 +1,290 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/Jason5Lee/simple-blog/core/data"
+	"github.com/Jason5Lee/simple-blog/core/errors"
+	"github.com/Jason5Lee/simple-blog/core/repository"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/gridfs"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Metadata stored in the GridFS file document.
+type DBMediaMetadata struct {
+	Type      string    `bson:"type"`
+	CreatedAt time.Time `bson:"created_at"`
+}
+
+// GridFS file document, as defined by the GridFS specification.
+type DBGridFSFile struct {
+	ID        primitive.ObjectID `bson:"_id"`
+	Length    int64              `bson:"length"`
+	ChunkSize int32              `bson:"chunkSize"`
+	Filename  string             `bson:"filename"`
+	Metadata  DBMediaMetadata    `bson:"metadata"`
+}
+
+// MediaStoreGridFS is a MongoDB GridFS implementation of MediaStore, for deployments without object storage.
+// The filename of a file is the media ID, and the file ID is generated, so a failed upload racing with
+// another upload of the same content never deletes the chunks of the other one.
+// If such a race stores the content twice, the first upload is used and Delete deletes both.
+type MediaStoreGridFS struct {
+	db *mongo.Database
+}
+
+const mediaBucketName = "media"
+
+// NewMediaStoreGridFS creates a new MediaStoreGridFS sharing the connection of the article repository.
+func NewMediaStoreGridFS(articleRepo *ArticleRepositoryMongoDB) *MediaStoreGridFS {
+	return &MediaStoreGridFS{db: articleRepo.db}
+}
+
+// bucket creates the GridFS bucket, whose operations time out at the deadline of the context.
+func (store *MediaStoreGridFS) bucket(ctx context.Context) (*gridfs.Bucket, error) {
+	bucket, err := gridfs.NewBucket(store.db, options.GridFSBucket().SetName(mediaBucketName))
+	if err != nil {
+		return nil, err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		if err = bucket.SetReadDeadline(deadline); err != nil {
+			return nil, err
+		}
+		if err = bucket.SetWriteDeadline(deadline); err != nil {
+			return nil, err
+		}
+	}
+	return bucket, nil
+}
+
+func (store *MediaStoreGridFS) filesCollection() *mongo.Collection {
+	return store.db.Collection(mediaBucketName + ".files")
+}
+
+func (store *MediaStoreGridFS) chunksCollection() *mongo.Collection {
+	return store.db.Collection(mediaBucketName + ".chunks")
+}
+
+func (store *MediaStoreGridFS) Put(ctx context.Context, media *data.Media, content io.Reader) (bool, error) {
+	if !data.IsValidMediaID(string(media.ID)) {
+		return false, errors.ErrMediaNotFound
+	}
+	if _, err := store.findFile(ctx, media.ID); err == nil {
+		return false, nil
+	} else if err != errors.ErrMediaNotFound {
+		return false, err
+	}
+	bucket, err := store.bucket(ctx)
+	if err != nil {
+		return false, err
+	}
+	// The content is streamed in chunks, and the file is visible once all the chunks are written.
+	_, err = bucket.UploadFromStream(string(media.ID), content, options.GridFSUpload().SetMetadata(DBMediaMetadata{
+		Type:      string(media.Type),
+		CreatedAt: media.CreatedAt,
+	}))
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (store *MediaStoreGridFS) Get(ctx context.Context, id data.MediaID) (*data.Media, io.ReadSeekCloser, error) {
+	if !data.IsValidMediaID(string(id)) {
+		// Invalid ID does not match any file, so we return ErrMediaNotFound.
+		return nil, nil, errors.ErrMediaNotFound
+	}
+	file, err := store.findFile(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	return file.toMedia(), &gridFSObject{ctx: ctx, store: store, file: file}, nil
+}
+
+func (store *MediaStoreGridFS) Delete(ctx context.Context, id data.MediaID) error {
+	if !data.IsValidMediaID(string(id)) {
+		return errors.ErrMediaNotFound
+	}
+	files, err := store.findFiles(ctx, bson.M{"filename": string(id)})
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return errors.ErrMediaNotFound
+	}
+	bucket, err := store.bucket(ctx)
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		if err = bucket.DeleteContext(ctx, file.ID); err != nil && err != gridfs.ErrFileNotFound {
+			return err
+		}
+	}
+	return nil
+}
+
+func (store *MediaStoreGridFS) List(ctx context.Context) ([]*data.Media, error) {
+	files, err := store.findFiles(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*data.Media, 0, len(files))
+	seen := make(map[string]bool, len(files))
+	for _, file := range files {
+		if !seen[file.Filename] {
+			seen[file.Filename] = true
+			result = append(result, file.toMedia())
+		}
+	}
+	return result, nil
+}
+
+// findFile finds the first uploaded file of the media.
+func (store *MediaStoreGridFS) findFile(ctx context.Context, id data.MediaID) (*DBGridFSFile, error) {
+	var file DBGridFSFile
+	err := store.filesCollection().FindOne(ctx, bson.M{"filename": string(id)},
+		options.FindOne().SetSort(bson.D{{Key: "uploadDate", Value: 1}})).Decode(&file)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.ErrMediaNotFound
+		}
+		return nil, err
+	}
+	return &file, nil
+}
+
+// findFiles finds the files in upload order.
+func (store *MediaStoreGridFS) findFiles(ctx context.Context, filter bson.M) ([]*DBGridFSFile, error) {
+	cursor, err := store.filesCollection().Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "uploadDate", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	var files []*DBGridFSFile
+	if err := cursor.All(ctx, &files); err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+func (file *DBGridFSFile) toMedia() *data.Media {
+	return &data.Media{
+		ID: data.MediaID(file.Filename),
+		// Assume the data in MongoDB is valid.
+		Type:      data.MediaType(file.Metadata.Type),
+		Size:      file.Length,
+		CreatedAt: file.Metadata.CreatedAt,
+	}
+}
+
+// Dropping the bucket for integration testing.
+func (store *MediaStoreGridFS) Drop() error {
+	bucket, err := store.bucket(context.Background())
+	if err != nil {
+		return err
+	}
+	return bucket.Drop()
+}
+
+// gridFSObject reads a file from the chunk containing the offset,
+// so seeking, like for a range request of a video, does not read the skipped chunks.
+type gridFSObject struct {
+	ctx    context.Context
+	store  *MediaStoreGridFS
+	file   *DBGridFSFile
+	offset int64
+	// cursor iterates the chunks from the offset, nil until the next read.
+	cursor *mongo.Cursor
+	// buffer is the unread part of the current chunk.
+	buffer []byte
+}
+
+// GridFS chunk document.
+type dbGridFSChunk struct {
+	N    int32  `bson:"n"`
+	Data []byte `bson:"data"`
+}
+
+func (object *gridFSObject) Read(p []byte) (int, error) {
+	if object.offset >= object.file.Length {
+		return 0, io.EOF
+	}
+	if object.cursor == nil {
+		chunkSize := int64(object.file.ChunkSize)
+		cursor, err := object.store.chunksCollection().Find(object.ctx,
+			bson.M{"files_id": object.file.ID, "n": bson.M{"$gte": object.offset / chunkSize}},
+			options.Find().SetSort(bson.D{{Key: "n", Value: 1}}))
+		if err != nil {
+			return 0, err
+		}
+		object.cursor = cursor
+		object.buffer = nil
+		if err = object.nextChunk(); err != nil {
+			return 0, err
+		}
+		// Skip the part of the first chunk before the offset.
+		skip := object.offset % chunkSize
+		if skip > int64(len(object.buffer)) {
+			return 0, fmt.Errorf("gridfs: chunk of file %s is shorter than expected", object.file.ID.Hex())
+		}
+		object.buffer = object.buffer[skip:]
+	}
+	for len(object.buffer) == 0 {
+		if err := object.nextChunk(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, object.buffer)
+	object.buffer = object.buffer[n:]
+	object.offset += int64(n)
+	return n, nil
+}
+
+// nextChunk reads the next chunk into the buffer.
+func (object *gridFSObject) nextChunk() error {
+	if !object.cursor.Next(object.ctx) {
+		if err := object.cursor.Err(); err != nil {
+			return err
+		}
+		return fmt.Errorf("gridfs: file %s is missing chunks", object.file.ID.Hex())
+	}
+	var chunk dbGridFSChunk
+	if err := object.cursor.Decode(&chunk); err != nil {
+		return err
+	}
+	object.buffer = chunk.Data
+	return nil
+}
+
+func (object *gridFSObject) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekCurrent:
+		offset += object.offset
+	case io.SeekEnd:
+		offset += object.file.Length
+	}
+	if offset < 0 {
+		return 0, fmt.Errorf("gridfs: seek to negative offset %d", offset)
+	}
+	if offset != object.offset && object.cursor != nil {
+		object.cursor.Close(object.ctx)
+		object.cursor = nil
+	}
+	object.offset = offset
+	return offset, nil
+}
+
+func (object *gridFSObject) Close() error {
+	if object.cursor != nil {
+		return object.cursor.Close(object.ctx)
+	}
+	return nil
+}
+
+var _ repository.MediaStore = (*MediaStoreGridFS)(nil)
@@ -0,0 +1,163 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Jason5Lee/simple-blog/core/data"
+	"github.com/Jason5Lee/simple-blog/core/errors"
+	"github.com/Jason5Lee/simple-blog/core/repository"
+)
+
+// Metadata stored next to the content.
+type localMediaMeta struct {
+	Type      string    `json:"type"`
+	Size      int64     `json:"size"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// MediaStoreLocal is a local filesystem implementation of MediaStore.
+// The content of a media is stored at `<dir>/<first 2 chars of ID>/<ID>`, with the metadata in a `.json` file next to it.
+type MediaStoreLocal struct {
+	dir string
+}
+
+// NewMediaStoreLocal creates a new MediaStoreLocal storing the files under the directory, which is created if missing.
+func NewMediaStoreLocal(dir string) (*MediaStoreLocal, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &MediaStoreLocal{dir: dir}, nil
+}
+
+func (store *MediaStoreLocal) path(id data.MediaID) string {
+	return filepath.Join(store.dir, string(id[:2]), string(id))
+}
+
+func (store *MediaStoreLocal) Put(ctx context.Context, media *data.Media, content io.Reader) (bool, error) {
+	if !data.IsValidMediaID(string(media.ID)) {
+		return false, errors.ErrMediaNotFound
+	}
+	path := store.path(media.ID)
+	// The metadata is written last, so the media exists once its metadata exists.
+	if _, err := os.Stat(path + ".json"); err == nil {
+		return false, nil
+	} else if !os.IsNotExist(err) {
+		return false, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return false, err
+	}
+
+	if err := store.writeFile(path, func(w io.Writer) error {
+		_, err := io.Copy(w, content)
+		return err
+	}); err != nil {
+		return false, err
+	}
+	if err := store.writeFile(path+".json", func(w io.Writer) error {
+		return json.NewEncoder(w).Encode(localMediaMeta{
+			Type:      string(media.Type),
+			Size:      media.Size,
+			CreatedAt: media.CreatedAt,
+		})
+	}); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// writeFile writes a file atomically by renaming a temporary file, so readers never see a partial file.
+func (store *MediaStoreLocal) writeFile(path string, write func(io.Writer) error) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err = write(tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func (store *MediaStoreLocal) Get(ctx context.Context, id data.MediaID) (*data.Media, io.ReadSeekCloser, error) {
+	if !data.IsValidMediaID(string(id)) {
+		// Invalid ID does not match any file, so we return ErrMediaNotFound.
+		return nil, nil, errors.ErrMediaNotFound
+	}
+	path := store.path(id)
+	metaBytes, err := os.ReadFile(path + ".json")
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil, errors.ErrMediaNotFound
+		}
+		return nil, nil, err
+	}
+	var meta localMediaMeta
+	if err = json.Unmarshal(metaBytes, &meta); err != nil {
+		return nil, nil, err
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &data.Media{
+		ID:        id,
+		Type:      data.MediaType(meta.Type),
+		Size:      meta.Size,
+		CreatedAt: meta.CreatedAt,
+	}, file, nil
+}
+
+func (store *MediaStoreLocal) Delete(ctx context.Context, id data.MediaID) error {
+	if !data.IsValidMediaID(string(id)) {
+		return errors.ErrMediaNotFound
+	}
+	path := store.path(id)
+	if err := os.Remove(path + ".json"); err != nil {
+		if os.IsNotExist(err) {
+			return errors.ErrMediaNotFound
+		}
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (store *MediaStoreLocal) List(ctx context.Context) ([]*data.Media, error) {
+	paths, err := filepath.Glob(filepath.Join(store.dir, "*", "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*data.Media, 0, len(paths))
+	for _, path := range paths {
+		id := strings.TrimSuffix(filepath.Base(path), ".json")
+		if !data.IsValidMediaID(id) {
+			continue
+		}
+		media, content, err := store.Get(ctx, data.MediaID(id))
+		if err == errors.ErrMediaNotFound {
+			// Deleted concurrently.
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		content.Close()
+		result = append(result, media)
+	}
+	return result, nil
+}
+
+var _ repository.MediaStore = (*MediaStoreLocal)(nil)
@@ -0,0 +1,356 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Jason5Lee/simple-blog/core/data"
+	"github.com/Jason5Lee/simple-blog/core/errors"
+	"github.com/Jason5Lee/simple-blog/core/repository"
+)
+
+// MediaStoreS3 is an implementation of MediaStore on an S3-compatible object storage, like AWS S3 or MinIO.
+// Each media is an object named by its ID, with the type as the object content type.
+// Requests are signed with AWS Signature Version 4 and use path-style URLs, i.e. `<endpoint>/<bucket>/<ID>`.
+type MediaStoreS3 struct {
+	endpoint        string
+	region          string
+	bucket          string
+	accessKeyID     string
+	secretAccessKey string
+	client          *http.Client
+}
+
+// The SHA-256 hash of an empty payload.
+const emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+const s3CreatedAtHeader = "X-Amz-Meta-Created-At"
+
+// s3ResponseHeaderTimeout limits the wait for the response of the storage. The whole request is not limited,
+// as the body of a large media is streamed to the client downloading it, however slow.
+const s3ResponseHeaderTimeout = 30 * time.Second
+
+// newS3Client creates the client of the storage, which stops waiting for an unresponsive storage.
+func newS3Client() *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.ResponseHeaderTimeout = s3ResponseHeaderTimeout
+	return &http.Client{Transport: transport}
+}
+
+// NewMediaStoreS3 creates a new MediaStoreS3 storing the objects in the bucket, which must exist.
+func NewMediaStoreS3(endpoint string, region string, bucket string, accessKeyID string, secretAccessKey string) *MediaStoreS3 {
+	return &MediaStoreS3{
+		endpoint:        strings.TrimSuffix(endpoint, "/"),
+		region:          region,
+		bucket:          bucket,
+		accessKeyID:     accessKeyID,
+		secretAccessKey: secretAccessKey,
+		client:          newS3Client(),
+	}
+}
+
+func (store *MediaStoreS3) Put(ctx context.Context, media *data.Media, content io.Reader) (bool, error) {
+	if !data.IsValidMediaID(string(media.ID)) {
+		return false, errors.ErrMediaNotFound
+	}
+	if _, err := store.head(ctx, media.ID); err == nil {
+		return false, nil
+	} else if err != errors.ErrMediaNotFound {
+		return false, err
+	}
+
+	// The ID is not always the hash of the content, like that of an image variant, so the content is hashed.
+	payloadHash, content, err := hashPayload(content)
+	if err != nil {
+		return false, err
+	}
+	req, err := http.NewRequestWithContext(ctx, "PUT", store.objectURL(media.ID), content)
+	if err != nil {
+		return false, err
+	}
+	req.ContentLength = media.Size
+	req.Header.Set("Content-Type", string(media.Type))
+	req.Header.Set(s3CreatedAtHeader, media.CreatedAt.UTC().Format(time.RFC3339))
+	resp, err := store.do(req, payloadHash)
+	if err != nil {
+		return false, err
+	}
+	resp.Body.Close()
+	return true, nil
+}
+
+// hashPayload returns the hex-encoded SHA-256 hash of the content and a reader of the content from the start.
+// A seekable content is read twice, and any other content is buffered.
+func hashPayload(content io.Reader) (string, io.Reader, error) {
+	hash := sha256.New()
+	if seeker, ok := content.(io.ReadSeeker); ok {
+		start, err := seeker.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return "", nil, err
+		}
+		if _, err = io.Copy(hash, seeker); err != nil {
+			return "", nil, err
+		}
+		if _, err = seeker.Seek(start, io.SeekStart); err != nil {
+			return "", nil, err
+		}
+		return hex.EncodeToString(hash.Sum(nil)), seeker, nil
+	}
+	var buffer bytes.Buffer
+	if _, err := io.Copy(io.MultiWriter(hash, &buffer), content); err != nil {
+		return "", nil, err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), &buffer, nil
+}
+
+func (store *MediaStoreS3) Get(ctx context.Context, id data.MediaID) (*data.Media, io.ReadSeekCloser, error) {
+	if !data.IsValidMediaID(string(id)) {
+		// Invalid ID does not match any object, so we return ErrMediaNotFound.
+		return nil, nil, errors.ErrMediaNotFound
+	}
+	media, err := store.head(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	return media, &s3Object{ctx: ctx, store: store, id: id, size: media.Size}, nil
+}
+
+func (store *MediaStoreS3) Delete(ctx context.Context, id data.MediaID) error {
+	if !data.IsValidMediaID(string(id)) {
+		return errors.ErrMediaNotFound
+	}
+	// Deleting a missing object succeeds in S3, so the existence is checked first.
+	if _, err := store.head(ctx, id); err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, "DELETE", store.objectURL(id), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := store.do(req, emptyPayloadHash)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// s3ListResult is the response of ListObjectsV2.
+type s3ListResult struct {
+	Contents []struct {
+		Key          string    `xml:"Key"`
+		LastModified time.Time `xml:"LastModified"`
+		Size         int64     `xml:"Size"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+// List lists the objects named as media IDs. The type is not listed, and the creation time is the last modified time.
+func (store *MediaStoreS3) List(ctx context.Context) ([]*data.Media, error) {
+	var result []*data.Media
+	continuationToken := ""
+	for {
+		query := url.Values{"list-type": {"2"}}
+		if continuationToken != "" {
+			query.Set("continuation-token", continuationToken)
+		}
+		req, err := http.NewRequestWithContext(ctx, "GET", store.endpoint+"/"+store.bucket+"?"+query.Encode(), nil)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := store.do(req, emptyPayloadHash)
+		if err != nil {
+			return nil, err
+		}
+		var page s3ListResult
+		err = xml.NewDecoder(resp.Body).Decode(&page)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		for _, object := range page.Contents {
+			if data.IsValidMediaID(object.Key) {
+				result = append(result, &data.Media{ID: data.MediaID(object.Key), Size: object.Size, CreatedAt: object.LastModified})
+			}
+		}
+		if !page.IsTruncated || page.NextContinuationToken == "" {
+			return result, nil
+		}
+		continuationToken = page.NextContinuationToken
+	}
+}
+
+func (store *MediaStoreS3) objectURL(id data.MediaID) string {
+	return store.endpoint + "/" + store.bucket + "/" + string(id)
+}
+
+// head gets the metadata of the object.
+func (store *MediaStoreS3) head(ctx context.Context, id data.MediaID) (*data.Media, error) {
+	req, err := http.NewRequestWithContext(ctx, "HEAD", store.objectURL(id), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := store.do(req, emptyPayloadHash)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+
+	createdAt, err := time.Parse(time.RFC3339, resp.Header.Get(s3CreatedAtHeader))
+	if err != nil {
+		// Objects uploaded by other tools do not have the creation time.
+		createdAt, _ = http.ParseTime(resp.Header.Get("Last-Modified"))
+	}
+	return &data.Media{
+		ID: id,
+		// Assume the objects in the bucket are valid.
+		Type:      data.MediaType(resp.Header.Get("Content-Type")),
+		Size:      resp.ContentLength,
+		CreatedAt: createdAt,
+	}, nil
+}
+
+// do signs and sends the request, returning ErrMediaNotFound for 404 and an error for other unsuccessful statuses.
+func (store *MediaStoreS3) do(req *http.Request, payloadHash string) (*http.Response, error) {
+	store.sign(req, payloadHash, time.Now())
+	resp, err := store.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil, errors.ErrMediaNotFound
+	}
+	if resp.StatusCode/100 != 2 {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("s3 %s %s: %s: %s", req.Method, req.URL.Path, resp.Status, body)
+	}
+	return resp, nil
+}
+
+// sign adds the AWS Signature Version 4 to the request.
+// The host, the content type and all the `x-amz-*` headers are signed.
+func (store *MediaStoreS3) sign(req *http.Request, payloadHash string, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		name = strings.ToLower(name)
+		if name == "content-type" || strings.HasPrefix(name, "x-amz-") {
+			headers[name] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	// The query is expected to be encoded by url.Values, which sorts the parameters as the canonical query.
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	scope := date + "/" + store.region + "/s3/aws4_request"
+	canonicalRequestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(canonicalRequestHash[:])
+
+	key := []byte("AWS4" + store.secretAccessKey)
+	for _, part := range []string{date, store.region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		store.accessKeyID, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, message string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(message))
+	return mac.Sum(nil)
+}
+
+// s3Object reads an object with ranged GET requests, so seeking does not download the skipped part.
+type s3Object struct {
+	ctx    context.Context
+	store  *MediaStoreS3
+	id     data.MediaID
+	size   int64
+	offset int64
+	// body is the response of the request reading from the offset, nil until the next read.
+	body io.ReadCloser
+}
+
+func (object *s3Object) Read(p []byte) (int, error) {
+	if object.offset >= object.size {
+		return 0, io.EOF
+	}
+	if object.body == nil {
+		req, err := http.NewRequestWithContext(object.ctx, "GET", object.store.objectURL(object.id), nil)
+		if err != nil {
+			return 0, err
+		}
+		req.Header.Set("Range", "bytes="+strconv.FormatInt(object.offset, 10)+"-")
+		resp, err := object.store.do(req, emptyPayloadHash)
+		if err != nil {
+			return 0, err
+		}
+		object.body = resp.Body
+	}
+	n, err := object.body.Read(p)
+	object.offset += int64(n)
+	return n, err
+}
+
+func (object *s3Object) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekCurrent:
+		offset += object.offset
+	case io.SeekEnd:
+		offset += object.size
+	}
+	if offset < 0 {
+		return 0, fmt.Errorf("s3: seek to negative offset %d", offset)
+	}
+	if offset != object.offset && object.body != nil {
+		object.body.Close()
+		object.body = nil
+	}
+	object.offset = offset
+	return offset, nil
+}
+
+func (object *s3Object) Close() error {
+	if object.body != nil {
+		return object.body.Close()
+	}
+	return nil
+}
+
+var _ repository.MediaStore = (*MediaStoreS3)(nil)
@@ -0,0 +1,217 @@
+package repository_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Jason5Lee/simple-blog/core/data"
+	"github.com/Jason5Lee/simple-blog/core/errors"
+	"github.com/Jason5Lee/simple-blog/infra/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeS3 is a local stand-in of an S3-compatible storage, supporting the requests used by MediaStoreS3.
+// Like S3, it verifies the signature of the requests with the secret of the access key "key".
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+	headers map[string]http.Header
+	secret  string
+}
+
+func (s3 *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !verifySigV4(r, "key", s3.secret) {
+		w.WriteHeader(403)
+		return
+	}
+	s3.mu.Lock()
+	defer s3.mu.Unlock()
+	object, ok := s3.objects[r.URL.Path]
+	if r.Method == "GET" && r.URL.Query().Get("list-type") == "2" {
+		// Listed one object per page to test the pagination.
+		keys := make([]string, 0, len(s3.objects))
+		for path := range s3.objects {
+			keys = append(keys, strings.TrimPrefix(path, r.URL.Path+"/"))
+		}
+		sort.Strings(keys)
+		start := sort.SearchStrings(keys, r.URL.Query().Get("continuation-token"))
+		if start >= len(keys) {
+			fmt.Fprint(w, "<ListBucketResult><IsTruncated>false</IsTruncated></ListBucketResult>")
+			return
+		}
+		next := ""
+		if start+1 < len(keys) {
+			next = keys[start+1]
+		}
+		fmt.Fprintf(w, "<ListBucketResult><Contents><Key>%s</Key><LastModified>2022-01-02T03:04:05.000Z</LastModified><Size>%d</Size></Contents>"+
+			"<IsTruncated>%t</IsTruncated><NextContinuationToken>%s</NextContinuationToken></ListBucketResult>",
+			keys[start], len(s3.objects[r.URL.Path+"/"+keys[start]]), next != "", next)
+		return
+	}
+	switch r.Method {
+	case "PUT":
+		body, _ := io.ReadAll(r.Body)
+		if hash := sha256.Sum256(body); hex.EncodeToString(hash[:]) != r.Header.Get("X-Amz-Content-Sha256") {
+			w.WriteHeader(400)
+			return
+		}
+		s3.objects[r.URL.Path] = body
+		s3.headers[r.URL.Path] = r.Header.Clone()
+	case "HEAD", "GET":
+		if !ok {
+			w.WriteHeader(404)
+			return
+		}
+		w.Header().Set("Content-Type", s3.headers[r.URL.Path].Get("Content-Type"))
+		w.Header().Set("X-Amz-Meta-Created-At", s3.headers[r.URL.Path].Get("X-Amz-Meta-Created-At"))
+		status := 200
+		if rangeHeader := r.Header.Get("Range"); rangeHeader != "" {
+			start, _ := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(rangeHeader, "bytes="), "-"))
+			object = object[start:]
+			status = 206
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(len(object)))
+		w.WriteHeader(status)
+		if r.Method == "GET" {
+			w.Write(object)
+		}
+	case "DELETE":
+		delete(s3.objects, r.URL.Path)
+		w.WriteHeader(204)
+	}
+}
+
+// verifySigV4 recomputes the AWS Signature Version 4 of the request from the headers it lists as signed,
+// returning whether it matches the one of the Authorization header. The host and the payload hash must be signed.
+func verifySigV4(r *http.Request, accessKeyID string, secret string) bool {
+	authorization, ok := strings.CutPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 ")
+	if !ok {
+		return false
+	}
+	fields := map[string]string{}
+	for _, field := range strings.Split(authorization, ", ") {
+		name, value, _ := strings.Cut(field, "=")
+		fields[name] = value
+	}
+	credential := strings.Split(fields["Credential"], "/")
+	if len(credential) != 5 || credential[0] != accessKeyID || credential[3] != "s3" || credential[4] != "aws4_request" {
+		return false
+	}
+	date, region := credential[1], credential[2]
+	amzDate := r.Header.Get("X-Amz-Date")
+	if !strings.HasPrefix(amzDate, date) {
+		return false
+	}
+
+	signedHeaders := strings.Split(fields["SignedHeaders"], ";")
+	var canonicalHeaders strings.Builder
+	signed := map[string]bool{}
+	for _, name := range signedHeaders {
+		value := r.Header.Get(name)
+		if name == "host" {
+			value = r.Host
+		}
+		canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(value) + "\n")
+		signed[name] = true
+	}
+	if !signed["host"] || !signed["x-amz-content-sha256"] || !signed["x-amz-date"] {
+		return false
+	}
+	canonicalRequest := r.Method + "\n" + r.URL.EscapedPath() + "\n" + r.URL.Query().Encode() + "\n" +
+		canonicalHeaders.String() + "\n" + fields["SignedHeaders"] + "\n" + r.Header.Get("X-Amz-Content-Sha256")
+	canonicalRequestHash := sha256.Sum256([]byte(canonicalRequest))
+	scope := date + "/" + region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(canonicalRequestHash[:])
+
+	key := []byte("AWS4" + secret)
+	for _, part := range []string{date, region, "s3", "aws4_request", stringToSign} {
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(part))
+		key = mac.Sum(nil)
+	}
+	return hmac.Equal([]byte(hex.EncodeToString(key)), []byte(fields["Signature"]))
+}
+
+func Test_MediaStoreS3(t *testing.T) {
+	assert := assert.New(t)
+
+	server := httptest.NewServer(&fakeS3{objects: map[string][]byte{}, headers: map[string]http.Header{}, secret: "secret"})
+	defer server.Close()
+	store := repository.NewMediaStoreS3(server.URL, "us-east-1", "bucket", "key", "secret")
+	ctx := context.Background()
+
+	content := []byte("%PDF-1.4 test content")
+	hash := sha256.Sum256(content)
+	media := &data.Media{
+		ID:        data.NewMediaID(hash[:]),
+		Type:      "application/pdf",
+		Size:      int64(len(content)),
+		CreatedAt: time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+
+	_, _, err := store.Get(ctx, media.ID)
+	assert.Equal(errors.ErrMediaNotFound, err, "missing object should return ErrMediaNotFound")
+	_, err = repository.NewMediaStoreS3(server.URL, "us-east-1", "bucket", "key", "wrong").Put(ctx, media, bytes.NewReader(content))
+	assert.NotNil(err, "a request signed with another secret should be rejected")
+
+	created, err := store.Put(ctx, media, bytes.NewReader(content))
+	assert.Nil(err, "put should not return error")
+	assert.True(created)
+	created, err = store.Put(ctx, media, bytes.NewReader(content))
+	assert.Nil(err)
+	assert.False(created, "existing object should not be put again")
+
+	got, object, err := store.Get(ctx, media.ID)
+	assert.Nil(err)
+	assert.Equal(media, got)
+	_, err = object.Seek(5, io.SeekStart)
+	assert.Nil(err)
+	rest, err := io.ReadAll(object)
+	assert.Nil(err)
+	assert.Equal(content[5:], rest, "seek should read the object from the offset")
+	assert.Nil(object.Close())
+
+	other := []byte("%PDF-1.4 other content")
+	otherHash := sha256.Sum256(other)
+	_, err = store.Put(ctx, &data.Media{ID: data.NewMediaID(otherHash[:]), Type: "application/pdf", Size: int64(len(other))}, bytes.NewReader(other))
+	assert.Nil(err)
+	listed, err := store.List(ctx)
+	assert.Nil(err)
+	assert.Len(listed, 2, "all pages should be listed")
+
+	assert.Nil(store.Delete(ctx, media.ID))
+	assert.Equal(errors.ErrMediaNotFound, store.Delete(ctx, media.ID), "deleting a missing object should return ErrMediaNotFound")
+
+	// The ID of an image variant is not the hash of its content.
+	variant := []byte("resized image")
+	variantMedia := &data.Media{
+		ID:        data.NewImageVariantID(media.ID, 320, data.ImageWebP),
+		Type:      "image/webp",
+		Size:      int64(len(variant)),
+		CreatedAt: media.CreatedAt,
+	}
+	// The content is not seekable, so it is buffered to be hashed.
+	created, err = store.Put(ctx, variantMedia, io.MultiReader(bytes.NewReader(variant)))
+	assert.Nil(err, "putting a variant should not return error")
+	assert.True(created)
+	got, object, err = store.Get(ctx, variantMedia.ID)
+	assert.Nil(err)
+	assert.Equal(variantMedia, got)
+	stored, err := io.ReadAll(object)
+	assert.Nil(err)
+	assert.Equal(variant, stored, "the variant should be read back")
+	assert.Nil(object.Close())
+}
@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"embed"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+//go:embed postgres_migrations/*.sql
+var postgresMigrations embed.FS
+
+// The key of the advisory lock serializing the migrations of the server instances starting together.
+const postgresMigrationLockKey = 7_362_718_424
+
+// migratePostgres applies the schema migrations not applied yet, each in a transaction
+// recording its version, so a failed migration leaves no partial schema behind.
+func migratePostgres(ctx context.Context, pool *pgxpool.Pool) error {
+	migrations, err := loadSchemaMigrations(postgresMigrations, "postgres_migrations")
+	if err != nil {
+		return err
+	}
+	conn, err := pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	// The lock is held by the session, so it must be released on the same connection.
+	if _, err := conn.Exec(ctx, "SELECT pg_advisory_lock($1)", postgresMigrationLockKey); err != nil {
+		return err
+	}
+	defer conn.Exec(context.Background(), "SELECT pg_advisory_unlock($1)", postgresMigrationLockKey)
+
+	if _, err := conn.Exec(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    INTEGER PRIMARY KEY,
+		applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	)`); err != nil {
+		return err
+	}
+	for _, m := range migrations {
+		if err := applyPostgresMigration(ctx, conn.Conn(), m); err != nil {
+			return fmt.Errorf("migration %s: %w", m.name, err)
+		}
+	}
+	return nil
+}
+
+func applyPostgresMigration(ctx context.Context, conn *pgx.Conn, m schemaMigration) error {
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var applied bool
+	if err := tx.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM schema_migrations WHERE version = $1)", m.version).Scan(&applied); err != nil {
+		return err
+	}
+	if applied {
+		return nil
+	}
+	// Without arguments, the script runs with the simple protocol, which allows several statements.
+	if _, err := tx.Exec(ctx, m.script); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, "INSERT INTO schema_migrations (version) VALUES ($1)", m.version); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
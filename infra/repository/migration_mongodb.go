@@ -0,0 +1,332 @@
+package repository
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+	"reflect"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// mongoDBMigration upgrades the documents of a collection to a schema version, stored in their "schema_version" field.
+// The documents without the field have the version 0.
+type mongoDBMigration struct {
+	version    int
+	name       string
+	collection string
+	// upgrade changes a document of the previous version in place. As it is also used to upgrade a document when it
+	// is read, it should only change the document, and tolerate the fields written by the current version.
+	upgrade func(ctx context.Context, db *mongo.Database, doc bson.M) error
+}
+
+const migrationCollectionName = "schema_migrations"
+const lockCollectionName = "locks"
+
+// mongoDBMigrationBatchSize is the number of documents upgraded by a bulk write.
+const mongoDBMigrationBatchSize = 500
+
+// mongoDBLockTTL is how long the migration lock is held without being renewed, so it is released
+// if the process holding it dies.
+const mongoDBLockTTL = time.Minute
+
+// DBAppliedMigration is a migration applied to all the documents of its collection.
+type DBAppliedMigration struct {
+	Version    int       `bson:"_id"`
+	Name       string    `bson:"name"`
+	Collection string    `bson:"collection"`
+	Documents  int64     `bson:"documents"`
+	AppliedAt  time.Time `bson:"applied_at"`
+}
+
+// MigrateMongoDB applies the migrations not applied yet, in order and in batches, recording each once done.
+// Only one process runs them at a time. If another holds the lock, it returns without waiting,
+// as the documents not upgraded yet are upgraded when they are read.
+func MigrateMongoDB(ctx context.Context, articleRepo *ArticleRepositoryMongoDB) error {
+	db := articleRepo.db
+	applied, err := appliedMongoDBMigrations(ctx, db)
+	if err != nil {
+		return err
+	}
+	pending := 0
+	for _, migration := range mongoDBMigrations {
+		if !applied[migration.version] {
+			pending++
+		}
+	}
+	if pending == 0 {
+		return nil
+	}
+
+	lock, err := acquireMongoDBLock(ctx, db, migrationCollectionName)
+	if err != nil {
+		return err
+	}
+	if lock == nil {
+		log.Printf("%d migrations are being applied by another process, documents are upgraded when read meanwhile", pending)
+		return nil
+	}
+	defer lock.release()
+
+	// The migrations may have been applied while acquiring the lock.
+	if applied, err = appliedMongoDBMigrations(ctx, db); err != nil {
+		return err
+	}
+	for _, migration := range mongoDBMigrations {
+		if applied[migration.version] {
+			continue
+		}
+		if err := lock.err(); err != nil {
+			return err
+		}
+		n, err := runMongoDBMigration(ctx, articleRepo, &migration)
+		if err != nil {
+			return fmt.Errorf("migration %d %s: %w", migration.version, migration.name, err)
+		}
+		_, err = db.Collection(migrationCollectionName).InsertOne(ctx, DBAppliedMigration{
+			Version:    migration.version,
+			Name:       migration.name,
+			Collection: migration.collection,
+			Documents:  n,
+			AppliedAt:  time.Now(),
+		})
+		if err != nil {
+			return err
+		}
+		log.Printf("applied migration %d %s to %d documents", migration.version, migration.name, n)
+	}
+	return nil
+}
+
+func appliedMongoDBMigrations(ctx context.Context, db *mongo.Database) (map[int]bool, error) {
+	cursor, err := db.Collection(migrationCollectionName).Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	var migrations []*DBAppliedMigration
+	if err := cursor.All(ctx, &migrations); err != nil {
+		return nil, err
+	}
+	applied := make(map[int]bool, len(migrations))
+	for _, migration := range migrations {
+		applied[migration.Version] = true
+	}
+	return applied, nil
+}
+
+// runMongoDBMigration upgrades the documents older than the migration a batch at a time, in the order of "_id",
+// returning the number of documents upgraded.
+func runMongoDBMigration(ctx context.Context, articleRepo *ArticleRepositoryMongoDB, migration *mongoDBMigration) (int64, error) {
+	collection := articleRepo.collectionNamed(migration.collection)
+	filter := olderSchemaFilter(migration.version)
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+
+	var upgraded int64
+	var lastID interface{}
+	for {
+		batchFilter := filter
+		if lastID != nil {
+			batchFilter = bson.M{"$and": bson.A{filter, bson.M{"_id": bson.M{"$gt": lastID}}}}
+		}
+		cursor, err := collection.Find(ctx, batchFilter, options.Find().SetSort(bson.M{"_id": 1}).SetLimit(mongoDBMigrationBatchSize))
+		if err != nil {
+			return upgraded, err
+		}
+		var docs []bson.M
+		if err := cursor.All(ctx, &docs); err != nil {
+			return upgraded, err
+		}
+		if len(docs) == 0 {
+			return upgraded, nil
+		}
+
+		models := make([]mongo.WriteModel, 0, len(docs))
+		for _, doc := range docs {
+			update, err := upgradeMongoDBDocument(ctx, articleRepo.db, migration.collection, migration.version, doc)
+			if err != nil {
+				return upgraded, err
+			}
+			models = append(models, mongo.NewUpdateOneModel().SetFilter(update.filter).SetUpdate(update.update))
+		}
+		if _, err := collection.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false)); err != nil {
+			return upgraded, err
+		}
+		upgraded += int64(len(docs))
+		lastID = docs[len(docs)-1]["_id"]
+		log.Printf("migration %d %s: %d/%d documents", migration.version, migration.name, upgraded, total)
+	}
+}
+
+// olderSchemaFilter matches the documents whose schema is older than the version.
+func olderSchemaFilter(version int) bson.M {
+	return bson.M{"$or": bson.A{
+		bson.M{"schema_version": bson.M{"$lt": version}},
+		bson.M{"schema_version": bson.M{"$exists": false}},
+	}}
+}
+
+// mongoDBDocumentUpdate is the update storing an upgraded document, unless it has been upgraded concurrently.
+type mongoDBDocumentUpdate struct {
+	filter bson.M
+	update bson.M
+}
+
+// upgradeMongoDBDocument applies the migrations of the collection newer than the schema of the document, up to the
+// version, to it in place, returning the update storing it. The update only sets and unsets the changed fields,
+// so a concurrent update of the other fields is not lost, and only if these fields still have the values read,
+// so a concurrent update of them, like that of the version by ArticleRepositoryMongoDB.Update, is not overwritten.
+// The document is then upgraded again when next read.
+func upgradeMongoDBDocument(ctx context.Context, db *mongo.Database, collection string, toVersion int, doc bson.M) (*mongoDBDocumentUpdate, error) {
+	version := mongoDBSchemaVersion(doc)
+	before := make(bson.M, len(doc))
+	for key, value := range doc {
+		before[key] = value
+	}
+	for _, migration := range mongoDBMigrations {
+		if migration.collection != collection || migration.version <= version || migration.version > toVersion {
+			continue
+		}
+		if err := migration.upgrade(ctx, db, doc); err != nil {
+			return nil, err
+		}
+		doc["schema_version"] = migration.version
+	}
+
+	set, unset := bson.M{}, bson.M{}
+	for key, value := range doc {
+		if old, ok := before[key]; !ok || !reflect.DeepEqual(old, value) {
+			set[key] = value
+		}
+	}
+	for key := range before {
+		if _, ok := doc[key]; !ok {
+			unset[key] = ""
+		}
+	}
+	update := bson.M{"$set": set}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+	filter := bson.M{"_id": doc["_id"]}
+	for key, value := range olderSchemaFilter(version + 1) {
+		filter[key] = value
+	}
+	for _, changed := range []bson.M{set, unset} {
+		for key := range changed {
+			if key == "schema_version" {
+				continue
+			}
+			if old, ok := before[key]; ok {
+				filter[key] = bson.M{"$eq": old}
+			} else {
+				filter[key] = bson.M{"$exists": false}
+			}
+		}
+	}
+	return &mongoDBDocumentUpdate{filter: filter, update: update}, nil
+}
+
+func mongoDBSchemaVersion(doc bson.M) int {
+	switch version := doc["schema_version"].(type) {
+	case int32:
+		return int(version)
+	case int64:
+		return int(version)
+	case float64:
+		return int(version)
+	}
+	return 0
+}
+
+// mongoDBLock is a lock acquired by the process, renewed in the background until it is released.
+// It is stored as a document with the owner and the expiry time in the locks collection.
+type mongoDBLock struct {
+	db       *mongo.Database
+	id       string
+	owner    string
+	stop     chan struct{}
+	done     chan struct{}
+	renewErr error
+}
+
+// acquireMongoDBLock acquires the lock with the ID, or returns nil if another process holds it.
+func acquireMongoDBLock(ctx context.Context, db *mongo.Database, id string) (*mongoDBLock, error) {
+	lock := &mongoDBLock{db: db, id: id, owner: newLockOwner(), stop: make(chan struct{}), done: make(chan struct{})}
+	ok, err := lock.renew(ctx)
+	if err != nil || !ok {
+		return nil, err
+	}
+	go lock.keepRenewing()
+	return lock, nil
+}
+
+// renew extends the lock if it is free, expired or already held by the process.
+func (lock *mongoDBLock) renew(ctx context.Context) (bool, error) {
+	now := time.Now()
+	_, err := lock.db.Collection(lockCollectionName).UpdateOne(ctx,
+		bson.M{"_id": lock.id, "$or": bson.A{bson.M{"owner": lock.owner}, bson.M{"expires_at": bson.M{"$lt": now}}}},
+		bson.M{"$set": bson.M{"owner": lock.owner, "expires_at": now.Add(mongoDBLockTTL)}},
+		options.Update().SetUpsert(true),
+	)
+	// The filter does not match a lock held by another process, so the upsert conflicts with it.
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (lock *mongoDBLock) keepRenewing() {
+	defer close(lock.done)
+	ticker := time.NewTicker(mongoDBLockTTL / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-lock.stop:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), mongoDBLockTTL/3)
+			ok, err := lock.renew(ctx)
+			cancel()
+			if err == nil && !ok {
+				err = fmt.Errorf("lock %s was taken by another process", lock.id)
+			}
+			if err != nil {
+				lock.renewErr = err
+				return
+			}
+		}
+	}
+}
+
+// err returns why the lock could not be renewed, in which case it may be held by another process.
+func (lock *mongoDBLock) err() error {
+	select {
+	case <-lock.done:
+		return lock.renewErr
+	default:
+		return nil
+	}
+}
+
+func (lock *mongoDBLock) release() {
+	close(lock.stop)
+	<-lock.done
+	_, _ = lock.db.Collection(lockCollectionName).DeleteOne(context.Background(), bson.M{"_id": lock.id, "owner": lock.owner})
+}
+
+// newLockOwner identifies the process holding a lock.
+func newLockOwner() string {
+	hostname, _ := os.Hostname()
+	random := make([]byte, 4)
+	_, _ = rand.Read(random)
+	return fmt.Sprintf("%s-%d-%s", hostname, os.Getpid(), hex.EncodeToString(random))
+}
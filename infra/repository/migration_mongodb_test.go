@@ -0,0 +1,57 @@
+//go:build integration
+// +build integration
+
+package repository
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Jason5Lee/simple-blog/core/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Test_MongoDBUpgradeAfterUpdate stores the upgrade of a document read before it was updated,
+// like a lazy upgrade racing with an update.
+func Test_MongoDBUpgradeAfterUpdate(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	ctx := context.Background()
+	repo, err := NewArticleRepositoryMongoDB(ctx, os.Getenv("MONGODB_URI"), &MongoDBOptions{})
+	require.NoError(err)
+
+	// A document of the second version, without timestamps and version, created an hour ago.
+	docID := primitive.NewObjectIDFromTimestamp(time.Now().Add(-time.Hour))
+	_, err = repo.collection().InsertOne(ctx, bson.M{"_id": docID, "title": "title", "content": "content", "author_ids": bson.A{"1"}},
+		options.InsertOne().SetBypassDocumentValidation(true))
+	require.NoError(err)
+	defer repo.collection().DeleteOne(ctx, bson.M{"_id": docID})
+
+	var doc bson.M
+	require.NoError(repo.collection().FindOne(ctx, bson.M{"_id": docID}).Decode(&doc))
+	pending, err := upgradeMongoDBDocument(ctx, repo.db, collectionName, articleSchemaVersion, doc)
+	require.NoError(err)
+
+	id := data.ArticleID(docID.Hex())
+	require.NoError(repo.Update(ctx, id, data.FirstArticleVersion, &data.ArticleInfo{Title: "updated", Content: "content", AuthorIDs: []data.AuthorID{"1"}}))
+	result, err := repo.collection().UpdateOne(ctx, pending.filter, pending.update)
+	require.NoError(err)
+	assert.Zero(result.MatchedCount, "the upgrade of the document read before the update should not be stored")
+
+	article, err := repo.GetByID(ctx, id)
+	require.NoError(err)
+	assert.Equal(data.FirstArticleVersion+1, article.Version, "the version should not go backwards")
+	assert.Equal(data.ArticleTitle("updated"), article.Title)
+	assert.WithinDuration(time.Now(), article.UpdatedAt, time.Minute, "the update time should be kept")
+	assert.WithinDuration(docID.Timestamp(), article.CreatedAt, time.Second, "the creation time should be upgraded")
+
+	var upgraded bson.M
+	require.NoError(repo.collection().FindOne(ctx, bson.M{"_id": docID}).Decode(&upgraded))
+	assert.EqualValues(articleSchemaVersion, upgraded["schema_version"], "the document should be upgraded when read again")
+}
@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"context"
+	"strings"
+
+	"github.com/Jason5Lee/simple-blog/core/data"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// mongoDBMigrations are the migrations of all the collections, in the order they are applied.
+// The version of a new migration must be greater than all the others.
+var mongoDBMigrations = []mongoDBMigration{
+	{version: 1, name: "article_author_strings", collection: collectionName, upgrade: upgradeArticleAuthorString},
+	{version: 2, name: "article_single_author", collection: collectionName, upgrade: upgradeArticleSingleAuthor},
+	{version: 3, name: "article_timestamps", collection: collectionName, upgrade: upgradeArticleTimestamps},
+	{version: 4, name: "article_versions", collection: collectionName, upgrade: upgradeArticleVersion},
+}
+
+// articleSchemaVersion is the version of the article documents written by the current version.
+const articleSchemaVersion = 4
+
+// upgradeArticleAuthorString converts the author string of an article written before author profiles
+// into an author profile referenced by ID. Author strings only differing in case or surrounding spaces become
+// the same profile, and an existing profile with the same name is reused.
+// The upgrade runs concurrently on the reads of any replica, so the profile is upserted, and the profiles created
+// by it have a unique "legacy_name_key", making the loser of concurrent upserts reuse the profile of the winner.
+func upgradeArticleAuthorString(ctx context.Context, db *mongo.Database, doc bson.M) error {
+	author, ok := doc["author"].(string)
+	if !ok {
+		return nil
+	}
+	delete(doc, "author")
+	if _, ok := doc["author_ids"]; ok {
+		return nil
+	}
+	if _, ok := doc["author_id"]; ok {
+		return nil
+	}
+
+	key := data.AuthorNameKey(data.AuthorName(author))
+	displayName := strings.TrimSpace(author)
+	if displayName == "" {
+		displayName = author
+	}
+	upsert := func() (*DBAuthor, error) {
+		var profile DBAuthor
+		err := db.Collection(authorCollectionName).FindOneAndUpdate(ctx, bson.M{"name_key": key}, bson.M{"$setOnInsert": bson.M{
+			"display_name":    displayName,
+			"name_key":        key,
+			"legacy_name_key": key,
+			"bio":             "",
+			"avatar":          "",
+			"links":           bson.A{},
+		}}, options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)).Decode(&profile)
+		return &profile, err
+	}
+	profile, err := upsert()
+	if mongo.IsDuplicateKeyError(err) {
+		// Another upgrade has just created the profile.
+		profile, err = upsert()
+	}
+	if err != nil {
+		return err
+	}
+	doc["author_ids"] = bson.A{profile.ID.Hex()}
+	return nil
+}
+
+// upgradeArticleSingleAuthor converts the single author ID of an article written before co-authors into a list.
+func upgradeArticleSingleAuthor(ctx context.Context, db *mongo.Database, doc bson.M) error {
+	authorID, ok := doc["author_id"]
+	if !ok {
+		return nil
+	}
+	delete(doc, "author_id")
+	if _, ok := doc["author_ids"]; !ok {
+		doc["author_ids"] = bson.A{authorID}
+	}
+	return nil
+}
+
+// upgradeArticleTimestamps sets the missing timestamps of an article written before they were recorded
+// to the creation time embedded in the ObjectID. An article updated before being upgraded keeps its update time.
+func upgradeArticleTimestamps(ctx context.Context, db *mongo.Database, doc bson.M) error {
+	id, ok := doc["_id"].(primitive.ObjectID)
+	if !ok {
+		return nil
+	}
+	createdAt := primitive.NewDateTimeFromTime(id.Timestamp())
+	for _, key := range []string{"created_at", "updated_at"} {
+		if _, ok := doc[key]; !ok {
+			doc[key] = createdAt
+		}
+	}
+	return nil
+}
+
+// upgradeArticleVersion gives the first version to an article written before the versions.
+func upgradeArticleVersion(ctx context.Context, db *mongo.Database, doc bson.M) error {
+	if _, ok := doc["version"]; !ok {
+		doc["version"] = int64(data.FirstArticleVersion)
+	}
+	return nil
+}
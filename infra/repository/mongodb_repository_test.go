@@ -0,0 +1,20 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Jason5Lee/simple-blog/infra/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_MongoDBUnreachable(t *testing.T) {
+	assert := assert.New(t)
+	start := time.Now()
+	// Nothing listens on the port 1, so the server selection of the ping times out.
+	_, err := repository.NewArticleRepositoryMongoDB(context.Background(), "mongodb://127.0.0.1:1/?connect=direct",
+		&repository.MongoDBOptions{ServerSelectionTimeout: 200 * time.Millisecond})
+	assert.ErrorContains(err, "MongoDB is unreachable", "creating the repository should fail fast")
+	assert.Less(time.Since(start), 5*time.Second, "the server selection timeout should be used")
+}
@@ -0,0 +1,62 @@
+CREATE TABLE articles (
+    id         BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    title      TEXT NOT NULL,
+    content    TEXT NOT NULL,
+    -- The authors in the order they are credited.
+    author_ids TEXT[] NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL,
+    -- The full-text index document, weighting the title over the content.
+    search     TSVECTOR GENERATED ALWAYS AS (
+        setweight(to_tsvector('english', title), 'A') || setweight(to_tsvector('english', content), 'B')
+    ) STORED
+);
+CREATE INDEX articles_author_ids ON articles USING GIN (author_ids);
+CREATE INDEX articles_search ON articles USING GIN (search);
+
+CREATE TABLE authors (
+    id           BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    display_name TEXT NOT NULL,
+    bio          TEXT NOT NULL,
+    avatar       TEXT NOT NULL,
+    links        TEXT[] NOT NULL,
+    -- NULL if no token has been issued.
+    token_hash   TEXT UNIQUE
+);
+
+CREATE TABLE comments (
+    id         BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    article_id TEXT NOT NULL,
+    author     TEXT NOT NULL,
+    content    TEXT NOT NULL,
+    status     TEXT NOT NULL,
+    spam_score DOUBLE PRECISION NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX comments_article_id_status ON comments (article_id, status, created_at);
+CREATE INDEX comments_status ON comments (status, created_at);
+
+CREATE TABLE reactions (
+    article_id TEXT NOT NULL,
+    "user"     TEXT NOT NULL,
+    kind       TEXT NOT NULL,
+    PRIMARY KEY (article_id, "user", kind)
+);
+CREATE INDEX reactions_article_id_kind ON reactions (article_id, kind);
+
+CREATE TABLE article_views (
+    article_id TEXT NOT NULL,
+    day        DATE NOT NULL,
+    views      BIGINT NOT NULL,
+    PRIMARY KEY (article_id, day)
+);
+CREATE INDEX article_views_day ON article_views (day);
+
+CREATE TABLE series (
+    id          BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    title       TEXT NOT NULL,
+    description TEXT NOT NULL,
+    -- The parts in order, so each change of the order is a single-row update.
+    article_ids TEXT[] NOT NULL
+);
+CREATE INDEX series_article_ids ON series USING GIN (article_ids);
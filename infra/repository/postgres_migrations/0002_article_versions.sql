@@ -0,0 +1,2 @@
+-- The number of times an article has been written, so an article is only changed as of the version it was read.
+ALTER TABLE articles ADD COLUMN version BIGINT NOT NULL DEFAULT 1;
@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/Jason5Lee/simple-blog/core/data"
+	"github.com/Jason5Lee/simple-blog/core/repository"
+	bolt "go.etcd.io/bbolt"
+)
+
+// ReactionRepositoryBolt is a bbolt implementation of ReactionRepository.
+// Each reaction is a key, and the counts are kept in a separate bucket updated in the same transaction.
+type ReactionRepositoryBolt struct {
+	db *bolt.DB
+}
+
+var (
+	boltReactionsBucket      = []byte("reactions")
+	boltReactionCountsBucket = []byte("reaction_counts")
+)
+
+// NewReactionRepositoryBolt creates a new ReactionRepositoryBolt sharing the database of the article repository.
+func NewReactionRepositoryBolt(articleRepo *ArticleRepositoryBolt) *ReactionRepositoryBolt {
+	return &ReactionRepositoryBolt{db: articleRepo.db}
+}
+
+func (repo *ReactionRepositoryBolt) Add(ctx context.Context, articleID data.ArticleID, user data.ReactionUser, kind data.ReactionKind) (bool, error) {
+	return repo.update(articleID, user, kind, true)
+}
+
+func (repo *ReactionRepositoryBolt) Remove(ctx context.Context, articleID data.ArticleID, user data.ReactionUser, kind data.ReactionKind) (bool, error) {
+	return repo.update(articleID, user, kind, false)
+}
+
+// update adds or removes the reaction, returning false if it is already in the wanted state.
+func (repo *ReactionRepositoryBolt) update(articleID data.ArticleID, user data.ReactionUser, kind data.ReactionKind, add bool) (bool, error) {
+	var changed bool
+	err := repo.db.Update(func(tx *bolt.Tx) error {
+		reactions := tx.Bucket(boltReactionsBucket)
+		key := boltKey([]byte(articleID), []byte(user), []byte(kind))
+		if exists := reactions.Get(key) != nil; exists == add {
+			changed = false
+			return nil
+		}
+		changed = true
+		counts := tx.Bucket(boltReactionCountsBucket)
+		countKey := boltKey([]byte(articleID), []byte(kind))
+		var count uint64
+		if value := counts.Get(countKey); value != nil {
+			count = binary.BigEndian.Uint64(value)
+		}
+		if add {
+			if err := reactions.Put(key, nil); err != nil {
+				return err
+			}
+			return counts.Put(countKey, boltID(count+1))
+		}
+		if err := reactions.Delete(key); err != nil {
+			return err
+		}
+		if count <= 1 {
+			return counts.Delete(countKey)
+		}
+		return counts.Put(countKey, boltID(count-1))
+	})
+	return changed, err
+}
+
+func (repo *ReactionRepositoryBolt) Counts(ctx context.Context, articleIDs []data.ArticleID) (map[data.ArticleID]data.ReactionCounts, error) {
+	result := make(map[data.ArticleID]data.ReactionCounts)
+	err := repo.db.View(func(tx *bolt.Tx) error {
+		cursor := tx.Bucket(boltReactionCountsBucket).Cursor()
+		for _, articleID := range articleIDs {
+			prefix := boltKey([]byte(articleID))
+			for key, value := cursor.Seek(prefix); key != nil && bytes.HasPrefix(key, prefix); key, value = cursor.Next() {
+				counts, ok := result[articleID]
+				if !ok {
+					counts = make(data.ReactionCounts)
+					result[articleID] = counts
+				}
+				// The kind is the second part of the key, after its length.
+				kind := key[len(prefix)+2:]
+				counts[data.ReactionKind(kind)] = int64(binary.BigEndian.Uint64(value))
+			}
+		}
+		return nil
+	})
+	return result, err
+}
+
+func (repo *ReactionRepositoryBolt) GetByArticle(ctx context.Context, articleID data.ArticleID) ([]data.Reaction, error) {
+	var result []data.Reaction
+	err := repo.db.View(func(tx *bolt.Tx) error {
+		cursor := tx.Bucket(boltReactionsBucket).Cursor()
+		prefix := boltKey([]byte(articleID))
+		for key, _ := cursor.Seek(prefix); key != nil && bytes.HasPrefix(key, prefix); key, _ = cursor.Next() {
+			parts, ok := splitBoltKey(key)
+			if !ok || len(parts) != 3 {
+				return fmt.Errorf("malformed reaction key %x", key)
+			}
+			result = append(result, data.Reaction{User: data.ReactionUser(parts[1]), Kind: data.ReactionKind(parts[2])})
+		}
+		return nil
+	})
+	return result, err
+}
+
+var _ repository.ReactionRepository = (*ReactionRepositoryBolt)(nil)
@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"context"
+	"sync"
+
+	"github.com/Jason5Lee/simple-blog/core/data"
+	"github.com/Jason5Lee/simple-blog/core/repository"
+)
+
+type reactionKey struct {
+	articleID data.ArticleID
+	user      data.ReactionUser
+	kind      data.ReactionKind
+}
+
+// In-memory implementation of ReactionRepository.
+type ReactionRepositoryInMemory struct {
+	mu        sync.RWMutex
+	reactions map[reactionKey]struct{}
+	counts    map[data.ArticleID]data.ReactionCounts
+}
+
+func NewReactionRepositoryInMemory() *ReactionRepositoryInMemory {
+	return &ReactionRepositoryInMemory{
+		reactions: make(map[reactionKey]struct{}),
+		counts:    make(map[data.ArticleID]data.ReactionCounts),
+	}
+}
+
+func (r *ReactionRepositoryInMemory) Add(ctx context.Context, articleID data.ArticleID, user data.ReactionUser, kind data.ReactionKind) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := reactionKey{articleID, user, kind}
+	if _, ok := r.reactions[key]; ok {
+		return false, nil
+	}
+	r.reactions[key] = struct{}{}
+	if r.counts[articleID] == nil {
+		r.counts[articleID] = data.ReactionCounts{}
+	}
+	r.counts[articleID][kind]++
+	return true, nil
+}
+
+func (r *ReactionRepositoryInMemory) Remove(ctx context.Context, articleID data.ArticleID, user data.ReactionUser, kind data.ReactionKind) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := reactionKey{articleID, user, kind}
+	if _, ok := r.reactions[key]; !ok {
+		return false, nil
+	}
+	delete(r.reactions, key)
+	if r.counts[articleID][kind]--; r.counts[articleID][kind] == 0 {
+		delete(r.counts[articleID], kind)
+	}
+	return true, nil
+}
+
+func (r *ReactionRepositoryInMemory) Counts(ctx context.Context, articleIDs []data.ArticleID) (map[data.ArticleID]data.ReactionCounts, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	result := make(map[data.ArticleID]data.ReactionCounts, len(articleIDs))
+	for _, id := range articleIDs {
+		if counts, ok := r.counts[id]; ok {
+			copied := make(data.ReactionCounts, len(counts))
+			for kind, count := range counts {
+				copied[kind] = count
+			}
+			result[id] = copied
+		}
+	}
+	return result, nil
+}
+
+func (r *ReactionRepositoryInMemory) GetByArticle(ctx context.Context, articleID data.ArticleID) ([]data.Reaction, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var result []data.Reaction
+	for key := range r.reactions {
+		if key.articleID == articleID {
+			result = append(result, data.Reaction{User: key.user, Kind: key.kind})
+		}
+	}
+	return result, nil
+}
+
+var _ repository.ReactionRepository = (*ReactionRepositoryInMemory)(nil)
@@ -0,0 +1,206 @@
+package repository
+
+import (
+	"context"
+	"log"
+	"sync/atomic"
+	"time"
+
+	"github.com/Jason5Lee/simple-blog/core/data"
+	"github.com/Jason5Lee/simple-blog/core/repository"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// The key of a reaction. Using it as "_id" makes the uniqueness enforced by MongoDB.
+type DBReactionKey struct {
+	ArticleID string `bson:"article_id"`
+	User      string `bson:"user"`
+	Kind      string `bson:"kind"`
+}
+
+type DBReaction struct {
+	ID        DBReactionKey `bson:"_id"`
+	CreatedAt time.Time     `bson:"created_at"`
+}
+
+// The counts of an article's reactions, with the article ID as "_id".
+type DBReactionCounts struct {
+	ArticleID string           `bson:"_id"`
+	Counts    map[string]int64 `bson:"counts"`
+}
+
+// ReactionRepositoryMongoDB is a MongoDB implementation of ReactionRepository.
+// Each reaction is a document, and the counts are kept in a separate collection updated with `$inc`,
+// so reading the counts does not need to aggregate all reactions.
+// A reaction and its count are written in a transaction, so the count cannot drift if either write fails.
+// A standalone MongoDB has no transactions, in which case the count is recomputed from the reactions
+// if incrementing it fails.
+type ReactionRepositoryMongoDB struct {
+	db *mongo.Database
+	// standalone is set once MongoDB is found to have no transactions.
+	standalone atomic.Bool
+}
+
+// transactionNotSupportedCode is returned by a standalone server, as only replica sets and sharded clusters
+// have transactions.
+const transactionNotSupportedCode = 20
+
+const reactionCollectionName = "reactions"
+const reactionCountsCollectionName = "reaction_counts"
+
+// NewReactionRepositoryMongoDB creates a new ReactionRepositoryMongoDB sharing the connection of the article repository.
+func NewReactionRepositoryMongoDB(articleRepo *ArticleRepositoryMongoDB) *ReactionRepositoryMongoDB {
+	return &ReactionRepositoryMongoDB{db: articleRepo.db}
+}
+
+func (repo *ReactionRepositoryMongoDB) reactions() *mongo.Collection {
+	return repo.db.Collection(reactionCollectionName)
+}
+
+func (repo *ReactionRepositoryMongoDB) counts() *mongo.Collection {
+	return repo.db.Collection(reactionCountsCollectionName)
+}
+
+func (repo *ReactionRepositoryMongoDB) Add(ctx context.Context, articleID data.ArticleID, user data.ReactionUser, kind data.ReactionKind) (bool, error) {
+	key := DBReactionKey{ArticleID: string(articleID), User: string(user), Kind: string(kind)}
+	return repo.change(ctx, articleID, kind, 1, func(ctx context.Context) (bool, error) {
+		// An upsert, as a duplicate key error would abort the transaction.
+		updateResult, err := repo.reactions().UpdateOne(ctx, bson.M{"_id": key},
+			bson.M{"$setOnInsert": bson.M{"created_at": time.Now()}},
+			options.Update().SetUpsert(true),
+		)
+		if err != nil {
+			return false, err
+		}
+		return updateResult.UpsertedCount > 0, nil
+	})
+}
+
+func (repo *ReactionRepositoryMongoDB) Remove(ctx context.Context, articleID data.ArticleID, user data.ReactionUser, kind data.ReactionKind) (bool, error) {
+	key := DBReactionKey{ArticleID: string(articleID), User: string(user), Kind: string(kind)}
+	return repo.change(ctx, articleID, kind, -1, func(ctx context.Context) (bool, error) {
+		deleteResult, err := repo.reactions().DeleteOne(ctx, bson.M{"_id": key})
+		if err != nil {
+			return false, err
+		}
+		return deleteResult.DeletedCount > 0, nil
+	})
+}
+
+// change writes a reaction, and increments its count by the delta if it changed, in a transaction if supported.
+func (repo *ReactionRepositoryMongoDB) change(ctx context.Context, articleID data.ArticleID, kind data.ReactionKind, delta int64, write func(context.Context) (bool, error)) (bool, error) {
+	if !repo.standalone.Load() {
+		session, err := repo.db.Client().StartSession()
+		if err != nil {
+			return false, err
+		}
+		defer session.EndSession(ctx)
+		changed, err := session.WithTransaction(ctx, func(ctx mongo.SessionContext) (interface{}, error) {
+			changed, err := write(ctx)
+			if err != nil || !changed {
+				return false, err
+			}
+			return true, repo.increment(ctx, articleID, kind, delta)
+		})
+		if serverErr, ok := err.(mongo.ServerError); !ok || !serverErr.HasErrorCode(transactionNotSupportedCode) {
+			if err != nil {
+				return false, err
+			}
+			return changed.(bool), nil
+		}
+		repo.standalone.Store(true)
+	}
+
+	changed, err := write(ctx)
+	if err != nil || !changed {
+		return false, err
+	}
+	if err := repo.increment(ctx, articleID, kind, delta); err != nil {
+		// The reaction is written, so the count is repaired even if the request is canceled.
+		if recountErr := repo.recount(context.WithoutCancel(ctx), articleID, kind); recountErr != nil {
+			log.Printf("failed to recount the %s reactions of article %s: %v", kind, articleID, recountErr)
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// recount sets the count of the reactions of the kind to an article from the reactions.
+// It scans the reactions, as they are only indexed by "_id", so it is only used to repair a count.
+func (repo *ReactionRepositoryMongoDB) recount(ctx context.Context, articleID data.ArticleID, kind data.ReactionKind) error {
+	count, err := repo.reactions().CountDocuments(ctx, bson.M{"_id.article_id": string(articleID), "_id.kind": string(kind)})
+	if err != nil {
+		return err
+	}
+	_, err = repo.counts().UpdateOne(ctx,
+		bson.M{"_id": string(articleID)},
+		bson.M{"$set": bson.M{"counts." + string(kind): count}},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
+
+func (repo *ReactionRepositoryMongoDB) increment(ctx context.Context, articleID data.ArticleID, kind data.ReactionKind, delta int64) error {
+	_, err := repo.counts().UpdateOne(ctx,
+		bson.M{"_id": string(articleID)},
+		bson.M{"$inc": bson.M{"counts." + string(kind): delta}},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
+
+func (repo *ReactionRepositoryMongoDB) Counts(ctx context.Context, articleIDs []data.ArticleID) (map[data.ArticleID]data.ReactionCounts, error) {
+	ids := make([]string, len(articleIDs))
+	for i, id := range articleIDs {
+		ids[i] = string(id)
+	}
+	cursor, err := repo.counts().Find(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		return nil, err
+	}
+	var docs []*DBReactionCounts
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+	result := make(map[data.ArticleID]data.ReactionCounts, len(docs))
+	for _, doc := range docs {
+		counts := make(data.ReactionCounts, len(doc.Counts))
+		for kind, count := range doc.Counts {
+			// Kinds whose reactions were all removed are kept with zero count by `$inc`.
+			if count > 0 {
+				counts[data.ReactionKind(kind)] = count
+			}
+		}
+		result[data.ArticleID(doc.ArticleID)] = counts
+	}
+	return result, nil
+}
+
+// GetByArticle gets the reactions to the article, scanning them like recount.
+func (repo *ReactionRepositoryMongoDB) GetByArticle(ctx context.Context, articleID data.ArticleID) ([]data.Reaction, error) {
+	cursor, err := repo.reactions().Find(ctx, bson.M{"_id.article_id": string(articleID)})
+	if err != nil {
+		return nil, err
+	}
+	var docs []*DBReaction
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+	result := make([]data.Reaction, len(docs))
+	for i, doc := range docs {
+		result[i] = data.Reaction{User: data.ReactionUser(doc.ID.User), Kind: data.ReactionKind(doc.ID.Kind)}
+	}
+	return result, nil
+}
+
+// Dropping the collections for integration testing.
+func (repo *ReactionRepositoryMongoDB) Drop() error {
+	if err := repo.reactions().Drop(context.Background()); err != nil {
+		return err
+	}
+	return repo.counts().Drop(context.Background())
+}
+
+var _ repository.ReactionRepository = (*ReactionRepositoryMongoDB)(nil)
@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Jason5Lee/simple-blog/core/data"
+	"github.com/Jason5Lee/simple-blog/core/repository"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ReactionRepositoryPostgres is a PostgreSQL implementation of ReactionRepository.
+// Each reaction is a row, and the counts are aggregated on read with the index on the article and the kind.
+type ReactionRepositoryPostgres struct {
+	pool *pgxpool.Pool
+}
+
+// NewReactionRepositoryPostgres creates a new ReactionRepositoryPostgres sharing the pool of the article repository.
+func NewReactionRepositoryPostgres(articleRepo *ArticleRepositoryPostgres) *ReactionRepositoryPostgres {
+	return &ReactionRepositoryPostgres{pool: articleRepo.pool}
+}
+
+func (repo *ReactionRepositoryPostgres) Add(ctx context.Context, articleID data.ArticleID, user data.ReactionUser, kind data.ReactionKind) (bool, error) {
+	tag, err := repo.pool.Exec(ctx, `INSERT INTO reactions (article_id, "user", kind) VALUES ($1, $2, $3) ON CONFLICT DO NOTHING`,
+		string(articleID), string(user), string(kind))
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+func (repo *ReactionRepositoryPostgres) Remove(ctx context.Context, articleID data.ArticleID, user data.ReactionUser, kind data.ReactionKind) (bool, error) {
+	tag, err := repo.pool.Exec(ctx, `DELETE FROM reactions WHERE article_id = $1 AND "user" = $2 AND kind = $3`,
+		string(articleID), string(user), string(kind))
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+func (repo *ReactionRepositoryPostgres) Counts(ctx context.Context, articleIDs []data.ArticleID) (map[data.ArticleID]data.ReactionCounts, error) {
+	ids := make([]string, len(articleIDs))
+	for i, id := range articleIDs {
+		ids[i] = string(id)
+	}
+	rows, err := repo.pool.Query(ctx, "SELECT article_id, kind, COUNT(*) FROM reactions WHERE article_id = ANY($1) GROUP BY article_id, kind", ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make(map[data.ArticleID]data.ReactionCounts)
+	for rows.Next() {
+		var articleID, kind string
+		var count int64
+		if err := rows.Scan(&articleID, &kind, &count); err != nil {
+			return nil, err
+		}
+		counts, ok := result[data.ArticleID(articleID)]
+		if !ok {
+			counts = make(data.ReactionCounts)
+			result[data.ArticleID(articleID)] = counts
+		}
+		counts[data.ReactionKind(kind)] = count
+	}
+	return result, rows.Err()
+}
+
+func (repo *ReactionRepositoryPostgres) GetByArticle(ctx context.Context, articleID data.ArticleID) ([]data.Reaction, error) {
+	rows, err := repo.pool.Query(ctx, `SELECT "user", kind FROM reactions WHERE article_id = $1`, string(articleID))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []data.Reaction
+	for rows.Next() {
+		var user, kind string
+		if err := rows.Scan(&user, &kind); err != nil {
+			return nil, err
+		}
+		result = append(result, data.Reaction{User: data.ReactionUser(user), Kind: data.ReactionKind(kind)})
+	}
+	return result, rows.Err()
+}
+
+var _ repository.ReactionRepository = (*ReactionRepositoryPostgres)(nil)
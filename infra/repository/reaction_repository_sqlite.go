@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/Jason5Lee/simple-blog/core/data"
+	"github.com/Jason5Lee/simple-blog/core/repository"
+)
+
+// ReactionRepositorySQLite is a SQLite implementation of ReactionRepository.
+// Each reaction is a row, and the counts are aggregated on read with the index on the article and the kind.
+type ReactionRepositorySQLite struct {
+	db *sql.DB
+}
+
+// NewReactionRepositorySQLite creates a new ReactionRepositorySQLite sharing the database of the article repository.
+func NewReactionRepositorySQLite(articleRepo *ArticleRepositorySQLite) *ReactionRepositorySQLite {
+	return &ReactionRepositorySQLite{db: articleRepo.db}
+}
+
+func (repo *ReactionRepositorySQLite) Add(ctx context.Context, articleID data.ArticleID, user data.ReactionUser, kind data.ReactionKind) (bool, error) {
+	result, err := repo.db.ExecContext(ctx, "INSERT INTO reactions (article_id, user, kind) VALUES (?, ?, ?) ON CONFLICT DO NOTHING",
+		string(articleID), string(user), string(kind))
+	if err != nil {
+		return false, err
+	}
+	added, err := result.RowsAffected()
+	return added > 0, err
+}
+
+func (repo *ReactionRepositorySQLite) Remove(ctx context.Context, articleID data.ArticleID, user data.ReactionUser, kind data.ReactionKind) (bool, error) {
+	result, err := repo.db.ExecContext(ctx, "DELETE FROM reactions WHERE article_id = ? AND user = ? AND kind = ?",
+		string(articleID), string(user), string(kind))
+	if err != nil {
+		return false, err
+	}
+	removed, err := result.RowsAffected()
+	return removed > 0, err
+}
+
+func (repo *ReactionRepositorySQLite) Counts(ctx context.Context, articleIDs []data.ArticleID) (map[data.ArticleID]data.ReactionCounts, error) {
+	result := make(map[data.ArticleID]data.ReactionCounts)
+	if len(articleIDs) == 0 {
+		return result, nil
+	}
+	args := make([]interface{}, len(articleIDs))
+	for i, id := range articleIDs {
+		args[i] = string(id)
+	}
+	rows, err := repo.db.QueryContext(ctx, "SELECT article_id, kind, COUNT(*) FROM reactions WHERE article_id IN ("+
+		sqlitePlaceholders(len(args))+") GROUP BY article_id, kind", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var articleID, kind string
+		var count int64
+		if err := rows.Scan(&articleID, &kind, &count); err != nil {
+			return nil, err
+		}
+		counts, ok := result[data.ArticleID(articleID)]
+		if !ok {
+			counts = make(data.ReactionCounts)
+			result[data.ArticleID(articleID)] = counts
+		}
+		counts[data.ReactionKind(kind)] = count
+	}
+	return result, rows.Err()
+}
+
+func (repo *ReactionRepositorySQLite) GetByArticle(ctx context.Context, articleID data.ArticleID) ([]data.Reaction, error) {
+	rows, err := repo.db.QueryContext(ctx, "SELECT user, kind FROM reactions WHERE article_id = ?", string(articleID))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []data.Reaction
+	for rows.Next() {
+		var user, kind string
+		if err := rows.Scan(&user, &kind); err != nil {
+			return nil, err
+		}
+		result = append(result, data.Reaction{User: data.ReactionUser(user), Kind: data.ReactionKind(kind)})
+	}
+	return result, rows.Err()
+}
+
+var _ repository.ReactionRepository = (*ReactionRepositorySQLite)(nil)
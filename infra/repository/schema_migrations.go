@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// schemaMigration is a versioned SQL script changing the schema of a SQL database.
+type schemaMigration struct {
+	version int
+	name    string
+	script  string
+}
+
+// loadSchemaMigrations loads the SQL scripts in the directory, named "<version>_<description>.sql",
+// in the order of the versions.
+func loadSchemaMigrations(fsys embed.FS, dir string) ([]schemaMigration, error) {
+	names, err := fs.Glob(fsys, dir+"/*.sql")
+	if err != nil {
+		return nil, err
+	}
+	migrations := make([]schemaMigration, len(names))
+	for i, name := range names {
+		prefix, _, _ := strings.Cut(path.Base(name), "_")
+		version, err := strconv.Atoi(prefix)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration name %q", name)
+		}
+		script, err := fsys.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+		migrations[i] = schemaMigration{version: version, name: path.Base(name), script: string(script)}
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].version < migrations[j].version })
+	for i := 1; i < len(migrations); i++ {
+		if migrations[i].version == migrations[i-1].version {
+			return nil, fmt.Errorf("duplicate migration version %d", migrations[i].version)
+		}
+	}
+	return migrations, nil
+}
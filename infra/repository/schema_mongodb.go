@@ -0,0 +1,284 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/Jason5Lee/simple-blog/core/data"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// mongoDBCollectionSchema is the declared schema of a collection, i.e. its indexes besides the one on "_id",
+// and its validator if not nil.
+// The indexes are compared by name, so changing the definition of an index requires giving it a new name.
+type mongoDBCollectionSchema struct {
+	name      string
+	indexes   []mongo.IndexModel
+	validator bson.D
+}
+
+func mongoDBIndex(name string, keys bson.D) mongo.IndexModel {
+	return mongo.IndexModel{Keys: keys, Options: options.Index().SetName(name)}
+}
+
+// jsonSchemaString is the JSON schema of a string field, whose length is between minLength and maxLength.
+// MongoDB counts the characters while data counts the bytes, so the validator never rejects valid data
+// and only catches the writes bypassing the validation, like those of older versions or other tools.
+func jsonSchemaString(minLength int, maxLength int) bson.D {
+	return bson.D{{Key: "bsonType", Value: "string"}, {Key: "minLength", Value: minLength}, {Key: "maxLength", Value: maxLength}}
+}
+
+func jsonSchemaStringArray(minItems int, maxItems int) bson.D {
+	return bson.D{
+		{Key: "bsonType", Value: "array"},
+		{Key: "minItems", Value: minItems},
+		{Key: "maxItems", Value: maxItems},
+		{Key: "items", Value: bson.D{{Key: "bsonType", Value: "string"}}},
+	}
+}
+
+func jsonSchemaValidator(required bson.A, properties bson.D) bson.D {
+	return bson.D{{Key: "$jsonSchema", Value: bson.D{
+		{Key: "bsonType", Value: "object"},
+		{Key: "required", Value: required},
+		{Key: "properties", Value: properties},
+	}}}
+}
+
+var jsonSchemaDate = bson.D{{Key: "bsonType", Value: "date"}}
+
+// mongoDBSchema is the schema of all the collections, matching the queries of the repositories
+// and the constraints of the data.
+// The articles are listed in the order of "_id", which starts with the creation time, so they need no index on it.
+var mongoDBSchema = []mongoDBCollectionSchema{
+	{
+		name: collectionName,
+		indexes: []mongo.IndexModel{
+			// A multikey index, so the articles of an author are found without scanning the collection.
+			mongoDBIndex("author_ids_1", bson.D{{Key: "author_ids", Value: 1}}),
+			// The text index used by Search, weighting the title over the content.
+			{
+				Keys:    bson.D{{Key: "title", Value: "text"}, {Key: "content", Value: "text"}},
+				Options: options.Index().SetName("title_text_content_text").SetWeights(bson.D{{Key: "title", Value: 10}, {Key: "content", Value: 1}}),
+			},
+		},
+		validator: jsonSchemaValidator(bson.A{"title", "content", "author_ids", "created_at", "updated_at"}, bson.D{
+			{Key: "title", Value: jsonSchemaString(1, data.MAX_ARTICLE_TITLE_LENGTH)},
+			{Key: "content", Value: jsonSchemaString(1, data.MAX_ARTICLE_CONTENT_LENGTH)},
+			{Key: "author_ids", Value: append(jsonSchemaStringArray(1, data.MAX_ARTICLE_AUTHORS), bson.E{Key: "uniqueItems", Value: true})},
+			{Key: "version", Value: bson.D{{Key: "bsonType", Value: "long"}, {Key: "minimum", Value: int64(data.FirstArticleVersion)}}},
+			{Key: "created_at", Value: jsonSchemaDate},
+			{Key: "updated_at", Value: jsonSchemaDate},
+		}),
+	},
+	{
+		name: authorCollectionName,
+		indexes: []mongo.IndexModel{
+			mongoDBIndex("name_key_1", bson.D{{Key: "name_key", Value: 1}}),
+			{
+				Keys: bson.D{{Key: "legacy_name_key", Value: 1}},
+				// Only the profiles created from the author strings are indexed, so concurrent upgrades
+				// create a single profile per author string, while other authors can share a name.
+				Options: options.Index().SetName("legacy_name_key_1_unique").SetUnique(true).
+					SetPartialFilterExpression(bson.M{"legacy_name_key": bson.M{"$exists": true}}),
+			},
+			{
+				Keys: bson.D{{Key: "token_hash", Value: 1}},
+				// Only the authors with a token are indexed, and a token authenticates a single author.
+				Options: options.Index().SetName("token_hash_1_unique").SetUnique(true).
+					SetPartialFilterExpression(bson.M{"token_hash": bson.M{"$exists": true}}),
+			},
+		},
+		validator: jsonSchemaValidator(bson.A{"display_name", "name_key", "bio", "avatar", "links"}, bson.D{
+			{Key: "display_name", Value: jsonSchemaString(1, data.MAX_AUTHOR_NAME_LENGTH)},
+			{Key: "name_key", Value: jsonSchemaString(0, data.MAX_AUTHOR_NAME_LENGTH)},
+			{Key: "bio", Value: jsonSchemaString(0, data.MAX_AUTHOR_BIO_LENGTH)},
+			{Key: "avatar", Value: jsonSchemaString(0, data.MAX_AUTHOR_URL_LENGTH)},
+			{Key: "links", Value: jsonSchemaStringArray(0, data.MAX_AUTHOR_LINKS)},
+			{Key: "token_hash", Value: bson.D{{Key: "bsonType", Value: "string"}}},
+			{Key: "legacy_name_key", Value: bson.D{{Key: "bsonType", Value: "string"}}},
+		}),
+	},
+	{
+		name: commentCollectionName,
+		indexes: []mongo.IndexModel{
+			mongoDBIndex("article_id_1_status_1_created_at_1", bson.D{{Key: "article_id", Value: 1}, {Key: "status", Value: 1}, {Key: "created_at", Value: 1}}),
+			mongoDBIndex("status_1_created_at_1", bson.D{{Key: "status", Value: 1}, {Key: "created_at", Value: 1}}),
+		},
+		validator: jsonSchemaValidator(bson.A{"article_id", "author", "content", "status", "spam_score", "created_at"}, bson.D{
+			{Key: "article_id", Value: bson.D{{Key: "bsonType", Value: "string"}}},
+			{Key: "author", Value: jsonSchemaString(1, data.MAX_COMMENT_AUTHOR_LENGTH)},
+			{Key: "content", Value: jsonSchemaString(1, data.MAX_COMMENT_CONTENT_LENGTH)},
+			{Key: "status", Value: bson.D{{Key: "enum", Value: bson.A{
+				string(data.CommentPending), string(data.CommentApproved), string(data.CommentRejected), string(data.CommentSpam),
+			}}}},
+			{Key: "spam_score", Value: bson.D{{Key: "bsonType", Value: "double"}}},
+			{Key: "created_at", Value: jsonSchemaDate},
+		}),
+	},
+	{
+		name: seriesCollectionName,
+		indexes: []mongo.IndexModel{
+			mongoDBIndex("article_ids_1", bson.D{{Key: "article_ids", Value: 1}}),
+		},
+		validator: jsonSchemaValidator(bson.A{"title", "description", "article_ids"}, bson.D{
+			{Key: "title", Value: jsonSchemaString(1, data.MAX_SERIES_TITLE_LENGTH)},
+			{Key: "description", Value: jsonSchemaString(0, data.MAX_SERIES_DESCRIPTION_LENGTH)},
+			{Key: "article_ids", Value: bson.D{
+				{Key: "bsonType", Value: "array"},
+				{Key: "uniqueItems", Value: true},
+				{Key: "items", Value: bson.D{{Key: "bsonType", Value: "string"}}},
+			}},
+		}),
+	},
+	{
+		name: viewCollectionName,
+		indexes: []mongo.IndexModel{
+			mongoDBIndex("article_id_1_day_1", bson.D{{Key: "article_id", Value: 1}, {Key: "day", Value: 1}}),
+			mongoDBIndex("day_1", bson.D{{Key: "day", Value: 1}}),
+		},
+	},
+	// The reactions and their counts are only accessed by "_id".
+	{name: reactionCollectionName},
+	{name: reactionCountsCollectionName},
+}
+
+// MongoDBSchemaChange is a change making a collection match the declared schema.
+type MongoDBSchemaChange struct {
+	Collection string
+	// Action is "create index", "drop index" or "set validator".
+	Action string
+	// Index is the name of the created or dropped index.
+	Index string
+}
+
+func (change MongoDBSchemaChange) String() string {
+	if change.Index == "" {
+		return fmt.Sprintf("%s %s", change.Action, change.Collection)
+	}
+	return fmt.Sprintf("%s %s.%s", change.Action, change.Collection, change.Index)
+}
+
+// EnsureSchemaMongoDB makes the collections match the declared schema, creating the missing indexes, dropping the
+// obsolete ones and setting the validators, returning the changes. With dryRun, it only returns the changes.
+// The validators use the moderate level, so the documents written by older versions can still be upgraded.
+func EnsureSchemaMongoDB(ctx context.Context, articleRepo *ArticleRepositoryMongoDB, dryRun bool) ([]MongoDBSchemaChange, error) {
+	db := articleRepo.db
+	specs, err := db.ListCollectionSpecifications(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	existing := make(map[string]*mongo.CollectionSpecification, len(specs))
+	for _, spec := range specs {
+		existing[spec.Name] = spec
+	}
+
+	var changes []MongoDBSchemaChange
+	for _, schema := range mongoDBSchema {
+		collection := articleRepo.collectionNamed(schema.name)
+		validatorChanged, err := schema.validatorChanged(existing[collection.Name()])
+		if err != nil {
+			return nil, err
+		}
+		if validatorChanged {
+			changes = append(changes, MongoDBSchemaChange{Collection: collection.Name(), Action: "set validator"})
+			if !dryRun {
+				if err := schema.setValidator(ctx, collection, existing[collection.Name()] != nil); err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		indexChanges, err := schema.ensureIndexes(ctx, collection, existing[collection.Name()] != nil, dryRun)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, indexChanges...)
+	}
+	return changes, nil
+}
+
+func (schema *mongoDBCollectionSchema) validatorChanged(spec *mongo.CollectionSpecification) (bool, error) {
+	if schema.validator == nil {
+		return false, nil
+	}
+	if spec == nil || spec.Options == nil {
+		return true, nil
+	}
+	current, err := spec.Options.LookupErr("validator")
+	if err != nil {
+		return true, nil
+	}
+	// The relaxed JSON ignores the integer types, which the server may not keep.
+	currentJSON, err := bson.MarshalExtJSON(current.Document(), false, false)
+	if err != nil {
+		return false, err
+	}
+	declaredJSON, err := bson.MarshalExtJSON(schema.validator, false, false)
+	if err != nil {
+		return false, err
+	}
+	return string(currentJSON) != string(declaredJSON), nil
+}
+
+func (schema *mongoDBCollectionSchema) setValidator(ctx context.Context, collection *mongo.Collection, exists bool) error {
+	db := collection.Database()
+	if !exists {
+		return db.CreateCollection(ctx, collection.Name(), options.CreateCollection().
+			SetValidator(schema.validator).SetValidationLevel("moderate").SetValidationAction("error"))
+	}
+	return db.RunCommand(ctx, bson.D{
+		{Key: "collMod", Value: collection.Name()},
+		{Key: "validator", Value: schema.validator},
+		{Key: "validationLevel", Value: "moderate"},
+		{Key: "validationAction", Value: "error"},
+	}).Err()
+}
+
+func (schema *mongoDBCollectionSchema) ensureIndexes(ctx context.Context, collection *mongo.Collection, exists bool, dryRun bool) ([]MongoDBSchemaChange, error) {
+	current := make(map[string]bool)
+	if exists {
+		specs, err := collection.Indexes().ListSpecifications(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, spec := range specs {
+			current[spec.Name] = true
+		}
+	}
+
+	var changes []MongoDBSchemaChange
+	declared := make(map[string]bool, len(schema.indexes))
+	for _, index := range schema.indexes {
+		name := *index.Options.Name
+		declared[name] = true
+		if current[name] {
+			continue
+		}
+		changes = append(changes, MongoDBSchemaChange{Collection: collection.Name(), Action: "create index", Index: name})
+		if !dryRun {
+			if _, err := collection.Indexes().CreateOne(ctx, index); err != nil {
+				return nil, err
+			}
+		}
+	}
+	obsolete := make([]string, 0, len(current))
+	for name := range current {
+		if name != "_id_" && !declared[name] {
+			obsolete = append(obsolete, name)
+		}
+	}
+	sort.Strings(obsolete)
+	for _, name := range obsolete {
+		changes = append(changes, MongoDBSchemaChange{Collection: collection.Name(), Action: "drop index", Index: name})
+		if !dryRun {
+			if _, err := collection.Indexes().DropOne(ctx, name); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return changes, nil
+}
@@ -0,0 +1,203 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"strconv"
+
+	"github.com/Jason5Lee/simple-blog/core/data"
+	"github.com/Jason5Lee/simple-blog/core/errors"
+	"github.com/Jason5Lee/simple-blog/core/repository"
+	bolt "go.etcd.io/bbolt"
+)
+
+// Data stored in bbolt, with the ID as the key.
+type BoltSeries struct {
+	Title       string   `json:"title"`
+	Description string   `json:"description"`
+	ArticleIDs  []string `json:"article_ids"`
+}
+
+// SeriesRepositoryBolt is a bbolt implementation of SeriesRepository.
+// The series are indexed by article in a separate bucket, updated in the same transaction as the parts.
+type SeriesRepositoryBolt struct {
+	db *bolt.DB
+}
+
+var (
+	boltSeriesBucket          = []byte("series")
+	boltSeriesByArticleBucket = []byte("series_by_article")
+)
+
+// NewSeriesRepositoryBolt creates a new SeriesRepositoryBolt sharing the database of the article repository.
+func NewSeriesRepositoryBolt(articleRepo *ArticleRepositoryBolt) *SeriesRepositoryBolt {
+	return &SeriesRepositoryBolt{db: articleRepo.db}
+}
+
+func (repo *SeriesRepositoryBolt) Create(ctx context.Context, series *data.SeriesInfo) (data.SeriesID, error) {
+	var id data.SeriesID
+	err := repo.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(boltSeriesBucket)
+		seq, err := bucket.NextSequence()
+		if err != nil {
+			return err
+		}
+		id = data.SeriesID(strconv.FormatUint(seq, 10))
+		return putBoltJSON(bucket, boltID(seq), &BoltSeries{
+			Title:       string(series.Title),
+			Description: string(series.Description),
+			ArticleIDs:  []string{},
+		})
+	})
+	return id, err
+}
+
+func (repo *SeriesRepositoryBolt) GetByID(ctx context.Context, id data.SeriesID) (*data.Series, error) {
+	key, ok := parseBoltID(string(id))
+	if !ok {
+		// Invalid ID does not match any record, so we return ErrSeriesNotFound.
+		return nil, errors.ErrSeriesNotFound
+	}
+	var result *data.Series
+	err := repo.db.View(func(tx *bolt.Tx) error {
+		series, err := getBoltSeries(tx, key)
+		if err != nil {
+			return err
+		}
+		result = series.toSeries(key)
+		return nil
+	})
+	return result, err
+}
+
+func (repo *SeriesRepositoryBolt) GetAll(ctx context.Context) ([]*data.Series, error) {
+	result := []*data.Series{}
+	err := repo.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltSeriesBucket).ForEach(func(key, value []byte) error {
+			var series BoltSeries
+			if err := json.Unmarshal(value, &series); err != nil {
+				return err
+			}
+			result = append(result, series.toSeries(key))
+			return nil
+		})
+	})
+	return result, err
+}
+
+func (repo *SeriesRepositoryBolt) GetByArticle(ctx context.Context, articleID data.ArticleID) ([]*data.Series, error) {
+	result := []*data.Series{}
+	err := repo.db.View(func(tx *bolt.Tx) error {
+		prefix := boltKey([]byte(articleID))
+		cursor := tx.Bucket(boltSeriesByArticleBucket).Cursor()
+		for indexKey, _ := cursor.Seek(prefix); indexKey != nil && bytes.HasPrefix(indexKey, prefix); indexKey, _ = cursor.Next() {
+			key := indexKey[len(indexKey)-8:]
+			series, err := getBoltSeries(tx, key)
+			if err != nil {
+				return err
+			}
+			result = append(result, series.toSeries(key))
+		}
+		return nil
+	})
+	return result, err
+}
+
+func (repo *SeriesRepositoryBolt) AddArticle(ctx context.Context, id data.SeriesID, articleID data.ArticleID) error {
+	return repo.update(id, func(tx *bolt.Tx, key []byte, series *BoltSeries) error {
+		for _, part := range series.ArticleIDs {
+			if part == string(articleID) {
+				return errors.ErrArticleAlreadyInSeries
+			}
+		}
+		series.ArticleIDs = append(series.ArticleIDs, string(articleID))
+		return tx.Bucket(boltSeriesByArticleBucket).Put(boltKey([]byte(articleID), key), nil)
+	})
+}
+
+func (repo *SeriesRepositoryBolt) RemoveArticle(ctx context.Context, id data.SeriesID, articleID data.ArticleID) error {
+	return repo.update(id, func(tx *bolt.Tx, key []byte, series *BoltSeries) error {
+		for i, part := range series.ArticleIDs {
+			if part == string(articleID) {
+				series.ArticleIDs = append(series.ArticleIDs[:i], series.ArticleIDs[i+1:]...)
+				return tx.Bucket(boltSeriesByArticleBucket).Delete(boltKey([]byte(articleID), key))
+			}
+		}
+		return errors.ErrArticleNotInSeries
+	})
+}
+
+func (repo *SeriesRepositoryBolt) Reorder(ctx context.Context, id data.SeriesID, articleIDs []data.ArticleID) error {
+	return repo.update(id, func(tx *bolt.Tx, key []byte, series *BoltSeries) error {
+		// The order is applied only if the parts are exactly the given articles, which are distinct.
+		if len(articleIDs) != len(series.ArticleIDs) {
+			return errors.ErrInvalidSeriesOrder
+		}
+		parts := make(map[string]bool, len(series.ArticleIDs))
+		for _, part := range series.ArticleIDs {
+			parts[part] = true
+		}
+		for _, articleID := range articleIDs {
+			if !parts[string(articleID)] {
+				return errors.ErrInvalidSeriesOrder
+			}
+			delete(parts, string(articleID))
+		}
+		series.ArticleIDs = make([]string, len(articleIDs))
+		for i, articleID := range articleIDs {
+			series.ArticleIDs[i] = string(articleID)
+		}
+		return nil
+	})
+}
+
+// update runs the change of the series in a transaction and stores the changed series.
+// The transaction is rolled back if the change returns an error.
+func (repo *SeriesRepositoryBolt) update(id data.SeriesID, change func(tx *bolt.Tx, key []byte, series *BoltSeries) error) error {
+	key, ok := parseBoltID(string(id))
+	if !ok {
+		return errors.ErrSeriesNotFound
+	}
+	return repo.db.Update(func(tx *bolt.Tx) error {
+		series, err := getBoltSeries(tx, key)
+		if err != nil {
+			return err
+		}
+		if err := change(tx, key, series); err != nil {
+			return err
+		}
+		return putBoltJSON(tx.Bucket(boltSeriesBucket), key, series)
+	})
+}
+
+func getBoltSeries(tx *bolt.Tx, key []byte) (*BoltSeries, error) {
+	value := tx.Bucket(boltSeriesBucket).Get(key)
+	if value == nil {
+		return nil, errors.ErrSeriesNotFound
+	}
+	var series BoltSeries
+	if err := json.Unmarshal(value, &series); err != nil {
+		return nil, err
+	}
+	return &series, nil
+}
+
+func (series *BoltSeries) toSeries(key []byte) *data.Series {
+	articleIDs := make([]data.ArticleID, len(series.ArticleIDs))
+	for i, id := range series.ArticleIDs {
+		articleIDs[i] = data.ArticleID(id)
+	}
+	return &data.Series{
+		ID: data.SeriesID(strconv.FormatUint(binary.BigEndian.Uint64(key), 10)),
+		// Assume the data in bbolt is valid.
+		SeriesInfo: data.SeriesInfo{
+			Title:       data.SeriesTitle(series.Title),
+			Description: data.SeriesDescription(series.Description),
+		},
+		ArticleIDs: articleIDs,
+	}
+}
+
+var _ repository.SeriesRepository = (*SeriesRepositoryBolt)(nil)
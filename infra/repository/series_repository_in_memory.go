@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/Jason5Lee/simple-blog/core/data"
+	"github.com/Jason5Lee/simple-blog/core/errors"
+	"github.com/Jason5Lee/simple-blog/core/repository"
+)
+
+// In-memory implementation of SeriesRepository.
+type SeriesRepositoryInMemory struct {
+	mu     sync.RWMutex
+	nextID int
+	series map[data.SeriesID]*data.Series
+	// order keeps the IDs in creation order.
+	order []data.SeriesID
+}
+
+func NewSeriesRepositoryInMemory() *SeriesRepositoryInMemory {
+	return &SeriesRepositoryInMemory{
+		series: make(map[data.SeriesID]*data.Series),
+	}
+}
+
+func copySeries(series *data.Series) *data.Series {
+	result := *series
+	result.ArticleIDs = append([]data.ArticleID{}, series.ArticleIDs...)
+	return &result
+}
+
+func (r *SeriesRepositoryInMemory) Create(ctx context.Context, series *data.SeriesInfo) (data.SeriesID, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.nextID++
+	id := data.SeriesID(fmt.Sprint(r.nextID))
+	r.series[id] = &data.Series{
+		ID:         id,
+		SeriesInfo: *series,
+		ArticleIDs: []data.ArticleID{},
+	}
+	r.order = append(r.order, id)
+	return id, nil
+}
+
+func (r *SeriesRepositoryInMemory) GetByID(ctx context.Context, id data.SeriesID) (*data.Series, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	series, ok := r.series[id]
+	if !ok {
+		return nil, errors.ErrSeriesNotFound
+	}
+	return copySeries(series), nil
+}
+
+func (r *SeriesRepositoryInMemory) GetAll(ctx context.Context) ([]*data.Series, error) {
+	return r.filter(func(*data.Series) bool { return true }), nil
+}
+
+func (r *SeriesRepositoryInMemory) GetByArticle(ctx context.Context, articleID data.ArticleID) ([]*data.Series, error) {
+	return r.filter(func(series *data.Series) bool {
+		return series.IndexOf(articleID) >= 0
+	}), nil
+}
+
+func (r *SeriesRepositoryInMemory) AddArticle(ctx context.Context, id data.SeriesID, articleID data.ArticleID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	series, ok := r.series[id]
+	if !ok {
+		return errors.ErrSeriesNotFound
+	}
+	if series.IndexOf(articleID) >= 0 {
+		return errors.ErrArticleAlreadyInSeries
+	}
+	series.ArticleIDs = append(series.ArticleIDs, articleID)
+	return nil
+}
+
+func (r *SeriesRepositoryInMemory) RemoveArticle(ctx context.Context, id data.SeriesID, articleID data.ArticleID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	series, ok := r.series[id]
+	if !ok {
+		return errors.ErrSeriesNotFound
+	}
+	index := series.IndexOf(articleID)
+	if index < 0 {
+		return errors.ErrArticleNotInSeries
+	}
+	series.ArticleIDs = append(series.ArticleIDs[:index:index], series.ArticleIDs[index+1:]...)
+	return nil
+}
+
+func (r *SeriesRepositoryInMemory) Reorder(ctx context.Context, id data.SeriesID, articleIDs []data.ArticleID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	series, ok := r.series[id]
+	if !ok {
+		return errors.ErrSeriesNotFound
+	}
+	if len(articleIDs) != len(series.ArticleIDs) {
+		return errors.ErrInvalidSeriesOrder
+	}
+	for _, articleID := range articleIDs {
+		if series.IndexOf(articleID) < 0 {
+			return errors.ErrInvalidSeriesOrder
+		}
+	}
+	series.ArticleIDs = append([]data.ArticleID{}, articleIDs...)
+	return nil
+}
+
+func (r *SeriesRepositoryInMemory) filter(pred func(*data.Series) bool) []*data.Series {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	result := make([]*data.Series, 0)
+	for _, id := range r.order {
+		if series := r.series[id]; pred(series) {
+			result = append(result, copySeries(series))
+		}
+	}
+	return result
+}
+
+var _ repository.SeriesRepository = (*SeriesRepositoryInMemory)(nil)
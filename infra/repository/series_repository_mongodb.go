@@ -0,0 +1,173 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Jason5Lee/simple-blog/core/data"
+	"github.com/Jason5Lee/simple-blog/core/errors"
+	"github.com/Jason5Lee/simple-blog/core/repository"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Data for inserting into MongoDB.
+type DBSeriesInfo struct {
+	Title       string   `bson:"title"`
+	Description string   `bson:"description"`
+	ArticleIDs  []string `bson:"article_ids"`
+}
+
+// Data for reading from MongoDB, with extra field "_id".
+type DBSeries struct {
+	ID           primitive.ObjectID `bson:"_id"`
+	DBSeriesInfo `bson:",inline"`
+}
+
+// SeriesRepositoryMongoDB is a MongoDB implementation of SeriesRepository.
+// The parts of a series are stored in the series document, so each change of the order is a single-document update.
+type SeriesRepositoryMongoDB struct {
+	db *mongo.Database
+}
+
+const seriesCollectionName = "series"
+
+// NewSeriesRepositoryMongoDB creates a new SeriesRepositoryMongoDB sharing the connection of the article repository.
+func NewSeriesRepositoryMongoDB(articleRepo *ArticleRepositoryMongoDB) *SeriesRepositoryMongoDB {
+	return &SeriesRepositoryMongoDB{db: articleRepo.db}
+}
+
+func (repo *SeriesRepositoryMongoDB) collection() *mongo.Collection {
+	return repo.db.Collection(seriesCollectionName)
+}
+
+func (repo *SeriesRepositoryMongoDB) Create(ctx context.Context, series *data.SeriesInfo) (data.SeriesID, error) {
+	insertResult, err := repo.collection().InsertOne(ctx, DBSeriesInfo{
+		Title:       string(series.Title),
+		Description: string(series.Description),
+		ArticleIDs:  []string{},
+	})
+	if err != nil {
+		return "", err
+	}
+	return data.SeriesID(insertResult.InsertedID.(primitive.ObjectID).Hex()), nil
+}
+
+func (repo *SeriesRepositoryMongoDB) GetByID(ctx context.Context, id data.SeriesID) (*data.Series, error) {
+	docID, err := primitive.ObjectIDFromHex(string(id))
+	if err != nil {
+		// Invalid ID does not match any document, so we return ErrSeriesNotFound.
+		return nil, errors.ErrSeriesNotFound
+	}
+	var series DBSeries
+	if err := repo.collection().FindOne(ctx, bson.M{"_id": docID}).Decode(&series); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.ErrSeriesNotFound
+		}
+		return nil, err
+	}
+	return series.toSeries(), nil
+}
+
+func (repo *SeriesRepositoryMongoDB) GetAll(ctx context.Context) ([]*data.Series, error) {
+	return repo.find(ctx, bson.M{})
+}
+
+func (repo *SeriesRepositoryMongoDB) GetByArticle(ctx context.Context, articleID data.ArticleID) ([]*data.Series, error) {
+	return repo.find(ctx, bson.M{"article_ids": string(articleID)})
+}
+
+func (repo *SeriesRepositoryMongoDB) AddArticle(ctx context.Context, id data.SeriesID, articleID data.ArticleID) error {
+	return repo.update(ctx, id,
+		bson.M{"article_ids": bson.M{"$ne": string(articleID)}},
+		bson.M{"$push": bson.M{"article_ids": string(articleID)}},
+		errors.ErrArticleAlreadyInSeries)
+}
+
+func (repo *SeriesRepositoryMongoDB) RemoveArticle(ctx context.Context, id data.SeriesID, articleID data.ArticleID) error {
+	return repo.update(ctx, id,
+		bson.M{"article_ids": string(articleID)},
+		bson.M{"$pull": bson.M{"article_ids": string(articleID)}},
+		errors.ErrArticleNotInSeries)
+}
+
+func (repo *SeriesRepositoryMongoDB) Reorder(ctx context.Context, id data.SeriesID, articleIDs []data.ArticleID) error {
+	ids := make([]string, len(articleIDs))
+	for i, articleID := range articleIDs {
+		ids[i] = string(articleID)
+	}
+	// The order is applied only if the parts are exactly the given articles, which are distinct.
+	// `$all` matches nothing if empty, so an empty order only checks that the series has no part.
+	parts := bson.M{"$size": len(ids)}
+	if len(ids) > 0 {
+		parts["$all"] = ids
+	}
+	return repo.update(ctx, id,
+		bson.M{"article_ids": parts},
+		bson.M{"$set": bson.M{"article_ids": ids}},
+		errors.ErrInvalidSeriesOrder)
+}
+
+// update updates the series if it matches the condition, returning errUnmatched if the series exists but does not match.
+func (repo *SeriesRepositoryMongoDB) update(ctx context.Context, id data.SeriesID, condition bson.M, update bson.M, errUnmatched error) error {
+	docID, err := primitive.ObjectIDFromHex(string(id))
+	if err != nil {
+		return errors.ErrSeriesNotFound
+	}
+	condition["_id"] = docID
+	updateResult, err := repo.collection().UpdateOne(ctx, condition, update)
+	if err != nil {
+		return err
+	}
+	if updateResult.MatchedCount > 0 {
+		return nil
+	}
+	count, err := repo.collection().CountDocuments(ctx, bson.M{"_id": docID})
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return errors.ErrSeriesNotFound
+	}
+	return errUnmatched
+}
+
+func (repo *SeriesRepositoryMongoDB) find(ctx context.Context, filter bson.M) ([]*data.Series, error) {
+	cursor, err := repo.collection().Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	var allSeries []*DBSeries
+	if err := cursor.All(ctx, &allSeries); err != nil {
+		return nil, err
+	}
+	result := make([]*data.Series, len(allSeries))
+	for i, series := range allSeries {
+		result[i] = series.toSeries()
+	}
+	return result, nil
+}
+
+func (series *DBSeries) toSeries() *data.Series {
+	articleIDs := make([]data.ArticleID, len(series.ArticleIDs))
+	for i, id := range series.ArticleIDs {
+		articleIDs[i] = data.ArticleID(id)
+	}
+	return &data.Series{
+		ID: data.SeriesID(series.ID.Hex()),
+		// Assume the data in MongoDB is valid.
+		SeriesInfo: data.SeriesInfo{
+			Title:       data.SeriesTitle(series.Title),
+			Description: data.SeriesDescription(series.Description),
+		},
+		ArticleIDs: articleIDs,
+	}
+}
+
+// Dropping the collection for integration testing.
+func (repo *SeriesRepositoryMongoDB) Drop() error {
+	return repo.collection().Drop(context.Background())
+}
+
+var _ repository.SeriesRepository = (*SeriesRepositoryMongoDB)(nil)
@@ -0,0 +1,138 @@
+package repository
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/Jason5Lee/simple-blog/core/data"
+	"github.com/Jason5Lee/simple-blog/core/errors"
+	"github.com/Jason5Lee/simple-blog/core/repository"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// SeriesRepositoryPostgres is a PostgreSQL implementation of SeriesRepository.
+// The parts of a series are an array column, so each change of the order is a single-row update.
+type SeriesRepositoryPostgres struct {
+	pool *pgxpool.Pool
+}
+
+// NewSeriesRepositoryPostgres creates a new SeriesRepositoryPostgres sharing the pool of the article repository.
+func NewSeriesRepositoryPostgres(articleRepo *ArticleRepositoryPostgres) *SeriesRepositoryPostgres {
+	return &SeriesRepositoryPostgres{pool: articleRepo.pool}
+}
+
+func (repo *SeriesRepositoryPostgres) Create(ctx context.Context, series *data.SeriesInfo) (data.SeriesID, error) {
+	var rowID int64
+	err := repo.pool.QueryRow(ctx, "INSERT INTO series (title, description, article_ids) VALUES ($1, $2, '{}') RETURNING id",
+		string(series.Title), string(series.Description)).Scan(&rowID)
+	if err != nil {
+		return "", err
+	}
+	return data.SeriesID(strconv.FormatInt(rowID, 10)), nil
+}
+
+func (repo *SeriesRepositoryPostgres) GetByID(ctx context.Context, id data.SeriesID) (*data.Series, error) {
+	rowID, ok := parseRowID(string(id))
+	if !ok {
+		// Invalid ID does not match any row, so we return ErrSeriesNotFound.
+		return nil, errors.ErrSeriesNotFound
+	}
+	allSeries, err := repo.find(ctx, "WHERE id = $1", rowID)
+	if err != nil {
+		return nil, err
+	}
+	if len(allSeries) == 0 {
+		return nil, errors.ErrSeriesNotFound
+	}
+	return allSeries[0], nil
+}
+
+func (repo *SeriesRepositoryPostgres) GetAll(ctx context.Context) ([]*data.Series, error) {
+	return repo.find(ctx, "ORDER BY id")
+}
+
+func (repo *SeriesRepositoryPostgres) GetByArticle(ctx context.Context, articleID data.ArticleID) ([]*data.Series, error) {
+	return repo.find(ctx, "WHERE article_ids @> ARRAY[$1::TEXT] ORDER BY id", string(articleID))
+}
+
+func (repo *SeriesRepositoryPostgres) AddArticle(ctx context.Context, id data.SeriesID, articleID data.ArticleID) error {
+	return repo.update(ctx, id,
+		"article_ids = array_append(article_ids, $2::TEXT)", "NOT article_ids @> ARRAY[$2::TEXT]",
+		errors.ErrArticleAlreadyInSeries, string(articleID))
+}
+
+func (repo *SeriesRepositoryPostgres) RemoveArticle(ctx context.Context, id data.SeriesID, articleID data.ArticleID) error {
+	return repo.update(ctx, id,
+		"article_ids = array_remove(article_ids, $2::TEXT)", "article_ids @> ARRAY[$2::TEXT]",
+		errors.ErrArticleNotInSeries, string(articleID))
+}
+
+func (repo *SeriesRepositoryPostgres) Reorder(ctx context.Context, id data.SeriesID, articleIDs []data.ArticleID) error {
+	ids := make([]string, len(articleIDs))
+	for i, articleID := range articleIDs {
+		ids[i] = string(articleID)
+	}
+	// The order is applied only if the parts are exactly the given articles, which are distinct.
+	return repo.update(ctx, id,
+		"article_ids = $2", "cardinality(article_ids) = cardinality($2::TEXT[]) AND article_ids @> $2",
+		errors.ErrInvalidSeriesOrder, ids)
+}
+
+// update sets the assignment of the series if it matches the condition, whose argument is $2,
+// returning errUnmatched if the series exists but does not match.
+func (repo *SeriesRepositoryPostgres) update(ctx context.Context, id data.SeriesID, assignment string, condition string, errUnmatched error, arg interface{}) error {
+	rowID, ok := parseRowID(string(id))
+	if !ok {
+		return errors.ErrSeriesNotFound
+	}
+	tag, err := repo.pool.Exec(ctx, "UPDATE series SET "+assignment+" WHERE id = $1 AND "+condition, rowID, arg)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() > 0 {
+		return nil
+	}
+	var exists bool
+	if err := repo.pool.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM series WHERE id = $1)", rowID).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return errors.ErrSeriesNotFound
+	}
+	return errUnmatched
+}
+
+// find gets the series with the clause following "FROM series".
+func (repo *SeriesRepositoryPostgres) find(ctx context.Context, clause string, args ...interface{}) ([]*data.Series, error) {
+	rows, err := repo.pool.Query(ctx, "SELECT id, title, description, article_ids FROM series "+clause, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []*data.Series{}
+	for rows.Next() {
+		var rowID int64
+		var title, description string
+		var articleIDs []string
+		if err := rows.Scan(&rowID, &title, &description, &articleIDs); err != nil {
+			return nil, err
+		}
+		series := &data.Series{
+			ID: data.SeriesID(strconv.FormatInt(rowID, 10)),
+			// Assume the data in PostgreSQL is valid.
+			SeriesInfo: data.SeriesInfo{
+				Title:       data.SeriesTitle(title),
+				Description: data.SeriesDescription(description),
+			},
+			ArticleIDs: make([]data.ArticleID, len(articleIDs)),
+		}
+		for i, articleID := range articleIDs {
+			series.ArticleIDs[i] = data.ArticleID(articleID)
+		}
+		result = append(result, series)
+	}
+	return result, rows.Err()
+}
+
+var _ repository.SeriesRepository = (*SeriesRepositoryPostgres)(nil)
@@ -0,0 +1,179 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strconv"
+
+	"github.com/Jason5Lee/simple-blog/core/data"
+	"github.com/Jason5Lee/simple-blog/core/errors"
+	"github.com/Jason5Lee/simple-blog/core/repository"
+)
+
+// SeriesRepositorySQLite is a SQLite implementation of SeriesRepository.
+// The parts of a series are rows of a separate table with their positions, changed in a transaction.
+type SeriesRepositorySQLite struct {
+	db *sql.DB
+}
+
+// NewSeriesRepositorySQLite creates a new SeriesRepositorySQLite sharing the database of the article repository.
+func NewSeriesRepositorySQLite(articleRepo *ArticleRepositorySQLite) *SeriesRepositorySQLite {
+	return &SeriesRepositorySQLite{db: articleRepo.db}
+}
+
+func (repo *SeriesRepositorySQLite) Create(ctx context.Context, series *data.SeriesInfo) (data.SeriesID, error) {
+	result, err := repo.db.ExecContext(ctx, "INSERT INTO series (title, description) VALUES (?, ?)",
+		string(series.Title), string(series.Description))
+	if err != nil {
+		return "", err
+	}
+	rowID, err := result.LastInsertId()
+	if err != nil {
+		return "", err
+	}
+	return data.SeriesID(strconv.FormatInt(rowID, 10)), nil
+}
+
+func (repo *SeriesRepositorySQLite) GetByID(ctx context.Context, id data.SeriesID) (*data.Series, error) {
+	rowID, ok := parseRowID(string(id))
+	if !ok {
+		// Invalid ID does not match any row, so we return ErrSeriesNotFound.
+		return nil, errors.ErrSeriesNotFound
+	}
+	allSeries, err := repo.find(ctx, "WHERE s.id = ?", rowID)
+	if err != nil {
+		return nil, err
+	}
+	if len(allSeries) == 0 {
+		return nil, errors.ErrSeriesNotFound
+	}
+	return allSeries[0], nil
+}
+
+func (repo *SeriesRepositorySQLite) GetAll(ctx context.Context) ([]*data.Series, error) {
+	return repo.find(ctx, "ORDER BY s.id")
+}
+
+func (repo *SeriesRepositorySQLite) GetByArticle(ctx context.Context, articleID data.ArticleID) ([]*data.Series, error) {
+	return repo.find(ctx, "WHERE s.id IN (SELECT series_id FROM series_articles WHERE article_id = ?) ORDER BY s.id", string(articleID))
+}
+
+func (repo *SeriesRepositorySQLite) AddArticle(ctx context.Context, id data.SeriesID, articleID data.ArticleID) error {
+	return repo.update(ctx, id, func(tx *sql.Tx, rowID int64) error {
+		result, err := tx.ExecContext(ctx, `INSERT INTO series_articles (series_id, position, article_id)
+			SELECT ?, COALESCE(MAX(position) + 1, 0), ? FROM series_articles WHERE series_id = ?
+			ON CONFLICT DO NOTHING`, rowID, string(articleID), rowID)
+		if err != nil {
+			return err
+		}
+		if added, err := result.RowsAffected(); err != nil {
+			return err
+		} else if added == 0 {
+			return errors.ErrArticleAlreadyInSeries
+		}
+		return nil
+	})
+}
+
+func (repo *SeriesRepositorySQLite) RemoveArticle(ctx context.Context, id data.SeriesID, articleID data.ArticleID) error {
+	return repo.update(ctx, id, func(tx *sql.Tx, rowID int64) error {
+		result, err := tx.ExecContext(ctx, "DELETE FROM series_articles WHERE series_id = ? AND article_id = ?", rowID, string(articleID))
+		if err != nil {
+			return err
+		}
+		if removed, err := result.RowsAffected(); err != nil {
+			return err
+		} else if removed == 0 {
+			return errors.ErrArticleNotInSeries
+		}
+		return nil
+	})
+}
+
+func (repo *SeriesRepositorySQLite) Reorder(ctx context.Context, id data.SeriesID, articleIDs []data.ArticleID) error {
+	return repo.update(ctx, id, func(tx *sql.Tx, rowID int64) error {
+		// The order is applied only if the parts are exactly the given articles, which are distinct.
+		var count int
+		if err := tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM series_articles WHERE series_id = ?", rowID).Scan(&count); err != nil {
+			return err
+		}
+		if count != len(articleIDs) {
+			return errors.ErrInvalidSeriesOrder
+		}
+		for i, articleID := range articleIDs {
+			result, err := tx.ExecContext(ctx, "UPDATE series_articles SET position = ? WHERE series_id = ? AND article_id = ?",
+				i, rowID, string(articleID))
+			if err != nil {
+				return err
+			}
+			if updated, err := result.RowsAffected(); err != nil {
+				return err
+			} else if updated == 0 {
+				return errors.ErrInvalidSeriesOrder
+			}
+		}
+		return nil
+	})
+}
+
+// update runs the change of the parts in a transaction, after checking the series exists.
+// The transaction is rolled back if the change returns an error.
+func (repo *SeriesRepositorySQLite) update(ctx context.Context, id data.SeriesID, change func(tx *sql.Tx, rowID int64) error) error {
+	rowID, ok := parseRowID(string(id))
+	if !ok {
+		return errors.ErrSeriesNotFound
+	}
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var count int
+	if err := tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM series WHERE id = ?", rowID).Scan(&count); err != nil {
+		return err
+	}
+	if count == 0 {
+		return errors.ErrSeriesNotFound
+	}
+	if err := change(tx, rowID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// find gets the series with the clause following "FROM series s".
+func (repo *SeriesRepositorySQLite) find(ctx context.Context, clause string, args ...interface{}) ([]*data.Series, error) {
+	rows, err := repo.db.QueryContext(ctx, `SELECT s.id, s.title, s.description,
+		(SELECT json_group_array(article_id ORDER BY position) FROM series_articles WHERE series_id = s.id)
+		FROM series s `+clause, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []*data.Series{}
+	for rows.Next() {
+		var rowID int64
+		var title, description, articleIDs string
+		if err := rows.Scan(&rowID, &title, &description, &articleIDs); err != nil {
+			return nil, err
+		}
+		series := &data.Series{
+			ID: data.SeriesID(strconv.FormatInt(rowID, 10)),
+			// Assume the data in SQLite is valid.
+			SeriesInfo: data.SeriesInfo{
+				Title:       data.SeriesTitle(title),
+				Description: data.SeriesDescription(description),
+			},
+		}
+		if err := json.Unmarshal([]byte(articleIDs), &series.ArticleIDs); err != nil {
+			return nil, err
+		}
+		result = append(result, series)
+	}
+	return result, rows.Err()
+}
+
+var _ repository.SeriesRepository = (*SeriesRepositorySQLite)(nil)
@@ -0,0 +1,88 @@
+-- Times are stored as fixed-width RFC 3339 text in UTC, so they sort correctly as strings.
+
+CREATE TABLE articles (
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    title      TEXT NOT NULL,
+    content    TEXT NOT NULL,
+    created_at TEXT NOT NULL,
+    updated_at TEXT NOT NULL
+);
+
+-- The authors of an article in order.
+CREATE TABLE article_authors (
+    article_id INTEGER NOT NULL REFERENCES articles (id) ON DELETE CASCADE,
+    position   INTEGER NOT NULL,
+    author_id  TEXT NOT NULL,
+    PRIMARY KEY (article_id, position)
+);
+CREATE INDEX article_authors_author_id ON article_authors (author_id);
+
+-- The full-text index of the articles, kept in sync with the triggers below.
+CREATE VIRTUAL TABLE articles_fts USING fts5 (title, content, content = 'articles', content_rowid = 'id');
+
+CREATE TRIGGER articles_fts_insert AFTER INSERT ON articles BEGIN
+    INSERT INTO articles_fts (rowid, title, content) VALUES (new.id, new.title, new.content);
+END;
+
+CREATE TRIGGER articles_fts_delete AFTER DELETE ON articles BEGIN
+    INSERT INTO articles_fts (articles_fts, rowid, title, content) VALUES ('delete', old.id, old.title, old.content);
+END;
+
+CREATE TRIGGER articles_fts_update AFTER UPDATE ON articles BEGIN
+    INSERT INTO articles_fts (articles_fts, rowid, title, content) VALUES ('delete', old.id, old.title, old.content);
+    INSERT INTO articles_fts (rowid, title, content) VALUES (new.id, new.title, new.content);
+END;
+
+CREATE TABLE authors (
+    id           INTEGER PRIMARY KEY AUTOINCREMENT,
+    display_name TEXT NOT NULL,
+    bio          TEXT NOT NULL,
+    avatar       TEXT NOT NULL,
+    -- A JSON array of the URLs.
+    links        TEXT NOT NULL,
+    -- NULL if no token has been issued.
+    token_hash   TEXT UNIQUE
+);
+
+CREATE TABLE comments (
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    article_id TEXT NOT NULL,
+    author     TEXT NOT NULL,
+    content    TEXT NOT NULL,
+    status     TEXT NOT NULL,
+    spam_score REAL NOT NULL,
+    created_at TEXT NOT NULL
+);
+CREATE INDEX comments_article_id_status ON comments (article_id, status, created_at);
+CREATE INDEX comments_status ON comments (status, created_at);
+
+CREATE TABLE reactions (
+    article_id TEXT NOT NULL,
+    user       TEXT NOT NULL,
+    kind       TEXT NOT NULL,
+    PRIMARY KEY (article_id, user, kind)
+);
+CREATE INDEX reactions_article_id_kind ON reactions (article_id, kind);
+
+CREATE TABLE article_views (
+    article_id TEXT NOT NULL,
+    day        TEXT NOT NULL,
+    views      INTEGER NOT NULL,
+    PRIMARY KEY (article_id, day)
+);
+CREATE INDEX article_views_day ON article_views (day);
+
+CREATE TABLE series (
+    id          INTEGER PRIMARY KEY AUTOINCREMENT,
+    title       TEXT NOT NULL,
+    description TEXT NOT NULL
+);
+
+-- The parts of a series in order.
+CREATE TABLE series_articles (
+    series_id  INTEGER NOT NULL REFERENCES series (id) ON DELETE CASCADE,
+    position   INTEGER NOT NULL,
+    article_id TEXT NOT NULL,
+    PRIMARY KEY (series_id, article_id)
+);
+CREATE INDEX series_articles_article_id ON series_articles (article_id);
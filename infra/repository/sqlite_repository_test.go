@@ -0,0 +1,192 @@
+package repository_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Jason5Lee/simple-blog/core/data"
+	"github.com/Jason5Lee/simple-blog/core/errors"
+	"github.com/Jason5Lee/simple-blog/infra/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestSQLite(t *testing.T) *repository.ArticleRepositorySQLite {
+	repo, err := repository.NewArticleRepositorySQLite(filepath.Join(t.TempDir(), "blog.db"))
+	assert.Nil(t, err, "opening a new database should not return error")
+	t.Cleanup(func() { _ = repo.Close() })
+	return repo
+}
+
+func Test_ArticleRepositorySQLite(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "blog.db")
+	repo, err := repository.NewArticleRepositorySQLite(path)
+	assert.Nil(err, "opening a new database should not return error")
+
+	id, err := repo.Create(ctx, &data.ArticleInfo{Title: "Hello", Content: "World", AuthorIDs: []data.AuthorID{"2", "1"}})
+	assert.Nil(err, "create should not return error")
+	article, err := repo.GetByID(ctx, id)
+	assert.Nil(err, "get by id should not return error")
+	assert.Equal(data.ArticleTitle("Hello"), article.Title, "title should be stored")
+	assert.Equal([]data.AuthorID{"2", "1"}, article.AuthorIDs, "authors should keep their credit order")
+	assert.Equal(article.CreatedAt, article.UpdatedAt, "a new article should not be updated")
+
+	assert.Nil(repo.Update(ctx, id, data.FirstArticleVersion, &data.ArticleInfo{Title: "Hi", Content: "There", AuthorIDs: []data.AuthorID{"1"}}), "update should not return error")
+	article, err = repo.GetByID(ctx, id)
+	assert.Nil(err, "get by id should not return error")
+	assert.Equal(data.ArticleContent("There"), article.Content, "content should be updated")
+	assert.Equal([]data.AuthorID{"1"}, article.AuthorIDs, "authors should be replaced")
+
+	byAuthor, err := repo.GetByAuthor(ctx, "2")
+	assert.Nil(err, "get by author should not return error")
+	assert.Empty(byAuthor, "a removed author should no longer match")
+
+	// Reopening applies no migration twice and keeps the data.
+	assert.Nil(repo.Close(), "close should not return error")
+	repo, err = repository.NewArticleRepositorySQLite(path)
+	assert.Nil(err, "reopening the database should not return error")
+	defer repo.Close()
+	all, err := repo.GetAll(ctx)
+	assert.Nil(err, "get all should not return error")
+	assert.Len(all, 1, "the article should survive reopening")
+
+	for _, invalid := range []data.ArticleID{"", "abc", "01", "-1", "999"} {
+		_, err = repo.GetByID(ctx, invalid)
+		assert.Equal(errors.ErrNotFound, err, "get by id %q should return ErrNotFound", invalid)
+		assert.Equal(errors.ErrNotFound, repo.Update(ctx, invalid, data.FirstArticleVersion, &article.ArticleInfo), "update %q should return ErrNotFound", invalid)
+		assert.Equal(errors.ErrNotFound, repo.Delete(ctx, invalid, data.FirstArticleVersion), "delete %q should return ErrNotFound", invalid)
+	}
+
+	assert.Nil(repo.Delete(ctx, id, article.Version), "delete should not return error")
+	_, err = repo.GetByID(ctx, id)
+	assert.Equal(errors.ErrNotFound, err, "deleted article should not be found")
+}
+
+func Test_ArticleRepositorySQLite_Search(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+	repo := newTestSQLite(t)
+
+	authors := []data.AuthorID{"1"}
+	goID, _ := repo.Create(ctx, &data.ArticleInfo{Title: "Learning Go", Content: "Goroutines and channels.", AuthorIDs: authors})
+	rustID, _ := repo.Create(ctx, &data.ArticleInfo{Title: "Learning Rust", Content: "Ownership, borrowing and channels.", AuthorIDs: authors})
+
+	articles, err := repo.Search(ctx, "channels")
+	assert.Nil(err, "search should not return error")
+	assert.Len(articles, 2, "both articles mention channels")
+
+	articles, err = repo.Search(ctx, "learning GO")
+	assert.Nil(err, "search should not return error")
+	assert.Len(articles, 1, "only one article contains all the terms")
+	assert.Equal(goID, articles[0].ID, "the terms should match ignoring case")
+
+	articles, err = repo.Search(ctx, `"borrowing AND NOT*`)
+	assert.Nil(err, "the query syntax of FTS5 should be matched literally")
+	assert.Empty(articles, "no article contains the literal terms")
+
+	assert.Nil(repo.Update(ctx, rustID, data.FirstArticleVersion, &data.ArticleInfo{Title: "Learning Zig", Content: "Comptime.", AuthorIDs: authors}), "update should not return error")
+	articles, err = repo.Search(ctx, "rust")
+	assert.Nil(err, "search should not return error")
+	assert.Empty(articles, "the index should follow the update")
+
+	assert.Nil(repo.Delete(ctx, goID, data.FirstArticleVersion), "delete should not return error")
+	articles, err = repo.Search(ctx, "learning")
+	assert.Nil(err, "search should not return error")
+	assert.Len(articles, 1, "the index should follow the deletion")
+}
+
+func Test_ArticleRepositorySQLite_GetPage(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+	repo := newTestSQLite(t)
+
+	var ids []data.ArticleID
+	for i := 0; i < 3; i++ {
+		id, err := repo.Create(ctx, &data.ArticleInfo{Title: "Title", Content: "Content", AuthorIDs: []data.AuthorID{"1"}})
+		assert.Nil(err, "create should not return error")
+		ids = append(ids, id)
+	}
+
+	page, err := repo.GetPage(ctx, "", 2)
+	assert.Nil(err, "get page should not return error")
+	assert.Len(page, 2, "the page should be full")
+	assert.Equal(ids[:2], []data.ArticleID{page[0].ID, page[1].ID}, "the first page should start from the first article")
+
+	assert.Nil(repo.Delete(ctx, ids[1], data.FirstArticleVersion), "delete should not return error")
+	page, err = repo.GetPage(ctx, ids[1], 2)
+	assert.Nil(err, "the cursor of a deleted article should still be valid")
+	assert.Len(page, 1, "the last page should not be full")
+	assert.Equal(ids[2], page[0].ID, "the page should follow the cursor")
+
+	_, err = repo.GetPage(ctx, "abc", 2)
+	assert.Equal(errors.ErrInvalidPageCursor, err, "a malformed cursor should return ErrInvalidPageCursor")
+}
+
+func Test_SeriesRepositorySQLite(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+	repo := repository.NewSeriesRepositorySQLite(newTestSQLite(t))
+
+	id, err := repo.Create(ctx, &data.SeriesInfo{Title: "Series"})
+	assert.Nil(err, "create should not return error")
+	for _, articleID := range []data.ArticleID{"a", "b", "c"} {
+		assert.Nil(repo.AddArticle(ctx, id, articleID), "add should not return error")
+	}
+	assert.Equal(errors.ErrArticleAlreadyInSeries, repo.AddArticle(ctx, id, "a"), "adding a part twice should fail")
+	assert.Equal(errors.ErrSeriesNotFound, repo.AddArticle(ctx, "999", "a"), "adding to a missing series should fail")
+	assert.Nil(repo.RemoveArticle(ctx, id, "b"), "remove should not return error")
+	assert.Equal(errors.ErrArticleNotInSeries, repo.RemoveArticle(ctx, id, "b"), "removing a missing part should fail")
+	assert.Nil(repo.AddArticle(ctx, id, "d"), "add should not return error")
+
+	assert.Equal(errors.ErrInvalidSeriesOrder, repo.Reorder(ctx, id, []data.ArticleID{"c", "a"}), "an order missing a part should fail")
+	assert.Equal(errors.ErrInvalidSeriesOrder, repo.Reorder(ctx, id, []data.ArticleID{"c", "a", "b"}), "an order with another article should fail")
+	series, err := repo.GetByID(ctx, id)
+	assert.Nil(err, "get by id should not return error")
+	assert.Equal([]data.ArticleID{"a", "c", "d"}, series.ArticleIDs, "a failed reorder should change nothing")
+
+	assert.Nil(repo.Reorder(ctx, id, []data.ArticleID{"d", "a", "c"}), "reorder should not return error")
+	byArticle, err := repo.GetByArticle(ctx, "d")
+	assert.Nil(err, "get by article should not return error")
+	assert.Len(byArticle, 1, "the series should contain the article")
+	assert.Equal([]data.ArticleID{"d", "a", "c"}, byArticle[0].ArticleIDs, "the order should be replaced")
+}
+
+func Test_ReactionAndViewRepositorySQLite(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+	articleRepo := newTestSQLite(t)
+	reactionRepo := repository.NewReactionRepositorySQLite(articleRepo)
+	viewRepo := repository.NewViewRepositorySQLite(articleRepo)
+
+	added, err := reactionRepo.Add(ctx, "1", "alice", "like")
+	assert.True(added, "the first reaction should be added")
+	assert.Nil(err, "add should not return error")
+	added, _ = reactionRepo.Add(ctx, "1", "alice", "like")
+	assert.False(added, "a repeated reaction should not be added")
+	_, _ = reactionRepo.Add(ctx, "1", "bob", "like")
+	_, _ = reactionRepo.Add(ctx, "2", "bob", "🎉")
+	removed, _ := reactionRepo.Remove(ctx, "2", "bob", "🎉")
+	assert.True(removed, "an existing reaction should be removed")
+	counts, err := reactionRepo.Counts(ctx, []data.ArticleID{"1", "2"})
+	assert.Nil(err, "counts should not return error")
+	assert.Equal(map[data.ArticleID]data.ReactionCounts{"1": {"like": 2}}, counts, "only the remaining reactions should be counted")
+	reactions, err := reactionRepo.GetByArticle(ctx, "1")
+	assert.Nil(err, "get by article should not return error")
+	assert.ElementsMatch([]data.Reaction{{User: "alice", Kind: "like"}, {User: "bob", Kind: "like"}}, reactions)
+
+	day := data.Day(time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC))
+	assert.Nil(viewRepo.AddViews(ctx, []data.ViewIncrement{
+		{ArticleID: "1", Day: day, Views: 2},
+		{ArticleID: "1", Day: day.Add(time.Hour), Views: 3},
+		{ArticleID: "2", Day: day.AddDate(0, 0, 1), Views: 4},
+	}), "add views should not return error")
+	daily, err := viewRepo.GetDaily(ctx, "1", day, day.AddDate(0, 0, 1))
+	assert.Nil(err, "get daily should not return error")
+	assert.Equal([]data.DailyViews{{Day: day, Views: 5}}, daily, "the views of the same day should be summed")
+	top, err := viewRepo.GetTop(ctx, day, day.AddDate(0, 0, 1), 1)
+	assert.Nil(err, "get top should not return error")
+	assert.Equal([]data.ArticleViews{{ArticleID: "1", Views: 5}}, top, "the most viewed article should be first")
+}
@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Jason5Lee/simple-blog/core/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// VerifyArticlesMongoDB reads all the articles like the repository does, returning the corrupt ones in the order of
+// "_id". With quarantine, they are moved to the quarantine collection, named after the article collection with
+// "_quarantine" appended, so they can be fixed and moved back by hand.
+func VerifyArticlesMongoDB(ctx context.Context, articleRepo *ArticleRepositoryMongoDB, quarantine bool) ([]*errors.CorruptDocumentError, error) {
+	cursor, err := articleRepo.collection().Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var corrupt []*errors.CorruptDocumentError
+	for cursor.Next(ctx) {
+		raw := cursor.Current
+		_, err := articleRepo.decode(ctx, raw)
+		if err == nil {
+			continue
+		}
+		corruptErr, ok := err.(*errors.CorruptDocumentError)
+		if !ok {
+			return corrupt, err
+		}
+		corrupt = append(corrupt, corruptErr)
+		if quarantine {
+			if err := articleRepo.quarantine(ctx, raw); err != nil {
+				return corrupt, err
+			}
+		}
+	}
+	return corrupt, cursor.Err()
+}
+
+// quarantine moves the document to the quarantine collection. Replacing a document with the same ID
+// makes it safe to run again if it failed after copying.
+func (repo *ArticleRepositoryMongoDB) quarantine(ctx context.Context, raw bson.Raw) error {
+	filter := bson.M{"_id": raw.Lookup("_id")}
+	_, err := repo.db.Collection(repo.articleCollection+"_quarantine").
+		ReplaceOne(ctx, filter, raw, options.Replace().SetUpsert(true))
+	if err != nil {
+		return err
+	}
+	_, err = repo.collection().DeleteOne(ctx, filter)
+	return err
+}
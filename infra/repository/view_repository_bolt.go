@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"sort"
+	"time"
+
+	"github.com/Jason5Lee/simple-blog/core/data"
+	"github.com/Jason5Lee/simple-blog/core/repository"
+	bolt "go.etcd.io/bbolt"
+)
+
+// ViewRepositoryBolt is a bbolt implementation of ViewRepository, keeping a count per article per day.
+// The counts are keyed by article then day, and duplicated keyed by day then article for GetTop.
+type ViewRepositoryBolt struct {
+	db *bolt.DB
+}
+
+var (
+	boltViewsBucket      = []byte("article_views")
+	boltViewsByDayBucket = []byte("article_views_by_day")
+)
+
+// NewViewRepositoryBolt creates a new ViewRepositoryBolt sharing the database of the article repository.
+func NewViewRepositoryBolt(articleRepo *ArticleRepositoryBolt) *ViewRepositoryBolt {
+	return &ViewRepositoryBolt{db: articleRepo.db}
+}
+
+func (repo *ViewRepositoryBolt) AddViews(ctx context.Context, increments []data.ViewIncrement) error {
+	if len(increments) == 0 {
+		return nil
+	}
+	return repo.db.Update(func(tx *bolt.Tx) error {
+		views := tx.Bucket(boltViewsBucket)
+		byDay := tx.Bucket(boltViewsByDayBucket)
+		for _, increment := range increments {
+			day := boltTime(data.Day(increment.Day))
+			key := boltKey([]byte(increment.ArticleID), day)
+			var count uint64
+			if value := views.Get(key); value != nil {
+				count = binary.BigEndian.Uint64(value)
+			}
+			count += uint64(increment.Views)
+			if err := views.Put(key, boltID(count)); err != nil {
+				return err
+			}
+			if err := byDay.Put(boltKey(day, []byte(increment.ArticleID)), boltID(count)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (repo *ViewRepositoryBolt) GetDaily(ctx context.Context, articleID data.ArticleID, from time.Time, to time.Time) ([]data.DailyViews, error) {
+	result := []data.DailyViews{}
+	err := repo.db.View(func(tx *bolt.Tx) error {
+		prefix := boltKey([]byte(articleID))
+		end := boltKey([]byte(articleID), boltTime(to))
+		cursor := tx.Bucket(boltViewsBucket).Cursor()
+		for key, value := cursor.Seek(boltKey([]byte(articleID), boltTime(from))); key != nil && bytes.HasPrefix(key, prefix) && bytes.Compare(key, end) <= 0; key, value = cursor.Next() {
+			// The day is the second part of the key, after its length.
+			result = append(result, data.DailyViews{Day: parseBoltTime(key[len(prefix)+2:]), Views: int64(binary.BigEndian.Uint64(value))})
+		}
+		return nil
+	})
+	return result, err
+}
+
+func (repo *ViewRepositoryBolt) GetTop(ctx context.Context, from time.Time, to time.Time, n int) ([]data.ArticleViews, error) {
+	totals := make(map[data.ArticleID]int64)
+	err := repo.db.View(func(tx *bolt.Tx) error {
+		end := boltTime(to)
+		cursor := tx.Bucket(boltViewsByDayBucket).Cursor()
+		for key, value := cursor.Seek(boltKey(boltTime(from))); key != nil; key, value = cursor.Next() {
+			// The day is the first part of the key, after its length.
+			if bytes.Compare(key[2:10], end) > 0 {
+				break
+			}
+			totals[data.ArticleID(key[12:])] += int64(binary.BigEndian.Uint64(value))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	result := make([]data.ArticleViews, 0, len(totals))
+	for articleID, views := range totals {
+		result = append(result, data.ArticleViews{ArticleID: articleID, Views: views})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Views != result[j].Views {
+			return result[i].Views > result[j].Views
+		}
+		return result[i].ArticleID < result[j].ArticleID
+	})
+	if len(result) > n {
+		result = result[:n]
+	}
+	return result, nil
+}
+
+var _ repository.ViewRepository = (*ViewRepositoryBolt)(nil)
@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Jason5Lee/simple-blog/core/data"
+	"github.com/Jason5Lee/simple-blog/core/repository"
+)
+
+// In-memory implementation of ViewRepository.
+type ViewRepositoryInMemory struct {
+	mu    sync.RWMutex
+	views map[data.ArticleID]map[time.Time]int64
+}
+
+func NewViewRepositoryInMemory() *ViewRepositoryInMemory {
+	return &ViewRepositoryInMemory{
+		views: make(map[data.ArticleID]map[time.Time]int64),
+	}
+}
+
+func (r *ViewRepositoryInMemory) AddViews(ctx context.Context, increments []data.ViewIncrement) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, increment := range increments {
+		if r.views[increment.ArticleID] == nil {
+			r.views[increment.ArticleID] = make(map[time.Time]int64)
+		}
+		r.views[increment.ArticleID][data.Day(increment.Day)] += increment.Views
+	}
+	return nil
+}
+
+func (r *ViewRepositoryInMemory) GetDaily(ctx context.Context, articleID data.ArticleID, from time.Time, to time.Time) ([]data.DailyViews, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	result := make([]data.DailyViews, 0)
+	for day, views := range r.views[articleID] {
+		if !day.Before(from) && !day.After(to) {
+			result = append(result, data.DailyViews{Day: day, Views: views})
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Day.Before(result[j].Day)
+	})
+	return result, nil
+}
+
+func (r *ViewRepositoryInMemory) GetTop(ctx context.Context, from time.Time, to time.Time, n int) ([]data.ArticleViews, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	result := make([]data.ArticleViews, 0)
+	for articleID, daily := range r.views {
+		total := int64(0)
+		for day, views := range daily {
+			if !day.Before(from) && !day.After(to) {
+				total += views
+			}
+		}
+		if total > 0 {
+			result = append(result, data.ArticleViews{ArticleID: articleID, Views: total})
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Views > result[j].Views
+	})
+	if len(result) > n {
+		result = result[:n]
+	}
+	return result, nil
+}
+
+var _ repository.ViewRepository = (*ViewRepositoryInMemory)(nil)
@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Jason5Lee/simple-blog/core/data"
+	"github.com/Jason5Lee/simple-blog/core/repository"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// The key of the views of an article on a day.
+type DBViewKey struct {
+	ArticleID string    `bson:"article_id"`
+	Day       time.Time `bson:"day"`
+}
+
+// The views of an article on a day. The key fields are duplicated outside "_id" so they can be queried.
+type DBDailyViews struct {
+	ID        DBViewKey `bson:"_id"`
+	ArticleID string    `bson:"article_id"`
+	Day       time.Time `bson:"day"`
+	Views     int64     `bson:"views"`
+}
+
+// ViewRepositoryMongoDB is a MongoDB implementation of ViewRepository, keeping a document per article per day.
+type ViewRepositoryMongoDB struct {
+	db *mongo.Database
+}
+
+const viewCollectionName = "article_views"
+
+// NewViewRepositoryMongoDB creates a new ViewRepositoryMongoDB sharing the connection of the article repository.
+func NewViewRepositoryMongoDB(articleRepo *ArticleRepositoryMongoDB) *ViewRepositoryMongoDB {
+	return &ViewRepositoryMongoDB{db: articleRepo.db}
+}
+
+func (repo *ViewRepositoryMongoDB) collection() *mongo.Collection {
+	return repo.db.Collection(viewCollectionName)
+}
+
+func (repo *ViewRepositoryMongoDB) AddViews(ctx context.Context, increments []data.ViewIncrement) error {
+	if len(increments) == 0 {
+		return nil
+	}
+	models := make([]mongo.WriteModel, len(increments))
+	for i, increment := range increments {
+		day := data.Day(increment.Day)
+		models[i] = mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": DBViewKey{ArticleID: string(increment.ArticleID), Day: day}}).
+			SetUpdate(bson.M{
+				"$inc":         bson.M{"views": increment.Views},
+				"$setOnInsert": bson.M{"article_id": string(increment.ArticleID), "day": day},
+			}).
+			SetUpsert(true)
+	}
+	_, err := repo.collection().BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+	return err
+}
+
+func (repo *ViewRepositoryMongoDB) GetDaily(ctx context.Context, articleID data.ArticleID, from time.Time, to time.Time) ([]data.DailyViews, error) {
+	cursor, err := repo.collection().Find(ctx,
+		bson.M{"article_id": string(articleID), "day": bson.M{"$gte": from, "$lte": to}},
+		options.Find().SetSort(bson.D{{Key: "day", Value: 1}}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	var docs []*DBDailyViews
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+	result := make([]data.DailyViews, len(docs))
+	for i, doc := range docs {
+		result[i] = data.DailyViews{Day: doc.Day.UTC(), Views: doc.Views}
+	}
+	return result, nil
+}
+
+func (repo *ViewRepositoryMongoDB) GetTop(ctx context.Context, from time.Time, to time.Time, n int) ([]data.ArticleViews, error) {
+	cursor, err := repo.collection().Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"day": bson.M{"$gte": from, "$lte": to}}}},
+		{{Key: "$group", Value: bson.M{"_id": "$article_id", "views": bson.M{"$sum": "$views"}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "views", Value: -1}}}},
+		{{Key: "$limit", Value: n}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	var docs []struct {
+		ArticleID string `bson:"_id"`
+		Views     int64  `bson:"views"`
+	}
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+	result := make([]data.ArticleViews, len(docs))
+	for i, doc := range docs {
+		result[i] = data.ArticleViews{ArticleID: data.ArticleID(doc.ArticleID), Views: doc.Views}
+	}
+	return result, nil
+}
+
+// Dropping the collection for integration testing.
+func (repo *ViewRepositoryMongoDB) Drop() error {
+	return repo.collection().Drop(context.Background())
+}
+
+var _ repository.ViewRepository = (*ViewRepositoryMongoDB)(nil)
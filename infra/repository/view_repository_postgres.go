@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Jason5Lee/simple-blog/core/data"
+	"github.com/Jason5Lee/simple-blog/core/repository"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ViewRepositoryPostgres is a PostgreSQL implementation of ViewRepository, keeping a row per article per day.
+type ViewRepositoryPostgres struct {
+	pool *pgxpool.Pool
+}
+
+// NewViewRepositoryPostgres creates a new ViewRepositoryPostgres sharing the pool of the article repository.
+func NewViewRepositoryPostgres(articleRepo *ArticleRepositoryPostgres) *ViewRepositoryPostgres {
+	return &ViewRepositoryPostgres{pool: articleRepo.pool}
+}
+
+func (repo *ViewRepositoryPostgres) AddViews(ctx context.Context, increments []data.ViewIncrement) error {
+	if len(increments) == 0 {
+		return nil
+	}
+	// The upserts are sent in a single round trip, and run in an implicit transaction.
+	batch := &pgx.Batch{}
+	for _, increment := range increments {
+		batch.Queue(`INSERT INTO article_views (article_id, day, views) VALUES ($1, $2, $3)
+			ON CONFLICT (article_id, day) DO UPDATE SET views = article_views.views + excluded.views`,
+			string(increment.ArticleID), data.Day(increment.Day), increment.Views)
+	}
+	return repo.pool.SendBatch(ctx, batch).Close()
+}
+
+func (repo *ViewRepositoryPostgres) GetDaily(ctx context.Context, articleID data.ArticleID, from time.Time, to time.Time) ([]data.DailyViews, error) {
+	rows, err := repo.pool.Query(ctx, "SELECT day, views FROM article_views WHERE article_id = $1 AND day BETWEEN $2 AND $3 ORDER BY day",
+		string(articleID), data.Day(from), data.Day(to))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []data.DailyViews{}
+	for rows.Next() {
+		var daily data.DailyViews
+		if err := rows.Scan(&daily.Day, &daily.Views); err != nil {
+			return nil, err
+		}
+		daily.Day = daily.Day.UTC()
+		result = append(result, daily)
+	}
+	return result, rows.Err()
+}
+
+func (repo *ViewRepositoryPostgres) GetTop(ctx context.Context, from time.Time, to time.Time, n int) ([]data.ArticleViews, error) {
+	rows, err := repo.pool.Query(ctx, `SELECT article_id, SUM(views)::BIGINT AS total FROM article_views WHERE day BETWEEN $1 AND $2
+		GROUP BY article_id ORDER BY total DESC LIMIT $3`,
+		data.Day(from), data.Day(to), n)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []data.ArticleViews{}
+	for rows.Next() {
+		var articleID string
+		var views int64
+		if err := rows.Scan(&articleID, &views); err != nil {
+			return nil, err
+		}
+		result = append(result, data.ArticleViews{ArticleID: data.ArticleID(articleID), Views: views})
+	}
+	return result, rows.Err()
+}
+
+var _ repository.ViewRepository = (*ViewRepositoryPostgres)(nil)
@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/Jason5Lee/simple-blog/core/data"
+	"github.com/Jason5Lee/simple-blog/core/repository"
+)
+
+// ViewRepositorySQLite is a SQLite implementation of ViewRepository, keeping a row per article per day.
+type ViewRepositorySQLite struct {
+	db *sql.DB
+}
+
+// NewViewRepositorySQLite creates a new ViewRepositorySQLite sharing the database of the article repository.
+func NewViewRepositorySQLite(articleRepo *ArticleRepositorySQLite) *ViewRepositorySQLite {
+	return &ViewRepositorySQLite{db: articleRepo.db}
+}
+
+func (repo *ViewRepositorySQLite) AddViews(ctx context.Context, increments []data.ViewIncrement) error {
+	if len(increments) == 0 {
+		return nil
+	}
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, increment := range increments {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO article_views (article_id, day, views) VALUES (?, ?, ?)
+			ON CONFLICT (article_id, day) DO UPDATE SET views = views + excluded.views`,
+			string(increment.ArticleID), formatSQLiteTime(data.Day(increment.Day)), increment.Views); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (repo *ViewRepositorySQLite) GetDaily(ctx context.Context, articleID data.ArticleID, from time.Time, to time.Time) ([]data.DailyViews, error) {
+	rows, err := repo.db.QueryContext(ctx, "SELECT day, views FROM article_views WHERE article_id = ? AND day BETWEEN ? AND ? ORDER BY day",
+		string(articleID), formatSQLiteTime(from), formatSQLiteTime(to))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []data.DailyViews{}
+	for rows.Next() {
+		var day string
+		var views int64
+		if err := rows.Scan(&day, &views); err != nil {
+			return nil, err
+		}
+		t, err := parseSQLiteTime(day)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, data.DailyViews{Day: t, Views: views})
+	}
+	return result, rows.Err()
+}
+
+func (repo *ViewRepositorySQLite) GetTop(ctx context.Context, from time.Time, to time.Time, n int) ([]data.ArticleViews, error) {
+	rows, err := repo.db.QueryContext(ctx, `SELECT article_id, SUM(views) AS total FROM article_views WHERE day BETWEEN ? AND ?
+		GROUP BY article_id ORDER BY total DESC LIMIT ?`,
+		formatSQLiteTime(from), formatSQLiteTime(to), n)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []data.ArticleViews{}
+	for rows.Next() {
+		var articleID string
+		var views int64
+		if err := rows.Scan(&articleID, &views); err != nil {
+			return nil, err
+		}
+		result = append(result, data.ArticleViews{ArticleID: data.ArticleID(articleID), Views: views})
+	}
+	return result, rows.Err()
+}
+
+var _ repository.ViewRepository = (*ViewRepositorySQLite)(nil)
@@ -0,0 +1,195 @@
+package infra
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strconv"
+
+	"github.com/Jason5Lee/simple-blog/core/events"
+	"github.com/Jason5Lee/simple-blog/core/repository"
+	infra_repository "github.com/Jason5Lee/simple-blog/infra/repository"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
+)
+
+// Storage is the repositories of the storage backend selected by the configuration.
+type Storage struct {
+	ArticleRepo  repository.ArticleRepository
+	AuthorRepo   repository.AuthorRepository
+	CommentRepo  repository.CommentRepository
+	ReactionRepo repository.ReactionRepository
+	ViewRepo     repository.ViewRepository
+	SeriesRepo   repository.SeriesRepository
+	// MongoDB is the MongoDB article repository whose connection is shared by the other MongoDB stores,
+	// nil unless the backend is MongoDB.
+	MongoDB *infra_repository.ArticleRepositoryMongoDB
+	close   func() error
+}
+
+// OpenStorage connects to the storage backend selected by the configuration, upgrading the stored data and schema if needed.
+// The articles are stored in the Markdown files of the articles directory instead if it is configured.
+func OpenStorage(ctx context.Context, config *Config) (*Storage, error) {
+	storage, err := openBackend(ctx, config)
+	if err != nil || config.ArticlesDir == "" {
+		return storage, err
+	}
+	var repo interface {
+		repository.ArticleRepository
+		Close() error
+	}
+	if config.ArticlesStore == "git" {
+		repo, err = infra_repository.NewArticleRepositoryGit(config.ArticlesDir)
+	} else {
+		repo, err = infra_repository.NewArticleRepositoryFilesystem(config.ArticlesDir)
+	}
+	if err != nil {
+		_ = storage.Close()
+		return nil, err
+	}
+	closeBackend := storage.close
+	storage.ArticleRepo = repo
+	storage.close = func() error {
+		return errors.Join(repo.Close(), closeBackend())
+	}
+	return storage, nil
+}
+
+func openBackend(ctx context.Context, config *Config) (*Storage, error) {
+	switch config.Storage {
+	case "sqlite":
+		repo, err := infra_repository.NewArticleRepositorySQLite(config.SQLitePath)
+		if err != nil {
+			return nil, err
+		}
+		return &Storage{
+			ArticleRepo:  repo,
+			AuthorRepo:   infra_repository.NewAuthorRepositorySQLite(repo),
+			CommentRepo:  infra_repository.NewCommentRepositorySQLite(repo),
+			ReactionRepo: infra_repository.NewReactionRepositorySQLite(repo),
+			ViewRepo:     infra_repository.NewViewRepositorySQLite(repo),
+			SeriesRepo:   infra_repository.NewSeriesRepositorySQLite(repo),
+			close:        repo.Close,
+		}, nil
+	case "postgres":
+		repo, err := infra_repository.NewArticleRepositoryPostgres(config.PostgresUri)
+		if err != nil {
+			return nil, err
+		}
+		return &Storage{
+			ArticleRepo:  repo,
+			AuthorRepo:   infra_repository.NewAuthorRepositoryPostgres(repo),
+			CommentRepo:  infra_repository.NewCommentRepositoryPostgres(repo),
+			ReactionRepo: infra_repository.NewReactionRepositoryPostgres(repo),
+			ViewRepo:     infra_repository.NewViewRepositoryPostgres(repo),
+			SeriesRepo:   infra_repository.NewSeriesRepositoryPostgres(repo),
+			close:        repo.Close,
+		}, nil
+	case "bolt":
+		repo, err := infra_repository.NewArticleRepositoryBolt(config.BoltPath)
+		if err != nil {
+			return nil, err
+		}
+		return &Storage{
+			ArticleRepo:  repo,
+			AuthorRepo:   infra_repository.NewAuthorRepositoryBolt(repo),
+			CommentRepo:  infra_repository.NewCommentRepositoryBolt(repo),
+			ReactionRepo: infra_repository.NewReactionRepositoryBolt(repo),
+			ViewRepo:     infra_repository.NewViewRepositoryBolt(repo),
+			SeriesRepo:   infra_repository.NewSeriesRepositoryBolt(repo),
+			close:        repo.Close,
+		}, nil
+	}
+
+	mongoDBOptions, err := newMongoDBOptions(&config.MongoDB)
+	if err != nil {
+		return nil, err
+	}
+	repo, err := infra_repository.NewArticleRepositoryMongoDB(ctx, config.MongoDBUri, mongoDBOptions)
+	if err != nil {
+		return nil, err
+	}
+	changes, err := infra_repository.EnsureSchemaMongoDB(ctx, repo, config.MongoDBSchemaDryRun)
+	if err != nil {
+		_ = repo.Close()
+		return nil, err
+	}
+	for _, change := range changes {
+		if config.MongoDBSchemaDryRun {
+			log.Printf("schema dry run: would %s", change)
+		} else {
+			log.Printf("schema: %s", change)
+		}
+	}
+	if err := infra_repository.MigrateMongoDB(ctx, repo); err != nil {
+		_ = repo.Close()
+		return nil, err
+	}
+	return &Storage{
+		ArticleRepo:  repo,
+		AuthorRepo:   infra_repository.NewAuthorRepositoryMongoDB(repo),
+		CommentRepo:  infra_repository.NewCommentRepositoryMongoDB(repo),
+		ReactionRepo: infra_repository.NewReactionRepositoryMongoDB(repo),
+		ViewRepo:     infra_repository.NewViewRepositoryMongoDB(repo),
+		SeriesRepo:   infra_repository.NewSeriesRepositoryMongoDB(repo),
+		MongoDB:      repo,
+		close:        repo.Close,
+	}, nil
+}
+
+// Close closes the connection to the storage backend.
+func (s *Storage) Close() error {
+	return s.close()
+}
+
+// newMongoDBOptions converts the configuration of MongoDB into the options of the repositories,
+// where the empty values keep the defaults.
+func newMongoDBOptions(config *MongoDBConfig) (*infra_repository.MongoDBOptions, error) {
+	result := &infra_repository.MongoDBOptions{
+		Database:               config.Database,
+		ArticleCollection:      config.ArticleCollection,
+		MinPoolSize:            config.MinPoolSize,
+		MaxPoolSize:            config.MaxPoolSize,
+		ServerSelectionTimeout: config.ServerSelectionTimeout,
+		ConnectTimeout:         config.ConnectTimeout,
+		OperationTimeout:       config.OperationTimeout,
+	}
+	if config.ReadPreference != "" {
+		mode, err := readpref.ModeFromString(config.ReadPreference)
+		if err != nil {
+			return nil, err
+		}
+		if result.ReadPreference, err = readpref.New(mode); err != nil {
+			return nil, err
+		}
+	}
+	switch config.WriteConcern {
+	case "":
+	case "majority":
+		result.WriteConcern = writeconcern.New(writeconcern.WMajority())
+	default:
+		w, err := strconv.Atoi(config.WriteConcern)
+		if err != nil {
+			return nil, err
+		}
+		result.WriteConcern = writeconcern.New(writeconcern.W(w))
+	}
+	return result, nil
+}
+
+// WatchArticles returns the bus publishing the changes of the articles made by any replica until the context is done,
+// when it is closed, or nil if the storage does not publish them.
+func (s *Storage) WatchArticles(ctx context.Context, consumer string) *events.ArticleBus {
+	if s.MongoDB == nil {
+		return nil
+	}
+	bus := events.NewArticleBus()
+	go func() {
+		if err := infra_repository.WatchArticlesMongoDB(ctx, s.MongoDB, consumer, bus.Publish); err != nil {
+			log.Printf("the changes of the articles are not published: %v", err)
+			<-ctx.Done()
+		}
+		bus.Close()
+	}()
+	return bus
+}
@@ -0,0 +1,51 @@
+package infra
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+
+	infra_repository "github.com/Jason5Lee/simple-blog/infra/repository"
+)
+
+// RunVerify runs the verify command, reading all the articles of the configured MongoDB storage and writing the
+// corrupt ones to `out`. With `--quarantine`, they are moved to the quarantine collection.
+func RunVerify(ctx context.Context, args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("verify", flag.ContinueOnError)
+	quarantine := flags.Bool("quarantine", false, "move the corrupt articles to the quarantine collection, named after the article collection with _quarantine appended")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	config, err := LoadConfig()
+	if err != nil {
+		return err
+	}
+	storage, err := OpenStorage(ctx, config)
+	if err != nil {
+		return err
+	}
+	defer storage.Close()
+	if storage.MongoDB == nil {
+		return errors.New("verify requires the mongo storage")
+	}
+
+	corrupt, err := infra_repository.VerifyArticlesMongoDB(ctx, storage.MongoDB, *quarantine)
+	for _, corruptErr := range corrupt {
+		fmt.Fprintln(out, corruptErr)
+	}
+	if err != nil {
+		return err
+	}
+	switch {
+	case len(corrupt) == 0:
+		fmt.Fprintln(out, "no corrupt article")
+	case *quarantine:
+		fmt.Fprintf(out, "%d corrupt articles moved to %s_quarantine\n", len(corrupt), config.MongoDB.ArticleCollection)
+	default:
+		fmt.Fprintf(out, "%d corrupt articles, run with --quarantine to move them\n", len(corrupt))
+	}
+	return nil
+}
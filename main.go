@@ -1,23 +1,132 @@
 package main
 
 import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/Jason5Lee/simple-blog/core/analytics"
+	"github.com/Jason5Lee/simple-blog/core/data"
+	"github.com/Jason5Lee/simple-blog/core/repository"
+	"github.com/Jason5Lee/simple-blog/core/usecase"
 	"github.com/Jason5Lee/simple-blog/infra"
 	infra_repository "github.com/Jason5Lee/simple-blog/infra/repository"
 )
 
+// commands are run with `simple-blog <command> [args]` instead of starting the server.
+var commands = map[string]func(ctx context.Context, args []string) error{
+	"migrate": func(ctx context.Context, args []string) error {
+		return infra.RunMigrate(ctx, args, os.Stdout)
+	},
+	"verify": func(ctx context.Context, args []string) error {
+		return infra.RunVerify(ctx, args, os.Stdout)
+	},
+}
+
 func main() {
+	if len(os.Args) > 1 {
+		runCommand(os.Args[1], os.Args[2:])
+		return
+	}
+
 	config, err := infra.LoadConfig()
 	if err != nil {
 		panic(err)
 	}
-	repo, err := infra_repository.NewArticleRepositoryMongoDB(config.MongoDBUri)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	storage, err := infra.OpenStorage(ctx, config)
 	if err != nil {
 		panic(err)
 	}
-	defer repo.Close()
+	defer storage.Close()
 
-	err = infra.StartHttpServer(repo, config.Listen)
+	moderator := infra.NewModerator(&config.Moderation)
+	if err = usecase.TrainModerator(ctx, storage.CommentRepo, moderator); err != nil {
+		panic(err)
+	}
+
+	viewCounter := analytics.NewViewCounter(storage.ViewRepo, config.ViewDedupWindow)
+	viewCounterDone := make(chan struct{})
+	go func() {
+		viewCounter.Run(ctx, config.ViewFlushInterval)
+		close(viewCounterDone)
+	}()
+	// The remaining views are flushed once the server is shut down. The context is canceled first, as this runs
+	// before the deferred stop, so a panic while starting does not wait for the view counter forever.
+	defer func() {
+		stop()
+		<-viewCounterDone
+	}()
+
+	mediaStore, err := infra.NewMediaStore(&config.Media, storage.MongoDB)
 	if err != nil {
 		panic(err)
 	}
+
+	articleRepo := storage.ArticleRepo
+	articleEvents := storage.WatchArticles(ctx, config.MongoDB.ChangeStreamConsumer)
+	var articleCacheStats func() data.CacheStats
+	cached, err := infra.NewCachedArticleRepository(ctx, &config.ArticleCache, articleRepo, articleEvents)
+	if err != nil {
+		panic(err)
+	}
+	if cached != nil {
+		articleRepo = cached
+		articleCacheStats = cached.Stats
+	}
+
+	var idMapping repository.IDMapping
+	if config.IDMapFile != "" {
+		idMappingFile, err := infra_repository.NewIDMappingFile(config.IDMapFile)
+		if err != nil {
+			panic(err)
+		}
+		defer idMappingFile.Close()
+		idMapping = idMappingFile
+	}
+
+	err = infra.StartHttpServer(ctx, &infra.Services{
+		ArticleRepo:        articleRepo,
+		AuthorRepo:         storage.AuthorRepo,
+		CommentRepo:        storage.CommentRepo,
+		ReactionRepo:       storage.ReactionRepo,
+		ViewRepo:           storage.ViewRepo,
+		SeriesRepo:         storage.SeriesRepo,
+		MediaStore:         mediaStore,
+		MediaMaxSize:       config.Media.MaxSize,
+		MediaImageWidths:   config.Media.ImageWidths,
+		MediaGCGracePeriod: config.Media.GCGracePeriod,
+		BackupDir:          config.BackupDir,
+		IDMapping:          idMapping,
+		ArticleEvents:      articleEvents,
+		ArticleCacheStats:  articleCacheStats,
+		ViewCounter:        viewCounter,
+		Moderator:          moderator,
+		ReactionKinds:      config.ReactionKinds,
+		AdminToken:         config.AdminToken,
+		CacheControl:       config.CacheControl,
+	}, config.Listen)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func runCommand(name string, args []string) {
+	command, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n", name)
+		os.Exit(2)
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	err := command(ctx, args)
+	stop()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
 }